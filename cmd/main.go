@@ -16,8 +16,13 @@ import (
 	"github.com/modcoco/OpsFlow/pkg/agent"
 	"github.com/modcoco/OpsFlow/pkg/core"
 	"github.com/modcoco/OpsFlow/pkg/handler"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
 	"github.com/modcoco/OpsFlow/pkg/queue"
 	"github.com/modcoco/OpsFlow/pkg/tasks"
+	"github.com/modcoco/OpsFlow/pkg/webhook"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -25,13 +30,27 @@ import (
 )
 
 type Config struct {
-	GrpcAddr       string
-	ListenAddr     string
-	QueueName      string
-	WorkerCount    int
-	RedisAddrs     []string
-	RedisPwd       string
-	RedisIsCluster bool
+	GrpcAddr        string
+	ListenAddr      string
+	QueueName       string
+	WorkerCount     int
+	RedisAddrs      []string
+	RedisPwd        string
+	RedisIsCluster  bool
+	Tracker         resourceinfo.ResourceTracker
+	UsageBreakdown  resourceinfo.UsageBreakdownMode
+	CollectMetrics  bool   // 从 metrics-server 采集实际使用
+	DCGMExporterURL string // DCGM exporter 地址模板，为空时不采集 GPU 使用
+	NodeEvents      bool   // 是否把节点变化写为 Kubernetes Event
+	EventNamespace  string // 节点变化 Event 写入的 namespace
+	HistoryMaxLen   int64  // 每个节点在 Redis 中保留的变化条数
+	WebhookEnabled  bool   // CRD 使用 Webhook 转换策略时必须开启，关闭时 CRD 需要使用 None 策略
+	WebhookAddr     string
+	WebhookCert     string
+	WebhookKey      string
+	ModelConfigRoot string // 模型卷在本服务中的挂载目录，为空时不读取模型 config.json
+	EndpointProbe   model.EndpointProbeOptions
+	HostPath        model.HostPathPolicy
 }
 
 func getEnv(key, def string) string {
@@ -54,20 +73,73 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("no Redis addresses provided")
 	}
 
+	// 逗号分隔的 glob，如 cpu,memory,*.com/gpu,rdma/*,hugepages-*
+	tracker, err := resourceinfo.NewResourceTracker(
+		getEnv("TRACKED_RESOURCES", strings.Join(resourceinfo.DefaultTrackedResources, ",")),
+		getEnv("TRACK_EXTENDED_RESOURCES", "false") == "true",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRACKED_RESOURCES: %v", err)
+	}
+
+	historyMaxLen, err := strconv.ParseInt(getEnv("NODE_HISTORY_MAXLEN", strconv.Itoa(history.DefaultMaxLen)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NODE_HISTORY_MAXLEN: %v", err)
+	}
+
+	probeTimeout, err := time.ParseDuration(getEnv("ENDPOINT_PROBE_TIMEOUT", "3s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENDPOINT_PROBE_TIMEOUT: %v", err)
+	}
+	degradedAfter, err := time.ParseDuration(getEnv("ENDPOINT_DEGRADED_AFTER", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENDPOINT_DEGRADED_AFTER: %v", err)
+	}
+	loadTimeout, err := time.ParseDuration(getEnv("ENDPOINT_LOAD_TIMEOUT", "30m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENDPOINT_LOAD_TIMEOUT: %v", err)
+	}
+
+	// hostPath 卷默认关闭，HOST_PATH_PREFIXES 为逗号分隔的允许挂载的目录，为空时允许所有目录
+	hostPathPolicy := model.HostPathPolicy{Enabled: getEnv("ALLOW_HOST_PATH_VOLUMES", "false") == "true"}
+	if prefixes := getEnv("HOST_PATH_PREFIXES", ""); prefixes != "" {
+		hostPathPolicy.AllowedPrefixes = strings.Split(prefixes, ",")
+	}
+
+	// status: 记录到 CRD status，manager: 同时通过 NodeResource.properties 上报
+	usageBreakdown, err := resourceinfo.ParseUsageBreakdownMode(getEnv("USAGE_BREAKDOWN", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid USAGE_BREAKDOWN: %v", err)
+	}
+
 	return &Config{
-		GrpcAddr:       getEnv("GRPC_ADDR", "idp.baihai.co:8980"),
-		ListenAddr:     getEnv("LISTEN_ADDR", ":8090"),
-		QueueName:      getEnv("QUEUE_NAME", "task_queue"),
-		WorkerCount:    workerCount,
-		RedisAddrs:     redisAddrs,
-		RedisPwd:       "",
-		RedisIsCluster: getEnv("REDIS_CLUSTER", "false") == "true",
+		GrpcAddr:        getEnv("GRPC_ADDR", "idp.baihai.co:8980"),
+		ListenAddr:      getEnv("LISTEN_ADDR", ":8090"),
+		QueueName:       getEnv("QUEUE_NAME", "task_queue"),
+		WorkerCount:     workerCount,
+		RedisAddrs:      redisAddrs,
+		RedisPwd:        "",
+		RedisIsCluster:  getEnv("REDIS_CLUSTER", "false") == "true",
+		Tracker:         tracker,
+		UsageBreakdown:  usageBreakdown,
+		CollectMetrics:  getEnv("COLLECT_METRICS", "false") == "true",
+		DCGMExporterURL: getEnv("DCGM_EXPORTER_URL", ""),
+		NodeEvents:      getEnv("NODE_EVENTS", "true") == "true",
+		EventNamespace:  getEnv("NODE_EVENT_NAMESPACE", "default"),
+		HistoryMaxLen:   historyMaxLen,
+		WebhookEnabled:  getEnv("CONVERSION_WEBHOOK", "true") == "true",
+		WebhookAddr:     getEnv("WEBHOOK_LISTEN_ADDR", ":9443"),
+		WebhookCert:     getEnv("WEBHOOK_CERT_FILE", "/etc/opsflow/webhook/tls.crt"),
+		WebhookKey:      getEnv("WEBHOOK_KEY_FILE", "/etc/opsflow/webhook/tls.key"),
+		ModelConfigRoot: getEnv("MODEL_CONFIG_ROOT", ""),
+		EndpointProbe:   model.EndpointProbeOptions{Timeout: probeTimeout, DegradedAfter: degradedAfter, LoadTimeout: loadTimeout},
+		HostPath:        hostPathPolicy,
 	}, nil
 }
 
-func CreateGinRouter(client core.Client) *gin.Engine {
+func CreateGinRouter(client core.Client, redisClient redis.Cmdable, jobOptions model.JobOptions) *gin.Engine {
 	r := gin.Default()
-	r.Use(core.AppContextMiddleware(client))
+	r.Use(core.AppContextMiddleware(client, redisClient, jobOptions))
 
 	api := r.Group("/api/v1")
 	{
@@ -76,11 +148,80 @@ func CreateGinRouter(client core.Client) *gin.Engine {
 		api.POST("/rayjob", handler.CreateRayJobHandle)
 		api.GET("/rayjob/:namespace/:name", handler.RayJobInfoHandle)
 		api.DELETE("/rayjob/:namespace/:name", handler.RemoveRayJobHandle)
+		api.GET("/nodes/:name/usage", handler.NodeUsageHandle)
+		api.POST("/nodes/:name/cordon", handler.CordonNodeHandle)
+		api.POST("/nodes/:name/uncordon", handler.UncordonNodeHandle)
+		api.POST("/nodes/:name/drain", handler.DrainNodeHandle)
+		api.GET("/nodes/:name/history", handler.NodeHistoryHandle)
+		api.GET("/nodes/:name/history/stream", handler.NodeChangeStreamHandle)
+		api.GET("/cluster/changes/stream", handler.ClusterChangeStreamHandle)
+		api.GET("/cluster/capacity", handler.ClusterCapacityHandle)
+		api.POST("/placement/check", handler.PlacementCheckHandle)
+		api.POST("/deployments", handler.CreateModelDeploymentHandle)
+		api.GET("/deployments/:namespace/:name", handler.ModelDeploymentInfoHandle)
+		api.DELETE("/deployments/:namespace/:name", handler.RemoveModelDeploymentHandle)
+		api.POST("/deployments/:namespace/:name/revisions", handler.AddModelRevisionHandle)
+		api.POST("/deployments/:namespace/:name/rollback", handler.RollbackModelDeploymentHandle)
+		api.POST("/models", handler.CreateModelArtifactHandle)
+		api.GET("/models/:namespace", handler.ListModelArtifactsHandle)
+		api.GET("/models/:namespace/:name", handler.ModelArtifactInfoHandle)
+		api.DELETE("/models/:namespace/:name", handler.RemoveModelArtifactHandle)
 	}
 
 	return r
 }
 
+// CRD 版本转换 webhook，apiserver 要求使用 https
+func CreateWebhookRouter() *gin.Engine {
+	r := gin.Default()
+	r.POST("/convert", webhook.ConvertHandle)
+	return r
+}
+
+// newJobOptions 创建任务与探测推理服务时使用的选项，HTTP 接口与定时任务共用
+func newJobOptions(cfg *Config) model.JobOptions {
+	options := model.JobOptions{
+		EndpointProbe: cfg.EndpointProbe,
+		HostPath:      cfg.HostPath,
+	}
+	if cfg.ModelConfigRoot != "" {
+		options.ModelConfigLoader = job.LocalModelConfigLoader(cfg.ModelConfigRoot)
+	}
+	return options
+}
+
+func newLoadOptions(cfg *Config, client core.Client) resourceinfo.LoadOptions {
+	options := resourceinfo.LoadOptions{
+		ResourceTracker: cfg.Tracker,
+		UsageBreakdown:  cfg.UsageBreakdown,
+	}
+	if cfg.CollectMetrics || cfg.DCGMExporterURL != "" {
+		collector := &resourceinfo.UtilizationCollector{}
+		if cfg.CollectMetrics {
+			collector.Metrics = client.Metrics()
+		}
+		if cfg.DCGMExporterURL != "" {
+			collector.DCGM = &resourceinfo.DCGMExporter{
+				URLTemplate: cfg.DCGMExporterURL,
+				Client:      &http.Client{Timeout: 5 * time.Second},
+			}
+		}
+		options.Utilization = collector
+	}
+	return options
+}
+
+// 节点变化写入 Redis stream，开启时同时写为 Kubernetes Event
+func newRecorder(cfg *Config, client core.Client, redisClient redis.Cmdable) history.Recorder {
+	recorders := history.Recorders{
+		&history.RedisStore{Client: redisClient, MaxLen: cfg.HistoryMaxLen},
+	}
+	if cfg.NodeEvents {
+		recorders = append(recorders, &history.EventRecorder{Clientset: client.Core(), Namespace: cfg.EventNamespace})
+	}
+	return recorders
+}
+
 func createRedisClient(cfg *Config) (redis.Cmdable, error) {
 	if cfg.RedisIsCluster {
 		client := redis.NewClusterClient(&redis.ClusterOptions{
@@ -169,6 +310,10 @@ func main() {
 		log.Fatal(err)
 	}
 
+	recorder := newRecorder(cfg, client, redisClient)
+
+	jobOptions := newJobOptions(cfg)
+
 	var wg sync.WaitGroup
 
 	// Start task queue processor
@@ -177,7 +322,9 @@ func main() {
 		defer wg.Done()
 		queueConfig := queue.TaskProcessorConfig{
 			Clientset:   client.Core(),
-			CRDClient:   client.DynamicNRI(),
+			CRDClient:   client.OpsFlow().OpsflowV1beta1().NodeResourceInfos(),
+			LoadOptions: newLoadOptions(cfg, client),
+			Recorder:    recorder,
 			RpcConn:     conn,
 			RedisClient: redisClient,
 			WorkerCount: cfg.WorkerCount,
@@ -190,11 +337,12 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tasksConfig := tasks.InitializeTasks(client, redisClient, conn)
+		tasksConfig := tasks.InitializeTasks(client, redisClient, conn, recorder, jobOptions)
 		tasks.StartTaskScheduler(redisClient, tasksConfig)
 	}()
 
 	// Start agent
+	agent.RegisterMaintenanceFunctions(client.Core())
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -202,7 +350,7 @@ func main() {
 	}()
 
 	// Start HTTP server
-	r := CreateGinRouter(client)
+	r := CreateGinRouter(client, redisClient, jobOptions)
 	server := &http.Server{
 		Addr:    cfg.ListenAddr,
 		Handler: r,
@@ -216,6 +364,29 @@ func main() {
 		}
 	}()
 
+	// Start conversion webhook server, CRD 的转换策略为 Webhook 时 apiserver 依赖该服务读写 v1alpha1，
+	// 开启但未挂载证书时直接退出，避免 v1alpha1 请求在运行时失败
+	var webhookServer *http.Server
+	if cfg.WebhookEnabled {
+		if _, err := os.Stat(cfg.WebhookCert); err != nil {
+			log.Fatalf("Webhook certificate %s not available: %v, set CONVERSION_WEBHOOK=false if the CRD uses the None conversion strategy", cfg.WebhookCert, err)
+		}
+		webhookServer = &http.Server{
+			Addr:    cfg.WebhookAddr,
+			Handler: CreateWebhookRouter(),
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := webhookServer.ListenAndServeTLS(cfg.WebhookCert, cfg.WebhookKey); err != nil && err != http.ErrServerClosed {
+				log.Printf("Failed to start webhook server: %v", err)
+			}
+		}()
+	} else {
+		log.Println("Conversion webhook disabled")
+	}
+
 	// Handle shutdown gracefully
 	<-ctx.Done()
 	log.Println("Shutting down server...")
@@ -226,6 +397,11 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Server shutdown error: %v", err)
 	}
+	if webhookServer != nil {
+		if err := webhookServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Webhook server shutdown error: %v", err)
+		}
+	}
 
 	wg.Wait()
 	log.Println("Server exited properly")
@@ -2,15 +2,36 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"sync"
 	"time"
 
 	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
+// 函数请求没有指定超时时间时的默认超时，需要大于 drain 的最大等待时间
+const defaultFunctionTimeout = 15 * time.Minute
+
+// session 一个 stream 上的状态。gRPC stream 的 Send 不能并发调用，心跳与各个函数的结果需要串行发送
+type session struct {
+	stream pb.AgentService_AgentStreamClient
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc // 正在执行的函数，用于处理 CancelTask
+}
+
+func (s *session) send(msg *pb.AgentMessage) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.stream.Send(msg)
+}
+
 func RunAgent(conn *grpc.ClientConn, agentID string) error {
 	client := pb.NewAgentServiceClient(conn)
 
@@ -21,9 +42,10 @@ func RunAgent(conn *grpc.ClientConn, agentID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to connect stream: %w", err)
 	}
+	s := &session{stream: stream, running: map[string]context.CancelFunc{}}
 
 	// Send initial heartbeat (required)
-	if err := stream.Send(&pb.AgentMessage{
+	if err := s.send(&pb.AgentMessage{
 		Body: &pb.AgentMessage_Heartbeat{
 			Heartbeat: &pb.Heartbeat{
 				AgentId:   agentID,
@@ -44,7 +66,7 @@ func RunAgent(conn *grpc.ClientConn, agentID string) error {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				err := stream.Send(&pb.AgentMessage{
+				err := s.send(&pb.AgentMessage{
 					Body: &pb.AgentMessage_Heartbeat{
 						Heartbeat: &pb.Heartbeat{
 							AgentId:   agentID,
@@ -62,7 +84,7 @@ func RunAgent(conn *grpc.ClientConn, agentID string) error {
 		}
 	}()
 
-	// Listen for server messages
+	// Listen for server messages，函数在各自的 goroutine 中执行，不阻塞接收
 	for {
 		in, err := stream.Recv()
 		if err == io.EOF {
@@ -73,66 +95,78 @@ func RunAgent(conn *grpc.ClientConn, agentID string) error {
 			log.Printf("stream recv error: %v", err)
 			return err
 		}
-		handleMessage(stream, in)
+		s.handleMessage(ctx, in)
 	}
 }
 
-func handleMessage(stream pb.AgentService_AgentStreamClient, msg *pb.AgentMessage) {
+func (s *session) handleMessage(ctx context.Context, msg *pb.AgentMessage) {
 	switch body := msg.Body.(type) {
 	case *pb.AgentMessage_FunctionRequest:
 		req := body.FunctionRequest
 		log.Printf("received function request: id=%s function=%s", req.RequestId, req.FunctionName)
-		go executeFunction(stream, req)
+		timeout := defaultFunctionTimeout
+		if req.TimeoutSeconds > 0 {
+			timeout = time.Duration(req.TimeoutSeconds) * time.Second
+		}
+		// 在接收下一条消息前登记，之后到达的 CancelTask 一定能找到该请求
+		funcCtx, cancel := context.WithTimeout(ctx, timeout)
+		s.mu.Lock()
+		s.running[req.RequestId] = cancel
+		s.mu.Unlock()
+		go s.executeFunction(funcCtx, cancel, req)
 
 	case *pb.AgentMessage_CancelTask:
 		cancelReq := body.CancelTask
 		log.Printf("received cancel for request: id=%s", cancelReq.RequestId)
-		// TODO: Implement cancellation logic for running functions
+		s.mu.Lock()
+		if cancel, ok := s.running[cancelReq.RequestId]; ok {
+			cancel()
+		}
+		s.mu.Unlock()
 
 	default:
 		log.Println("received unknown message")
 	}
 }
 
-func executeFunction(stream pb.AgentService_AgentStreamClient, req *pb.FunctionRequest) {
+// executeFunction 在 ctx 内执行函数，ctx 在 timeout_seconds（默认 defaultFunctionTimeout）后、stream 断开或收到 CancelTask 时取消
+func (s *session) executeFunction(ctx context.Context, cancel context.CancelFunc, req *pb.FunctionRequest) {
+	defer func() {
+		cancel()
+		s.mu.Lock()
+		delete(s.running, req.RequestId)
+		s.mu.Unlock()
+	}()
 	log.Printf("executing function %s (request_id: %s)", req.FunctionName, req.RequestId)
 
 	handler, ok := functionRegistry[req.FunctionName]
 	if !ok {
 		log.Printf("unknown function: %s", req.FunctionName)
-		sendError(stream, req.RequestId, "unknown function")
+		s.sendResult(req.RequestId, nil, errors.New("unknown function"))
 		return
 	}
 
-	result, err := handler(req.Parameters)
+	result, err := handler(ctx, req.Parameters)
 	if err != nil {
 		log.Printf("handler error for function %s: %v", req.FunctionName, err)
-		sendError(stream, req.RequestId, err.Error())
-		return
 	}
+	s.sendResult(req.RequestId, result, err)
+	log.Printf("finished function %s (request_id: %s)", req.FunctionName, req.RequestId)
+}
 
-	err = stream.Send(&pb.AgentMessage{
-		Body: &pb.AgentMessage_FunctionResult{
-			FunctionResult: &pb.FunctionResult{
-				RequestId: req.RequestId,
-				Success:   true,
-				Result:    result,
-			},
-		},
-	})
+// 失败时 result 不为空的同样返回，如 drain 被阻塞时受影响的 Pod
+func (s *session) sendResult(requestID string, result *structpb.Struct, err error) {
+	functionResult := &pb.FunctionResult{
+		RequestId: requestID,
+		Success:   err == nil,
+		Result:    result,
+	}
 	if err != nil {
+		functionResult.ErrorMessage = err.Error()
+	}
+	if err := s.send(&pb.AgentMessage{
+		Body: &pb.AgentMessage_FunctionResult{FunctionResult: functionResult},
+	}); err != nil {
 		log.Printf("failed to send function result: %v", err)
 	}
-	log.Printf("finished function %s (request_id: %s)", req.FunctionName, req.RequestId)
-}
-func sendError(stream pb.AgentService_AgentStreamClient, requestID, message string) {
-	_ = stream.Send(&pb.AgentMessage{
-		Body: &pb.AgentMessage_FunctionResult{
-			FunctionResult: &pb.FunctionResult{
-				RequestId:    requestID,
-				Success:      false,
-				ErrorMessage: message,
-			},
-		},
-	})
 }
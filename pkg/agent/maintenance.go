@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modcoco/OpsFlow/pkg/node/maintenance"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/client-go/kubernetes"
+)
+
+type CordonInput struct {
+	NodeName string `json:"nodeName"`
+}
+
+type CordonOutput struct {
+	Node    string `json:"node"`
+	Changed bool   `json:"changed"`
+}
+
+type DrainInput struct {
+	NodeName string `json:"nodeName"`
+	maintenance.DrainOptions
+}
+
+// RegisterMaintenanceFunctions 注册节点维护相关的函数：CordonNode、UncordonNode、DrainNode
+func RegisterMaintenanceFunctions(clientset kubernetes.Interface) {
+	Register("CordonNode", cordonHandler(clientset, maintenance.Cordon))
+	Register("UncordonNode", cordonHandler(clientset, maintenance.Uncordon))
+	Register("DrainNode", drainHandler(clientset))
+}
+
+func cordonHandler(clientset kubernetes.Interface, fn func(context.Context, kubernetes.Interface, string) (bool, error)) FunctionHandler {
+	return func(ctx context.Context, params *structpb.Struct) (*structpb.Struct, error) {
+		var input CordonInput
+		if err := decodeParams(params, &input); err != nil {
+			return nil, err
+		}
+		if input.NodeName == "" {
+			return nil, fmt.Errorf("nodeName 不能为空")
+		}
+
+		changed, err := fn(ctx, clientset, input.NodeName)
+		if err != nil {
+			return nil, err
+		}
+		return encodeResult(CordonOutput{Node: input.NodeName, Changed: changed})
+	}
+}
+
+func drainHandler(clientset kubernetes.Interface) FunctionHandler {
+	return func(ctx context.Context, params *structpb.Struct) (*structpb.Struct, error) {
+		var input DrainInput
+		if err := decodeParams(params, &input); err != nil {
+			return nil, err
+		}
+		if input.NodeName == "" {
+			return nil, fmt.Errorf("nodeName 不能为空")
+		}
+		if err := input.DrainOptions.Validate(); err != nil {
+			return nil, err
+		}
+
+		result, err := maintenance.Drain(ctx, clientset, input.NodeName, input.DrainOptions)
+		if err != nil {
+			// 被阻塞时同时返回需要 force 或 deleteEmptyDirData 的 Pod
+			if errors.Is(err, maintenance.ErrDrainBlocked) && result != nil {
+				output, encodeErr := encodeResult(result)
+				if encodeErr != nil {
+					return nil, encodeErr
+				}
+				return output, err
+			}
+			return nil, err
+		}
+		return encodeResult(result)
+	}
+}
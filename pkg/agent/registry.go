@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -30,28 +31,48 @@ func Hello(input InputStruct) OutputStruct {
 	}
 }
 
-type FunctionHandler func(*structpb.Struct) (*structpb.Struct, error)
+// FunctionHandler 执行一次函数调用，ctx 在超时、stream 断开或收到 CancelTask 时取消。
+// 返回错误时 result 不为空的同样会返回给管理端
+type FunctionHandler func(ctx context.Context, params *structpb.Struct) (*structpb.Struct, error)
 
 var functionRegistry = map[string]FunctionHandler{
 	"Hello": helloHandler,
 }
 
-func helloHandler(params *structpb.Struct) (*structpb.Struct, error) {
+// Register 注册可由管理端调用的函数，需要在 RunAgent 之前调用
+func Register(name string, handler FunctionHandler) {
+	functionRegistry[name] = handler
+}
+
+func helloHandler(_ context.Context, params *structpb.Struct) (*structpb.Struct, error) {
 	var input InputStruct
 
 	// Step 1: 将 protobuf Struct 参数转为 InputStruct
-	paramJson, err := protojson.Marshal(params)
-	if err != nil {
-		return nil, fmt.Errorf("marshal error: %w", err)
-	}
-	if err := json.Unmarshal(paramJson, &input); err != nil {
-		return nil, fmt.Errorf("unmarshal error: %w", err)
+	if err := decodeParams(params, &input); err != nil {
+		return nil, err
 	}
 
 	// Step 2: 调用逻辑函数
 	output := Hello(input)
 
-	// Step 3: 把输出结构体转为 JSON -> map[string]interface{} -> *structpb.Struct
+	// Step 3: 把输出结构体转为 *structpb.Struct
+	return encodeResult(output)
+}
+
+// 将 protobuf Struct 参数按 JSON 解析到 input
+func decodeParams(params *structpb.Struct, input any) error {
+	paramJson, err := protojson.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	if err := json.Unmarshal(paramJson, input); err != nil {
+		return fmt.Errorf("unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// 输出结构体转为 JSON -> map[string]interface{} -> *structpb.Struct
+func encodeResult(output any) (*structpb.Struct, error) {
 	outputJson, err := json.Marshal(output)
 	if err != nil {
 		return nil, fmt.Errorf("output marshal to json error: %w", err)
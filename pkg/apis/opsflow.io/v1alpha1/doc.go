@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=opsflow.io
+
+// Package v1alpha1 是 NodeResourceInfo 的旧版本 API，节点观测数据保存在 spec 中，
+// 仅为兼容保留，存储版本为 v1beta1。
+package v1alpha1
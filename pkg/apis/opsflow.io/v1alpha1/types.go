@@ -2,12 +2,22 @@ package v1alpha1
 
 import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+// NodeManagerFinalizer 阻止 NodeResourceInfo 在 manager 确认删除节点之前被删除
+const NodeManagerFinalizer = "opsflow.io/node-manager"
+
 type ResourceInfo struct {
 	Total       string `json:"total"`
 	Allocatable string `json:"allocatable"`
 	Used        string `json:"used"`
 }
 
+// NodeTaint 节点污点
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"` // NoSchedule | PreferNoSchedule | NoExecute
+}
+
 type NodeResourceInfoSpec struct {
 	NodeName         string                  `json:"nodeName"`
 	Resources        map[string]ResourceInfo `json:"resources"`
@@ -18,10 +28,44 @@ type NodeResourceInfoSpec struct {
 	OS               string                  `json:"os"`
 	KernelVersion    string                  `json:"kernelVersion"`
 	ContainerRuntime string                  `json:"containerRuntime"`
+	Labels           map[string]string       `json:"labels,omitempty"`      // 过滤后的节点标签
+	Annotations      map[string]string       `json:"annotations,omitempty"` // 过滤后的节点注解
+	Taints           []NodeTaint             `json:"taints,omitempty"`
+}
+
+// ManagerSyncStatus 记录 NodeManager 已确认的 CRD 版本，未确认的版本会在后续周期重试通知
+type ManagerSyncStatus struct {
+	AcknowledgedGeneration int64        `json:"acknowledgedGeneration"`    // manager 已确认的 metadata.generation
+	PendingOperation       string       `json:"pendingOperation"`          // 待确认的操作 AddNode/UpdateNode，已确认时为空
+	Attempts               int32        `json:"attempts"`                  // 当前待确认版本的通知次数
+	LastAttemptTime        *metav1.Time `json:"lastAttemptTime,omitempty"` // 最近一次通知时间
+	LastError              string       `json:"lastError"`                 // 最近一次通知失败原因
 }
 
+type NodeResourceInfoStatus struct {
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	ManagerSync        *ManagerSyncStatus `json:"managerSync,omitempty"`
+	LastHeartbeatTime  *metav1.Time       `json:"lastHeartbeatTime,omitempty"` // 最近一次 manager 确认心跳的时间
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=noderesourceinfos,scope=Cluster,shortName=nri
+// +kubebuilder:subresource:status
 type NodeResourceInfo struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              NodeResourceInfoSpec `json:"spec"`
+	Spec              NodeResourceInfoSpec   `json:"spec"`
+	Status            NodeResourceInfoStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type NodeResourceInfoList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeResourceInfo `json:"items"`
 }
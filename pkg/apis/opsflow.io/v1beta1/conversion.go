@@ -0,0 +1,183 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+)
+
+// ConversionAnnotation 转换为 v1alpha1 时保存 v1alpha1 无法表示的字段，转换回 v1beta1 时恢复并移除，
+// 避免通过 v1alpha1 读写后丢失 GPU、健康状态、使用拆分等信息
+const ConversionAnnotation = "opsflow.io/v1beta1-fields"
+
+// conversionFields v1beta1 中 v1alpha1 没有的字段
+type conversionFields struct {
+	GPU            *GPUInfo                 `json:"gpu,omitempty"`
+	Resources      map[string]ResourceUsage `json:"resources,omitempty"` // limits、utilized 以及 spec 中没有的资源
+	Health         NodeHealth               `json:"health,omitempty"`
+	NodeConditions []NodeCondition          `json:"nodeConditions,omitempty"`
+	GPUAllocations []GPUAllocation          `json:"gpuAllocations,omitempty"`
+	UsageBreakdown *UsageBreakdown          `json:"usageBreakdown,omitempty"`
+	Utilization    *Utilization             `json:"utilization,omitempty"`
+}
+
+// ConvertFromV1alpha1 将旧版本对象转换为 v1beta1，spec 中的 status 与 used 移到 status 中
+func ConvertFromV1alpha1(in *v1alpha1.NodeResourceInfo) *NodeResourceInfo {
+	out := &NodeResourceInfo{
+		TypeMeta:   in.TypeMeta,
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: NodeResourceInfoSpec{
+			NodeName:         in.Spec.NodeName,
+			Roles:            in.Spec.Roles,
+			ScheduleVersion:  in.Spec.ScheduleVersion,
+			InternalIp:       in.Spec.InternalIp,
+			OS:               in.Spec.OS,
+			KernelVersion:    in.Spec.KernelVersion,
+			ContainerRuntime: in.Spec.ContainerRuntime,
+			Labels:           in.Spec.Labels,
+			Annotations:      in.Spec.Annotations,
+		},
+		Status: NodeResourceInfoStatus{
+			NodeStatus:         in.Spec.Status,
+			Conditions:         in.Status.Conditions,
+			ObservedGeneration: in.Status.ObservedGeneration,
+			LastHeartbeatTime:  in.Status.LastHeartbeatTime,
+		},
+	}
+	out.APIVersion = SchemeGroupVersion.String()
+
+	if in.Spec.Resources != nil {
+		out.Spec.Resources = make(map[string]ResourceCapacity, len(in.Spec.Resources))
+		out.Status.Resources = make(map[string]ResourceUsage, len(in.Spec.Resources))
+		for name, info := range in.Spec.Resources {
+			out.Spec.Resources[name] = ResourceCapacity{Total: info.Total, Allocatable: info.Allocatable}
+			if info.Used != "" {
+				out.Status.Resources[name] = ResourceUsage{Used: info.Used}
+			}
+		}
+		if len(out.Status.Resources) == 0 {
+			out.Status.Resources = nil
+		}
+	}
+	for _, taint := range in.Spec.Taints {
+		out.Spec.Taints = append(out.Spec.Taints, NodeTaint(taint))
+	}
+	if in.Status.ManagerSync != nil {
+		sync := ManagerSyncStatus(*in.Status.ManagerSync)
+		out.Status.ManagerSync = &sync
+	}
+	restoreConversionFields(out)
+	return out
+}
+
+// 恢复转换为 v1alpha1 时保存的字段，注解被修改为非法内容时忽略
+func restoreConversionFields(out *NodeResourceInfo) {
+	raw, ok := out.Annotations[ConversionAnnotation]
+	if !ok {
+		return
+	}
+	delete(out.Annotations, ConversionAnnotation)
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+
+	var fields conversionFields
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return
+	}
+	out.Spec.GPU = fields.GPU
+	out.Status.Health = fields.Health
+	out.Status.NodeConditions = fields.NodeConditions
+	out.Status.GPUAllocations = fields.GPUAllocations
+	out.Status.UsageBreakdown = fields.UsageBreakdown
+	out.Status.Utilization = fields.Utilization
+	for name, saved := range fields.Resources {
+		if out.Status.Resources == nil {
+			out.Status.Resources = map[string]ResourceUsage{}
+		}
+		// used 以 v1alpha1 中的值为准
+		if _, ok := out.Spec.Resources[name]; ok {
+			saved.Used = out.Status.Resources[name].Used
+		}
+		out.Status.Resources[name] = saved
+	}
+}
+
+// 收集 v1alpha1 无法表示的字段，没有时返回 nil
+func extractConversionFields(in *NodeResourceInfo) *conversionFields {
+	fields := &conversionFields{
+		GPU:            in.Spec.GPU,
+		Health:         in.Status.Health,
+		NodeConditions: in.Status.NodeConditions,
+		GPUAllocations: in.Status.GPUAllocations,
+		UsageBreakdown: in.Status.UsageBreakdown,
+		Utilization:    in.Status.Utilization,
+	}
+	for name, usage := range in.Status.Resources {
+		if _, ok := in.Spec.Resources[name]; ok && usage.Limits == "" && usage.Utilized == "" {
+			continue
+		}
+		if fields.Resources == nil {
+			fields.Resources = map[string]ResourceUsage{}
+		}
+		fields.Resources[name] = usage
+	}
+	if reflect.ValueOf(*fields).IsZero() {
+		return nil
+	}
+	return fields
+}
+
+// ConvertToV1alpha1 将 v1beta1 对象转换为旧版本，status 中的节点状态与使用量写回 spec
+func ConvertToV1alpha1(in *NodeResourceInfo) *v1alpha1.NodeResourceInfo {
+	out := &v1alpha1.NodeResourceInfo{
+		TypeMeta:   in.TypeMeta,
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: v1alpha1.NodeResourceInfoSpec{
+			NodeName:         in.Spec.NodeName,
+			Status:           in.Status.NodeStatus,
+			Roles:            in.Spec.Roles,
+			ScheduleVersion:  in.Spec.ScheduleVersion,
+			InternalIp:       in.Spec.InternalIp,
+			OS:               in.Spec.OS,
+			KernelVersion:    in.Spec.KernelVersion,
+			ContainerRuntime: in.Spec.ContainerRuntime,
+			Labels:           in.Spec.Labels,
+			Annotations:      in.Spec.Annotations,
+		},
+		Status: v1alpha1.NodeResourceInfoStatus{
+			Conditions:         in.Status.Conditions,
+			ObservedGeneration: in.Status.ObservedGeneration,
+			LastHeartbeatTime:  in.Status.LastHeartbeatTime,
+		},
+	}
+	out.APIVersion = v1alpha1.SchemeGroupVersion.String()
+
+	if in.Spec.Resources != nil {
+		out.Spec.Resources = make(map[string]v1alpha1.ResourceInfo, len(in.Spec.Resources))
+		for name, capacity := range in.Spec.Resources {
+			out.Spec.Resources[name] = v1alpha1.ResourceInfo{
+				Total:       capacity.Total,
+				Allocatable: capacity.Allocatable,
+				Used:        in.Status.Resources[name].Used,
+			}
+		}
+	}
+	for _, taint := range in.Spec.Taints {
+		out.Spec.Taints = append(out.Spec.Taints, v1alpha1.NodeTaint(taint))
+	}
+	if in.Status.ManagerSync != nil {
+		sync := v1alpha1.ManagerSyncStatus(*in.Status.ManagerSync)
+		out.Status.ManagerSync = &sync
+	}
+	if fields := extractConversionFields(in); fields != nil {
+		// 字段均为可序列化的类型，不会失败
+		raw, _ := json.Marshal(fields)
+		if out.Annotations == nil {
+			out.Annotations = map[string]string{}
+		}
+		out.Annotations[ConversionAnnotation] = string(raw)
+	}
+	return out
+}
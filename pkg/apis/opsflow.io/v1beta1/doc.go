@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=opsflow.io
+
+// Package v1beta1 是 NodeResourceInfo 的存储版本，节点描述信息保存在 spec，
+// 使用量、节点状态以及与 manager 的同步情况等观测数据保存在 status 子资源。
+package v1beta1
@@ -0,0 +1,110 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ModelSourceType 模型的下载来源
+// +kubebuilder:validation:Enum=S3;HuggingFace
+type ModelSourceType string
+
+const (
+	ModelSourceS3          ModelSourceType = "S3"          // S3 兼容的对象存储，如 MinIO
+	ModelSourceHuggingFace ModelSourceType = "HuggingFace" // HuggingFace 或兼容的镜像站
+)
+
+// ModelArtifactPhase 模型的下载阶段
+// +kubebuilder:validation:Enum=Pending;Downloading;Ready;Failed
+type ModelArtifactPhase string
+
+const (
+	ModelArtifactPending     ModelArtifactPhase = "Pending"     // 等待创建 PVC 与下载任务
+	ModelArtifactDownloading ModelArtifactPhase = "Downloading" // 下载任务正在运行
+	ModelArtifactReady       ModelArtifactPhase = "Ready"       // 下载完成，可以被任务引用
+	ModelArtifactFailed      ModelArtifactPhase = "Failed"      // 下载任务失败或校验和不一致
+)
+
+// ModelArtifactLabel 下载任务与 PVC 上记录所属模型的 label
+const ModelArtifactLabel = "opsflow.io/model-artifact"
+
+// S3ModelSource S3 兼容存储中 prefix 下的所有对象
+type S3ModelSource struct {
+	Endpoint string `json:"endpoint"` // 如 http://minio.minio:9000
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`
+	Region   string `json:"region,omitempty"` // 默认为 us-east-1
+	// 包含 AWS_ACCESS_KEY_ID 与 AWS_SECRET_ACCESS_KEY 的 Secret，为空时匿名访问
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// HuggingFaceModelSource HuggingFace 风格的模型仓库
+type HuggingFaceModelSource struct {
+	Repo     string `json:"repo"`               // 如 Qwen/Qwen2.5-7B-Instruct
+	Revision string `json:"revision,omitempty"` // 默认为 main
+	Endpoint string `json:"endpoint,omitempty"` // 镜像站地址，默认为 https://huggingface.co
+	// 包含 HF_TOKEN 的 Secret，为空时匿名访问
+	SecretName string `json:"secretName,omitempty"`
+}
+
+type ModelSource struct {
+	Type        ModelSourceType         `json:"type"`
+	S3          *S3ModelSource          `json:"s3,omitempty"`
+	HuggingFace *HuggingFaceModelSource `json:"huggingFace,omitempty"`
+}
+
+// ModelStorage 保存模型的 PVC，名称为 model-<模型名称>
+type ModelStorage struct {
+	Size             string `json:"size"` // 如 100Gi
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// 默认为 ReadWriteMany，多机推理时每个节点都需要挂载
+	AccessModes []string `json:"accessModes,omitempty"`
+}
+
+type ModelArtifactSpec struct {
+	Source  ModelSource  `json:"source"`
+	Storage ModelStorage `json:"storage"`
+	// 期望的校验和，见 status.checksum，不一致时下载失败
+	Checksum string `json:"checksum,omitempty"`
+	// 下载任务的镜像，需要 python3，默认为 python:3.12-slim
+	DownloaderImage string `json:"downloaderImage,omitempty"`
+}
+
+type ModelArtifactStatus struct {
+	Phase     ModelArtifactPhase `json:"phase,omitempty"`
+	PVCName   string             `json:"pvcName,omitempty"`
+	JobName   string             `json:"jobName,omitempty"`
+	Files     int32              `json:"files,omitempty"`     // 需要下载的文件数
+	Completed int32              `json:"completed,omitempty"` // 已下载的文件数
+	// 已下载与总共的字节数
+	DownloadedBytes int64 `json:"downloadedBytes,omitempty"`
+	TotalBytes      int64 `json:"totalBytes,omitempty"`
+	// 所有文件 sha256 的清单的 sha256，清单每行为 "<sha256>  <相对路径>"，按路径排序
+	Checksum    string       `json:"checksum,omitempty"`
+	StartedAt   *metav1.Time `json:"startedAt,omitempty"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	Message     string       `json:"message,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=modelartifacts,scope=Namespaced,shortName=mart
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source.type`
+// +kubebuilder:printcolumn:name="Size",type=string,JSONPath=`.spec.storage.size`
+// +kubebuilder:printcolumn:name="Files",type=integer,JSONPath=`.status.completed`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ModelArtifact struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ModelArtifactSpec   `json:"spec"`
+	Status            ModelArtifactStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type ModelArtifactList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelArtifact `json:"items"`
+}
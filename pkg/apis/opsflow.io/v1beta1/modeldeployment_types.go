@@ -0,0 +1,91 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// RolloutStrategy 新版本接管流量的方式
+// +kubebuilder:validation:Enum=Canary;BlueGreen
+type RolloutStrategy string
+
+const (
+	RolloutCanary    RolloutStrategy = "Canary"    // 新版本就绪后按 stepWeight 逐步增加权重
+	RolloutBlueGreen RolloutStrategy = "BlueGreen" // 新版本就绪后一次切换全部流量
+)
+
+// RolloutPhase 模型部署当前的发布阶段
+// +kubebuilder:validation:Enum=Idle;Progressing;Succeeded;RolledBack
+type RolloutPhase string
+
+const (
+	RolloutIdle        RolloutPhase = "Idle"        // 还没有版本
+	RolloutProgressing RolloutPhase = "Progressing" // 新版本正在接管流量
+	RolloutSucceeded   RolloutPhase = "Succeeded"   // 新版本已接管全部流量
+	RolloutRolledBack  RolloutPhase = "RolledBack"  // 新版本已回滚，流量回到稳定版本
+)
+
+// ModelDeploymentLabel RayJob 上记录所属模型部署的 label
+const ModelDeploymentLabel = "opsflow.io/model-deployment"
+
+type ModelDeploymentSpec struct {
+	Host string `json:"host"`           // VirtualService 的域名
+	Path string `json:"path,omitempty"` // 路径前缀，默认为 /，不为 / 时改写为 /
+	// 已有的 Istio Gateway，namespace/name，为空时为部署创建 Gateway
+	Gateway string `json:"gateway,omitempty"`
+	// 创建 Gateway 时的 selector，默认为 istio: ingressgateway
+	GatewaySelector map[string]string `json:"gatewaySelector,omitempty"`
+	// +kubebuilder:default=Canary
+	Strategy RolloutStrategy `json:"strategy,omitempty"`
+	// Canary 每一步增加的权重，默认为 20
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	StepWeight int32 `json:"stepWeight,omitempty"`
+	// Canary 每一步之间的间隔，默认为 60
+	// +kubebuilder:validation:Minimum=0
+	StepIntervalSeconds int32 `json:"stepIntervalSeconds,omitempty"`
+}
+
+// ModelRevision 模型部署的一个版本，对应一个 RayJob
+type ModelRevision struct {
+	Revision    int32       `json:"revision"`
+	JobName     string      `json:"jobName"`               // RayJob 名称
+	ClusterName string      `json:"clusterName,omitempty"` // RayCluster 名称，创建后才有
+	CreatedAt   metav1.Time `json:"createdAt"`
+}
+
+type ModelDeploymentStatus struct {
+	Phase          RolloutPhase    `json:"phase,omitempty"`
+	StableRevision int32           `json:"stableRevision,omitempty"` // 接管全部流量的版本，0 表示没有
+	CanaryRevision int32           `json:"canaryRevision,omitempty"` // 正在发布的版本，0 表示没有
+	CanaryWeight   int32           `json:"canaryWeight,omitempty"`   // 发布版本的流量权重，0-100
+	LatestRevision int32           `json:"latestRevision,omitempty"` // 已创建的最大版本号
+	Revisions      []ModelRevision `json:"revisions,omitempty"`      // 存在的版本，只有稳定版本与发布版本
+	// 最近一次修改权重或阶段的时间
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=modeldeployments,scope=Namespaced,shortName=mdeploy
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Stable",type=integer,JSONPath=`.status.stableRevision`
+// +kubebuilder:printcolumn:name="Canary",type=integer,JSONPath=`.status.canaryRevision`
+// +kubebuilder:printcolumn:name="Weight",type=integer,JSONPath=`.status.canaryWeight`
+// +kubebuilder:printcolumn:name="Host",type=string,JSONPath=`.spec.host`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ModelDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ModelDeploymentSpec   `json:"spec"`
+	Status            ModelDeploymentStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type ModelDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelDeployment `json:"items"`
+}
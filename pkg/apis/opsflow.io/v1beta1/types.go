@@ -0,0 +1,194 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// NodeManagerFinalizer 阻止 NodeResourceInfo 在 manager 确认删除节点之前被删除
+const NodeManagerFinalizer = "opsflow.io/node-manager"
+
+const (
+	// ConditionReady 节点是否处于 Ready 状态
+	ConditionReady = "Ready"
+	// ConditionSyncedToManager 当前 generation 与节点状态是否已被 NodeManager 确认
+	ConditionSyncedToManager = "SyncedToManager"
+)
+
+// NodeHealth 由节点 conditions 与是否可调度推导出的健康状态
+// +kubebuilder:validation:Enum=Ready;NotReady;Degraded;Cordoned;Unknown
+type NodeHealth string
+
+const (
+	NodeHealthReady    NodeHealth = "Ready"    // Ready 且没有其他异常 condition
+	NodeHealthNotReady NodeHealth = "NotReady" // Ready condition 为 False
+	NodeHealthDegraded NodeHealth = "Degraded" // Ready，但 MemoryPressure、DiskPressure 等其他 condition 为 True
+	NodeHealthCordoned NodeHealth = "Cordoned" // Ready，但节点被设置为不可调度
+	NodeHealthUnknown  NodeHealth = "Unknown"  // 没有上报 Ready condition 或状态为 Unknown
+)
+
+// NodeCondition 节点的一个 condition，与 Node 的 status.conditions 一一对应
+type NodeCondition struct {
+	Type               string      `json:"type"`   // Ready、MemoryPressure、DiskPressure、PIDPressure、NetworkUnavailable 等
+	Status             string      `json:"status"` // True | False | Unknown
+	Reason             string      `json:"reason,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ResourceCapacity 节点的资源总量与可分配量
+type ResourceCapacity struct {
+	Total       string `json:"total"`
+	Allocatable string `json:"allocatable"`
+}
+
+// ResourceUsage 节点上非终止状态 Pod 申请的资源，计算方式与 kube-scheduler 一致
+type ResourceUsage struct {
+	Used     string `json:"used"`               // requests 之和
+	Limits   string `json:"limits,omitempty"`   // limits 之和
+	Utilized string `json:"utilized,omitempty"` // 实际使用量，来自 metrics-server 或 DCGM exporter，GPU 为折算后的卡数
+}
+
+// NodeTaint 节点污点
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"` // NoSchedule | PreferNoSchedule | NoExecute
+}
+
+// GPUInfo 来自 GPU feature discovery 标签的 GPU 型号信息
+type GPUInfo struct {
+	Product     string       `json:"product,omitempty"`     // nvidia.com/gpu.product
+	Family      string       `json:"family,omitempty"`      // nvidia.com/gpu.family，如 ampere、hopper
+	Machine     string       `json:"machine,omitempty"`     // nvidia.com/gpu.machine
+	MemoryMiB   int64        `json:"memoryMiB,omitempty"`   // 单卡显存，nvidia.com/gpu.memory
+	Count       int64        `json:"count,omitempty"`       // 物理卡数量，nvidia.com/gpu.count
+	MigStrategy string       `json:"migStrategy,omitempty"` // none | single | mixed
+	MigProfiles []MIGProfile `json:"migProfiles,omitempty"`
+}
+
+// MIGProfile 节点上的一种 MIG 切分规格
+type MIGProfile struct {
+	Profile   string `json:"profile"`             // 如 1g.10gb
+	Resource  string `json:"resource"`            // 对应的扩展资源名，如 nvidia.com/mig-1g.10gb
+	Count     int64  `json:"count"`               // 该规格的实例数
+	MemoryMiB int64  `json:"memoryMiB,omitempty"` // 单个实例的显存
+}
+
+// GPUAllocation 节点上 Pod 占用的 GPU/MIG 资源
+type GPUAllocation struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Resource  string `json:"resource"`
+	Count     int64  `json:"count"`
+}
+
+// UsageBreakdown 节点上已申请资源按 namespace 与所属工作负载的拆分
+type UsageBreakdown struct {
+	ReportToManager bool             `json:"reportToManager,omitempty"` // 是否通过 NodeResource.properties 上报给 manager
+	Namespaces      []NamespaceUsage `json:"namespaces,omitempty"`
+	Workloads       []WorkloadUsage  `json:"workloads,omitempty"`
+}
+
+type NamespaceUsage struct {
+	Namespace string            `json:"namespace"`
+	Pods      int32             `json:"pods"`
+	Requests  map[string]string `json:"requests,omitempty"`
+}
+
+// WorkloadUsage 按顶层工作负载（RayCluster、Deployment、Job 等）统计的资源申请
+type WorkloadUsage struct {
+	Namespace string            `json:"namespace"`
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Pods      int32             `json:"pods"`
+	Requests  map[string]string `json:"requests,omitempty"`
+}
+
+// Utilization 实际资源使用的采集结果
+type Utilization struct {
+	Time    metav1.Time            `json:"time"`             // 采集时间
+	Window  string                 `json:"window,omitempty"` // metrics-server 的采样窗口
+	Source  string                 `json:"source,omitempty"` // NodeMetrics | PodMetrics
+	Devices []GPUDeviceUtilization `json:"devices,omitempty"`
+}
+
+// GPUDeviceUtilization 单张 GPU 的使用情况，Pod 为空且利用率为 0 的卡是空闲的，
+// Pod 不为空但利用率为 0 的卡是被占用但未使用的卡
+type GPUDeviceUtilization struct {
+	Index         string `json:"index"`
+	UUID          string `json:"uuid,omitempty"`
+	ModelName     string `json:"modelName,omitempty"`
+	Utilization   int64  `json:"utilization"`             // GPU 利用率百分比
+	MemoryUsedMiB int64  `json:"memoryUsedMiB,omitempty"` // 已使用显存
+	Namespace     string `json:"namespace,omitempty"`
+	Pod           string `json:"pod,omitempty"`
+}
+
+type NodeResourceInfoSpec struct {
+	NodeName         string                      `json:"nodeName"`
+	Resources        map[string]ResourceCapacity `json:"resources,omitempty"`
+	Roles            string                      `json:"roles,omitempty"`
+	ScheduleVersion  string                      `json:"scheduleVersion,omitempty"`
+	InternalIp       string                      `json:"internalIp,omitempty"`
+	OS               string                      `json:"os,omitempty"`
+	KernelVersion    string                      `json:"kernelVersion,omitempty"`
+	ContainerRuntime string                      `json:"containerRuntime,omitempty"`
+	Labels           map[string]string           `json:"labels,omitempty"`      // 过滤后的节点标签
+	Annotations      map[string]string           `json:"annotations,omitempty"` // 过滤后的节点注解
+	Taints           []NodeTaint                 `json:"taints,omitempty"`
+	GPU              *GPUInfo                    `json:"gpu,omitempty"`
+}
+
+// ManagerSyncStatus 记录 NodeManager 已确认的 CRD 版本，未确认的版本会在后续周期重试通知
+type ManagerSyncStatus struct {
+	AcknowledgedGeneration int64        `json:"acknowledgedGeneration"`    // manager 已确认的 metadata.generation
+	PendingOperation       string       `json:"pendingOperation"`          // 待确认的操作 AddNode/UpdateNode，已确认时为空
+	Attempts               int32        `json:"attempts"`                  // 当前待确认版本的通知次数
+	LastAttemptTime        *metav1.Time `json:"lastAttemptTime,omitempty"` // 最近一次通知时间
+	LastError              string       `json:"lastError"`                 // 最近一次通知失败原因
+}
+
+type NodeResourceInfoStatus struct {
+	NodeStatus         string                   `json:"nodeStatus,omitempty"` // 为 True 的 condition 拼接，如 Ready,SchedulingDisabled，只用于兼容 v1alpha1，新代码使用 health
+	Health             NodeHealth               `json:"health,omitempty"`
+	NodeConditions     []NodeCondition          `json:"nodeConditions,omitempty"` // Node 上报的 conditions
+	Resources          map[string]ResourceUsage `json:"resources,omitempty"`
+	GPUAllocations     []GPUAllocation          `json:"gpuAllocations,omitempty"` // 按 Pod 统计的 GPU 占用
+	UsageBreakdown     *UsageBreakdown          `json:"usageBreakdown,omitempty"` // 未开启时为空
+	Utilization        *Utilization             `json:"utilization,omitempty"`    // 未开启采集时为空
+	Conditions         []metav1.Condition       `json:"conditions,omitempty"`
+	ObservedGeneration int64                    `json:"observedGeneration,omitempty"`
+	ManagerSync        *ManagerSyncStatus       `json:"managerSync,omitempty"`
+	LastHeartbeatTime  *metav1.Time             `json:"lastHeartbeatTime,omitempty"` // 最近一次 manager 确认心跳的时间
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=noderesourceinfos,scope=Cluster,shortName=nri
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health`
+// +kubebuilder:printcolumn:name="CPU_Used",type=string,JSONPath=`.status.resources.cpu.used`
+// +kubebuilder:printcolumn:name="CPU_Alloc",type=string,JSONPath=`.spec.resources.cpu.allocatable`
+// +kubebuilder:printcolumn:name="MEM_Used",type=string,JSONPath=`.status.resources.memory.used`
+// +kubebuilder:printcolumn:name="MEM_Alloc",type=string,JSONPath=`.spec.resources.memory.allocatable`
+// +kubebuilder:printcolumn:name="GPU_Used",type=string,JSONPath=`.status.resources.nvidia\.com/gpu.used`
+// +kubebuilder:printcolumn:name="GPU_Alloc",type=string,JSONPath=`.spec.resources.nvidia\.com/gpu.allocatable`
+// +kubebuilder:printcolumn:name="CPU_Util",type=string,JSONPath=`.status.resources.cpu.utilized`,priority=1
+// +kubebuilder:printcolumn:name="GPU_Util",type=string,JSONPath=`.status.resources.nvidia\.com/gpu.utilized`,priority=1
+// +kubebuilder:printcolumn:name="GPU_Model",type=string,JSONPath=`.spec.gpu.product`,priority=1
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="SyncedToManager")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type NodeResourceInfo struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              NodeResourceInfoSpec   `json:"spec"`
+	Status            NodeResourceInfoStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type NodeResourceInfoList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeResourceInfo `json:"items"`
+}
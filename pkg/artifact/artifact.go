@@ -0,0 +1,208 @@
+package artifact
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	DefaultDownloaderImage = "python:3.12-slim"
+	// 下载任务中 PVC 的挂载目录，模型文件直接保存在 PVC 的根目录
+	downloadDir = "/model"
+	// 下载失败后重试的次数，已下载且大小一致的文件不会重新下载
+	downloadBackoffLimit = 2
+)
+
+var checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// CreateRequest 创建模型的请求体
+type CreateRequest struct {
+	Namespace string                    `json:"namespace" binding:"required"`
+	Name      string                    `json:"name" binding:"required"`
+	Spec      v1beta1.ModelArtifactSpec `json:"spec"`
+}
+
+// PVCName 保存模型的 PVC 名称
+func PVCName(name string) string {
+	return "model-" + name
+}
+
+// JobName 下载模型的 Job 名称
+func JobName(name string) string {
+	return name + "-download"
+}
+
+// 模型的 PVC 配置，accessModes 默认为 ReadWriteMany
+func storagePVC(name string, storage v1beta1.ModelStorage) *model.PVCSource {
+	accessModes := storage.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []string{string(corev1.ReadWriteMany)}
+	}
+	return &model.PVCSource{
+		ClaimName:        PVCName(name),
+		StorageClassName: storage.StorageClassName,
+		Size:             storage.Size,
+		AccessModes:      accessModes,
+	}
+}
+
+// Validate 检查模型的来源与存储配置
+func Validate(name string, spec *v1beta1.ModelArtifactSpec) error {
+	source := spec.Source
+	switch source.Type {
+	case v1beta1.ModelSourceS3:
+		if source.S3 == nil || source.S3.Endpoint == "" || source.S3.Bucket == "" {
+			return fmt.Errorf("source.s3 requires endpoint and bucket")
+		}
+		if err := validateEndpoint(source.S3.Endpoint); err != nil {
+			return fmt.Errorf("source.s3.endpoint: %w", err)
+		}
+	case v1beta1.ModelSourceHuggingFace:
+		if source.HuggingFace == nil || source.HuggingFace.Repo == "" {
+			return fmt.Errorf("source.huggingFace.repo is required")
+		}
+		if endpoint := source.HuggingFace.Endpoint; endpoint != "" {
+			if err := validateEndpoint(endpoint); err != nil {
+				return fmt.Errorf("source.huggingFace.endpoint: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown source.type %q, supported types: %s, %s", source.Type, v1beta1.ModelSourceS3, v1beta1.ModelSourceHuggingFace)
+	}
+	if source.Type != v1beta1.ModelSourceS3 && source.S3 != nil || source.Type != v1beta1.ModelSourceHuggingFace && source.HuggingFace != nil {
+		return fmt.Errorf("only the source of type %s can be set", source.Type)
+	}
+
+	if spec.Storage.Size == "" {
+		return fmt.Errorf("storage.size is required")
+	}
+	if err := job.ValidatePVCSource(storagePVC(name, spec.Storage)); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	if spec.Checksum != "" && !checksumPattern.MatchString(spec.Checksum) {
+		return fmt.Errorf("checksum must be a lowercase hex sha256, got %q", spec.Checksum)
+	}
+	return nil
+}
+
+func validateEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("must be an http or https url, got %q", endpoint)
+	}
+	return nil
+}
+
+func ownerReferences(artifact *v1beta1.ModelArtifact) []metav1.OwnerReference {
+	return []metav1.OwnerReference{*metav1.NewControllerRef(artifact, v1beta1.SchemeGroupVersion.WithKind("ModelArtifact"))}
+}
+
+// NewPVC 生成保存模型的 PVC，PVC 不属于模型，删除模型时默认保留数据
+func NewPVC(artifact *v1beta1.ModelArtifact) *corev1.PersistentVolumeClaim {
+	pvc := job.CreatePVC(artifact.Namespace, storagePVC(artifact.Name, artifact.Spec.Storage))
+	pvc.Labels = map[string]string{v1beta1.ModelArtifactLabel: artifact.Name}
+	return pvc
+}
+
+// NewDownloadJob 生成下载模型的 Job，进度按行输出到日志，结果写入 termination message
+func NewDownloadJob(artifact *v1beta1.ModelArtifact) *batchv1.Job {
+	labels := map[string]string{v1beta1.ModelArtifactLabel: artifact.Name}
+	image := artifact.Spec.DownloaderImage
+	if image == "" {
+		image = DefaultDownloaderImage
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            JobName(artifact.Name),
+			Namespace:       artifact.Namespace,
+			Labels:          labels,
+			OwnerReferences: ownerReferences(artifact),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To(int32(downloadBackoffLimit)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "downloader",
+							Image:   image,
+							Command: []string{"python3", "-u", "-c", downloaderScript},
+							Env:     downloaderEnv(&artifact.Spec),
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "model", MountPath: downloadDir},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "model",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: PVCName(artifact.Name)},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func downloaderEnv(spec *v1beta1.ModelArtifactSpec) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "MODEL_DIR", Value: downloadDir},
+		{Name: "SOURCE_TYPE", Value: string(spec.Source.Type)},
+		{Name: "EXPECTED_CHECKSUM", Value: spec.Checksum},
+	}
+	switch spec.Source.Type {
+	case v1beta1.ModelSourceS3:
+		s3 := spec.Source.S3
+		env = append(env,
+			corev1.EnvVar{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+			corev1.EnvVar{Name: "S3_BUCKET", Value: s3.Bucket},
+			corev1.EnvVar{Name: "S3_PREFIX", Value: s3.Prefix},
+			corev1.EnvVar{Name: "S3_REGION", Value: s3.Region},
+		)
+		if s3.SecretName != "" {
+			env = append(env, secretEnv("AWS_ACCESS_KEY_ID", s3.SecretName), secretEnv("AWS_SECRET_ACCESS_KEY", s3.SecretName))
+		}
+	case v1beta1.ModelSourceHuggingFace:
+		hf := spec.Source.HuggingFace
+		env = append(env,
+			corev1.EnvVar{Name: "HF_ENDPOINT", Value: hf.Endpoint},
+			corev1.EnvVar{Name: "HF_REPO", Value: hf.Repo},
+			corev1.EnvVar{Name: "HF_REVISION", Value: hf.Revision},
+		)
+		if hf.SecretName != "" {
+			env = append(env, secretEnv("HF_TOKEN", hf.SecretName))
+		}
+	}
+	return env
+}
+
+// 从 Secret 中同名的键读取环境变量
+func secretEnv(key, secretName string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: key,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
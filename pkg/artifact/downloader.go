@@ -0,0 +1,248 @@
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	ProgressEvent = "progress"
+	DoneEvent     = "done"
+	FailedEvent   = "failed"
+)
+
+// Progress 下载任务按行输出的 JSON，结束时 done 或 failed 事件同时写入 termination message
+type Progress struct {
+	Event           string `json:"event"`
+	Files           int32  `json:"files"`
+	Completed       int32  `json:"completed"`
+	DownloadedBytes int64  `json:"downloadedBytes"`
+	TotalBytes      int64  `json:"totalBytes"`
+	Checksum        string `json:"checksum,omitempty"`
+	Message         string `json:"message,omitempty"`
+}
+
+// ParseProgress 返回日志中最后一条进度，其他输出忽略，没有进度时返回 nil
+func ParseProgress(logs string) *Progress {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var progress Progress
+		if err := json.Unmarshal([]byte(line), &progress); err != nil || progress.Event == "" {
+			continue
+		}
+		return &progress
+	}
+	return nil
+}
+
+// ParseResult 解析下载容器的 termination message
+func ParseResult(message string) (*Progress, error) {
+	var result Progress
+	if err := json.Unmarshal([]byte(strings.TrimSpace(message)), &result); err != nil {
+		return nil, fmt.Errorf("invalid termination message %q: %w", message, err)
+	}
+	switch result.Event {
+	case DoneEvent:
+		if !checksumPattern.MatchString(result.Checksum) {
+			return nil, fmt.Errorf("invalid checksum %q in termination message", result.Checksum)
+		}
+	case FailedEvent:
+	default:
+		return nil, fmt.Errorf("unexpected event %q in termination message", result.Event)
+	}
+	return &result, nil
+}
+
+// downloaderScript 只依赖 Python 标准库。S3 使用 path-style 地址与 SigV4 签名，没有密钥时匿名访问；
+// HuggingFace 通过 /api/models/<repo>/revision/<revision> 获取文件列表。
+// 文件按路径排序下载，清单 "<sha256>  <路径>" 写入 .opsflow-manifest.sha256，
+// 清单的 sha256 即模型的校验和
+const downloaderScript = `
+import hashlib, hmac, json, os, sys, time
+import urllib.parse, urllib.request
+import xml.etree.ElementTree as ET
+from datetime import datetime, timezone
+
+ROOT = os.environ.get("MODEL_DIR", "/model")
+MANIFEST = ".opsflow-manifest.sha256"
+CHUNK = 1 << 20
+PROGRESS_INTERVAL = 5
+
+
+def emit(event, **fields):
+    fields["event"] = event
+    line = json.dumps(fields, sort_keys=True)
+    print(line, flush=True)
+    return line
+
+
+def terminate(line):
+    try:
+        with open(os.environ.get("TERMINATION_LOG", "/dev/termination-log"), "w") as f:
+            f.write(line)
+    except OSError:
+        pass
+
+
+def s3_open(path, query=None):
+    endpoint = urllib.parse.urlsplit(os.environ["S3_ENDPOINT"])
+    path = endpoint.path.rstrip("/") + urllib.parse.quote(path, safe="/-_.~")
+    query = "&".join(
+        urllib.parse.quote(k, safe="-_.~") + "=" + urllib.parse.quote(v, safe="-_.~")
+        for k, v in sorted((query or {}).items())
+    )
+    url = endpoint.scheme + "://" + endpoint.netloc + path + ("?" + query if query else "")
+    headers = {}
+    access_key = os.environ.get("AWS_ACCESS_KEY_ID")
+    secret_key = os.environ.get("AWS_SECRET_ACCESS_KEY")
+    if access_key and secret_key:
+        region = os.environ.get("S3_REGION") or "us-east-1"
+        amz_date = datetime.now(timezone.utc).strftime("%Y%m%dT%H%M%SZ")
+        payload_hash = hashlib.sha256(b"").hexdigest()
+        signed = {"host": endpoint.netloc, "x-amz-content-sha256": payload_hash, "x-amz-date": amz_date}
+        signed_headers = ";".join(sorted(signed))
+        canonical_request = "\n".join([
+            "GET", path, query,
+            "".join(k + ":" + signed[k] + "\n" for k in sorted(signed)),
+            signed_headers, payload_hash,
+        ])
+        scope = amz_date[:8] + "/" + region + "/s3/aws4_request"
+        string_to_sign = "\n".join([
+            "AWS4-HMAC-SHA256", amz_date, scope,
+            hashlib.sha256(canonical_request.encode()).hexdigest(),
+        ])
+        key = ("AWS4" + secret_key).encode()
+        for part in (amz_date[:8], region, "s3", "aws4_request"):
+            key = hmac.new(key, part.encode(), hashlib.sha256).digest()
+        signature = hmac.new(key, string_to_sign.encode(), hashlib.sha256).hexdigest()
+        headers = {
+            "x-amz-content-sha256": payload_hash,
+            "x-amz-date": amz_date,
+            "Authorization": "AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s"
+            % (access_key, scope, signed_headers, signature),
+        }
+    return urllib.request.urlopen(urllib.request.Request(url, headers=headers), timeout=60)
+
+
+def list_s3():
+    bucket = os.environ["S3_BUCKET"]
+    prefix = os.environ.get("S3_PREFIX", "").lstrip("/")
+    if prefix and not prefix.endswith("/"):
+        prefix += "/"
+    files, token = [], None
+    while True:
+        query = {"list-type": "2", "prefix": prefix}
+        if token:
+            query["continuation-token"] = token
+        with s3_open("/" + bucket, query) as resp:
+            root = ET.fromstring(resp.read())
+        ns = root.tag[:root.tag.index("}") + 1] if root.tag.startswith("{") else ""
+        for item in root.iter(ns + "Contents"):
+            key = item.find(ns + "Key").text
+            if key.endswith("/"):
+                continue
+            files.append((key[len(prefix):], int(item.find(ns + "Size").text), "/" + bucket + "/" + key))
+        truncated = root.find(ns + "IsTruncated")
+        next_token = root.find(ns + "NextContinuationToken")
+        if truncated is None or truncated.text != "true" or next_token is None:
+            return files
+        token = next_token.text
+
+
+def hf_open(url):
+    headers = {}
+    if os.environ.get("HF_TOKEN"):
+        headers["Authorization"] = "Bearer " + os.environ["HF_TOKEN"]
+    return urllib.request.urlopen(urllib.request.Request(url, headers=headers), timeout=60)
+
+
+def list_hf():
+    endpoint = (os.environ.get("HF_ENDPOINT") or "https://huggingface.co").rstrip("/")
+    repo = os.environ["HF_REPO"]
+    revision = urllib.parse.quote(os.environ.get("HF_REVISION") or "main", safe="")
+    with hf_open("%s/api/models/%s/revision/%s?blobs=true" % (endpoint, repo, revision)) as resp:
+        info = json.load(resp)
+    files = []
+    for sibling in info.get("siblings", []):
+        name = sibling["rfilename"]
+        size = sibling.get("size") or (sibling.get("lfs") or {}).get("size") or 0
+        files.append((name, int(size), "%s/%s/resolve/%s/%s" % (endpoint, repo, revision, urllib.parse.quote(name))))
+    return files
+
+
+def target_path(name):
+    path = os.path.normpath(os.path.join(ROOT, name))
+    if not path.startswith(os.path.normpath(ROOT) + os.sep):
+        raise RuntimeError("file %s escapes the model directory" % name)
+    return path
+
+
+def hash_file(path):
+    digest = hashlib.sha256()
+    with open(path, "rb") as f:
+        for chunk in iter(lambda: f.read(CHUNK), b""):
+            digest.update(chunk)
+    return digest.hexdigest()
+
+
+def main():
+    source = os.environ["SOURCE_TYPE"]
+    if source == "S3":
+        files, opener = list_s3(), s3_open
+    elif source == "HuggingFace":
+        files, opener = list_hf(), hf_open
+    else:
+        raise RuntimeError("unknown source type %s" % source)
+    files = sorted(f for f in files if f[0] != MANIFEST)
+    if not files:
+        raise RuntimeError("no files found in the source")
+
+    state = {"files": len(files), "completed": 0, "downloadedBytes": 0, "totalBytes": sum(f[1] for f in files)}
+    emit("progress", **state)
+    last = time.monotonic()
+    manifest = []
+    for name, size, location in files:
+        path = target_path(name)
+        if size and os.path.isfile(path) and os.path.getsize(path) == size:
+            # 重试时跳过已下载的文件
+            checksum = hash_file(path)
+            state["downloadedBytes"] += size
+        else:
+            os.makedirs(os.path.dirname(path), exist_ok=True)
+            digest = hashlib.sha256()
+            with opener(location) as resp, open(path + ".part", "wb") as out:
+                for chunk in iter(lambda: resp.read(CHUNK), b""):
+                    out.write(chunk)
+                    digest.update(chunk)
+                    state["downloadedBytes"] += len(chunk)
+                    if time.monotonic() - last >= PROGRESS_INTERVAL:
+                        emit("progress", **state)
+                        last = time.monotonic()
+            os.replace(path + ".part", path)
+            checksum = digest.hexdigest()
+        manifest.append("%s  %s\n" % (checksum, name))
+        state["completed"] += 1
+        emit("progress", **state)
+        last = time.monotonic()
+
+    content = "".join(manifest)
+    with open(os.path.join(ROOT, MANIFEST), "w") as f:
+        f.write(content)
+    checksum = hashlib.sha256(content.encode()).hexdigest()
+    expected = os.environ.get("EXPECTED_CHECKSUM")
+    if expected and expected != checksum:
+        raise RuntimeError("checksum mismatch: expected %s, got %s" % (expected, checksum))
+    terminate(emit("done", checksum=checksum, **state))
+
+
+try:
+    main()
+except Exception as e:
+    terminate(emit("failed", message=str(e)[:1024]))
+    sys.exit(1)
+`
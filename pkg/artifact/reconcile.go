@@ -0,0 +1,247 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowclient "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// 读取下载进度时的日志行数，进度至少每 5 秒输出一次
+const progressTailLines = 20
+
+// Reconciler 为模型创建 PVC 与下载任务，并按任务的状态更新模型的状态
+type Reconciler struct {
+	Core    kubernetes.Interface
+	OpsFlow opsflowclient.Interface
+	Now     func() time.Time
+}
+
+func (r *Reconciler) now() metav1.Time {
+	if r.Now != nil {
+		return metav1.NewTime(r.Now())
+	}
+	return metav1.Now()
+}
+
+// ReconcileAll 更新所有 namespace 中未完成的模型，单个模型失败只记录日志
+func (r *Reconciler) ReconcileAll(ctx context.Context) error {
+	list, err := r.OpsFlow.OpsflowV1beta1().ModelArtifacts(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("无法获取模型列表: %w", err)
+	}
+	for i := range list.Items {
+		if err := r.Reconcile(ctx, &list.Items[i]); err != nil {
+			log.Printf("无法更新模型 %s/%s: %v", list.Items[i].Namespace, list.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile 确保 PVC 与下载任务存在，任务成功时按 termination message 记录校验和，
+// 运行中时从日志读取进度。Ready 与 Failed 的模型不再处理
+func (r *Reconciler) Reconcile(ctx context.Context, artifact *v1beta1.ModelArtifact) error {
+	if artifact.Status.Phase == v1beta1.ModelArtifactReady || artifact.Status.Phase == v1beta1.ModelArtifactFailed {
+		return nil
+	}
+	status := artifact.Status.DeepCopy()
+	if status.Phase == "" {
+		status.Phase = v1beta1.ModelArtifactPending
+	}
+
+	if err := r.ensurePVC(ctx, artifact); err != nil {
+		return err
+	}
+	status.PVCName = PVCName(artifact.Name)
+	downloadJob, err := r.ensureJob(ctx, artifact)
+	if err != nil {
+		return err
+	}
+	status.JobName = downloadJob.Name
+
+	switch {
+	case downloadJob.Status.Succeeded > 0:
+		r.complete(ctx, artifact, status, downloadJob)
+	case failedCondition(downloadJob) != nil:
+		status.Phase = v1beta1.ModelArtifactFailed
+		status.Message = failedCondition(downloadJob).Message
+		if result := r.podResult(ctx, downloadJob); result != nil && result.Message != "" {
+			status.Message = result.Message
+		}
+		status.CompletedAt = ptr.To(r.now())
+	default:
+		status.Phase = v1beta1.ModelArtifactDownloading
+		if status.StartedAt == nil {
+			status.StartedAt = ptr.To(r.now())
+		}
+		if progress := r.podProgress(ctx, downloadJob); progress != nil {
+			setProgress(status, progress)
+		}
+	}
+	return r.updateStatus(ctx, artifact, status)
+}
+
+// 任务成功时以 termination message 为准，校验和与 spec 不一致时失败
+func (r *Reconciler) complete(ctx context.Context, artifact *v1beta1.ModelArtifact, status *v1beta1.ModelArtifactStatus, downloadJob *batchv1.Job) {
+	status.CompletedAt = ptr.To(r.now())
+	result := r.podResult(ctx, downloadJob)
+	switch {
+	case result == nil || result.Event != DoneEvent:
+		status.Phase = v1beta1.ModelArtifactFailed
+		status.Message = "download job succeeded without a result"
+	case artifact.Spec.Checksum != "" && artifact.Spec.Checksum != result.Checksum:
+		setProgress(status, result)
+		status.Phase = v1beta1.ModelArtifactFailed
+		status.Message = fmt.Sprintf("checksum mismatch: expected %s, got %s", artifact.Spec.Checksum, result.Checksum)
+	default:
+		setProgress(status, result)
+		status.Phase = v1beta1.ModelArtifactReady
+		status.Checksum = result.Checksum
+		status.Message = ""
+	}
+}
+
+func setProgress(status *v1beta1.ModelArtifactStatus, progress *Progress) {
+	status.Files = progress.Files
+	status.Completed = progress.Completed
+	status.DownloadedBytes = progress.DownloadedBytes
+	status.TotalBytes = progress.TotalBytes
+}
+
+func failedCondition(downloadJob *batchv1.Job) *batchv1.JobCondition {
+	for i, condition := range downloadJob.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return &downloadJob.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) ensurePVC(ctx context.Context, artifact *v1beta1.ModelArtifact) error {
+	_, err := r.Core.CoreV1().PersistentVolumeClaims(artifact.Namespace).Get(ctx, PVCName(artifact.Name), metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("get pvc %s: %w", PVCName(artifact.Name), err)
+	}
+	if _, err := r.Core.CoreV1().PersistentVolumeClaims(artifact.Namespace).Create(ctx, NewPVC(artifact), metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create pvc %s: %w", PVCName(artifact.Name), err)
+	}
+	log.Printf("已为模型 %s/%s 创建 PVC，大小 %s", artifact.Namespace, artifact.Name, artifact.Spec.Storage.Size)
+	return nil
+}
+
+// 下载任务被删除时重新创建，已下载的文件不会重新下载
+func (r *Reconciler) ensureJob(ctx context.Context, artifact *v1beta1.ModelArtifact) (*batchv1.Job, error) {
+	jobs := r.Core.BatchV1().Jobs(artifact.Namespace)
+	downloadJob, err := jobs.Get(ctx, JobName(artifact.Name), metav1.GetOptions{})
+	if err == nil {
+		return downloadJob, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("get job %s: %w", JobName(artifact.Name), err)
+	}
+	downloadJob, err = jobs.Create(ctx, NewDownloadJob(artifact), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("create job %s: %w", JobName(artifact.Name), err)
+	}
+	log.Printf("已创建模型 %s/%s 的下载任务 %s", artifact.Namespace, artifact.Name, downloadJob.Name)
+	return downloadJob, nil
+}
+
+// 返回任务最近创建的 Pod
+func (r *Reconciler) latestPod(ctx context.Context, downloadJob *batchv1.Job) *corev1.Pod {
+	pods, err := r.Core.CoreV1().Pods(downloadJob.Namespace).List(ctx, metav1.ListOptions{LabelSelector: "job-name=" + downloadJob.Name})
+	if err != nil {
+		log.Printf("无法获取下载任务 %s/%s 的 Pod: %v", downloadJob.Namespace, downloadJob.Name, err)
+		return nil
+	}
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		if latest == nil || latest.CreationTimestamp.Before(&pods.Items[i].CreationTimestamp) {
+			latest = &pods.Items[i]
+		}
+	}
+	return latest
+}
+
+// 读取已退出的下载容器的 termination message
+func (r *Reconciler) podResult(ctx context.Context, downloadJob *batchv1.Job) *Progress {
+	pod := r.latestPod(ctx, downloadJob)
+	if pod == nil {
+		return nil
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		terminated := containerStatus.State.Terminated
+		if terminated == nil || terminated.Message == "" {
+			continue
+		}
+		result, err := ParseResult(terminated.Message)
+		if err != nil {
+			log.Printf("下载任务 %s/%s: %v", downloadJob.Namespace, downloadJob.Name, err)
+			return nil
+		}
+		return result
+	}
+	return nil
+}
+
+// 读取运行中的下载容器最近的日志中的进度
+func (r *Reconciler) podProgress(ctx context.Context, downloadJob *batchv1.Job) *Progress {
+	pod := r.latestPod(ctx, downloadJob)
+	if pod == nil || pod.Status.Phase != corev1.PodRunning {
+		return nil
+	}
+	stream, err := r.Core.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: ptr.To(int64(progressTailLines))}).Stream(ctx)
+	if err != nil {
+		log.Printf("无法读取下载任务 %s/%s 的日志: %v", pod.Namespace, pod.Name, err)
+		return nil
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		log.Printf("无法读取下载任务 %s/%s 的日志: %v", pod.Namespace, pod.Name, err)
+		return nil
+	}
+	return ParseProgress(string(logs))
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, artifact *v1beta1.ModelArtifact, status *v1beta1.ModelArtifactStatus) error {
+	if apiequality.Semantic.DeepEqual(&artifact.Status, status) {
+		return nil
+	}
+	updated := artifact.DeepCopy()
+	updated.Status = *status
+	if _, err := r.OpsFlow.OpsflowV1beta1().ModelArtifacts(artifact.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	artifact.Status = *status
+	return nil
+}
+
+// Delete 删除下载任务，deleteData 时同时删除保存模型的 PVC，模型本身由调用方删除
+func (r *Reconciler) Delete(ctx context.Context, artifact *v1beta1.ModelArtifact, deleteData bool) error {
+	err := r.Core.BatchV1().Jobs(artifact.Namespace).Delete(ctx, JobName(artifact.Name), metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete job %s: %w", JobName(artifact.Name), err)
+	}
+	if deleteData {
+		err := r.Core.CoreV1().PersistentVolumeClaims(artifact.Namespace).Delete(ctx, PVCName(artifact.Name), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("delete pvc %s: %w", PVCName(artifact.Name), err)
+		}
+	}
+	return nil
+}
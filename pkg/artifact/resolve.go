@@ -0,0 +1,62 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowclient "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ModelMountRoot 模型在任务 Pod 中的挂载目录，模型路径为 <ModelMountRoot>/<模型名称>
+	ModelMountRoot  = "/mnt/data/models"
+	modelVolumeName = "model"
+)
+
+// ModelVolume 以只读方式挂载已下载模型的卷，label 为 model，vLLM 等任务种类按此查找模型路径
+func ModelVolume(artifact *v1beta1.ModelArtifact) model.VolumeConfig {
+	return model.VolumeConfig{
+		Name:      modelVolumeName,
+		Label:     map[string]string{"model": artifact.Name},
+		MountPath: path.Join(ModelMountRoot, artifact.Name),
+		ReadOnly:  true,
+		Source: model.VolumeSource{
+			PVC: &model.PVCSource{ClaimName: artifact.Status.PVCName},
+		},
+	}
+}
+
+// ResolveModel 按 config.Model 查找任务 namespace 中已下载完成的模型并挂载到所有机器，
+// 未设置 model 时不做修改。机器上已有 label 为 model 的卷或同名的卷时返回错误
+func ResolveModel(ctx context.Context, client opsflowclient.Interface, config *model.ClusterConfig) error {
+	if config.Model == "" {
+		return nil
+	}
+	artifact, err := client.OpsflowV1beta1().ModelArtifacts(config.Namespace).Get(ctx, config.Model, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("model %s not found in namespace %s", config.Model, config.Namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("get model %s: %w", config.Model, err)
+	}
+	if artifact.Status.Phase != v1beta1.ModelArtifactReady {
+		return fmt.Errorf("model %s is not ready, phase: %s", config.Model, artifact.Status.Phase)
+	}
+
+	volume := ModelVolume(artifact)
+	for i := range config.Machines {
+		machine := &config.Machines[i]
+		for _, existing := range machine.Volumes {
+			if _, ok := existing.Label["model"]; ok || existing.Name == volume.Name {
+				return fmt.Errorf("machine %s: volume %s conflicts with model %s", machine.Name, existing.Name, config.Model)
+			}
+		}
+		machine.Volumes = append(machine.Volumes, volume)
+	}
+	return nil
+}
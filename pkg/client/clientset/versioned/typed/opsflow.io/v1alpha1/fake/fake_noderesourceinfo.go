@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	opsflowiov1alpha1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeNodeResourceInfos implements NodeResourceInfoInterface
+type fakeNodeResourceInfos struct {
+	*gentype.FakeClientWithList[*v1alpha1.NodeResourceInfo, *v1alpha1.NodeResourceInfoList]
+	Fake *FakeOpsflowV1alpha1
+}
+
+func newFakeNodeResourceInfos(fake *FakeOpsflowV1alpha1) opsflowiov1alpha1.NodeResourceInfoInterface {
+	return &fakeNodeResourceInfos{
+		gentype.NewFakeClientWithList[*v1alpha1.NodeResourceInfo, *v1alpha1.NodeResourceInfoList](
+			fake.Fake,
+			"",
+			v1alpha1.SchemeGroupVersion.WithResource("noderesourceinfos"),
+			v1alpha1.SchemeGroupVersion.WithKind("NodeResourceInfo"),
+			func() *v1alpha1.NodeResourceInfo { return &v1alpha1.NodeResourceInfo{} },
+			func() *v1alpha1.NodeResourceInfoList { return &v1alpha1.NodeResourceInfoList{} },
+			func(dst, src *v1alpha1.NodeResourceInfoList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.NodeResourceInfoList) []*v1alpha1.NodeResourceInfo {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.NodeResourceInfoList, items []*v1alpha1.NodeResourceInfo) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -0,0 +1,24 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1alpha1"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeOpsflowV1alpha1 struct {
+	*testing.Fake
+}
+
+func (c *FakeOpsflowV1alpha1) NodeResourceInfos() v1alpha1.NodeResourceInfoInterface {
+	return newFakeNodeResourceInfos(c)
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeOpsflowV1alpha1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
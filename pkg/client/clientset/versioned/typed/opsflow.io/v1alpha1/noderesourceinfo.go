@@ -0,0 +1,54 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+
+	opsflowiov1alpha1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	scheme "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// NodeResourceInfosGetter has a method to return a NodeResourceInfoInterface.
+// A group's client should implement this interface.
+type NodeResourceInfosGetter interface {
+	NodeResourceInfos() NodeResourceInfoInterface
+}
+
+// NodeResourceInfoInterface has methods to work with NodeResourceInfo resources.
+type NodeResourceInfoInterface interface {
+	Create(ctx context.Context, nodeResourceInfo *opsflowiov1alpha1.NodeResourceInfo, opts v1.CreateOptions) (*opsflowiov1alpha1.NodeResourceInfo, error)
+	Update(ctx context.Context, nodeResourceInfo *opsflowiov1alpha1.NodeResourceInfo, opts v1.UpdateOptions) (*opsflowiov1alpha1.NodeResourceInfo, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, nodeResourceInfo *opsflowiov1alpha1.NodeResourceInfo, opts v1.UpdateOptions) (*opsflowiov1alpha1.NodeResourceInfo, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*opsflowiov1alpha1.NodeResourceInfo, error)
+	List(ctx context.Context, opts v1.ListOptions) (*opsflowiov1alpha1.NodeResourceInfoList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *opsflowiov1alpha1.NodeResourceInfo, err error)
+	NodeResourceInfoExpansion
+}
+
+// nodeResourceInfos implements NodeResourceInfoInterface
+type nodeResourceInfos struct {
+	*gentype.ClientWithList[*opsflowiov1alpha1.NodeResourceInfo, *opsflowiov1alpha1.NodeResourceInfoList]
+}
+
+// newNodeResourceInfos returns a NodeResourceInfos
+func newNodeResourceInfos(c *OpsflowV1alpha1Client) *nodeResourceInfos {
+	return &nodeResourceInfos{
+		gentype.NewClientWithList[*opsflowiov1alpha1.NodeResourceInfo, *opsflowiov1alpha1.NodeResourceInfoList](
+			"noderesourceinfos",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			"",
+			func() *opsflowiov1alpha1.NodeResourceInfo { return &opsflowiov1alpha1.NodeResourceInfo{} },
+			func() *opsflowiov1alpha1.NodeResourceInfoList { return &opsflowiov1alpha1.NodeResourceInfoList{} },
+		),
+	}
+}
@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeModelArtifacts implements ModelArtifactInterface
+type fakeModelArtifacts struct {
+	*gentype.FakeClientWithList[*v1beta1.ModelArtifact, *v1beta1.ModelArtifactList]
+	Fake *FakeOpsflowV1beta1
+}
+
+func newFakeModelArtifacts(fake *FakeOpsflowV1beta1, namespace string) opsflowiov1beta1.ModelArtifactInterface {
+	return &fakeModelArtifacts{
+		gentype.NewFakeClientWithList[*v1beta1.ModelArtifact, *v1beta1.ModelArtifactList](
+			fake.Fake,
+			namespace,
+			v1beta1.SchemeGroupVersion.WithResource("modelartifacts"),
+			v1beta1.SchemeGroupVersion.WithKind("ModelArtifact"),
+			func() *v1beta1.ModelArtifact { return &v1beta1.ModelArtifact{} },
+			func() *v1beta1.ModelArtifactList { return &v1beta1.ModelArtifactList{} },
+			func(dst, src *v1beta1.ModelArtifactList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.ModelArtifactList) []*v1beta1.ModelArtifact {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.ModelArtifactList, items []*v1beta1.ModelArtifact) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
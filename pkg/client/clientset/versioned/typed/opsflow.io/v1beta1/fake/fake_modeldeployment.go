@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeModelDeployments implements ModelDeploymentInterface
+type fakeModelDeployments struct {
+	*gentype.FakeClientWithList[*v1beta1.ModelDeployment, *v1beta1.ModelDeploymentList]
+	Fake *FakeOpsflowV1beta1
+}
+
+func newFakeModelDeployments(fake *FakeOpsflowV1beta1, namespace string) opsflowiov1beta1.ModelDeploymentInterface {
+	return &fakeModelDeployments{
+		gentype.NewFakeClientWithList[*v1beta1.ModelDeployment, *v1beta1.ModelDeploymentList](
+			fake.Fake,
+			namespace,
+			v1beta1.SchemeGroupVersion.WithResource("modeldeployments"),
+			v1beta1.SchemeGroupVersion.WithKind("ModelDeployment"),
+			func() *v1beta1.ModelDeployment { return &v1beta1.ModelDeployment{} },
+			func() *v1beta1.ModelDeploymentList { return &v1beta1.ModelDeploymentList{} },
+			func(dst, src *v1beta1.ModelDeploymentList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.ModelDeploymentList) []*v1beta1.ModelDeployment {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.ModelDeploymentList, items []*v1beta1.ModelDeployment) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
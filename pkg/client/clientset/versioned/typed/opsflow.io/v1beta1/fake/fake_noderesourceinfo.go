@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeNodeResourceInfos implements NodeResourceInfoInterface
+type fakeNodeResourceInfos struct {
+	*gentype.FakeClientWithList[*v1beta1.NodeResourceInfo, *v1beta1.NodeResourceInfoList]
+	Fake *FakeOpsflowV1beta1
+}
+
+func newFakeNodeResourceInfos(fake *FakeOpsflowV1beta1) opsflowiov1beta1.NodeResourceInfoInterface {
+	return &fakeNodeResourceInfos{
+		gentype.NewFakeClientWithList[*v1beta1.NodeResourceInfo, *v1beta1.NodeResourceInfoList](
+			fake.Fake,
+			"",
+			v1beta1.SchemeGroupVersion.WithResource("noderesourceinfos"),
+			v1beta1.SchemeGroupVersion.WithKind("NodeResourceInfo"),
+			func() *v1beta1.NodeResourceInfo { return &v1beta1.NodeResourceInfo{} },
+			func() *v1beta1.NodeResourceInfoList { return &v1beta1.NodeResourceInfoList{} },
+			func(dst, src *v1beta1.NodeResourceInfoList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.NodeResourceInfoList) []*v1beta1.NodeResourceInfo {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.NodeResourceInfoList, items []*v1beta1.NodeResourceInfo) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
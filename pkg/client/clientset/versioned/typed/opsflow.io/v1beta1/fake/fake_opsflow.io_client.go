@@ -0,0 +1,32 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeOpsflowV1beta1 struct {
+	*testing.Fake
+}
+
+func (c *FakeOpsflowV1beta1) ModelArtifacts(namespace string) v1beta1.ModelArtifactInterface {
+	return newFakeModelArtifacts(c, namespace)
+}
+
+func (c *FakeOpsflowV1beta1) ModelDeployments(namespace string) v1beta1.ModelDeploymentInterface {
+	return newFakeModelDeployments(c, namespace)
+}
+
+func (c *FakeOpsflowV1beta1) NodeResourceInfos() v1beta1.NodeResourceInfoInterface {
+	return newFakeNodeResourceInfos(c)
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeOpsflowV1beta1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
@@ -0,0 +1,9 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+type ModelArtifactExpansion interface{}
+
+type ModelDeploymentExpansion interface{}
+
+type NodeResourceInfoExpansion interface{}
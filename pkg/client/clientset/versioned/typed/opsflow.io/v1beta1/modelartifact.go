@@ -0,0 +1,54 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	scheme "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// ModelArtifactsGetter has a method to return a ModelArtifactInterface.
+// A group's client should implement this interface.
+type ModelArtifactsGetter interface {
+	ModelArtifacts(namespace string) ModelArtifactInterface
+}
+
+// ModelArtifactInterface has methods to work with ModelArtifact resources.
+type ModelArtifactInterface interface {
+	Create(ctx context.Context, modelArtifact *opsflowiov1beta1.ModelArtifact, opts v1.CreateOptions) (*opsflowiov1beta1.ModelArtifact, error)
+	Update(ctx context.Context, modelArtifact *opsflowiov1beta1.ModelArtifact, opts v1.UpdateOptions) (*opsflowiov1beta1.ModelArtifact, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, modelArtifact *opsflowiov1beta1.ModelArtifact, opts v1.UpdateOptions) (*opsflowiov1beta1.ModelArtifact, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*opsflowiov1beta1.ModelArtifact, error)
+	List(ctx context.Context, opts v1.ListOptions) (*opsflowiov1beta1.ModelArtifactList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *opsflowiov1beta1.ModelArtifact, err error)
+	ModelArtifactExpansion
+}
+
+// modelArtifacts implements ModelArtifactInterface
+type modelArtifacts struct {
+	*gentype.ClientWithList[*opsflowiov1beta1.ModelArtifact, *opsflowiov1beta1.ModelArtifactList]
+}
+
+// newModelArtifacts returns a ModelArtifacts
+func newModelArtifacts(c *OpsflowV1beta1Client, namespace string) *modelArtifacts {
+	return &modelArtifacts{
+		gentype.NewClientWithList[*opsflowiov1beta1.ModelArtifact, *opsflowiov1beta1.ModelArtifactList](
+			"modelartifacts",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *opsflowiov1beta1.ModelArtifact { return &opsflowiov1beta1.ModelArtifact{} },
+			func() *opsflowiov1beta1.ModelArtifactList { return &opsflowiov1beta1.ModelArtifactList{} },
+		),
+	}
+}
@@ -0,0 +1,54 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	scheme "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// ModelDeploymentsGetter has a method to return a ModelDeploymentInterface.
+// A group's client should implement this interface.
+type ModelDeploymentsGetter interface {
+	ModelDeployments(namespace string) ModelDeploymentInterface
+}
+
+// ModelDeploymentInterface has methods to work with ModelDeployment resources.
+type ModelDeploymentInterface interface {
+	Create(ctx context.Context, modelDeployment *opsflowiov1beta1.ModelDeployment, opts v1.CreateOptions) (*opsflowiov1beta1.ModelDeployment, error)
+	Update(ctx context.Context, modelDeployment *opsflowiov1beta1.ModelDeployment, opts v1.UpdateOptions) (*opsflowiov1beta1.ModelDeployment, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, modelDeployment *opsflowiov1beta1.ModelDeployment, opts v1.UpdateOptions) (*opsflowiov1beta1.ModelDeployment, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*opsflowiov1beta1.ModelDeployment, error)
+	List(ctx context.Context, opts v1.ListOptions) (*opsflowiov1beta1.ModelDeploymentList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *opsflowiov1beta1.ModelDeployment, err error)
+	ModelDeploymentExpansion
+}
+
+// modelDeployments implements ModelDeploymentInterface
+type modelDeployments struct {
+	*gentype.ClientWithList[*opsflowiov1beta1.ModelDeployment, *opsflowiov1beta1.ModelDeploymentList]
+}
+
+// newModelDeployments returns a ModelDeployments
+func newModelDeployments(c *OpsflowV1beta1Client, namespace string) *modelDeployments {
+	return &modelDeployments{
+		gentype.NewClientWithList[*opsflowiov1beta1.ModelDeployment, *opsflowiov1beta1.ModelDeploymentList](
+			"modeldeployments",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *opsflowiov1beta1.ModelDeployment { return &opsflowiov1beta1.ModelDeployment{} },
+			func() *opsflowiov1beta1.ModelDeploymentList { return &opsflowiov1beta1.ModelDeploymentList{} },
+		),
+	}
+}
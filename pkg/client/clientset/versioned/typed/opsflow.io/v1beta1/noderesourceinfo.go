@@ -0,0 +1,54 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	scheme "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// NodeResourceInfosGetter has a method to return a NodeResourceInfoInterface.
+// A group's client should implement this interface.
+type NodeResourceInfosGetter interface {
+	NodeResourceInfos() NodeResourceInfoInterface
+}
+
+// NodeResourceInfoInterface has methods to work with NodeResourceInfo resources.
+type NodeResourceInfoInterface interface {
+	Create(ctx context.Context, nodeResourceInfo *opsflowiov1beta1.NodeResourceInfo, opts v1.CreateOptions) (*opsflowiov1beta1.NodeResourceInfo, error)
+	Update(ctx context.Context, nodeResourceInfo *opsflowiov1beta1.NodeResourceInfo, opts v1.UpdateOptions) (*opsflowiov1beta1.NodeResourceInfo, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, nodeResourceInfo *opsflowiov1beta1.NodeResourceInfo, opts v1.UpdateOptions) (*opsflowiov1beta1.NodeResourceInfo, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*opsflowiov1beta1.NodeResourceInfo, error)
+	List(ctx context.Context, opts v1.ListOptions) (*opsflowiov1beta1.NodeResourceInfoList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *opsflowiov1beta1.NodeResourceInfo, err error)
+	NodeResourceInfoExpansion
+}
+
+// nodeResourceInfos implements NodeResourceInfoInterface
+type nodeResourceInfos struct {
+	*gentype.ClientWithList[*opsflowiov1beta1.NodeResourceInfo, *opsflowiov1beta1.NodeResourceInfoList]
+}
+
+// newNodeResourceInfos returns a NodeResourceInfos
+func newNodeResourceInfos(c *OpsflowV1beta1Client) *nodeResourceInfos {
+	return &nodeResourceInfos{
+		gentype.NewClientWithList[*opsflowiov1beta1.NodeResourceInfo, *opsflowiov1beta1.NodeResourceInfoList](
+			"noderesourceinfos",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			"",
+			func() *opsflowiov1beta1.NodeResourceInfo { return &opsflowiov1beta1.NodeResourceInfo{} },
+			func() *opsflowiov1beta1.NodeResourceInfoList { return &opsflowiov1beta1.NodeResourceInfoList{} },
+		),
+	}
+}
@@ -0,0 +1,95 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	http "net/http"
+
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	scheme "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/scheme"
+	rest "k8s.io/client-go/rest"
+)
+
+type OpsflowV1beta1Interface interface {
+	RESTClient() rest.Interface
+	ModelArtifactsGetter
+	ModelDeploymentsGetter
+	NodeResourceInfosGetter
+}
+
+// OpsflowV1beta1Client is used to interact with features provided by the opsflow.io group.
+type OpsflowV1beta1Client struct {
+	restClient rest.Interface
+}
+
+func (c *OpsflowV1beta1Client) ModelArtifacts(namespace string) ModelArtifactInterface {
+	return newModelArtifacts(c, namespace)
+}
+
+func (c *OpsflowV1beta1Client) ModelDeployments(namespace string) ModelDeploymentInterface {
+	return newModelDeployments(c, namespace)
+}
+
+func (c *OpsflowV1beta1Client) NodeResourceInfos() NodeResourceInfoInterface {
+	return newNodeResourceInfos(c)
+}
+
+// NewForConfig creates a new OpsflowV1beta1Client for the given config.
+// NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
+// where httpClient was generated with rest.HTTPClientFor(c).
+func NewForConfig(c *rest.Config) (*OpsflowV1beta1Client, error) {
+	config := *c
+	setConfigDefaults(&config)
+	httpClient, err := rest.HTTPClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return NewForConfigAndClient(&config, httpClient)
+}
+
+// NewForConfigAndClient creates a new OpsflowV1beta1Client for the given config and http client.
+// Note the http client provided takes precedence over the configured transport values.
+func NewForConfigAndClient(c *rest.Config, h *http.Client) (*OpsflowV1beta1Client, error) {
+	config := *c
+	setConfigDefaults(&config)
+	client, err := rest.RESTClientForConfigAndClient(&config, h)
+	if err != nil {
+		return nil, err
+	}
+	return &OpsflowV1beta1Client{client}, nil
+}
+
+// NewForConfigOrDie creates a new OpsflowV1beta1Client for the given config and
+// panics if there is an error in the config.
+func NewForConfigOrDie(c *rest.Config) *OpsflowV1beta1Client {
+	client, err := NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// New creates a new OpsflowV1beta1Client for the given RESTClient.
+func New(c rest.Interface) *OpsflowV1beta1Client {
+	return &OpsflowV1beta1Client{c}
+}
+
+func setConfigDefaults(config *rest.Config) {
+	gv := opsflowiov1beta1.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = rest.CodecFactoryForGeneratedClient(scheme.Scheme, scheme.Codecs).WithoutConversion()
+
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *OpsflowV1beta1Client) RESTClient() rest.Interface {
+	if c == nil {
+		return nil
+	}
+	return c.restClient
+}
@@ -0,0 +1,55 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package externalversions
+
+import (
+	fmt "fmt"
+
+	v1alpha1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	v1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// GenericInformer is type of SharedIndexInformer which will locate and delegate to other
+// sharedInformers based on type
+type GenericInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() cache.GenericLister
+}
+
+type genericInformer struct {
+	informer cache.SharedIndexInformer
+	resource schema.GroupResource
+}
+
+// Informer returns the SharedIndexInformer.
+func (f *genericInformer) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+// Lister returns the GenericLister.
+func (f *genericInformer) Lister() cache.GenericLister {
+	return cache.NewGenericLister(f.Informer().GetIndexer(), f.resource)
+}
+
+// ForResource gives generic access to a shared informer of the matching type
+// TODO extend this to unknown resources with a client pool
+func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
+	switch resource {
+	// Group=opsflow.io, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("noderesourceinfos"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Opsflow().V1alpha1().NodeResourceInfos().Informer()}, nil
+
+		// Group=opsflow.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("modelartifacts"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Opsflow().V1beta1().ModelArtifacts().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("modeldeployments"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Opsflow().V1beta1().ModelDeployments().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("noderesourceinfos"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Opsflow().V1beta1().NodeResourceInfos().Informer()}, nil
+
+	}
+
+	return nil, fmt.Errorf("no informer found for %v", resource)
+}
@@ -0,0 +1,29 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	internalinterfaces "github.com/modcoco/OpsFlow/pkg/client/informers/externalversions/internalinterfaces"
+)
+
+// Interface provides access to all the informers in this group version.
+type Interface interface {
+	// NodeResourceInfos returns a NodeResourceInfoInformer.
+	NodeResourceInfos() NodeResourceInfoInformer
+}
+
+type version struct {
+	factory          internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) Interface {
+	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
+}
+
+// NodeResourceInfos returns a NodeResourceInfoInformer.
+func (v *version) NodeResourceInfos() NodeResourceInfoInformer {
+	return &nodeResourceInfoInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,43 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	internalinterfaces "github.com/modcoco/OpsFlow/pkg/client/informers/externalversions/internalinterfaces"
+)
+
+// Interface provides access to all the informers in this group version.
+type Interface interface {
+	// ModelArtifacts returns a ModelArtifactInformer.
+	ModelArtifacts() ModelArtifactInformer
+	// ModelDeployments returns a ModelDeploymentInformer.
+	ModelDeployments() ModelDeploymentInformer
+	// NodeResourceInfos returns a NodeResourceInfoInformer.
+	NodeResourceInfos() NodeResourceInfoInformer
+}
+
+type version struct {
+	factory          internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) Interface {
+	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
+}
+
+// ModelArtifacts returns a ModelArtifactInformer.
+func (v *version) ModelArtifacts() ModelArtifactInformer {
+	return &modelArtifactInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ModelDeployments returns a ModelDeploymentInformer.
+func (v *version) ModelDeployments() ModelDeploymentInformer {
+	return &modelDeploymentInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// NodeResourceInfos returns a NodeResourceInfoInformer.
+func (v *version) NodeResourceInfos() NodeResourceInfoInformer {
+	return &nodeResourceInfoInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+	time "time"
+
+	apisopsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	versioned "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/modcoco/OpsFlow/pkg/client/informers/externalversions/internalinterfaces"
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/client/listers/opsflow.io/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ModelArtifactInformer provides access to a shared informer and lister for
+// ModelArtifacts.
+type ModelArtifactInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() opsflowiov1beta1.ModelArtifactLister
+}
+
+type modelArtifactInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewModelArtifactInformer constructs a new informer for ModelArtifact type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewModelArtifactInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredModelArtifactInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredModelArtifactInformer constructs a new informer for ModelArtifact type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredModelArtifactInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.OpsflowV1beta1().ModelArtifacts(namespace).List(context.Background(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.OpsflowV1beta1().ModelArtifacts(namespace).Watch(context.Background(), options)
+			},
+		},
+		&apisopsflowiov1beta1.ModelArtifact{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *modelArtifactInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredModelArtifactInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *modelArtifactInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apisopsflowiov1beta1.ModelArtifact{}, f.defaultInformer)
+}
+
+func (f *modelArtifactInformer) Lister() opsflowiov1beta1.ModelArtifactLister {
+	return opsflowiov1beta1.NewModelArtifactLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+	time "time"
+
+	apisopsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	versioned "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/modcoco/OpsFlow/pkg/client/informers/externalversions/internalinterfaces"
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/client/listers/opsflow.io/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ModelDeploymentInformer provides access to a shared informer and lister for
+// ModelDeployments.
+type ModelDeploymentInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() opsflowiov1beta1.ModelDeploymentLister
+}
+
+type modelDeploymentInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewModelDeploymentInformer constructs a new informer for ModelDeployment type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewModelDeploymentInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredModelDeploymentInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredModelDeploymentInformer constructs a new informer for ModelDeployment type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredModelDeploymentInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.OpsflowV1beta1().ModelDeployments(namespace).List(context.Background(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.OpsflowV1beta1().ModelDeployments(namespace).Watch(context.Background(), options)
+			},
+		},
+		&apisopsflowiov1beta1.ModelDeployment{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *modelDeploymentInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredModelDeploymentInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *modelDeploymentInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apisopsflowiov1beta1.ModelDeployment{}, f.defaultInformer)
+}
+
+func (f *modelDeploymentInformer) Lister() opsflowiov1beta1.ModelDeploymentLister {
+	return opsflowiov1beta1.NewModelDeploymentLister(f.Informer().GetIndexer())
+}
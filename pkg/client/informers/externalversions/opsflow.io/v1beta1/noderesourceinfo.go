@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	context "context"
+	time "time"
+
+	apisopsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	versioned "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/modcoco/OpsFlow/pkg/client/informers/externalversions/internalinterfaces"
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/client/listers/opsflow.io/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// NodeResourceInfoInformer provides access to a shared informer and lister for
+// NodeResourceInfos.
+type NodeResourceInfoInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() opsflowiov1beta1.NodeResourceInfoLister
+}
+
+type nodeResourceInfoInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewNodeResourceInfoInformer constructs a new informer for NodeResourceInfo type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewNodeResourceInfoInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredNodeResourceInfoInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredNodeResourceInfoInformer constructs a new informer for NodeResourceInfo type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredNodeResourceInfoInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.OpsflowV1beta1().NodeResourceInfos().List(context.Background(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.OpsflowV1beta1().NodeResourceInfos().Watch(context.Background(), options)
+			},
+		},
+		&apisopsflowiov1beta1.NodeResourceInfo{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *nodeResourceInfoInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredNodeResourceInfoInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *nodeResourceInfoInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apisopsflowiov1beta1.NodeResourceInfo{}, f.defaultInformer)
+}
+
+func (f *nodeResourceInfoInformer) Lister() opsflowiov1beta1.NodeResourceInfoLister {
+	return opsflowiov1beta1.NewNodeResourceInfoLister(f.Informer().GetIndexer())
+}
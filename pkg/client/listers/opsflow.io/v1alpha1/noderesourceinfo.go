@@ -0,0 +1,32 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	opsflowiov1alpha1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// NodeResourceInfoLister helps list NodeResourceInfos.
+// All objects returned here must be treated as read-only.
+type NodeResourceInfoLister interface {
+	// List lists all NodeResourceInfos in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*opsflowiov1alpha1.NodeResourceInfo, err error)
+	// Get retrieves the NodeResourceInfo from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*opsflowiov1alpha1.NodeResourceInfo, error)
+	NodeResourceInfoListerExpansion
+}
+
+// nodeResourceInfoLister implements the NodeResourceInfoLister interface.
+type nodeResourceInfoLister struct {
+	listers.ResourceIndexer[*opsflowiov1alpha1.NodeResourceInfo]
+}
+
+// NewNodeResourceInfoLister returns a new NodeResourceInfoLister.
+func NewNodeResourceInfoLister(indexer cache.Indexer) NodeResourceInfoLister {
+	return &nodeResourceInfoLister{listers.New[*opsflowiov1alpha1.NodeResourceInfo](indexer, opsflowiov1alpha1.Resource("noderesourceinfo"))}
+}
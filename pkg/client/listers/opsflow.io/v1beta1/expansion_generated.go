@@ -0,0 +1,23 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+// ModelArtifactListerExpansion allows custom methods to be added to
+// ModelArtifactLister.
+type ModelArtifactListerExpansion interface{}
+
+// ModelArtifactNamespaceListerExpansion allows custom methods to be added to
+// ModelArtifactNamespaceLister.
+type ModelArtifactNamespaceListerExpansion interface{}
+
+// ModelDeploymentListerExpansion allows custom methods to be added to
+// ModelDeploymentLister.
+type ModelDeploymentListerExpansion interface{}
+
+// ModelDeploymentNamespaceListerExpansion allows custom methods to be added to
+// ModelDeploymentNamespaceLister.
+type ModelDeploymentNamespaceListerExpansion interface{}
+
+// NodeResourceInfoListerExpansion allows custom methods to be added to
+// NodeResourceInfoLister.
+type NodeResourceInfoListerExpansion interface{}
@@ -0,0 +1,54 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ModelArtifactLister helps list ModelArtifacts.
+// All objects returned here must be treated as read-only.
+type ModelArtifactLister interface {
+	// List lists all ModelArtifacts in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*opsflowiov1beta1.ModelArtifact, err error)
+	// ModelArtifacts returns an object that can list and get ModelArtifacts.
+	ModelArtifacts(namespace string) ModelArtifactNamespaceLister
+	ModelArtifactListerExpansion
+}
+
+// modelArtifactLister implements the ModelArtifactLister interface.
+type modelArtifactLister struct {
+	listers.ResourceIndexer[*opsflowiov1beta1.ModelArtifact]
+}
+
+// NewModelArtifactLister returns a new ModelArtifactLister.
+func NewModelArtifactLister(indexer cache.Indexer) ModelArtifactLister {
+	return &modelArtifactLister{listers.New[*opsflowiov1beta1.ModelArtifact](indexer, opsflowiov1beta1.Resource("modelartifact"))}
+}
+
+// ModelArtifacts returns an object that can list and get ModelArtifacts.
+func (s *modelArtifactLister) ModelArtifacts(namespace string) ModelArtifactNamespaceLister {
+	return modelArtifactNamespaceLister{listers.NewNamespaced[*opsflowiov1beta1.ModelArtifact](s.ResourceIndexer, namespace)}
+}
+
+// ModelArtifactNamespaceLister helps list and get ModelArtifacts.
+// All objects returned here must be treated as read-only.
+type ModelArtifactNamespaceLister interface {
+	// List lists all ModelArtifacts in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*opsflowiov1beta1.ModelArtifact, err error)
+	// Get retrieves the ModelArtifact from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*opsflowiov1beta1.ModelArtifact, error)
+	ModelArtifactNamespaceListerExpansion
+}
+
+// modelArtifactNamespaceLister implements the ModelArtifactNamespaceLister
+// interface.
+type modelArtifactNamespaceLister struct {
+	listers.ResourceIndexer[*opsflowiov1beta1.ModelArtifact]
+}
@@ -0,0 +1,54 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	opsflowiov1beta1 "github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ModelDeploymentLister helps list ModelDeployments.
+// All objects returned here must be treated as read-only.
+type ModelDeploymentLister interface {
+	// List lists all ModelDeployments in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*opsflowiov1beta1.ModelDeployment, err error)
+	// ModelDeployments returns an object that can list and get ModelDeployments.
+	ModelDeployments(namespace string) ModelDeploymentNamespaceLister
+	ModelDeploymentListerExpansion
+}
+
+// modelDeploymentLister implements the ModelDeploymentLister interface.
+type modelDeploymentLister struct {
+	listers.ResourceIndexer[*opsflowiov1beta1.ModelDeployment]
+}
+
+// NewModelDeploymentLister returns a new ModelDeploymentLister.
+func NewModelDeploymentLister(indexer cache.Indexer) ModelDeploymentLister {
+	return &modelDeploymentLister{listers.New[*opsflowiov1beta1.ModelDeployment](indexer, opsflowiov1beta1.Resource("modeldeployment"))}
+}
+
+// ModelDeployments returns an object that can list and get ModelDeployments.
+func (s *modelDeploymentLister) ModelDeployments(namespace string) ModelDeploymentNamespaceLister {
+	return modelDeploymentNamespaceLister{listers.NewNamespaced[*opsflowiov1beta1.ModelDeployment](s.ResourceIndexer, namespace)}
+}
+
+// ModelDeploymentNamespaceLister helps list and get ModelDeployments.
+// All objects returned here must be treated as read-only.
+type ModelDeploymentNamespaceLister interface {
+	// List lists all ModelDeployments in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*opsflowiov1beta1.ModelDeployment, err error)
+	// Get retrieves the ModelDeployment from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*opsflowiov1beta1.ModelDeployment, error)
+	ModelDeploymentNamespaceListerExpansion
+}
+
+// modelDeploymentNamespaceLister implements the ModelDeploymentNamespaceLister
+// interface.
+type modelDeploymentNamespaceLister struct {
+	listers.ResourceIndexer[*opsflowiov1beta1.ModelDeployment]
+}
@@ -4,18 +4,21 @@ import (
 	"context"
 
 	rayclient "github.com/ray-project/kuberay/ray-operator/pkg/client/clientset/versioned"
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	"k8s.io/client-go/kubernetes"
 )
 
 type RayJobContext interface {
 	Core() kubernetes.Interface
 	Ray() rayclient.Interface
+	Istio() istioclient.Interface
 	Ctx() context.Context
 }
 
 type rayJobContext struct {
 	coreClient kubernetes.Interface
 	rayClient  rayclient.Interface
+	istio      istioclient.Interface
 	ctx        context.Context
 }
 
@@ -27,14 +30,19 @@ func (r *rayJobContext) Ray() rayclient.Interface {
 	return r.rayClient
 }
 
+func (r *rayJobContext) Istio() istioclient.Interface {
+	return r.istio
+}
+
 func (r *rayJobContext) Ctx() context.Context {
 	return r.ctx
 }
 
-func NewRayJobContext(core kubernetes.Interface, ray rayclient.Interface, ctx context.Context) RayJobContext {
+func NewRayJobContext(core kubernetes.Interface, ray rayclient.Interface, istio istioclient.Interface, ctx context.Context) RayJobContext {
 	return &rayJobContext{
 		coreClient: core,
 		rayClient:  ray,
+		istio:      istio,
 		ctx:        ctx,
 	}
 }
@@ -3,13 +3,14 @@ package core
 import (
 	"fmt"
 
+	opsflowclient "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
 	rayclient "github.com/ray-project/kuberay/ray-operator/pkg/client/clientset/versioned"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 type Client interface {
@@ -18,7 +19,8 @@ type Client interface {
 	// Volcano() versioned.Interface
 	Istio() istioclient.Interface
 	Dynamic() dynamic.Interface
-	DynamicNRI() dynamic.NamespaceableResourceInterface
+	OpsFlow() opsflowclient.Interface
+	Metrics() metricsclient.Interface
 	Config() rest.Config
 }
 
@@ -28,7 +30,8 @@ type clientImpl struct {
 	// volcano versioned.Interface
 	istio   istioclient.Interface
 	dynamic dynamic.Interface
-	nri     dynamic.NamespaceableResourceInterface
+	opsflow opsflowclient.Interface
+	metrics metricsclient.Interface
 	config  rest.Config
 }
 
@@ -36,10 +39,11 @@ func (c *clientImpl) Core() kubernetes.Interface { return c.core }
 func (c *clientImpl) Ray() rayclient.Interface   { return c.ray }
 
 // func (c *clientImpl) Volcano() versioned.Interface { return c.volcano }
-func (c *clientImpl) Istio() istioclient.Interface                       { return c.istio }
-func (c *clientImpl) Dynamic() dynamic.Interface                         { return c.dynamic }
-func (c *clientImpl) DynamicNRI() dynamic.NamespaceableResourceInterface { return c.nri }
-func (c *clientImpl) Config() rest.Config                                { return c.config }
+func (c *clientImpl) Istio() istioclient.Interface     { return c.istio }
+func (c *clientImpl) Dynamic() dynamic.Interface       { return c.dynamic }
+func (c *clientImpl) OpsFlow() opsflowclient.Interface { return c.opsflow }
+func (c *clientImpl) Metrics() metricsclient.Interface { return c.metrics }
+func (c *clientImpl) Config() rest.Config              { return c.config }
 
 func NewClient() (Client, error) {
 	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
@@ -75,11 +79,15 @@ func NewClient() (Client, error) {
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	crdClient := dynamicClient.Resource(schema.GroupVersionResource{
-		Group:    "opsflow.io",
-		Version:  "v1alpha1",
-		Resource: "noderesourceinfos",
-	})
+	opsflowClient, err := opsflowclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create opsflow client: %w", err)
+	}
+
+	metricsClient, err := metricsclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics client: %w", err)
+	}
 
 	return &clientImpl{
 		core: kubeClient,
@@ -87,7 +95,8 @@ func NewClient() (Client, error) {
 		// volcano: volcanoClient,
 		istio:   istioClient,
 		dynamic: dynamicClient,
-		nri:     crdClient,
+		opsflow: opsflowClient,
+		metrics: metricsClient,
 		config:  *cfg,
 	}, nil
 }
@@ -4,20 +4,28 @@ import (
 	"context"
 
 	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/redis/go-redis/v9"
 )
 
 type AppContext interface {
 	Ctx() context.Context
 	Client() Client
+	Redis() redis.Cmdable
+	JobOptions() model.JobOptions
 }
 
 type appContextImpl struct {
 	ctx    context.Context
 	client Client
+	redis  redis.Cmdable
+	job    model.JobOptions
 }
 
-func (a *appContextImpl) Ctx() context.Context { return a.ctx }
-func (a *appContextImpl) Client() Client       { return a.client }
+func (a *appContextImpl) Ctx() context.Context         { return a.ctx }
+func (a *appContextImpl) Client() Client               { return a.client }
+func (a *appContextImpl) Redis() redis.Cmdable         { return a.redis }
+func (a *appContextImpl) JobOptions() model.JobOptions { return a.job }
 
 func GetAppContext(c *gin.Context) AppContext {
 	return c.MustGet("appCtx").(AppContext)
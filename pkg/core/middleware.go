@@ -1,12 +1,19 @@
 package core
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/redis/go-redis/v9"
+)
 
-func AppContextMiddleware(client Client) gin.HandlerFunc {
+// AppContextMiddleware jobOptions 为部署时配置的创建与探测任务的选项，所有请求共用
+func AppContextMiddleware(client Client, redisClient redis.Cmdable, jobOptions model.JobOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		appCtx := &appContextImpl{
 			ctx:    c.Request.Context(),
 			client: client,
+			redis:  redisClient,
+			job:    jobOptions,
 		}
 		c.Set("appCtx", appCtx)
 		c.Next()
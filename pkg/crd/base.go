@@ -2,14 +2,18 @@ package crd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-func GetCRDList(crdClient dynamic.ResourceInterface, continueToken string) (*unstructured.UnstructuredList, string, error) {
+func GetCRDList(crdClient typedv1beta1.NodeResourceInfoInterface, continueToken string) (*v1beta1.NodeResourceInfoList, string, error) {
 	crdList, err := crdClient.List(context.TODO(), metav1.ListOptions{
 		Limit:    50,
 		Continue: continueToken,
@@ -23,6 +27,38 @@ func GetCRDList(crdClient dynamic.ResourceInterface, continueToken string) (*uns
 	return crdList, crdList.GetContinue(), nil
 }
 
-func DeleteCRD(crdClient dynamic.ResourceInterface, crdName string) error {
+func DeleteCRD(crdClient typedv1beta1.NodeResourceInfoInterface, crdName string) error {
 	return crdClient.Delete(context.TODO(), crdName, metav1.DeleteOptions{})
 }
+
+// PatchCRDStatus 通过 status 子资源合并更新 CRD 的 status
+func PatchCRDStatus(crdClient typedv1beta1.NodeResourceInfoInterface, crdName string, status map[string]any) error {
+	patch, err := json.Marshal(map[string]any{"status": status})
+	if err != nil {
+		return fmt.Errorf("failed to marshal status patch: %w", err)
+	}
+	_, err = crdClient.Patch(context.TODO(), crdName, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// RemoveCRDFinalizer 移除 CRD 上的 finalizer，CRD 不存在或没有该 finalizer 时直接返回
+func RemoveCRDFinalizer(crdClient typedv1beta1.NodeResourceInfoInterface, crdName string, finalizer string) error {
+	obj, err := crdClient.Get(context.TODO(), crdName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !slices.Contains(obj.Finalizers, finalizer) {
+		return nil
+	}
+	obj.Finalizers = slices.DeleteFunc(obj.Finalizers, func(f string) bool { return f == finalizer })
+
+	_, err = crdClient.Update(context.TODO(), obj, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -5,25 +5,32 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
 	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
 	"github.com/modcoco/OpsFlow/pkg/node"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
 	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
 	"github.com/modcoco/OpsFlow/pkg/utils"
 	"google.golang.org/grpc"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
+// 记录 lastHeartbeatTime 的最小间隔，心跳任务每 30 秒执行一次
+const heartbeatRecordInterval = 5 * time.Minute
+
 type NodeResourceInfoOptions struct {
-	CRDClient   dynamic.NamespaceableResourceInterface // CRD 客户端
+	CRDClient   typedv1beta1.NodeResourceInfoInterface // CRD 客户端
 	KubeClient  kubernetes.Interface                   // Kubernetes 客户端
 	GRPCClient  *grpc.ClientConn                       // gRPC 客户端
+	Recorder    history.Recorder                       // 节点变化记录，为空时不记录
 	Parallelism int                                    // 并发数
 }
 
@@ -31,7 +38,7 @@ type NodeResourceInfoOptions struct {
 func DeleteNonExistingNodeResourceInfo(opts NodeResourceInfoOptions, clusterId string) error {
 	var continueToken string
 	var wg sync.WaitGroup
-	errCh := make(chan error, 100) // 缓冲通道，防止阻塞
+	errs := &errorList{}
 
 	semaphore := make(chan struct{}, opts.Parallelism)
 	if opts.Parallelism <= 0 {
@@ -45,10 +52,14 @@ func DeleteNonExistingNodeResourceInfo(opts NodeResourceInfoOptions, clusterId s
 			return fmt.Errorf("无法查询 CRD 实例: %w", err)
 		}
 
-		// 2. 收集 CRD 里的所有 nodeName
+		// 2. 收集 CRD 里的所有 nodeName，正在删除中的 CRD 直接进入删除流程
 		crdNodeNames := make([]string, 0, len(crdList.Items))
+		toDelete := make(map[string]struct{})
 		for _, crd := range crdList.Items {
 			crdNodeNames = append(crdNodeNames, crd.GetName())
+			if crd.GetDeletionTimestamp() != nil {
+				toDelete[crd.GetName()] = struct{}{}
+			}
 		}
 
 		if len(crdNodeNames) == 0 {
@@ -64,9 +75,12 @@ func DeleteNonExistingNodeResourceInfo(opts NodeResourceInfoOptions, clusterId s
 		if err != nil {
 			return fmt.Errorf("查询 Node 失败: %w", err)
 		}
+		for _, nodeName := range nonExistingNodes {
+			toDelete[nodeName] = struct{}{}
+		}
 
 		// 4. 并发删除不存在的 CRD 实例
-		deleteCRDsConcurrently(opts, nonExistingNodes, semaphore, &wg, clusterId, errCh)
+		deleteCRDsConcurrently(opts, slices.Sorted(maps.Keys(toDelete)), semaphore, &wg, clusterId, errs)
 
 		if newContinueToken == "" {
 			break
@@ -75,23 +89,35 @@ func DeleteNonExistingNodeResourceInfo(opts NodeResourceInfoOptions, clusterId s
 	}
 
 	wg.Wait()
-	close(errCh)
+	return errs.join()
+}
 
-	var allErrors []error
-	for err := range errCh {
-		allErrors = append(allErrors, err)
-	}
-	return errors.Join(allErrors...)
+// errorList 并发收集错误，节点较多时有界的 channel 写满会导致 goroutine 阻塞
+type errorList struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *errorList) add(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, err)
 }
 
-// 并发删除 CRD 实例
+func (l *errorList) join() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return errors.Join(l.errs...)
+}
+
+// 并发删除 CRD 实例，只有 manager 确认删除后才移除 finalizer 并删除 CRD，失败的节点下个周期重试
 func deleteCRDsConcurrently(
 	opts NodeResourceInfoOptions,
 	nodeNames []string,
 	semaphore chan struct{},
 	wg *sync.WaitGroup,
 	clusterId string,
-	errCh chan<- error,
+	errs *errorList,
 ) {
 	for _, nodeName := range nodeNames {
 		wg.Add(1)
@@ -103,36 +129,75 @@ func deleteCRDsConcurrently(
 				defer func() { <-semaphore }() // 释放并发槽
 			}
 
-			if err := DeleteCRD(opts.CRDClient, n); err != nil {
-				log.Printf("无法删除 NodeResourceInfo CRD %s: %v", n, err)
-				errCh <- fmt.Errorf("删除失败: %s, 错误: %w", n, err)
-			} else {
-				// TODO: 删除 nodeName 对应的 Node CRD
-				c := pb.NewNodeManagerClient(opts.GRPCClient)
-				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-				defer cancel()
-
-				deleteResp, err := c.DeleteNode(ctx, &pb.DeleteNodeRequest{
-					NodeName:  nodeName,
-					ClusterId: clusterId,
-				})
-				if err != nil {
-					log.Fatalf("could not add node: %v", err)
-				}
+			if err := notifyDeleteNode(opts.GRPCClient, n, clusterId); err != nil {
+				log.Printf("manager 未确认删除节点 %s，保留 NodeResourceInfo 等待下个周期重试: %v", n, err)
+				errs.add(fmt.Errorf("通知 manager 删除节点失败: %s, 错误: %w", n, err))
+				return
+			}
+
+			// 删除前保留对象，用于记录节点移除事件
+			existing, err := opts.CRDClient.Get(context.TODO(), n, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				existing = nil
+			} else if err != nil {
+				errs.add(fmt.Errorf("查询 NodeResourceInfo 失败: %s, 错误: %w", n, err))
+				return
+			}
 
-				genericResp := deleteResp
-				log.Printf("Received response: %v", genericResp)
+			if err := RemoveCRDFinalizer(opts.CRDClient, n, v1beta1.NodeManagerFinalizer); err != nil {
+				log.Printf("无法移除 NodeResourceInfo CRD %s 的 finalizer: %v", n, err)
+				errs.add(fmt.Errorf("移除 finalizer 失败: %s, 错误: %w", n, err))
+				return
+			}
 
-				var deleteNodeResp pb.DeleteNodeResponse
-				if err := genericResp.GetData().UnmarshalTo(&deleteNodeResp); err != nil {
-					log.Fatalf("Failed to unmarshal deleteNodeResponse from data: %v", err)
+			if err := DeleteCRD(opts.CRDClient, n); err != nil && !apierrors.IsNotFound(err) {
+				log.Printf("无法删除 NodeResourceInfo CRD %s: %v", n, err)
+				errs.add(fmt.Errorf("删除失败: %s, 错误: %w", n, err))
+				return
+			}
+			log.Printf("已删除 NodeResourceInfo CRD %s", n)
+
+			if opts.Recorder != nil && existing != nil {
+				if err := opts.Recorder.Record(context.TODO(), existing, []history.NodeChange{history.NewNodeRemovedChange(n)}); err != nil {
+					log.Printf("记录节点 %s 的移除失败: %v", n, err)
 				}
-				log.Printf("已删除 NodeResourceInfo CRD %s", n)
 			}
 		}(nodeName)
 	}
 }
 
+func notifyDeleteNode(grpcClient *grpc.ClientConn, nodeName string, clusterId string) error {
+	c := pb.NewNodeManagerClient(grpcClient)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	deleteResp, err := c.DeleteNode(ctx, &pb.DeleteNodeRequest{
+		NodeName:  nodeName,
+		ClusterId: clusterId,
+	})
+	if err != nil {
+		return fmt.Errorf("调用 rpc DeleteNode 失败: %w", err)
+	}
+	log.Printf("Received response: %v", deleteResp)
+
+	// manager 中不存在该节点同样视为删除完成
+	if deleteResp.GetCode() == 404 {
+		return nil
+	}
+	if deleteResp.GetCode() != 0 && deleteResp.GetCode() != 200 {
+		return fmt.Errorf("rpc DeleteNode 返回错误: code=%d, message=%s", deleteResp.GetCode(), deleteResp.GetMessage())
+	}
+
+	var deleteNodeResp pb.DeleteNodeResponse
+	if err := deleteResp.GetData().UnmarshalTo(&deleteNodeResp); err != nil {
+		return fmt.Errorf("解析 DeleteNodeResponse 失败: %w", err)
+	}
+	if !deleteNodeResp.GetSuccess() {
+		return fmt.Errorf("manager 未能删除节点 %s", nodeName)
+	}
+	return nil
+}
+
 func NodeHeartbeat(opts NodeResourceInfoOptions, clusterId string) error {
 	if opts.CRDClient == nil {
 		return errors.New("CRD client is nil")
@@ -146,8 +211,8 @@ func NodeHeartbeat(opts NodeResourceInfoOptions, clusterId string) error {
 
 	var (
 		continueToken string
-		errCh         = make(chan error, 100)
 		wg            sync.WaitGroup
+		errs          = &errorList{}
 	)
 
 	semaphore := make(chan struct{}, opts.Parallelism)
@@ -163,15 +228,16 @@ func NodeHeartbeat(opts NodeResourceInfoOptions, clusterId string) error {
 			return fmt.Errorf("failed to list CRD instances: %w", err)
 		}
 
-		nodeNames := make([]string, 0, len(crdList.Items))
+		nodeInfos := make([]v1beta1.NodeResourceInfo, 0, len(crdList.Items))
 		for _, crd := range crdList.Items {
-			nodeName := crd.GetName()
-			if nodeName != "" {
-				nodeNames = append(nodeNames, nodeName)
+			// 正在删除的节点由删除任务通知 manager，不再发送心跳
+			if crd.GetName() == "" || crd.GetDeletionTimestamp() != nil {
+				continue
 			}
+			nodeInfos = append(nodeInfos, crd)
 		}
 
-		if len(nodeNames) == 0 {
+		if len(nodeInfos) == 0 {
 			if newContinueToken == "" {
 				break
 			}
@@ -179,65 +245,24 @@ func NodeHeartbeat(opts NodeResourceInfoOptions, clusterId string) error {
 			continue
 		}
 
-		// 并发对每个 nodeName 发起心跳
-		for _, nodeName := range nodeNames {
+		// 并发对每个节点发起心跳
+		for _, nodeInfo := range nodeInfos {
 			if semaphore != nil {
 				semaphore <- struct{}{}
 			}
 			wg.Add(1)
 
-			go func(name string) {
+			go func(nodeInfo v1beta1.NodeResourceInfo) {
 				defer wg.Done()
 				if semaphore != nil {
 					defer func() { <-semaphore }()
 				}
 
-				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-				defer cancel()
-
-				resp, err := c.Heartbeat(ctx, &pb.NodeHeartbeatRequest{
-					NodeName:  name,
-					ClusterId: clusterId,
-				})
-				if err != nil {
-					errCh <- fmt.Errorf("heartbeat failed for node %q: %w", name, err)
-					return
-				}
-
-				log.Printf("Heartbeat response for node %s: %v", name, resp)
-
-				// var parsedResp pb.NodeHeartbeatResponse
-				// if err := resp.GetData().UnmarshalTo(&parsedResp); err != nil {
-				// 	errCh <- fmt.Errorf("unmarshal failed for node %q: %w", name, err)
-				// 	return
-				// }
-
-				// 如果心跳是404,那么就需要触发添加该节点
-				if resp.GetCode() == 404 {
-					// TODO: 触发添加该节点
-					log.Printf("Node %s not found, triggering add node", name)
-					existingResourceInfo, err := opts.CRDClient.Get(context.TODO(), name, metav1.GetOptions{})
-					if err != nil {
-						log.Printf("Failed to get existing resource info for node %s: %v", name, err)
-					}
-
-					var nodeInfo v1alpha1.NodeResourceInfo
-					err = runtime.DefaultUnstructuredConverter.FromUnstructured(existingResourceInfo.UnstructuredContent(), &nodeInfo)
-					if err != nil {
-						log.Printf("Failed to convert unstructured to NodeResourceInfo: %v", err)
-						return
-					}
-					utils.MarshalToJSON(nodeInfo)
-
-					err = resourceinfo.CreateNodeResourceInfo(opts.CRDClient, opts.GRPCClient, &nodeInfo, clusterId)
-					if err != nil {
-						log.Printf("Failed to create node resource info for node %s: %v", name, err)
-
-					}
+				// 主循环会阻塞在并发槽上，错误不能写入有界的 channel，否则节点较多时会死锁
+				if err := sendNodeHeartbeat(c, opts, &nodeInfo, clusterId); err != nil {
+					errs.add(err)
 				}
-
-				// log.Printf("Parsed Heartbeat response for node %s: %+v", name, &parsedResp)
-			}(nodeName)
+			}(nodeInfo)
 		}
 
 		if newContinueToken == "" {
@@ -247,11 +272,76 @@ func NodeHeartbeat(opts NodeResourceInfoOptions, clusterId string) error {
 	}
 
 	wg.Wait()
-	close(errCh)
+	return errs.join()
+}
+
+func sendNodeHeartbeat(c pb.NodeManagerClient, opts NodeResourceInfoOptions, nodeInfo *v1beta1.NodeResourceInfo, clusterId string) error {
+	name := nodeInfo.Name
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resp, err := c.Heartbeat(ctx, &pb.NodeHeartbeatRequest{
+		NodeName:   name,
+		ClusterId:  clusterId,
+		NodeStatus: nodeInfo.Status.NodeStatus,
+		Health:     string(nodeInfo.Status.Health),
+		Conditions: resourceinfo.BuildNodeConditions(nodeInfo.Status.NodeConditions),
+	})
+	if err != nil {
+		return fmt.Errorf("heartbeat failed for node %q: %w", name, err)
+	}
+
+	log.Printf("Heartbeat response for node %s: %v", name, resp)
+
+	// 如果心跳是404,那么就需要触发添加该节点
+	if resp.GetCode() == 404 {
+		log.Printf("Node %s not found, triggering add node", name)
+		utils.MarshalToJSON(nodeInfo)
+
+		if err := resourceinfo.CreateNodeResourceInfo(opts.CRDClient, opts.GRPCClient, nodeInfo, clusterId, opts.Recorder); err != nil {
+			return fmt.Errorf("failed to add node %q after heartbeat 404: %w", name, err)
+		}
+		return nil
+	}
 
-	var allErrors []error
-	for err := range errCh {
-		allErrors = append(allErrors, err)
+	if resp.GetCode() != 0 && resp.GetCode() != 200 {
+		return fmt.Errorf("heartbeat rejected for node %q: code=%d, message=%s", name, resp.GetCode(), resp.GetMessage())
+	}
+
+	// 旧版本 manager 不返回 data，视为心跳成功且无需更新
+	var parsedResp pb.NodeHeartbeatResponse
+	if resp.GetData() != nil {
+		if err := resp.GetData().UnmarshalTo(&parsedResp); err != nil {
+			return fmt.Errorf("unmarshal heartbeat response failed for node %q: %w", name, err)
+		}
+		log.Printf("Parsed Heartbeat response for node %s: %+v", name, &parsedResp)
+	}
+
+	heartbeatTime := time.Now()
+	if parsedResp.GetLastHeartbeatTime() != nil {
+		heartbeatTime = parsedResp.GetLastHeartbeatTime().AsTime()
+	}
+	recordHeartbeatTime(opts.CRDClient, nodeInfo, heartbeatTime)
+
+	// manager 要求更新时推送完整的节点信息
+	if parsedResp.GetRequiresUpdate() {
+		log.Printf("Manager requires update for node %s, pushing full UpdateNode", name)
+		if err := resourceinfo.SyncToManager(opts.CRDClient, opts.GRPCClient, nodeInfo, clusterId, resourceinfo.OperationUpdateNode); err != nil {
+			return fmt.Errorf("failed to push update for node %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// lastHeartbeatTime 只用于展示，距上次记录超过 heartbeatRecordInterval 时才写入，避免每次心跳都更新 status
+func recordHeartbeatTime(crdClient typedv1beta1.NodeResourceInfoInterface, nodeInfo *v1beta1.NodeResourceInfo, heartbeatTime time.Time) {
+	if last := nodeInfo.Status.LastHeartbeatTime; last != nil && heartbeatTime.Sub(last.Time) < heartbeatRecordInterval {
+		return
+	}
+	if err := PatchCRDStatus(crdClient, nodeInfo.Name, map[string]any{
+		"lastHeartbeatTime": metav1.NewTime(heartbeatTime),
+	}); err != nil {
+		log.Printf("Failed to record last heartbeat time for node %s: %v", nodeInfo.Name, err)
 	}
-	return errors.Join(allErrors...)
 }
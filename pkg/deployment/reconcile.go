@@ -0,0 +1,323 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowclient "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
+	rayJobContext "github.com/modcoco/OpsFlow/pkg/context"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/svc"
+	rayclient "github.com/ray-project/kuberay/ray-operator/pkg/client/clientset/versioned"
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 新版本的 RayJob 在这段时间内不存在时不回滚
+const revisionCreateGracePeriod = time.Minute
+
+var (
+	// ErrRolloutInProgress 已有版本正在发布时不能创建新版本
+	ErrRolloutInProgress = errors.New("rollout in progress")
+	// ErrNoRollout 没有正在发布的版本，不能回滚
+	ErrNoRollout = errors.New("no rollout in progress")
+)
+
+// Reconciler 推进模型部署的发布，并按权重更新 VirtualService
+type Reconciler struct {
+	Core    kubernetes.Interface
+	Ray     rayclient.Interface
+	Istio   istioclient.Interface
+	OpsFlow opsflowclient.Interface
+	Prober  *job.EndpointProber
+	// JobOptions 创建版本的 RayJob 时使用的选项
+	JobOptions model.JobOptions
+	Now        func() time.Time
+}
+
+func (r *Reconciler) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// ExposeConfig 部署的 VirtualService 与 Gateway 配置
+func ExposeConfig(spec *v1beta1.ModelDeploymentSpec) *model.ExposeConfig {
+	return &model.ExposeConfig{
+		Mode:            svc.ExposeIstio,
+		Host:            spec.Host,
+		Path:            spec.Path,
+		Gateway:         spec.Gateway,
+		GatewaySelector: spec.GatewaySelector,
+	}
+}
+
+// Validate 检查部署的配置
+func Validate(spec *v1beta1.ModelDeploymentSpec) error {
+	switch spec.Strategy {
+	case "", v1beta1.RolloutCanary, v1beta1.RolloutBlueGreen:
+	default:
+		return fmt.Errorf("unknown strategy %q, supported strategies: %s, %s", spec.Strategy, v1beta1.RolloutCanary, v1beta1.RolloutBlueGreen)
+	}
+	if spec.StepWeight < 0 || spec.StepWeight > 100 {
+		return fmt.Errorf("stepWeight must be between 1 and 100, got %d", spec.StepWeight)
+	}
+	return svc.ValidateExpose(ExposeConfig(spec))
+}
+
+// ReconcileAll 推进所有 namespace 中的模型部署，单个部署失败只记录日志
+func (r *Reconciler) ReconcileAll(ctx context.Context) error {
+	list, err := r.OpsFlow.OpsflowV1beta1().ModelDeployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("无法获取模型部署列表: %w", err)
+	}
+	for i := range list.Items {
+		if err := r.Reconcile(ctx, &list.Items[i]); err != nil {
+			log.Printf("无法推进模型部署 %s/%s: %v", list.Items[i].Namespace, list.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile 记录各版本的 RayCluster，按发布版本的探测结果推进发布，更新 VirtualService 与状态
+func (r *Reconciler) Reconcile(ctx context.Context, deployment *v1beta1.ModelDeployment) error {
+	status := deployment.Status.DeepCopy()
+	for i := range status.Revisions {
+		revision := &status.Revisions[i]
+		if revision.ClusterName != "" {
+			continue
+		}
+		rayJob, err := r.Ray.RayV1().RayJobs(deployment.Namespace).Get(ctx, revision.JobName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			revision.ClusterName = rayJob.Status.RayClusterName
+		}
+	}
+
+	var retired int32
+	if canary := FindRevision(status, status.CanaryRevision); canary != nil {
+		rayJob, err := r.Ray.RayV1().RayJobs(deployment.Namespace).Get(ctx, canary.JobName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err) && r.now().Sub(canary.CreatedAt.Time) < revisionCreateGracePeriod:
+			// AddRevision 先更新状态再创建 RayJob
+		case apierrors.IsNotFound(err):
+			retired = Rollback(status, fmt.Sprintf("rayjob %s not found", canary.JobName), r.now())
+		case err != nil:
+			return err
+		default:
+			endpoint, err := r.Prober.Probe(ctx, rayJob)
+			if err != nil {
+				return err
+			}
+			retired = Advance(&deployment.Spec, status, endpoint, r.now())
+		}
+	}
+	return r.apply(ctx, deployment, status, retired)
+}
+
+// Rollback 手动回滚正在发布的版本
+func (r *Reconciler) Rollback(ctx context.Context, deployment *v1beta1.ModelDeployment, reason string) error {
+	status := deployment.Status.DeepCopy()
+	retired := Rollback(status, reason, r.now())
+	if retired == 0 {
+		return ErrNoRollout
+	}
+	return r.apply(ctx, deployment, status, retired)
+}
+
+// AddRevision 创建新版本的 RayJob 并开始发布，config 需要已经通过 job.ValidateJob 检查
+func (r *Reconciler) AddRevision(ctx context.Context, deployment *v1beta1.ModelDeployment, config model.ClusterConfig) (*v1beta1.ModelRevision, error) {
+	if deployment.Status.CanaryRevision != 0 {
+		return nil, fmt.Errorf("%w: revision %d", ErrRolloutInProgress, deployment.Status.CanaryRevision)
+	}
+
+	status := deployment.Status.DeepCopy()
+	status.LatestRevision++
+	revision := v1beta1.ModelRevision{
+		Revision:  status.LatestRevision,
+		JobName:   RevisionJobName(deployment.Name, status.LatestRevision),
+		CreatedAt: metav1.NewTime(r.now()),
+	}
+	status.Revisions = append(status.Revisions, revision)
+	status.CanaryRevision = revision.Revision
+	status.CanaryWeight = 0
+	status.Phase = v1beta1.RolloutProgressing
+	status.LastTransitionTime = revision.CreatedAt
+	status.Message = fmt.Sprintf("waiting for revision %d to be ready", revision.Revision)
+
+	// 先占用版本号，并发创建时由 resourceVersion 冲突拒绝
+	updated := deployment.DeepCopy()
+	updated.Status = *status
+	updated, err := r.OpsFlow.OpsflowV1beta1().ModelDeployments(deployment.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	config.Namespace = deployment.Namespace
+	config.Job.Name = revision.JobName
+	config.Job.Expose = nil
+	config.Job.Labels = map[string]string{v1beta1.ModelDeploymentLabel: deployment.Name}
+	config.Job.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(deployment, v1beta1.SchemeGroupVersion.WithKind("ModelDeployment")),
+	}
+	if _, err := job.CreateRayJob(config, rayJobContext.NewRayJobContext(r.Core, r.Ray, r.Istio, ctx), r.JobOptions); err != nil {
+		if rollbackErr := r.Rollback(ctx, updated, "create rayjob failed"); rollbackErr != nil {
+			log.Printf("无法回滚模型部署 %s/%s 的版本 %d: %v", deployment.Namespace, deployment.Name, revision.Revision, rollbackErr)
+		}
+		return nil, fmt.Errorf("create rayjob %s: %w", revision.JobName, err)
+	}
+	return &revision, nil
+}
+
+// Delete 删除所有版本与路由，部署本身由调用方删除
+func (r *Reconciler) Delete(ctx context.Context, deployment *v1beta1.ModelDeployment) error {
+	for _, revision := range deployment.Status.Revisions {
+		if err := r.deleteRevision(ctx, deployment.Namespace, revision.JobName); err != nil {
+			return err
+		}
+	}
+	if err := r.Istio.NetworkingV1().VirtualServices(deployment.Namespace).Delete(ctx, deployment.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if deployment.Spec.Gateway == "" {
+		if err := r.Istio.NetworkingV1().Gateways(deployment.Namespace).Delete(ctx, deployment.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// 先更新路由再删除被替换的版本，保证流量不会转发到已删除的 Service。
+// 删除成功后才从状态中去掉该版本，删除失败时下个周期按原来的状态重新推进并重试删除
+func (r *Reconciler) apply(ctx context.Context, deployment *v1beta1.ModelDeployment, status *v1beta1.ModelDeploymentStatus, retired int32) error {
+	if err := r.applyRouting(ctx, deployment, status); err != nil {
+		return err
+	}
+	if retired != 0 {
+		if err := r.deleteRevision(ctx, deployment.Namespace, RevisionJobName(deployment.Name, retired)); err != nil {
+			return err
+		}
+	}
+
+	if status.Phase == "" {
+		status.Phase = v1beta1.RolloutIdle
+	}
+	if apiequality.Semantic.DeepEqual(&deployment.Status, status) {
+		return nil
+	}
+	updated := deployment.DeepCopy()
+	updated.Status = *status
+	if _, err := r.OpsFlow.OpsflowV1beta1().ModelDeployments(deployment.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	deployment.Status = *status
+	return nil
+}
+
+// 稳定版本按 100 - canaryWeight 分配流量，发布版本权重大于 0 时才加入；没有稳定版本时发布版本接管全部流量
+func (r *Reconciler) applyRouting(ctx context.Context, deployment *v1beta1.ModelDeployment, status *v1beta1.ModelDeploymentStatus) error {
+	var destinations []svc.WeightedDestination
+	stable := FindRevision(status, status.StableRevision)
+	canary := FindRevision(status, status.CanaryRevision)
+	if stable != nil && stable.ClusterName != "" {
+		destinations = append(destinations, svc.WeightedDestination{ServiceName: svc.ServiceName(stable.ClusterName), Weight: 100 - status.CanaryWeight})
+		if canary != nil && canary.ClusterName != "" && status.CanaryWeight > 0 {
+			destinations = append(destinations, svc.WeightedDestination{ServiceName: svc.ServiceName(canary.ClusterName), Weight: status.CanaryWeight})
+		}
+	} else if canary != nil && canary.ClusterName != "" {
+		destinations = append(destinations, svc.WeightedDestination{ServiceName: svc.ServiceName(canary.ClusterName), Weight: 100})
+	}
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	owner := []metav1.OwnerReference{*metav1.NewControllerRef(deployment, v1beta1.SchemeGroupVersion.WithKind("ModelDeployment"))}
+	expose := ExposeConfig(&deployment.Spec)
+	if expose.Gateway == "" {
+		gateway := svc.GenerateGateway(deployment.Namespace, deployment.Name, expose)
+		gateway.OwnerReferences = owner
+		if _, err := r.Istio.NetworkingV1().Gateways(deployment.Namespace).Create(ctx, gateway, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("create gateway %s: %w", gateway.Name, err)
+		}
+	}
+
+	virtualService := svc.GenerateWeightedVirtualService(deployment.Namespace, deployment.Name, expose, destinations)
+	virtualService.OwnerReferences = owner
+	virtualServices := r.Istio.NetworkingV1().VirtualServices(deployment.Namespace)
+	existing, err := virtualServices.Get(ctx, deployment.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = virtualServices.Create(ctx, virtualService, metav1.CreateOptions{})
+	case err == nil:
+		virtualService.ResourceVersion = existing.ResourceVersion
+		_, err = virtualServices.Update(ctx, virtualService, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("apply virtualservice %s: %w", deployment.Name, err)
+	}
+	return nil
+}
+
+// 删除版本的 RayJob 以及创建 RayJob 时创建的 Service、ConfigMap 与探测状态
+func (r *Reconciler) deleteRevision(ctx context.Context, namespace, jobName string) error {
+	if err := r.Ray.RayV1().RayJobs(namespace).Delete(ctx, jobName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete rayjob %s: %w", jobName, err)
+	}
+
+	listOptions := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", model.ModelUniqueID, jobName)}
+	services, err := r.Core.CoreV1().Services(namespace).List(ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	for _, service := range services.Items {
+		if err := r.Core.CoreV1().Services(namespace).Delete(ctx, service.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	configMaps, err := r.Core.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	for _, configMap := range configMaps.Items {
+		if err := r.Core.CoreV1().ConfigMaps(namespace).Delete(ctx, configMap.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if r.Prober != nil && r.Prober.Store != nil {
+		if err := r.Prober.Store.Delete(ctx, namespace, jobName); err != nil {
+			log.Printf("无法删除 %s/%s 的探测状态: %v", namespace, jobName, err)
+		}
+	}
+	log.Printf("已删除模型版本 %s/%s", namespace, jobName)
+	return nil
+}
+
+// IsServingKind 只有提供 OpenAI 兼容接口的任务可以作为模型部署的版本
+func IsServingKind(kind string) error {
+	for _, servingKind := range job.ServingJobKinds {
+		if kind == servingKind {
+			return nil
+		}
+	}
+	return fmt.Errorf("job kind %q can't be a model deployment revision, supported kinds: %s", kind, strings.Join(job.ServingJobKinds, ", "))
+}
+
+// CreateRequest 创建模型部署的请求体
+type CreateRequest struct {
+	Namespace string                      `json:"namespace" binding:"required"`
+	Name      string                      `json:"name" binding:"required"`
+	Spec      v1beta1.ModelDeploymentSpec `json:"spec"`
+}
@@ -0,0 +1,126 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultStepWeight          = 20
+	DefaultStepIntervalSeconds = 60
+)
+
+// RevisionJobName 版本对应的 RayJob 名称
+func RevisionJobName(deploymentName string, revision int32) string {
+	return fmt.Sprintf("%s-r%d", deploymentName, revision)
+}
+
+// FindRevision 返回状态中的版本，不存在时返回 nil
+func FindRevision(status *v1beta1.ModelDeploymentStatus, revision int32) *v1beta1.ModelRevision {
+	if revision == 0 {
+		return nil
+	}
+	for i := range status.Revisions {
+		if status.Revisions[i].Revision == revision {
+			return &status.Revisions[i]
+		}
+	}
+	return nil
+}
+
+func stepWeight(spec *v1beta1.ModelDeploymentSpec) int32 {
+	if spec.StepWeight <= 0 {
+		return DefaultStepWeight
+	}
+	return min(spec.StepWeight, 100)
+}
+
+func stepInterval(spec *v1beta1.ModelDeploymentSpec) time.Duration {
+	if spec.StepIntervalSeconds <= 0 {
+		return DefaultStepIntervalSeconds * time.Second
+	}
+	return time.Duration(spec.StepIntervalSeconds) * time.Second
+}
+
+// Advance 按发布版本的探测结果推进发布，修改 status，返回需要删除的版本，没有时为 0。
+// 发布版本就绪前不分配流量，持续失败（degraded）时自动回滚；就绪后 BlueGreen 与
+// 没有稳定版本的部署直接切换，Canary 每隔 stepIntervalSeconds 增加 stepWeight，达到 100 时切换
+func Advance(spec *v1beta1.ModelDeploymentSpec, status *v1beta1.ModelDeploymentStatus, endpoint *model.EndpointStatus, now time.Time) int32 {
+	if status.CanaryRevision == 0 {
+		return 0
+	}
+
+	if endpoint == nil || endpoint.State != job.EndpointReady {
+		if endpoint != nil && endpoint.Degraded {
+			return Rollback(status, fmt.Sprintf("revision %d is degraded: %s", status.CanaryRevision, endpoint.Message), now)
+		}
+		state := "unknown"
+		if endpoint != nil {
+			state = endpoint.State
+		}
+		status.Message = fmt.Sprintf("waiting for revision %d to be ready, endpoint is %s", status.CanaryRevision, state)
+		return 0
+	}
+
+	if status.StableRevision == 0 || spec.Strategy == v1beta1.RolloutBlueGreen {
+		return promote(status, now)
+	}
+
+	if status.CanaryWeight > 0 && now.Sub(status.LastTransitionTime.Time) < stepInterval(spec) {
+		return 0
+	}
+	weight := status.CanaryWeight + stepWeight(spec)
+	if weight >= 100 {
+		return promote(status, now)
+	}
+	status.CanaryWeight = weight
+	status.LastTransitionTime = metav1.NewTime(now)
+	status.Message = fmt.Sprintf("revision %d receives %d%% of traffic", status.CanaryRevision, weight)
+	return 0
+}
+
+// 发布版本接管全部流量，返回被替换的稳定版本
+func promote(status *v1beta1.ModelDeploymentStatus, now time.Time) int32 {
+	retired := status.StableRevision
+	status.StableRevision = status.CanaryRevision
+	status.CanaryRevision = 0
+	status.CanaryWeight = 0
+	status.Phase = v1beta1.RolloutSucceeded
+	status.LastTransitionTime = metav1.NewTime(now)
+	status.Message = fmt.Sprintf("revision %d receives all traffic", status.StableRevision)
+	removeRevision(status, retired)
+	return retired
+}
+
+// Rollback 停止发布，流量回到稳定版本，返回需要删除的发布版本，没有发布时返回 0
+func Rollback(status *v1beta1.ModelDeploymentStatus, reason string, now time.Time) int32 {
+	canary := status.CanaryRevision
+	if canary == 0 {
+		return 0
+	}
+	status.CanaryRevision = 0
+	status.CanaryWeight = 0
+	status.Phase = v1beta1.RolloutRolledBack
+	status.LastTransitionTime = metav1.NewTime(now)
+	status.Message = fmt.Sprintf("revision %d rolled back: %s", canary, reason)
+	removeRevision(status, canary)
+	return canary
+}
+
+func removeRevision(status *v1beta1.ModelDeploymentStatus, revision int32) {
+	if revision == 0 {
+		return
+	}
+	revisions := status.Revisions[:0]
+	for _, item := range status.Revisions {
+		if item.Revision != revision {
+			revisions = append(revisions, item)
+		}
+	}
+	status.Revisions = revisions
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newArtifactReconciler(appCtx core.AppContext) *artifact.Reconciler {
+	return &artifact.Reconciler{
+		Core:    appCtx.Client().Core(),
+		OpsFlow: appCtx.Client().OpsFlow(),
+	}
+}
+
+// CreateModelArtifactHandle 创建模型并立即创建 PVC 与下载任务，之后由定时任务更新进度
+func CreateModelArtifactHandle(c *gin.Context) {
+	var request artifact.CreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := artifact.Validate(request.Name, &request.Spec); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	modelArtifact := &v1beta1.ModelArtifact{
+		ObjectMeta: metav1.ObjectMeta{Name: request.Name, Namespace: request.Namespace},
+		Spec:       request.Spec,
+	}
+	created, err := appCtx.Client().OpsFlow().OpsflowV1beta1().ModelArtifacts(request.Namespace).Create(appCtx.Ctx(), modelArtifact, metav1.CreateOptions{})
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			c.JSON(400, gin.H{"message": "Model already exists"})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Failed to create model", "error": err.Error()})
+		return
+	}
+
+	// 创建下载任务失败时由定时任务重试
+	if err := newArtifactReconciler(appCtx).Reconcile(appCtx.Ctx(), created); err != nil {
+		log.Printf("无法开始下载模型 %s/%s: %v", created.Namespace, created.Name, err)
+	}
+	c.JSON(200, created)
+}
+
+// ListModelArtifactsHandle 返回 namespace 中的所有模型
+func ListModelArtifactsHandle(c *gin.Context) {
+	appCtx := core.GetAppContext(c)
+	list, err := appCtx.Client().OpsFlow().OpsflowV1beta1().ModelArtifacts(c.Param("namespace")).List(appCtx.Ctx(), metav1.ListOptions{})
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to list models", "error": err.Error()})
+		return
+	}
+	c.JSON(200, list.Items)
+}
+
+// 获取路径参数指定的模型，失败时已写入响应
+func getModelArtifact(c *gin.Context, appCtx core.AppContext) (*v1beta1.ModelArtifact, bool) {
+	namespace, name := c.Param("namespace"), c.Param("name")
+	modelArtifact, err := appCtx.Client().OpsFlow().OpsflowV1beta1().ModelArtifacts(namespace).Get(appCtx.Ctx(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(404, gin.H{"message": "Model not found"})
+			return nil, false
+		}
+		c.JSON(500, gin.H{"message": "Internal server error", "error": err.Error()})
+		return nil, false
+	}
+	return modelArtifact, true
+}
+
+// ModelArtifactInfoHandle 返回模型的下载进度与校验和
+func ModelArtifactInfoHandle(c *gin.Context) {
+	appCtx := core.GetAppContext(c)
+	modelArtifact, ok := getModelArtifact(c, appCtx)
+	if !ok {
+		return
+	}
+	c.JSON(200, modelArtifact)
+}
+
+// RemoveModelArtifactHandle 删除模型与下载任务，deleteData=true 时同时删除保存模型的 PVC
+func RemoveModelArtifactHandle(c *gin.Context) {
+	deleteData, err := strconv.ParseBool(c.DefaultQuery("deleteData", "false"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid deleteData: " + err.Error()})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	modelArtifact, ok := getModelArtifact(c, appCtx)
+	if !ok {
+		return
+	}
+	if err := newArtifactReconciler(appCtx).Delete(appCtx.Ctx(), modelArtifact, deleteData); err != nil {
+		c.JSON(500, gin.H{"message": "Failed to delete download job", "error": err.Error()})
+		return
+	}
+	err = appCtx.Client().OpsFlow().OpsflowV1beta1().ModelArtifacts(modelArtifact.Namespace).Delete(appCtx.Ctx(), modelArtifact.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		c.JSON(500, gin.H{"message": "Failed to delete model", "error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"message":     "Model deleted successfully",
+		"name":        modelArtifact.Name,
+		"dataDeleted": deleteData,
+	})
+}
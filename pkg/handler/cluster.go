@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterCapacityHandle 汇总所有 Ready 且可调度节点的资源，groupBy 为节点标签名，
+// 如 topology.kubernetes.io/zone、nvidia.com/gpu.product；resources 为逗号分隔的 glob，默认返回 CRD 中记录的所有资源
+func ClusterCapacityHandle(c *gin.Context) {
+	resources := c.Query("resources")
+	if resources == "" {
+		resources = "*,*/*"
+	}
+	tracker, err := resourceinfo.NewResourceTracker(resources, false)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	list, err := appCtx.Client().OpsFlow().OpsflowV1beta1().NodeResourceInfos().List(appCtx.Ctx(), metav1.ListOptions{})
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to list NodeResourceInfo", "error": err.Error()})
+		return
+	}
+
+	c.JSON(200, resourceinfo.ComputeClusterCapacity(list.Items, tracker, c.Query("groupBy")))
+}
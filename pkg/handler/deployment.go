@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/deployment"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newRolloutReconciler(appCtx core.AppContext) *deployment.Reconciler {
+	return &deployment.Reconciler{
+		Core:    appCtx.Client().Core(),
+		Ray:     appCtx.Client().Ray(),
+		Istio:   appCtx.Client().Istio(),
+		OpsFlow: appCtx.Client().OpsFlow(),
+		Prober:  job.NewEndpointProber(&job.RedisEndpointStore{Client: appCtx.Redis()}, appCtx.JobOptions().EndpointProbe),
+		// 与创建任务的接口使用同样的选项
+		JobOptions: appCtx.JobOptions(),
+	}
+}
+
+// 获取路径参数指定的模型部署，失败时已写入响应
+func getModelDeployment(c *gin.Context, appCtx core.AppContext) (*v1beta1.ModelDeployment, bool) {
+	namespace, name := c.Param("namespace"), c.Param("name")
+	modelDeployment, err := appCtx.Client().OpsFlow().OpsflowV1beta1().ModelDeployments(namespace).Get(appCtx.Ctx(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(404, gin.H{"message": "Model deployment not found"})
+			return nil, false
+		}
+		c.JSON(500, gin.H{"message": "Internal server error", "error": err.Error()})
+		return nil, false
+	}
+	return modelDeployment, true
+}
+
+func CreateModelDeploymentHandle(c *gin.Context) {
+	var request deployment.CreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := deployment.Validate(&request.Spec); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Spec.Strategy == "" {
+		request.Spec.Strategy = v1beta1.RolloutCanary
+	}
+
+	appCtx := core.GetAppContext(c)
+	modelDeployment := &v1beta1.ModelDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: request.Name, Namespace: request.Namespace},
+		Spec:       request.Spec,
+	}
+	created, err := appCtx.Client().OpsFlow().OpsflowV1beta1().ModelDeployments(request.Namespace).Create(appCtx.Ctx(), modelDeployment, metav1.CreateOptions{})
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			c.JSON(400, gin.H{"message": "Model deployment already exists"})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Failed to create model deployment", "error": err.Error()})
+		return
+	}
+	c.JSON(200, created)
+}
+
+// ModelDeploymentInfoHandle 返回模型部署以及每个版本推理服务的状态
+func ModelDeploymentInfoHandle(c *gin.Context) {
+	appCtx := core.GetAppContext(c)
+	modelDeployment, ok := getModelDeployment(c, appCtx)
+	if !ok {
+		return
+	}
+
+	// 返回发布任务最近一次探测的结果，读取失败不影响返回部署的状态
+	prober := job.NewEndpointProber(&job.RedisEndpointStore{Client: appCtx.Redis()}, appCtx.JobOptions().EndpointProbe)
+	endpoints := map[int32]*model.EndpointStatus{}
+	for _, revision := range modelDeployment.Status.Revisions {
+		rayJob, err := appCtx.Client().Ray().RayV1().RayJobs(modelDeployment.Namespace).Get(appCtx.Ctx(), revision.JobName, metav1.GetOptions{})
+		if err != nil {
+			log.Printf("无法获取模型版本 %s/%s: %v", modelDeployment.Namespace, revision.JobName, err)
+			continue
+		}
+		endpoint, err := prober.Cached(appCtx.Ctx(), rayJob)
+		if err != nil {
+			log.Printf("无法读取 %s/%s 的推理服务状态: %v", modelDeployment.Namespace, revision.JobName, err)
+			continue
+		}
+		if endpoint != nil {
+			endpoints[revision.Revision] = endpoint
+		}
+	}
+	c.JSON(200, gin.H{"deployment": modelDeployment, "endpoints": endpoints})
+}
+
+// AddModelRevisionHandle 以请求体中的任务配置创建新版本并开始发布，namespace 与任务名称由部署决定
+func AddModelRevisionHandle(c *gin.Context) {
+	var clusterConfig model.ClusterConfig
+	if err := c.ShouldBindJSON(&clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	modelDeployment, ok := getModelDeployment(c, appCtx)
+	if !ok {
+		return
+	}
+	clusterConfig.Namespace = modelDeployment.Namespace
+	clusterConfig.Job.Name = deployment.RevisionJobName(modelDeployment.Name, modelDeployment.Status.LatestRevision+1)
+	if err := deployment.IsServingKind(clusterConfig.Job.Kind); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := artifact.ResolveModel(appCtx.Ctx(), appCtx.Client().OpsFlow(), &clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.ValidateJob(&clusterConfig, appCtx.JobOptions()); err != nil {
+		c.JSON(400, gin.H{"error": err.Error(), "supportedKinds": job.SupportedJobKinds()})
+		return
+	}
+	if err := job.ResolveRunCodeTemplate(appCtx.Ctx(), appCtx.Client().Core(), &clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !enforcePlacement(c, appCtx, clusterConfig) || !checkAutoscalerCapacity(c, appCtx, clusterConfig) {
+		return
+	}
+
+	revision, err := newRolloutReconciler(appCtx).AddRevision(appCtx.Ctx(), modelDeployment, clusterConfig)
+	if err != nil {
+		if errors.Is(err, deployment.ErrRolloutInProgress) || apierrors.IsConflict(err) {
+			c.JSON(400, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Failed to create revision", "error": err.Error()})
+		return
+	}
+	c.JSON(200, revision)
+}
+
+// RollbackModelDeploymentHandle 回滚正在发布的版本，流量回到稳定版本
+func RollbackModelDeploymentHandle(c *gin.Context) {
+	appCtx := core.GetAppContext(c)
+	modelDeployment, ok := getModelDeployment(c, appCtx)
+	if !ok {
+		return
+	}
+
+	if err := newRolloutReconciler(appCtx).Rollback(appCtx.Ctx(), modelDeployment, "manual rollback"); err != nil {
+		if errors.Is(err, deployment.ErrNoRollout) || apierrors.IsConflict(err) {
+			c.JSON(400, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Failed to rollback", "error": err.Error()})
+		return
+	}
+	c.JSON(200, modelDeployment.Status)
+}
+
+// RemoveModelDeploymentHandle 删除所有版本、路由与部署本身
+func RemoveModelDeploymentHandle(c *gin.Context) {
+	appCtx := core.GetAppContext(c)
+	modelDeployment, ok := getModelDeployment(c, appCtx)
+	if !ok {
+		return
+	}
+
+	if err := newRolloutReconciler(appCtx).Delete(appCtx.Ctx(), modelDeployment); err != nil {
+		c.JSON(500, gin.H{"message": "Failed to delete revisions", "error": err.Error()})
+		return
+	}
+	err := appCtx.Client().OpsFlow().OpsflowV1beta1().ModelDeployments(modelDeployment.Namespace).Delete(appCtx.Ctx(), modelDeployment.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		c.JSON(500, gin.H{"message": "Failed to delete model deployment", "error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "Model deployment and associated resources deleted successfully",
+		"name":    modelDeployment.Name,
+	})
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
+)
+
+// 无新变化时每次阻塞读取的时长，超时后发送一次 keepalive
+const changeStreamBlock = 15 * time.Second
+
+// NodeHistoryHandle 按时间倒序返回节点的变化历史，before 为上一页返回的 next，
+// type 为逗号分隔的变化类型，如 StatusChanged,ResourceChanged
+func NodeHistoryHandle(c *gin.Context) {
+	nodeName := c.Param("name")
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit <= 0 || limit > history.DefaultMaxLen {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", history.DefaultMaxLen)})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	store := &history.RedisStore{Client: appCtx.Redis()}
+	changes, err := store.List(appCtx.Ctx(), nodeName, limit, c.Query("before"))
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to read node history", "error": err.Error()})
+		return
+	}
+
+	// 分页按原始条数计算，过滤后每页可能少于 limit
+	var next string
+	if int64(len(changes)) == limit {
+		next = changes[len(changes)-1].ID
+	}
+	if types := c.Query("type"); types != "" {
+		wanted := strings.Split(types, ",")
+		changes = slices.DeleteFunc(changes, func(change history.NodeChange) bool {
+			return !slices.Contains(wanted, string(change.Type))
+		})
+	}
+
+	response := gin.H{
+		"node":    nodeName,
+		"changes": changes,
+	}
+	if next != "" {
+		response["next"] = next
+	}
+	c.JSON(200, response)
+}
+
+// NodeChangeStreamHandle 以 server-sent events 推送单个节点的实时变化
+func NodeChangeStreamHandle(c *gin.Context) {
+	streamNodeChanges(c, c.Param("name"))
+}
+
+// ClusterChangeStreamHandle 以 server-sent events 推送所有节点的实时变化
+func ClusterChangeStreamHandle(c *gin.Context) {
+	streamNodeChanges(c, "")
+}
+
+// 客户端重连时通过 Last-Event-ID 从断开的位置继续推送
+func streamNodeChanges(c *gin.Context, nodeName string) {
+	appCtx := core.GetAppContext(c)
+	store := &history.RedisStore{Client: appCtx.Redis()}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		var err error
+		if lastID, err = store.LatestID(appCtx.Ctx(), nodeName); err != nil {
+			c.JSON(500, gin.H{"message": "Failed to read node changes", "error": err.Error()})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	c.Writer.Flush()
+
+	for {
+		changes, err := store.Read(appCtx.Ctx(), nodeName, lastID, changeStreamBlock)
+		if appCtx.Ctx().Err() != nil {
+			return
+		}
+		if err != nil {
+			c.Render(-1, sse.Event{Event: "error", Data: err.Error()})
+			c.Writer.Flush()
+			return
+		}
+
+		if len(changes) == 0 {
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+		}
+		for _, change := range changes {
+			c.Render(-1, sse.Event{Id: change.ID, Event: "change", Data: change})
+			lastID = change.ID
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/node/maintenance"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CordonNodeHandle 将节点设置为不可调度，已经是不可调度时 changed 为 false
+func CordonNodeHandle(c *gin.Context) {
+	setNodeSchedulable(c, maintenance.Cordon)
+}
+
+// UncordonNodeHandle 恢复节点调度，已经可调度时 changed 为 false
+func UncordonNodeHandle(c *gin.Context) {
+	setNodeSchedulable(c, maintenance.Uncordon)
+}
+
+func setNodeSchedulable(c *gin.Context, fn func(context.Context, kubernetes.Interface, string) (bool, error)) {
+	nodeName := c.Param("name")
+	appCtx := core.GetAppContext(c)
+	changed, err := fn(appCtx.Ctx(), appCtx.Client().Core(), nodeName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(404, gin.H{"message": "Node not found"})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Failed to update node", "error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"node": nodeName, "changed": changed})
+}
+
+// DrainNodeHandle cordon 节点并通过 eviction API 驱逐节点上的 Pod，请求体为 DrainOptions，
+// dryRun 也可以通过查询参数指定，此时只返回会被驱逐的 Pod 以及被 PDB 阻塞的 Pod
+func DrainNodeHandle(c *gin.Context) {
+	nodeName := c.Param("name")
+
+	var opts maintenance.DrainOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if dryRun := c.Query("dryRun"); dryRun != "" {
+		value, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "dryRun must be a boolean"})
+			return
+		}
+		opts.DryRun = value
+	}
+	// 请求在 drain 完成前保持连接，等待时间不能超过 MaxDrainTimeout
+	if err := opts.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	result, err := maintenance.Drain(appCtx.Ctx(), appCtx.Client().Core(), nodeName, opts)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(404, gin.H{"message": "Node not found"})
+			return
+		}
+		// 存在需要 force 或 deleteEmptyDirData 的 Pod 时返回受影响的 Pod
+		if errors.Is(err, maintenance.ErrDrainBlocked) {
+			c.JSON(400, gin.H{"message": err.Error(), "result": result})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Failed to drain node", "error": err.Error()})
+		return
+	}
+	c.JSON(200, result)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeUsageHandle 返回节点上已申请资源按 namespace 与工作负载的拆分，
+// resources 为逗号分隔的 glob，默认统计 cpu、内存与所有扩展资源
+func NodeUsageHandle(c *gin.Context) {
+	nodeName := c.Param("name")
+	groupBy := c.DefaultQuery("groupBy", "all")
+	if groupBy != "all" && groupBy != "namespace" && groupBy != "workload" {
+		c.JSON(400, gin.H{"error": "groupBy must be one of all, namespace, workload"})
+		return
+	}
+
+	resources := c.Query("resources")
+	autoDiscover := resources == ""
+	if autoDiscover {
+		resources = "cpu,memory"
+	}
+	tracker, err := resourceinfo.NewResourceTracker(resources, autoDiscover)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	appCtx := core.GetAppContext(c)
+	if _, err := appCtx.Client().Core().CoreV1().Nodes().Get(appCtx.Ctx(), nodeName, metav1.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(404, gin.H{"message": "Node not found"})
+			return
+		}
+		c.JSON(500, gin.H{"message": "Internal server error", "error": err.Error()})
+		return
+	}
+
+	pods, err := resourceinfo.ListActivePodsOnNode(appCtx.Client().Core(), nodeName)
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to list pods", "error": err.Error()})
+		return
+	}
+
+	// 可选按 namespace 过滤
+	if namespace := c.Query("namespace"); namespace != "" {
+		filtered := pods[:0]
+		for _, pod := range pods {
+			if pod.Namespace == namespace {
+				filtered = append(filtered, pod)
+			}
+		}
+		pods = filtered
+	}
+
+	breakdown := resourceinfo.ComputeUsageBreakdown(pods, tracker)
+	response := gin.H{
+		"node": nodeName,
+	}
+	if groupBy != "workload" {
+		response["namespaces"] = breakdown.Namespaces
+	}
+	if groupBy != "namespace" {
+		response["workloads"] = breakdown.Workloads
+	}
+	c.JSON(200, response)
+}
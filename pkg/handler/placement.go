@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PlacementCheckHandle 模拟放置 ClusterConfig 中的 head 与所有 worker，返回无法放置的机器及原因
+func PlacementCheckHandle(c *gin.Context) {
+	var clusterConfig model.ClusterConfig
+	if err := c.ShouldBindJSON(&clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if len(clusterConfig.Machines) == 0 {
+		c.JSON(400, gin.H{"error": "machines is required"})
+		return
+	}
+	// 生成 Pod 模板时会应用 autoscaler 配置，需要先检查
+	if err := job.ValidateAutoscaler(&clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	appCtx := core.GetAppContext(c)
+	if err := job.ValidateVolumes(&clusterConfig, appCtx.JobOptions().HostPath); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.ValidatePodTemplates(&clusterConfig, appCtx.JobOptions().HostPath); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := checkPlacement(appCtx, clusterConfig)
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to check placement", "error": err.Error()})
+		return
+	}
+	c.JSON(200, result)
+}
+
+func checkPlacement(appCtx core.AppContext, clusterConfig model.ClusterConfig) (*resourceinfo.PlacementResult, error) {
+	list, err := appCtx.Client().OpsFlow().OpsflowV1beta1().NodeResourceInfos().List(appCtx.Ctx(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return resourceinfo.SimulatePlacement(list.Items, job.ClusterConfigPlacementRequests(clusterConfig)), nil
+}
+
+// 开启 enforcePlacement 时检查放置，不能放置时写入响应并返回 false
+func enforcePlacement(c *gin.Context, appCtx core.AppContext, clusterConfig model.ClusterConfig) bool {
+	if !clusterConfig.EnforcePlacement {
+		return true
+	}
+
+	result, err := checkPlacement(appCtx, clusterConfig)
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to check placement", "error": err.Error()})
+		return false
+	}
+	if !result.Feasible {
+		c.JSON(400, gin.H{"message": "Insufficient resources to place all machines", "placement": result})
+		return false
+	}
+	return true
+}
+
+// 开启 autoscaler 时检查所有 worker 组扩容到 maxReplicas 时能否放入节点的可分配量，不能放置时写入响应并返回 false
+func checkAutoscalerCapacity(c *gin.Context, appCtx core.AppContext, clusterConfig model.ClusterConfig) bool {
+	requests := job.AutoscalerPlacementRequests(clusterConfig)
+	if requests == nil {
+		return true
+	}
+
+	list, err := appCtx.Client().OpsFlow().OpsflowV1beta1().NodeResourceInfos().List(appCtx.Ctx(), metav1.ListOptions{})
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to check autoscaler capacity", "error": err.Error()})
+		return false
+	}
+	result := resourceinfo.SimulateCapacityPlacement(list.Items, requests)
+	if !result.Feasible {
+		c.JSON(400, gin.H{"message": "maxReplicas exceed the capacity of the cluster", "placement": result})
+		return false
+	}
+	return true
+}
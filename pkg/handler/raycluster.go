@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
 	"github.com/modcoco/OpsFlow/pkg/core"
 	"github.com/modcoco/OpsFlow/pkg/job"
 	"github.com/modcoco/OpsFlow/pkg/model"
@@ -21,8 +22,24 @@ func GetCreateRayClusterInfo(c *gin.Context) {
 		return
 	}
 	utils.MarshalToJSON(clusterConfig)
-
 	appCtx := core.GetAppContext(c)
+	if err := artifact.ResolveModel(appCtx.Ctx(), appCtx.Client().OpsFlow(), &clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.ValidateAutoscaler(&clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.ValidateVolumes(&clusterConfig, appCtx.JobOptions().HostPath); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.ValidatePodTemplates(&clusterConfig, appCtx.JobOptions().HostPath); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	existingCluster, err := appCtx.Client().Ray().RayV1().RayClusters(clusterConfig.Namespace).Get(appCtx.Ctx(), clusterConfig.ClusterName, metav1.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
 		c.JSON(500, gin.H{"error": err.Error()})
@@ -34,7 +51,19 @@ func GetCreateRayClusterInfo(c *gin.Context) {
 		return
 	}
 
-	rayCluster := CreateRayCluster(clusterConfig)
+	if !enforcePlacement(c, appCtx, clusterConfig) || !checkAutoscalerCapacity(c, appCtx, clusterConfig) {
+		return
+	}
+
+	rayCluster, err := CreateRayCluster(clusterConfig)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.EnsurePVCs(appCtx.Ctx(), appCtx.Client().Core(), clusterConfig.Namespace, clusterConfig.Machines); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 	utils.MarshalToJSON(rayCluster)
 	res, err := appCtx.Client().Ray().RayV1().RayClusters(clusterConfig.Namespace).Create(appCtx.Ctx(), rayCluster, metav1.CreateOptions{})
 	if err != nil {
@@ -48,7 +77,7 @@ func GetCreateRayClusterInfo(c *gin.Context) {
 	})
 }
 
-func CreateRayCluster(config model.ClusterConfig) *rayv1.RayCluster {
+func CreateRayCluster(config model.ClusterConfig) (*rayv1.RayCluster, error) {
 	rayVersion := config.RayVersion
 	if rayVersion == "" {
 		rayVersion = "2.41.0"
@@ -58,8 +87,10 @@ func CreateRayCluster(config model.ClusterConfig) *rayv1.RayCluster {
 		rayImage = "rayproject/ray:" + rayVersion
 	}
 
-	headGroupSpec := job.CreateHeadGroupSpec(config.Machines, rayImage)
-	workerGroupSpecs := job.CreateWorkerGroupSpecs(config.Machines, rayImage)
+	spec, err := job.CreateRayClusterSpec(config, rayVersion, rayImage)
+	if err != nil {
+		return nil, err
+	}
 
 	return &rayv1.RayCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -67,10 +98,6 @@ func CreateRayCluster(config model.ClusterConfig) *rayv1.RayCluster {
 			Namespace:         config.Namespace,
 			CreationTimestamp: metav1.Time{Time: time.Now()},
 		},
-		Spec: rayv1.RayClusterSpec{
-			RayVersion:       rayVersion,
-			HeadGroupSpec:    headGroupSpec,
-			WorkerGroupSpecs: workerGroupSpecs,
-		},
-	}
+		Spec: spec,
+	}, nil
 }
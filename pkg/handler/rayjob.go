@@ -2,8 +2,10 @@ package handler
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
 	"github.com/modcoco/OpsFlow/pkg/configmap"
 	"github.com/modcoco/OpsFlow/pkg/context"
 	"github.com/modcoco/OpsFlow/pkg/core"
@@ -24,6 +26,18 @@ func CreateRayJobHandle(c *gin.Context) {
 	utils.MarshalToJSON(clusterConfig)
 
 	appCtx := core.GetAppContext(c)
+	if err := artifact.ResolveModel(appCtx.Ctx(), appCtx.Client().OpsFlow(), &clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := job.ValidateJob(&clusterConfig, appCtx.JobOptions()); err != nil {
+		c.JSON(400, gin.H{"error": err.Error(), "supportedKinds": job.SupportedJobKinds()})
+		return
+	}
+	if err := job.ResolveRunCodeTemplate(appCtx.Ctx(), appCtx.Client().Core(), &clusterConfig); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	existingJob, err := appCtx.Client().Ray().RayV1().RayJobs(clusterConfig.Namespace).Get(appCtx.Ctx(), clusterConfig.Job.Name, metav1.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
 		c.JSON(500, gin.H{"error": err.Error()})
@@ -35,16 +49,22 @@ func CreateRayJobHandle(c *gin.Context) {
 		return
 	}
 
-	rayJobCtx := context.NewRayJobContext(appCtx.Client().Core(), appCtx.Client().Ray(), appCtx.Ctx())
-	createRayJobInfo, err := job.CreateRayJob(clusterConfig, rayJobCtx)
+	if !enforcePlacement(c, appCtx, clusterConfig) || !checkAutoscalerCapacity(c, appCtx, clusterConfig) {
+		return
+	}
+
+	rayJobCtx := context.NewRayJobContext(appCtx.Client().Core(), appCtx.Client().Ray(), appCtx.Client().Istio(), appCtx.Ctx())
+	createRayJobInfo, err := job.CreateRayJob(clusterConfig, rayJobCtx, appCtx.JobOptions())
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
+		return
 	}
 	utils.MarshalToJSON(createRayJobInfo)
 
 	response := model.RayJobResponse{
 		Namespace: createRayJobInfo.Namespace,
 		JobID:     createRayJobInfo.JobID,
+		Layout:    createRayJobInfo.Layout,
 	}
 	c.JSON(200, response)
 }
@@ -103,6 +123,25 @@ func RayJobInfoHandle(c *gin.Context) {
 	if len(configMapNames) > 0 {
 		response["configMaps"] = configMapNames
 	}
+
+	exposure, err := svc.GetExposure(appCtx.Ctx(), appCtx.Client().Core(), appCtx.Client().Istio(), namespace, labelSelector)
+	if err != nil {
+		c.JSON(500, gin.H{"message": "Failed to get exposure", "error": err.Error()})
+		return
+	}
+	if exposure != nil {
+		response["exposure"] = exposure
+	}
+
+	// 返回后台最近一次探测的结果，读取失败不影响返回 RayJob 的状态
+	prober := job.NewEndpointProber(&job.RedisEndpointStore{Client: appCtx.Redis()}, appCtx.JobOptions().EndpointProbe)
+	endpoint, err := prober.Cached(appCtx.Ctx(), existingJob)
+	if err != nil {
+		log.Printf("无法读取 %s/%s 的推理服务状态: %v", namespace, jobName, err)
+	} else if endpoint != nil {
+		response["endpoint"] = endpoint
+		response["degraded"] = endpoint.Degraded
+	}
 	c.JSON(200, response)
 }
 
@@ -130,7 +169,11 @@ func RemoveRayJobHandle(c *gin.Context) {
 
 	labelSelector := fmt.Sprintf("model-unique-id=%s", jobName)
 	_ = svc.DeleteServicesByLabel(appCtx, namespace, labelSelector)
+	if err := svc.DeleteExposureByLabel(appCtx.Ctx(), appCtx.Client().Core(), appCtx.Client().Istio(), namespace, labelSelector); err != nil {
+		log.Printf("无法删除 %s/%s 的暴露资源: %v", namespace, jobName, err)
+	}
 	_ = configmap.DeleteConfigMapsByLabel(appCtx, namespace, labelSelector)
+	_ = (&job.RedisEndpointStore{Client: appCtx.Redis()}).Delete(appCtx.Ctx(), namespace, jobName)
 
 	c.JSON(200, gin.H{
 		"message": "Job and associated resources deleted successfully",
@@ -0,0 +1,135 @@
+package job
+
+import (
+	"fmt"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	UpscalingDefault      = "Default"
+	UpscalingAggressive   = "Aggressive"
+	UpscalingConservative = "Conservative"
+
+	// KubeRay 未设置 autoscaler 资源时的默认值
+	defaultAutoscalerCPU    = "500m"
+	defaultAutoscalerMemory = "512Mi"
+)
+
+// ValidateAutoscaler 检查 group 的副本数范围与 autoscaler 配置，single 类型的机器固定为 1 个副本
+func ValidateAutoscaler(config *model.ClusterConfig) error {
+	for _, machine := range config.Machines {
+		if machine.IsHeadNode || machine.MachineType != model.MachineTypeGroup {
+			continue
+		}
+		if err := validateReplicas(machine); err != nil {
+			return fmt.Errorf("machine %s: %w", machine.Name, err)
+		}
+	}
+
+	autoscaler := config.Autoscaler
+	if autoscaler == nil || !autoscaler.Enabled {
+		return nil
+	}
+	switch autoscaler.UpscalingMode {
+	case "", UpscalingDefault, UpscalingAggressive, UpscalingConservative:
+	default:
+		return fmt.Errorf("unknown autoscaler.upscalingMode %q, supported modes: %s, %s, %s", autoscaler.UpscalingMode, UpscalingDefault, UpscalingAggressive, UpscalingConservative)
+	}
+	if autoscaler.IdleTimeoutSeconds != nil && *autoscaler.IdleTimeoutSeconds < 0 {
+		return fmt.Errorf("autoscaler.idleTimeoutSeconds must not be negative")
+	}
+	for name, value := range map[string]string{"cpu": autoscaler.CPU, "memory": autoscaler.Memory} {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("invalid autoscaler.%s %q: %w", name, value, err)
+		}
+	}
+	return nil
+}
+
+func validateReplicas(machine model.MachineConfig) error {
+	for name, value := range map[string]*int32{"replicas": machine.Replicas, "minReplicas": machine.MinReplicas, "maxReplicas": machine.MaxReplicas} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if machine.MinReplicas != nil && machine.MaxReplicas != nil && *machine.MinReplicas > *machine.MaxReplicas {
+		return fmt.Errorf("minReplicas %d is greater than maxReplicas %d", *machine.MinReplicas, *machine.MaxReplicas)
+	}
+	if machine.Replicas == nil {
+		return nil
+	}
+	if machine.MinReplicas != nil && *machine.Replicas < *machine.MinReplicas {
+		return fmt.Errorf("replicas %d is less than minReplicas %d", *machine.Replicas, *machine.MinReplicas)
+	}
+	if machine.MaxReplicas != nil && *machine.Replicas > *machine.MaxReplicas {
+		return fmt.Errorf("replicas %d is greater than maxReplicas %d", *machine.Replicas, *machine.MaxReplicas)
+	}
+	return nil
+}
+
+// ApplyAutoscaler 开启 KubeRay 内置的 autoscaler，未设置 maxReplicas 的 worker 组不向上扩容，
+// 配置需要已经通过 ValidateAutoscaler 检查
+func ApplyAutoscaler(spec *rayv1.RayClusterSpec, autoscaler *model.AutoscalerConfig) {
+	if autoscaler == nil || !autoscaler.Enabled {
+		return
+	}
+
+	options := &rayv1.AutoscalerOptions{
+		IdleTimeoutSeconds: autoscaler.IdleTimeoutSeconds,
+	}
+	if autoscaler.UpscalingMode != "" {
+		options.UpscalingMode = ptr.To(rayv1.UpscalingMode(autoscaler.UpscalingMode))
+	}
+	if autoscaler.CPU != "" || autoscaler.Memory != "" {
+		cpu, memory := autoscaler.CPU, autoscaler.Memory
+		if cpu == "" {
+			cpu = defaultAutoscalerCPU
+		}
+		if memory == "" {
+			memory = defaultAutoscalerMemory
+		}
+		resourceList := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+		options.Resources = &corev1.ResourceRequirements{Limits: resourceList, Requests: resourceList}
+	}
+	spec.EnableInTreeAutoscaling = ptr.To(true)
+	spec.AutoscalerOptions = options
+
+	for i := range spec.WorkerGroupSpecs {
+		worker := &spec.WorkerGroupSpecs[i]
+		if worker.MaxReplicas != nil {
+			continue
+		}
+		maxReplicas := int32(0)
+		if worker.Replicas != nil {
+			maxReplicas = *worker.Replicas
+		}
+		if worker.MinReplicas != nil {
+			maxReplicas = max(maxReplicas, *worker.MinReplicas)
+		}
+		worker.MaxReplicas = ptr.To(maxReplicas)
+	}
+}
+
+// AutoscalerPlacementRequests 生成 worker 组扩容到 maxReplicas 时的放置请求，未开启 autoscaler 时返回 nil
+func AutoscalerPlacementRequests(config model.ClusterConfig) []resourceinfo.PlacementRequest {
+	if config.Autoscaler == nil || !config.Autoscaler.Enabled {
+		return nil
+	}
+	spec := placementRayClusterSpec(config)
+	for i := range spec.WorkerGroupSpecs {
+		spec.WorkerGroupSpecs[i].Replicas = spec.WorkerGroupSpecs[i].MaxReplicas
+	}
+	return RayClusterPlacementRequests(spec)
+}
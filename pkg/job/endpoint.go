@@ -0,0 +1,378 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/svc"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	rayclient "github.com/ray-project/kuberay/ray-operator/pkg/client/clientset/versioned"
+	"github.com/redis/go-redis/v9"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 推理服务接口的状态
+const (
+	EndpointStarting     = "Starting"     // RayJob 还没有运行
+	EndpointLoadingModel = "LoadingModel" // RayJob 已运行，接口还没有就绪
+	EndpointReady        = "Ready"        // /health 正常且 /v1/models 返回了模型
+	EndpointFailing      = "Failing"      // 就绪过的接口探测失败，或 RayJob 已结束
+)
+
+const endpointRecordPrefix = "opsflow:endpoint:"
+
+// ServingJobKinds 提供 OpenAI 兼容接口的任务种类
+var ServingJobKinds = []string{JobKindVllmServe, JobKindSGLangServe}
+
+// 后台同时探测的 RayJob 数量
+const endpointProbeParallelism = 8
+
+// EndpointRecord 多次探测之间需要保留的状态，服务多副本运行，保存在 Redis 中
+type EndpointRecord struct {
+	ReadyAt      time.Time // 第一次就绪的时间，为零时还没有就绪过
+	FailingSince time.Time // 本次连续失败开始的时间，为零时没有失败
+	LoadingSince time.Time // 第一次探测到加载中的时间，为零时还没有加载过
+	// 最近一次探测的结果，查询接口直接返回，ProbedAt 为零时还没有探测过
+	ProbedAt time.Time
+	State    string
+	Message  string
+	Models   []string
+}
+
+// EndpointRecordStore 保存每个 RayJob 的探测状态
+type EndpointRecordStore interface {
+	Load(ctx context.Context, namespace, name string) (EndpointRecord, error)
+	Save(ctx context.Context, namespace, name string, record EndpointRecord) error
+	Delete(ctx context.Context, namespace, name string) error
+}
+
+// RedisEndpointStore 把探测状态保存在 Redis hash 中，一天没有探测后过期
+type RedisEndpointStore struct {
+	Client redis.Cmdable
+}
+
+func endpointRecordKey(namespace, name string) string {
+	return endpointRecordPrefix + namespace + "/" + name
+}
+
+func (s *RedisEndpointStore) Load(ctx context.Context, namespace, name string) (EndpointRecord, error) {
+	values, err := s.Client.HGetAll(ctx, endpointRecordKey(namespace, name)).Result()
+	if err != nil {
+		return EndpointRecord{}, fmt.Errorf("无法读取 %s/%s 的探测状态: %w", namespace, name, err)
+	}
+	record := EndpointRecord{
+		ReadyAt:      parseUnixTime(values["readyAt"]),
+		FailingSince: parseUnixTime(values["failingSince"]),
+		LoadingSince: parseUnixTime(values["loadingSince"]),
+		ProbedAt:     parseUnixTime(values["probedAt"]),
+		State:        values["state"],
+		Message:      values["message"],
+	}
+	if models := values["models"]; models != "" {
+		if err := json.Unmarshal([]byte(models), &record.Models); err != nil {
+			return EndpointRecord{}, fmt.Errorf("无法解析 %s/%s 的模型列表: %w", namespace, name, err)
+		}
+	}
+	return record, nil
+}
+
+func (s *RedisEndpointStore) Save(ctx context.Context, namespace, name string, record EndpointRecord) error {
+	key := endpointRecordKey(namespace, name)
+	models, err := json.Marshal(record.Models)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"readyAt", formatUnixTime(record.ReadyAt),
+			"failingSince", formatUnixTime(record.FailingSince),
+			"loadingSince", formatUnixTime(record.LoadingSince),
+			"probedAt", formatUnixTime(record.ProbedAt),
+			"state", record.State,
+			"message", record.Message,
+			"models", string(models),
+		)
+		pipe.Expire(ctx, key, 24*time.Hour)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("无法保存 %s/%s 的探测状态: %w", namespace, name, err)
+	}
+	return nil
+}
+
+func (s *RedisEndpointStore) Delete(ctx context.Context, namespace, name string) error {
+	if err := s.Client.Del(ctx, endpointRecordKey(namespace, name)).Err(); err != nil {
+		return fmt.Errorf("无法删除 %s/%s 的探测状态: %w", namespace, name, err)
+	}
+	return nil
+}
+
+func formatUnixTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func parseUnixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// EndpointProber 探测 head Service 上的 /health 与 /v1/models
+type EndpointProber struct {
+	Client        *http.Client
+	Store         EndpointRecordStore
+	DegradedAfter time.Duration
+	// LoadTimeout 从没有就绪过的接口加载超过该时间时视为 degraded，如权重损坏或加载时 OOM，为 0 时不限制
+	LoadTimeout time.Duration
+	// BaseURL 返回 RayCluster 推理服务的地址，为空时使用集群内 Service 的 DNS
+	BaseURL func(namespace, clusterName string) string
+	Now     func() time.Time
+}
+
+// NewEndpointProber 按 options 的超时、degraded 与加载时间创建探测器
+func NewEndpointProber(store EndpointRecordStore, options model.EndpointProbeOptions) *EndpointProber {
+	return &EndpointProber{
+		Client:        &http.Client{Timeout: options.Timeout},
+		Store:         store,
+		DegradedAfter: options.DegradedAfter,
+		LoadTimeout:   options.LoadTimeout,
+	}
+}
+
+// ServiceBaseURL 推理服务 Service 在集群内的地址
+func ServiceBaseURL(namespace, clusterName string) string {
+	return fmt.Sprintf("http://%s.%s.svc:%d", svc.ServiceName(clusterName), namespace, svc.ServicePort)
+}
+
+// Probe 探测 RayJob 的推理服务并保存结果，不是推理种类的任务返回 nil
+func (p *EndpointProber) Probe(ctx context.Context, rayJob *rayv1.RayJob) (*model.EndpointStatus, error) {
+	if !isServingJob(rayJob) {
+		return nil, nil
+	}
+	if status := startingStatus(rayJob); status != nil {
+		return status, nil
+	}
+
+	record, err := p.Store.Load(ctx, rayJob.Namespace, rayJob.Name)
+	if err != nil {
+		return nil, err
+	}
+	now := p.now()
+
+	var models []string
+	var probeErr error
+	if rayv1.IsJobTerminal(rayJob.Status.JobStatus) {
+		probeErr = fmt.Errorf("job is %s", rayJob.Status.JobStatus)
+	} else {
+		models, probeErr = p.probe(ctx, p.baseURL(rayJob))
+	}
+
+	record.ProbedAt = now
+	record.Models = models
+	record.Message = ""
+	switch {
+	case probeErr == nil:
+		record.State = EndpointReady
+		if record.ReadyAt.IsZero() {
+			record.ReadyAt = now
+		}
+		record.FailingSince = time.Time{}
+	case record.ReadyAt.IsZero() && !rayv1.IsJobTerminal(rayJob.Status.JobStatus):
+		// vLLM 加载完模型后才开始监听，此前的失败都视为加载中
+		record.State = EndpointLoadingModel
+		record.Message = probeErr.Error()
+		if record.LoadingSince.IsZero() {
+			record.LoadingSince = now
+		}
+	default:
+		record.State = EndpointFailing
+		record.Message = probeErr.Error()
+		if record.FailingSince.IsZero() {
+			record.FailingSince = now
+		}
+	}
+
+	if err := p.Store.Save(ctx, rayJob.Namespace, rayJob.Name, record); err != nil {
+		return nil, err
+	}
+	return p.status(rayJob, record, now), nil
+}
+
+// Cached 返回最近一次探测的结果，不请求推理服务，不是推理种类的任务返回 nil。
+// 探测由 ProbeAll 与发布任务在后台进行
+func (p *EndpointProber) Cached(ctx context.Context, rayJob *rayv1.RayJob) (*model.EndpointStatus, error) {
+	if !isServingJob(rayJob) {
+		return nil, nil
+	}
+	if status := startingStatus(rayJob); status != nil {
+		return status, nil
+	}
+
+	record, err := p.Store.Load(ctx, rayJob.Namespace, rayJob.Name)
+	if err != nil {
+		return nil, err
+	}
+	if record.ProbedAt.IsZero() {
+		return &model.EndpointStatus{State: EndpointLoadingModel, URL: p.baseURL(rayJob), Message: "endpoint has not been probed yet"}, nil
+	}
+	return p.status(rayJob, record, p.now()), nil
+}
+
+// ProbeAll 探测所有推理种类的 RayJob，模型部署的版本由发布任务探测，这里跳过
+func (p *EndpointProber) ProbeAll(ctx context.Context, ray rayclient.Interface) error {
+	selector := fmt.Sprintf("%s in (%s),!%s", model.JobKindLabel, strings.Join(ServingJobKinds, ","), v1beta1.ModelDeploymentLabel)
+	rayJobs, err := ray.RayV1().RayJobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("无法获取推理任务: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, endpointProbeParallelism)
+	for i := range rayJobs.Items {
+		rayJob := &rayJobs.Items[i]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := p.Probe(ctx, rayJob); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("无法探测 %s/%s 的推理服务: %w", rayJob.Namespace, rayJob.Name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// RayJob 还没有运行或还没有创建 RayCluster 时不需要探测
+func startingStatus(rayJob *rayv1.RayJob) *model.EndpointStatus {
+	switch rayJob.Status.JobStatus {
+	case rayv1.JobStatusNew, rayv1.JobStatusPending:
+		return &model.EndpointStatus{State: EndpointStarting, Message: "job is not running"}
+	}
+	if rayJob.Status.RayClusterName == "" {
+		return &model.EndpointStatus{State: EndpointStarting, Message: "ray cluster is not created"}
+	}
+	return nil
+}
+
+// 按保存的探测结果生成状态，degraded 按当前时间计算
+func (p *EndpointProber) status(rayJob *rayv1.RayJob, record EndpointRecord, now time.Time) *model.EndpointStatus {
+	probedAt := record.ProbedAt
+	status := &model.EndpointStatus{
+		State:    record.State,
+		URL:      p.baseURL(rayJob),
+		Models:   record.Models,
+		Message:  record.Message,
+		ProbedAt: &probedAt,
+	}
+	if record.State == EndpointFailing && !record.FailingSince.IsZero() {
+		failingSince := record.FailingSince
+		status.FailingSince = &failingSince
+		status.Degraded = now.Sub(failingSince) >= p.DegradedAfter
+	}
+	// 一直没有就绪的接口不会进入 Failing，加载超时后同样视为 degraded
+	if record.State == EndpointLoadingModel && !record.LoadingSince.IsZero() {
+		loadingSince := record.LoadingSince
+		status.LoadingSince = &loadingSince
+		if p.LoadTimeout > 0 && now.Sub(loadingSince) >= p.LoadTimeout {
+			status.Degraded = true
+			status.Message = fmt.Sprintf("endpoint is not ready after %s: %s", p.LoadTimeout, record.Message)
+		}
+	}
+	return status
+}
+
+func (p *EndpointProber) baseURL(rayJob *rayv1.RayJob) string {
+	if p.BaseURL != nil {
+		return p.BaseURL(rayJob.Namespace, rayJob.Status.RayClusterName)
+	}
+	return ServiceBaseURL(rayJob.Namespace, rayJob.Status.RayClusterName)
+}
+
+func (p *EndpointProber) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// 依次请求 /health 与 /v1/models，返回服务的模型名
+func (p *EndpointProber) probe(ctx context.Context, baseURL string) ([]string, error) {
+	if _, err := p.get(ctx, baseURL+"/health"); err != nil {
+		return nil, err
+	}
+	body, err := p.get(ctx, baseURL+"/v1/models")
+	if err != nil {
+		return nil, err
+	}
+
+	var models struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &models); err != nil {
+		return nil, fmt.Errorf("invalid /v1/models response: %w", err)
+	}
+	if len(models.Data) == 0 {
+		return nil, errors.New("no model served")
+	}
+	names := make([]string, 0, len(models.Data))
+	for _, item := range models.Data {
+		names = append(names, item.ID)
+	}
+	return names, nil
+}
+
+func (p *EndpointProber) get(ctx context.Context, url string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	return body, nil
+}
+
+func isServingJob(rayJob *rayv1.RayJob) bool {
+	return slices.Contains(ServingJobKinds, rayJob.Labels[model.JobKindLabel])
+}
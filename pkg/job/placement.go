@@ -0,0 +1,67 @@
+package job
+
+import (
+	"slices"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// 根据 ClusterConfig 生成 head 与所有 worker 的放置请求
+func ClusterConfigPlacementRequests(config model.ClusterConfig) []resourceinfo.PlacementRequest {
+	return RayClusterPlacementRequests(placementRayClusterSpec(config))
+}
+
+// 根据 RayCluster 的 Pod 模板生成放置请求，worker 按 replicas 放置，未设置时按 minReplicas
+func RayClusterPlacementRequests(spec rayv1.RayClusterSpec) []resourceinfo.PlacementRequest {
+	requests := []resourceinfo.PlacementRequest{
+		podTemplatePlacementRequest("head", 1, &spec.HeadGroupSpec.Template),
+	}
+	for i := range spec.WorkerGroupSpecs {
+		worker := &spec.WorkerGroupSpecs[i]
+		replicas := int32(1)
+		if worker.Replicas != nil {
+			replicas = *worker.Replicas
+		} else if worker.MinReplicas != nil {
+			replicas = *worker.MinReplicas
+		}
+		requests = append(requests, podTemplatePlacementRequest(worker.GroupName, replicas, &worker.Template))
+	}
+	return requests
+}
+
+func podTemplatePlacementRequest(group string, replicas int32, template *corev1.PodTemplateSpec) resourceinfo.PlacementRequest {
+	var machine string
+	if len(template.Spec.Containers) > 0 {
+		machine = template.Spec.Containers[0].Name
+	}
+	pod := &corev1.Pod{Spec: template.Spec}
+	request := resourceinfo.PlacementRequest{
+		Group:        group,
+		Machine:      machine,
+		Replicas:     replicas,
+		Requests:     resourceinfo.PodRequests(pod),
+		NodeSelector: template.Spec.NodeSelector,
+		Tolerations:  template.Spec.Tolerations,
+	}
+	// 节点亲和按节点 label 检查，Pod 间的亲和与拓扑分布需要已有 Pod 的位置，只在结果中标记为未检查
+	if affinity := template.Spec.Affinity; affinity != nil {
+		if affinity.NodeAffinity != nil {
+			request.NodeAffinity = affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		}
+		if affinity.PodAffinity != nil && len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+			request.Unchecked = append(request.Unchecked, "podAffinity")
+		}
+		if affinity.PodAntiAffinity != nil && len(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+			request.Unchecked = append(request.Unchecked, "podAntiAffinity")
+		}
+	}
+	if slices.ContainsFunc(template.Spec.TopologySpreadConstraints, func(constraint corev1.TopologySpreadConstraint) bool {
+		return constraint.WhenUnsatisfiable == corev1.DoNotSchedule
+	}) {
+		request.Unchecked = append(request.Unchecked, "topologySpreadConstraints")
+	}
+	return request
+}
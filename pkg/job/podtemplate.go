@@ -0,0 +1,256 @@
+package job
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	shmVolumeName = "shm"
+	shmMountPath  = "/dev/shm"
+)
+
+// CreateRayClusterSpec 根据 ClusterConfig 生成 RayCluster 的 spec，包括 Pod 模板配置与 autoscaler。
+// podTemplatePatch 无法应用时返回错误，spec 中对应的组保留应用前的模板
+func CreateRayClusterSpec(config model.ClusterConfig, rayVersion, rayImage string) (rayv1.RayClusterSpec, error) {
+	spec := rayv1.RayClusterSpec{
+		RayVersion:       rayVersion,
+		HeadGroupSpec:    CreateHeadGroupSpec(config.Machines, rayImage),
+		WorkerGroupSpecs: CreateWorkerGroupSpecs(config.Machines, rayImage),
+	}
+	ApplyAutoscaler(&spec, config.Autoscaler)
+
+	var errs []error
+	headMachine := &config.Machines[0]
+	for i := range config.Machines {
+		if config.Machines[i].IsHeadNode {
+			headMachine = &config.Machines[i]
+			break
+		}
+	}
+	if err := ApplyPodTemplateConfig(&spec.HeadGroupSpec.Template, config.PodDefaults, headMachine.PodTemplateConfig); err != nil {
+		errs = append(errs, fmt.Errorf("machine %s: %w", headMachine.Name, err))
+	}
+
+	// worker 组与非 head 机器一一对应
+	worker := 0
+	for _, machine := range config.Machines {
+		if machine.IsHeadNode {
+			continue
+		}
+		if err := ApplyPodTemplateConfig(&spec.WorkerGroupSpecs[worker].Template, config.PodDefaults, machine.PodTemplateConfig); err != nil {
+			errs = append(errs, fmt.Errorf("machine %s: %w", machine.Name, err))
+		}
+		worker++
+	}
+	if len(errs) > 0 {
+		return spec, errs[0]
+	}
+	return spec, nil
+}
+
+// 生成放置请求时使用，配置已经通过 ValidatePodTemplates 检查
+func placementRayClusterSpec(config model.ClusterConfig) rayv1.RayClusterSpec {
+	spec, err := CreateRayClusterSpec(config, "", "")
+	if err != nil {
+		log.Printf("无法应用 Pod 模板配置: %v", err)
+	}
+	return spec
+}
+
+// MergePodDefaults 合并集群的默认配置与机器的配置：env 与 extraContainers 按名称、nodeSelector 按键覆盖，
+// tolerations 与 imagePullSecrets 合并，其他字段机器上设置时使用机器的值。podTemplatePatch 不合并，见 ApplyPodTemplateConfig
+func MergePodDefaults(defaults *model.PodTemplateConfig, machine model.PodTemplateConfig) model.PodTemplateConfig {
+	if defaults == nil {
+		return machine
+	}
+
+	merged := model.PodTemplateConfig{
+		Env:                mergeByName(defaults.Env, machine.Env, func(env corev1.EnvVar) string { return env.Name }),
+		Tolerations:        slices.Concat(defaults.Tolerations, machine.Tolerations),
+		Affinity:           cmp.Or(machine.Affinity, defaults.Affinity),
+		SecurityContext:    cmp.Or(machine.SecurityContext, defaults.SecurityContext),
+		PodSecurityContext: cmp.Or(machine.PodSecurityContext, defaults.PodSecurityContext),
+		ShmSize:            cmp.Or(machine.ShmSize, defaults.ShmSize),
+		ServiceAccountName: cmp.Or(machine.ServiceAccountName, defaults.ServiceAccountName),
+		ExtraContainers:    mergeByName(defaults.ExtraContainers, machine.ExtraContainers, func(container corev1.Container) string { return container.Name }),
+		PodTemplatePatch:   machine.PodTemplatePatch,
+	}
+	if len(defaults.NodeSelector) > 0 || len(machine.NodeSelector) > 0 {
+		merged.NodeSelector = maps.Clone(defaults.NodeSelector)
+		if merged.NodeSelector == nil {
+			merged.NodeSelector = map[string]string{}
+		}
+		maps.Copy(merged.NodeSelector, machine.NodeSelector)
+	}
+	for _, secret := range slices.Concat(defaults.ImagePullSecrets, machine.ImagePullSecrets) {
+		if !slices.Contains(merged.ImagePullSecrets, secret) {
+			merged.ImagePullSecrets = append(merged.ImagePullSecrets, secret)
+		}
+	}
+	return merged
+}
+
+// ApplyPodTemplateConfig 把合并后的配置写入 Pod 模板，第一个容器为 Ray 容器；
+// 最后依次应用默认配置与机器的 podTemplatePatch
+func ApplyPodTemplateConfig(template *corev1.PodTemplateSpec, defaults *model.PodTemplateConfig, machine model.PodTemplateConfig) error {
+	config := MergePodDefaults(defaults, machine)
+	podSpec := &template.Spec
+	rayContainer := &podSpec.Containers[0]
+
+	rayContainer.Env = append(rayContainer.Env, config.Env...)
+	rayContainer.SecurityContext = config.SecurityContext
+	if len(config.NodeSelector) > 0 {
+		podSpec.NodeSelector = config.NodeSelector
+	}
+	podSpec.Tolerations = append(podSpec.Tolerations, config.Tolerations...)
+	podSpec.Affinity = config.Affinity
+	podSpec.SecurityContext = config.PodSecurityContext
+	podSpec.ServiceAccountName = config.ServiceAccountName
+	for _, secret := range config.ImagePullSecrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+	}
+	if config.ShmSize != "" {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: shmVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					Medium:    corev1.StorageMediumMemory,
+					SizeLimit: ptr.To(resource.MustParse(config.ShmSize)),
+				},
+			},
+		})
+		rayContainer.VolumeMounts = append(rayContainer.VolumeMounts, corev1.VolumeMount{Name: shmVolumeName, MountPath: shmMountPath})
+	}
+	podSpec.Containers = append(podSpec.Containers, config.ExtraContainers...)
+
+	if defaults != nil {
+		if err := applyPodTemplatePatch(template, defaults.PodTemplatePatch); err != nil {
+			return fmt.Errorf("podDefaults.podTemplatePatch: %w", err)
+		}
+	}
+	if err := applyPodTemplatePatch(template, machine.PodTemplatePatch); err != nil {
+		return fmt.Errorf("podTemplatePatch: %w", err)
+	}
+	return nil
+}
+
+func applyPodTemplatePatch(template *corev1.PodTemplateSpec, patch json.RawMessage) error {
+	if len(patch) == 0 {
+		return nil
+	}
+	original, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, corev1.PodTemplateSpec{})
+	if err != nil {
+		return err
+	}
+	var result corev1.PodTemplateSpec
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return err
+	}
+	*template = result
+	return nil
+}
+
+// ValidatePodTemplates 检查 Pod 模板配置，并试应用所有的 podTemplatePatch。
+// podTemplatePatch 可以添加卷，hostPath 按生成的 Pod 模板检查
+func ValidatePodTemplates(config *model.ClusterConfig, policy model.HostPathPolicy) error {
+	if len(config.Machines) == 0 {
+		return nil
+	}
+	if config.PodDefaults != nil {
+		if err := validatePodTemplateConfig("", *config.PodDefaults); err != nil {
+			return fmt.Errorf("podDefaults: %w", err)
+		}
+	}
+	for _, machine := range config.Machines {
+		if err := validatePodTemplateConfig(machine.Name, MergePodDefaults(config.PodDefaults, machine.PodTemplateConfig)); err != nil {
+			return fmt.Errorf("machine %s: %w", machine.Name, err)
+		}
+	}
+	spec, err := CreateRayClusterSpec(*config, "", "")
+	if err != nil {
+		return err
+	}
+	if err := validateTemplateHostPaths(spec.HeadGroupSpec.Template, policy); err != nil {
+		return fmt.Errorf("head: %w", err)
+	}
+	for _, group := range spec.WorkerGroupSpecs {
+		if err := validateTemplateHostPaths(group.Template, policy); err != nil {
+			return fmt.Errorf("worker group %s: %w", group.GroupName, err)
+		}
+	}
+	return nil
+}
+
+func validateTemplateHostPaths(template corev1.PodTemplateSpec, policy model.HostPathPolicy) error {
+	for _, volume := range template.Spec.Volumes {
+		if volume.HostPath == nil {
+			continue
+		}
+		if err := validateHostPath(volume.HostPath.Path, policy); err != nil {
+			return fmt.Errorf("volume %s: %w", volume.Name, err)
+		}
+	}
+	return nil
+}
+
+func validatePodTemplateConfig(rayContainerName string, config model.PodTemplateConfig) error {
+	if config.ShmSize != "" {
+		quantity, err := resource.ParseQuantity(config.ShmSize)
+		if err != nil {
+			return fmt.Errorf("invalid shmSize %q: %w", config.ShmSize, err)
+		}
+		if quantity.Sign() <= 0 {
+			return fmt.Errorf("shmSize must be positive, got %s", config.ShmSize)
+		}
+	}
+	for _, env := range config.Env {
+		if env.Name == "" {
+			return fmt.Errorf("env name is required")
+		}
+	}
+	for _, container := range config.ExtraContainers {
+		if container.Name == "" || container.Image == "" {
+			return fmt.Errorf("extraContainers require name and image")
+		}
+		if container.Name == rayContainerName {
+			return fmt.Errorf("extra container %s conflicts with the ray container", container.Name)
+		}
+	}
+	if len(config.PodTemplatePatch) > 0 {
+		var patch map[string]any
+		if err := json.Unmarshal(config.PodTemplatePatch, &patch); err != nil {
+			return fmt.Errorf("podTemplatePatch must be a json object: %w", err)
+		}
+	}
+	return nil
+}
+
+// 按名称合并，后者覆盖前者中同名的元素并保留原来的位置
+func mergeByName[T any](defaults, overrides []T, name func(T) string) []T {
+	merged := slices.Clone(defaults)
+	for _, override := range overrides {
+		index := slices.IndexFunc(merged, func(item T) bool { return name(item) == name(override) })
+		if index >= 0 {
+			merged[index] = override
+		} else {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
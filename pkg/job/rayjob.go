@@ -3,6 +3,7 @@ package job
 import (
 	"fmt"
 	"log"
+	"maps"
 	"strings"
 	"time"
 
@@ -20,8 +21,8 @@ import (
 	"k8s.io/utils/ptr"
 )
 
-func CreateRayJob(config model.ClusterConfig, c context.RayJobContext) (model.RayJobResponse, error) {
-	vllmConfig, err := ProcessVllmOnRaySimpleAutoJobClusterConfigByHeaderMachine(&config)
+func CreateRayJob(config model.ClusterConfig, c context.RayJobContext, opts model.JobOptions) (model.RayJobResponse, error) {
+	runCodeConfig, err := PrepareJob(&config, opts)
 	if err != nil {
 		return model.RayJobResponse{}, err
 	}
@@ -34,23 +35,34 @@ func CreateRayJob(config model.ClusterConfig, c context.RayJobContext) (model.Ra
 		rayImage = "rayproject/ray:" + rayVersion
 	}
 
-	headGroupSpec := CreateHeadGroupSpec(config.Machines, rayImage)
-	workerGroupSpecs := CreateWorkerGroupSpecs(config.Machines, rayImage)
+	rayCluster, err := CreateRayClusterSpec(config, rayVersion, rayImage)
+	if err != nil {
+		return model.RayJobResponse{}, err
+	}
+	if err := EnsurePVCs(c.Ctx(), c.Core(), config.Namespace, config.Machines); err != nil {
+		return model.RayJobResponse{}, err
+	}
 	uniqueRayJobId := config.Job.Name
 
-	rayCluster := rayv1.RayClusterSpec{
-		RayVersion:       rayVersion,
-		HeadGroupSpec:    headGroupSpec,
-		WorkerGroupSpecs: workerGroupSpecs,
-	}
 	labels := map[string]string{
 		model.ModelUniqueID: uniqueRayJobId,
 	}
+	kind := config.Job.Kind
+	if kind == "" {
+		kind = JobKindEntrypoint
+	}
+	rayJobLabels := maps.Clone(config.Job.Labels)
+	if rayJobLabels == nil {
+		rayJobLabels = map[string]string{}
+	}
+	rayJobLabels[model.ModelUniqueID] = uniqueRayJobId
+	rayJobLabels[model.JobKindLabel] = kind
 	rayJob := rayv1.RayJob{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      config.Job.Name,
-			Namespace: config.Namespace,
-			Labels:    labels,
+			Name:            config.Job.Name,
+			Namespace:       config.Namespace,
+			Labels:          rayJobLabels,
+			OwnerReferences: config.Job.OwnerReferences,
 			// CreationTimestamp: metav1.Time{Time: time.Now()},
 		},
 		Spec: rayv1.RayJobSpec{
@@ -59,8 +71,8 @@ func CreateRayJob(config model.ClusterConfig, c context.RayJobContext) (model.Ra
 		},
 	}
 
-	if vllmConfig != nil {
-		rayJobRuncodeConfigmap := CreateConfigMapFromVllmSimpleRunCodeConfig(*vllmConfig)
+	if runCodeConfig != nil {
+		rayJobRuncodeConfigmap := CreateConfigMapFromRunCodeConfig(*runCodeConfig)
 		common.AddLabelToConfigMap(rayJobRuncodeConfigmap, labels)
 		fmt.Println("Create ConfigMap")
 		utils.MarshalToJSON(rayJobRuncodeConfigmap)
@@ -94,30 +106,32 @@ func CreateRayJob(config model.ClusterConfig, c context.RayJobContext) (model.Ra
 				return
 			}
 
-			service := svc.GenerateRayClusterService(config.Namespace, clusterName)
-			common.AddLabelToService(service, labels)
 			ctx, cancel := officalCtx.WithTimeout(officalCtx.Background(), 10*time.Second)
 			defer cancel()
-			_, err := c.Core().CoreV1().Services(config.Namespace).Create(ctx, service, metav1.CreateOptions{})
+			err := svc.CreateExposure(ctx, c.Core(), c.Istio(), config.Namespace, config.Job.Name, clusterName, labels, config.Job.Expose)
 			if err != nil {
 				log.Printf("Create service error: %v", err)
 				return
 			}
 
-			log.Printf("Service %s create success!", service.Name)
+			log.Printf("Service %s create success! expose mode: %s", svc.ServiceName(clusterName), svc.ExposeMode(config.Job.Expose))
 
 		case <-time.After(30 * time.Minute):
 			log.Println("Wait RayClusterName time out")
 		}
 	}()
 
-	return model.RayJobResponse{
+	response := model.RayJobResponse{
 		JobID:     uniqueRayJobId,
 		Namespace: config.Namespace,
-	}, nil
+	}
+	if runCodeConfig != nil {
+		response.Layout = runCodeConfig.Layout
+	}
+	return response, nil
 }
 
-func CreateConfigMapFromVllmSimpleRunCodeConfig(config VllmSimpleRunCodeConfigForRayCluster) *corev1.ConfigMap {
+func CreateConfigMapFromRunCodeConfig(config RunCodeConfig) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: config.ConfigMapName,
@@ -164,7 +178,7 @@ func CreateHeadGroupSpec(machines []model.MachineConfig, rayImage string) rayv1.
 			Spec: corev1.PodSpec{
 				Containers: []corev1.Container{
 					{
-						Name:  machines[0].Name,
+						Name:  headMachine.Name,
 						Image: rayImage,
 						Resources: corev1.ResourceRequirements{
 							Limits:   resourceList,
@@ -259,59 +273,3 @@ func CreateWorkerGroupSpecs(machines []model.MachineConfig, rayImage string) []r
 
 	return workerGroupSpecs
 }
-
-func BuildVolumesAndMounts(volumesConfig []model.VolumeConfig) ([]corev1.Volume, []corev1.VolumeMount) {
-	var volumes []corev1.Volume
-	var volumeMounts []corev1.VolumeMount
-
-	for _, volume := range volumesConfig {
-		// 挂载 PVC
-		if volume.Source.PVC != nil {
-			volumes = append(volumes, corev1.Volume{
-				Name: volume.Name,
-				VolumeSource: corev1.VolumeSource{
-					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: volume.Source.PVC.ClaimName,
-					},
-				},
-			})
-
-			volumeMounts = append(volumeMounts, corev1.VolumeMount{
-				Name:      volume.Name,
-				MountPath: volume.MountPath,
-			})
-		}
-
-		// 挂载 ConfigMap
-		if volume.Source.ConfigMap != nil {
-			volumes = append(volumes, corev1.Volume{
-				Name: volume.Name,
-				VolumeSource: corev1.VolumeSource{
-					ConfigMap: &corev1.ConfigMapVolumeSource{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: volume.Source.ConfigMap.Name,
-						},
-						Items: func() []corev1.KeyToPath {
-							var items []corev1.KeyToPath
-							for _, item := range volume.Source.ConfigMap.Items {
-								items = append(items, corev1.KeyToPath{
-									Key:  item.Key,
-									Path: item.Path,
-								})
-							}
-							return items
-						}(),
-					},
-				},
-			})
-
-			volumeMounts = append(volumeMounts, corev1.VolumeMount{
-				Name:      volume.Name,
-				MountPath: volume.MountPath,
-				ReadOnly:  true,
-			})
-		}
-	}
-
-	return volumes, volumeMounts
-}
@@ -0,0 +1,205 @@
+package job
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/svc"
+)
+
+// 内置的任务种类
+const (
+	JobKindEntrypoint     = "entrypoint"             // 直接运行 Job.Cmd
+	JobKindVllmServe      = "vllmOnRaySimpleAutoJob" // vllm serve，自动构建运行代码与模型地址
+	JobKindSGLangServe    = "sglangServe"            // 单机 SGLang 推理服务
+	JobKindRayTrain       = "rayTrainFineTune"       // Ray Train 微调，向训练脚本传入 worker 数量
+	JobKindBatchInference = "batchInference"         // Ray Data + vLLM 离线批量推理
+)
+
+// JobKind 一种任务的处理逻辑，创建 RayJob 前依次调用 Validate、Mutate、RunCode，
+// opts 为部署时配置的选项，如读取模型配置的方法
+type JobKind interface {
+	// Validate 检查集群与任务配置是否满足该种类的要求
+	Validate(config *model.ClusterConfig, opts model.JobOptions) error
+	// Mutate 修改集群配置，如补充 Job.Cmd 的参数
+	Mutate(config *model.ClusterConfig) error
+	// RunCode 生成运行代码，不需要时返回 nil。生成的代码挂载到 head，并作为 entrypoint 运行
+	RunCode(config *model.ClusterConfig, opts model.JobOptions) (*RunCodeConfig, error)
+}
+
+var jobKindRegistry = map[string]JobKind{
+	JobKindEntrypoint:     entrypointJobKind{},
+	JobKindVllmServe:      vllmServeJobKind{},
+	JobKindSGLangServe:    sglangServeJobKind{},
+	JobKindRayTrain:       rayTrainJobKind{},
+	JobKindBatchInference: batchInferenceJobKind{},
+}
+
+// RegisterJobKind 注册任务种类，同名时覆盖，需要在处理请求之前调用
+func RegisterJobKind(name string, kind JobKind) {
+	jobKindRegistry[name] = kind
+}
+
+// SupportedJobKinds 返回已注册的任务种类，按名称排序
+func SupportedJobKinds() []string {
+	return slices.Sorted(maps.Keys(jobKindRegistry))
+}
+
+// GetJobKind 返回 Job.Kind 对应的处理逻辑，为空时使用 entrypoint
+func GetJobKind(name string) (JobKind, error) {
+	if name == "" {
+		name = JobKindEntrypoint
+	}
+	kind, ok := jobKindRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown job kind %q, supported kinds: %s", name, strings.Join(SupportedJobKinds(), ", "))
+	}
+	return kind, nil
+}
+
+// ValidateJob 检查任务种类是否存在以及配置是否满足要求，不修改配置
+func ValidateJob(config *model.ClusterConfig, opts model.JobOptions) error {
+	if config.Job == nil {
+		return fmt.Errorf("job is required")
+	}
+	kind, err := GetJobKind(config.Job.Kind)
+	if err != nil {
+		return err
+	}
+	if err := svc.ValidateExpose(config.Job.Expose); err != nil {
+		return err
+	}
+	if err := ValidateAutoscaler(config); err != nil {
+		return err
+	}
+	if err := ValidateVolumes(config, opts.HostPath); err != nil {
+		return err
+	}
+	if err := ValidatePodTemplates(config, opts.HostPath); err != nil {
+		return err
+	}
+	return kind.Validate(config, opts)
+}
+
+// PrepareJob 按任务种类校验并修改配置，生成运行代码时挂载到 head 并设置 Job.Cmd
+func PrepareJob(config *model.ClusterConfig, opts model.JobOptions) (*RunCodeConfig, error) {
+	if config.Job == nil {
+		return nil, fmt.Errorf("job is required")
+	}
+	kind, err := GetJobKind(config.Job.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := kind.Validate(config, opts); err != nil {
+		return nil, err
+	}
+	if err := kind.Mutate(config); err != nil {
+		return nil, err
+	}
+
+	runCodeConfig, err := kind.RunCode(config, opts)
+	if err != nil || runCodeConfig == nil {
+		return nil, err
+	}
+	headMachine := findHeadMachine(config)
+	if headMachine == nil {
+		return nil, fmt.Errorf("no machine available to add volume")
+	}
+	headMachine.Volumes = append(headMachine.Volumes, runCodeConfig.VolumeConfig)
+	config.Job.Cmd = "python " + runCodeConfig.RunCodeFilePathAndScriptName
+	return runCodeConfig, nil
+}
+
+// entrypointJobKind 直接运行 Job.Cmd
+type entrypointJobKind struct{}
+
+func (entrypointJobKind) Validate(config *model.ClusterConfig, _ model.JobOptions) error {
+	if strings.TrimSpace(config.Job.Cmd) == "" {
+		return fmt.Errorf("job cmd is required for kind %s", JobKindEntrypoint)
+	}
+	return nil
+}
+
+func (entrypointJobKind) Mutate(*model.ClusterConfig) error { return nil }
+
+func (entrypointJobKind) RunCode(*model.ClusterConfig, model.JobOptions) (*RunCodeConfig, error) {
+	return nil, nil
+}
+
+// 返回 head 机器，没有标记时使用第一个机器
+func findHeadMachine(config *model.ClusterConfig) *model.MachineConfig {
+	for i := range config.Machines {
+		if config.Machines[i].IsHeadNode {
+			return &config.Machines[i]
+		}
+	}
+	if len(config.Machines) > 0 {
+		return &config.Machines[0]
+	}
+	return nil
+}
+
+// 返回机器上 label 为 model 的卷中的模型路径，优先使用 actualModelPathInPod
+func findModelPath(machine *model.MachineConfig) (string, error) {
+	for _, volume := range machine.Volumes {
+		if _, exists := volume.Label["model"]; !exists {
+			continue
+		}
+		if path, ok := volume.Label["actualModelPathInPod"]; ok {
+			return path, nil
+		}
+		if volume.MountPath != "" {
+			return volume.MountPath, nil
+		}
+		break
+	}
+	return "", fmt.Errorf("no model volume, or path is none")
+}
+
+// 返回机器的 nvidia GPU 数量
+func machineGPUCount(machine *model.MachineConfig) (int, error) {
+	value, exists := machine.CustomResources["nvidia.com/gpu"]
+	if !exists {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(value.Quantity)
+	if err != nil {
+		return 0, fmt.Errorf("nvidia GPU value is none")
+	}
+	return count, nil
+}
+
+// head 机器的模型路径与 GPU 数量，推理服务类任务都需要
+func headServeParams(config *model.ClusterConfig) (string, int, error) {
+	headMachine := findHeadMachine(config)
+	if headMachine == nil {
+		return "", 0, fmt.Errorf("no header machine")
+	}
+	modelPath, err := findModelPath(headMachine)
+	if err != nil {
+		return "", 0, err
+	}
+	gpuCount, err := machineGPUCount(headMachine)
+	if err != nil {
+		return "", 0, err
+	}
+	if gpuCount == 0 {
+		return "", 0, fmt.Errorf("no gpu")
+	}
+	return modelPath, gpuCount, nil
+}
+
+// Args 中 label 为 labelKey=true 的参数合并，后面的覆盖前面的
+func collectArgParams(args []model.ArgItem, labelKey string) map[string]string {
+	params := map[string]string{}
+	for _, argItem := range args {
+		if value, exists := argItem.Label[labelKey]; exists && value == "true" {
+			maps.Copy(params, argItem.Params)
+		}
+	}
+	return params
+}
@@ -0,0 +1,113 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+const batchInferenceTemplate = `
+import ray
+from vllm import LLM, SamplingParams
+
+MODEL_PATH = {{pyStr .ModelPath}}
+INPUT_PATH = {{pyStr .Batch.InputPath}}
+OUTPUT_PATH = {{pyStr .Batch.OutputPath}}
+PROMPT_COLUMN = {{pyStr .Batch.PromptColumn}}
+
+
+class Predictor:
+    def __init__(self):
+        self.llm = LLM(model=MODEL_PATH, tensor_parallel_size={{.TensorParallelSize}}, trust_remote_code=True)
+        self.sampling_params = SamplingParams(max_tokens={{.Batch.MaxTokens}})
+
+    def __call__(self, batch):
+        outputs = self.llm.generate(list(batch[PROMPT_COLUMN]), self.sampling_params)
+        batch["generated_text"] = [output.outputs[0].text for output in outputs]
+        return batch
+
+
+if __name__ == "__main__":
+    ray.init()
+    ds = ray.data.read_json(INPUT_PATH)
+    ds = ds.map_batches(Predictor, concurrency={{.Concurrency}}, num_gpus={{.TensorParallelSize}}, batch_size={{.Batch.BatchSize}})
+    ds.write_json(OUTPUT_PATH)
+`
+
+// batchInferenceJobKind 使用 Ray Data 读取 JSON 输入，每个机器一个 vLLM 副本批量生成，结果写入 outputPath。
+// 参数来自 Args 中 label 为 batchInferenceParams=true 的项：inputPath、outputPath 必填，
+// promptColumn 默认为 prompt，maxTokens 默认为 256，batchSize 默认为 64
+type batchInferenceJobKind struct{}
+
+func (batchInferenceJobKind) Validate(config *model.ClusterConfig, _ model.JobOptions) error {
+	_, err := newBatchInferenceData(config)
+	return err
+}
+
+func (batchInferenceJobKind) Mutate(*model.ClusterConfig) error { return nil }
+
+func (batchInferenceJobKind) RunCode(config *model.ClusterConfig, _ model.JobOptions) (*RunCodeConfig, error) {
+	data, err := newBatchInferenceData(config)
+	if err != nil {
+		return nil, err
+	}
+	runCode, err := RenderRunCode(userRunCodeTemplate(config), batchInferenceTemplate, *data)
+	if err != nil {
+		return nil, err
+	}
+	return newRunCodeConfig(config.Job.Name, fmt.Sprintf("batch_%s.py", config.Job.Name), runCode), nil
+}
+
+func newBatchInferenceData(config *model.ClusterConfig) (*RunCodeData, error) {
+	total := CountMachines(config).TotalMachines
+	if total == 0 {
+		return nil, fmt.Errorf("total machine size is zero")
+	}
+	modelPath, gpuCount, err := headServeParams(config)
+	if err != nil {
+		return nil, err
+	}
+
+	args := collectArgParams(config.Job.Args, "batchInferenceParams")
+	var missingParams []string
+	for _, name := range []string{"inputPath", "outputPath"} {
+		if args[name] == "" {
+			missingParams = append(missingParams, name)
+		}
+	}
+	if len(missingParams) > 0 {
+		return nil, fmt.Errorf("missing batch inference parameters: %s", strings.Join(missingParams, ","))
+	}
+
+	batch := &BatchInferenceData{
+		InputPath:    args["inputPath"],
+		OutputPath:   args["outputPath"],
+		PromptColumn: "prompt",
+		MaxTokens:    256,
+		BatchSize:    64,
+	}
+	if column := args["promptColumn"]; column != "" {
+		batch.PromptColumn = column
+	}
+	for name, target := range map[string]*int{"maxTokens": &batch.MaxTokens, "batchSize": &batch.BatchSize} {
+		value, ok := args[name]
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("batch inference parameter %s must be a positive integer, got %q", name, value)
+		}
+		*target = parsed
+	}
+	return &RunCodeData{
+		JobName:            config.Job.Name,
+		Kind:               JobKindBatchInference,
+		ModelPath:          modelPath,
+		TensorParallelSize: gpuCount,
+		Concurrency:        total,
+		Batch:              batch,
+	}, nil
+}
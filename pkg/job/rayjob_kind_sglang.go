@@ -0,0 +1,51 @@
+package job
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+// sglangServeJobKind 在 head 上运行 sglang.launch_server，TP 为 head 的 GPU 数量。
+// SGLang 的多机部署不经过 Ray，因此只支持单个机器
+type sglangServeJobKind struct{}
+
+func (sglangServeJobKind) Validate(config *model.ClusterConfig, _ model.JobOptions) error {
+	if total := CountMachines(config).TotalMachines; total != 1 {
+		return fmt.Errorf("kind %s only supports a single machine, got %d", JobKindSGLangServe, total)
+	}
+	_, _, err := headServeParams(config)
+	return err
+}
+
+func (sglangServeJobKind) Mutate(*model.ClusterConfig) error { return nil }
+
+func (sglangServeJobKind) RunCode(config *model.ClusterConfig, _ model.JobOptions) (*RunCodeConfig, error) {
+	modelPath, gpuCount, err := headServeParams(config)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{
+		"--tp-size":           strconv.Itoa(gpuCount),
+		"--host":              "0.0.0.0",
+		"--port":              "8000",
+		"--trust-remote-code": "",
+	}
+	maps.Copy(params, collectArgParams(config.Job.Args, "sglangRuncodeCustomParams"))
+
+	runCode, err := RenderRunCode(userRunCodeTemplate(config), serveRunCodeTemplate, RunCodeData{
+		JobName:            config.Job.Name,
+		Kind:               JobKindSGLangServe,
+		FuncName:           "start_sglang",
+		ModelPath:          modelPath,
+		Command:            buildCommand([]string{"python", "-m", "sglang.launch_server", "--model-path", modelPath}, params),
+		TensorParallelSize: gpuCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newRunCodeConfig(config.Job.Name, fmt.Sprintf("sglang_%s.py", config.Job.Name), runCode), nil
+}
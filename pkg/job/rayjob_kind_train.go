@@ -0,0 +1,89 @@
+package job
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+// rayTrainJobKind 运行用户的 Ray Train 训练脚本（Job.Cmd），并追加 --num-workers 与 --use-gpu 参数，
+// 每个 GPU 一个 worker，脚本根据这两个参数构建 ScalingConfig。
+// Args 中 label 为 rayTrainParams=true 的参数会追加到命令后面
+type rayTrainJobKind struct{}
+
+// 与 Python 的 shlex.quote 相同，只包含这些字符的参数不需要引号
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func (rayTrainJobKind) Validate(config *model.ClusterConfig, _ model.JobOptions) error {
+	if strings.TrimSpace(config.Job.Cmd) == "" {
+		return fmt.Errorf("job cmd is required for kind %s, e.g. python /code/train.py", JobKindRayTrain)
+	}
+	if CountMachines(config).TotalMachines == 0 {
+		return fmt.Errorf("total machine size is zero")
+	}
+	_, err := trainWorkerCount(config)
+	return err
+}
+
+func (rayTrainJobKind) Mutate(config *model.ClusterConfig) error {
+	workers, err := trainWorkerCount(config)
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(config.Job.Cmd)
+	params := collectArgParams(config.Job.Args, "rayTrainParams")
+	// 用户已经指定时不覆盖
+	if !slices.Contains(fields, "--num-workers") {
+		if _, ok := params["--num-workers"]; !ok {
+			params["--num-workers"] = strconv.Itoa(max(workers, 1))
+		}
+	}
+	if workers > 0 && !slices.Contains(fields, "--use-gpu") {
+		params["--use-gpu"] = ""
+	}
+
+	// Job.Cmd 由 shell 执行，用户传入的参数需要转义，避免空格、引号或 $(...) 改变命令
+	for _, param := range slices.Sorted(maps.Keys(params)) {
+		config.Job.Cmd += " " + shellQuote(param)
+		if value := params[param]; value != "" {
+			config.Job.Cmd += " " + shellQuote(value)
+		}
+	}
+	return nil
+}
+
+// 使用单引号转义，字符串中的单引号先结束引号、转义后再重新开始引号
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (rayTrainJobKind) RunCode(*model.ClusterConfig, model.JobOptions) (*RunCodeConfig, error) {
+	return nil, nil
+}
+
+// 所有机器的 GPU 总数，没有 GPU 时返回 0，按 CPU 训练
+func trainWorkerCount(config *model.ClusterConfig) (int, error) {
+	workers := 0
+	for i := range config.Machines {
+		machine := &config.Machines[i]
+		gpuCount, err := machineGPUCount(machine)
+		if err != nil {
+			return 0, err
+		}
+		replicas := 1
+		if machine.Replicas != nil {
+			replicas = int(*machine.Replicas)
+		}
+		workers += gpuCount * replicas
+	}
+	return workers, nil
+}
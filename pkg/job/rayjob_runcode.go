@@ -1,30 +1,38 @@
 package job
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"html/template"
 	"maps"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/modcoco/OpsFlow/pkg/model"
 	"github.com/modcoco/OpsFlow/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
-const pythonTemplate = `
+// DefaultRunCodeTemplateKey 用户模板 ConfigMap 中未指定 key 时使用的 key
+const DefaultRunCodeTemplateKey = "runcode.py.tmpl"
+
+// 在 Ray 中通过 subprocess 启动推理服务
+const serveRunCodeTemplate = `
 import ray
 import subprocess
 
 @ray.remote
-def start_vllm():
-    {{.CommandStr | safe}}
+def {{.FuncName}}():
+    command = {{pyList .Command}}
     process = subprocess.Popen(command)
     process.wait()
 
 if __name__ == "__main__":
     ray.init()
-    ray.get(start_vllm.remote())
+    ray.get({{.FuncName}}.remote())
 `
 
 // 通过常见参数构建简单的vllm运行脚本
@@ -33,19 +41,52 @@ type VllmSimpleAutoJobScriptParams struct {
 	ModelPath            string
 	TensorParallelSize   int
 	PipelineParallelSize int
+	DataParallelSize     int // 大于 1 时传入 --data-parallel-size
 	Args                 []model.ArgItem
+	Template             string // 用户模板，为空时使用默认模板
 }
 
-type VllmSimpleRunCodeConfigForRayCluster struct {
+// RunCodeConfig 生成的运行代码，保存在 ConfigMap 中并挂载到 head
+type RunCodeConfig struct {
 	ConfigMapName                string
 	VolumeConfig                 model.VolumeConfig
 	RunCodeFilePath              string
 	RunCodeFilePathAndScriptName string
 	ScriptName                   string
 	RunCode                      string
+	Layout                       *model.ParallelLayout // vLLM 推理的并行方式，其他种类为空
+}
+
+// RunCodeData 渲染运行代码模板的数据，用户模板可以使用所有字段。
+// 字符串需要通过 pyStr 或 pyList 输出，保证生成的是合法的 Python 字面量
+type RunCodeData struct {
+	JobName              string
+	Kind                 string
+	FuncName             string   // 推理服务 remote 函数名，如 start_vllm
+	ModelPath            string   // head 上的模型路径
+	Command              []string // 推理服务的启动命令
+	TensorParallelSize   int
+	PipelineParallelSize int
+	DataParallelSize     int
+	Concurrency          int                 // 批量推理的副本数
+	Batch                *BatchInferenceData // 只有 batchInference 有值
+}
+
+// BatchInferenceData 批量推理的参数
+type BatchInferenceData struct {
+	InputPath    string
+	OutputPath   string
+	PromptColumn string
+	MaxTokens    int
+	BatchSize    int
 }
 
-func GetVllmOnRaySimpleAutoJobConfigMap(input VllmSimpleAutoJobScriptParams) (*VllmSimpleRunCodeConfigForRayCluster, error) {
+var runCodeFuncs = template.FuncMap{
+	"pyStr":  pythonString,
+	"pyList": pythonList,
+}
+
+func GetVllmOnRaySimpleAutoJobConfigMap(input VllmSimpleAutoJobScriptParams) (*RunCodeConfig, error) {
 	var missingParams []string
 	if input.RayJobName == "" {
 		missingParams = append(missingParams, "RayJobName")
@@ -64,45 +105,53 @@ func GetVllmOnRaySimpleAutoJobConfigMap(input VllmSimpleAutoJobScriptParams) (*V
 		return nil, errors.New("missing or invalid parameters: " + strings.Join(missingParams, ","))
 	}
 
-	configMapName := fmt.Sprintf("runcode-%s-%s", input.RayJobName, utils.RandStrLower(5))
-	scriptName := fmt.Sprintf("vllm_%s.py", input.RayJobName)
-
-	baseCommand := []string{
-		"vllm", "serve", input.ModelPath,
-	}
-
 	baseVllmParamMap := map[string]string{
-		"--tensor-parallel-size":   strconv.Itoa(input.TensorParallelSize),
-		"--pipeline-parallel-size": strconv.Itoa(input.PipelineParallelSize),
-		"--trust-remote-code":      "",
+		vllmTensorParallelParam:   strconv.Itoa(input.TensorParallelSize),
+		vllmPipelineParallelParam: strconv.Itoa(input.PipelineParallelSize),
+		"--trust-remote-code":     "",
+	}
+	if input.DataParallelSize > 1 {
+		baseVllmParamMap[vllmDataParallelParam] = strconv.Itoa(input.DataParallelSize)
 	}
 
 	// Change params
-	for _, argItem := range input.Args {
-		if value, exists := argItem.Label["vllmRuncodeCustomParams"]; exists && value == "true" {
-			maps.Copy(baseVllmParamMap, argItem.Params)
-		}
-	}
-	for param, value := range baseVllmParamMap {
-		baseCommand = append(baseCommand, param)
-		if value != "" {
-			baseCommand = append(baseCommand, value)
-		}
-	}
+	maps.Copy(baseVllmParamMap, collectArgParams(input.Args, "vllmRuncodeCustomParams"))
 
-	// Fix ""
-	quotedCommand := make([]string, len(baseCommand))
-	for i, item := range baseCommand {
-		quotedCommand[i] = fmt.Sprintf(`"%s"`, item)
+	data := RunCodeData{
+		JobName:              input.RayJobName,
+		Kind:                 JobKindVllmServe,
+		FuncName:             "start_vllm",
+		ModelPath:            input.ModelPath,
+		Command:              buildCommand([]string{"vllm", "serve", input.ModelPath}, baseVllmParamMap),
+		TensorParallelSize:   input.TensorParallelSize,
+		PipelineParallelSize: input.PipelineParallelSize,
+		DataParallelSize:     input.DataParallelSize,
 	}
-	commandStr := fmt.Sprintf("command = [%s]", strings.Join(quotedCommand, ", "))
 
 	// Get runcode
-	runCode, err := GenerateRunCode(commandStr)
+	runCode, err := RenderRunCode(input.Template, serveRunCodeTemplate, data)
 	if err != nil {
 		return nil, err
 	}
 
+	return newRunCodeConfig(input.RayJobName, fmt.Sprintf("vllm_%s.py", input.RayJobName), runCode), nil
+}
+
+// 命令由固定的位置参数与按名称排序的选项组成，值为空的选项只输出名称
+func buildCommand(positional []string, params map[string]string) []string {
+	command := slices.Clone(positional)
+	for _, param := range slices.Sorted(maps.Keys(params)) {
+		command = append(command, param)
+		if value := params[param]; value != "" {
+			command = append(command, value)
+		}
+	}
+	return command
+}
+
+// 生成 ConfigMap 名称与挂载配置
+func newRunCodeConfig(jobName, scriptName, runCode string) *RunCodeConfig {
+	configMapName := fmt.Sprintf("runcode-%s-%s", jobName, utils.RandStrLower(5))
 	runCodeFilePath := "/home/ray/.runcode"
 	runCodeFilePathAndScriptName := runCodeFilePath + "/" + scriptName
 	volumeConfig := model.VolumeConfig{
@@ -118,37 +167,117 @@ func GetVllmOnRaySimpleAutoJobConfigMap(input VllmSimpleAutoJobScriptParams) (*V
 		},
 	}
 
-	return &VllmSimpleRunCodeConfigForRayCluster{
+	return &RunCodeConfig{
 		ConfigMapName:                configMapName,
 		RunCode:                      runCode,
 		RunCodeFilePath:              runCodeFilePath,
 		RunCodeFilePathAndScriptName: runCodeFilePathAndScriptName,
 		ScriptName:                   scriptName,
 		VolumeConfig:                 volumeConfig,
-	}, nil
+	}
 }
 
-func GenerateRunCode(commandStr string) (string, error) {
-	funcMap := template.FuncMap{
-		"safe": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-	}
+// GenerateRunCode 生成通过 subprocess 启动 vllm 的运行代码
+func GenerateRunCode(command []string) (string, error) {
+	return RenderRunCode("", serveRunCodeTemplate, RunCodeData{FuncName: "start_vllm", Command: command})
+}
 
-	tmpl, err := template.New("pythonCode").Funcs(funcMap).Parse(pythonTemplate)
+// RenderRunCode 渲染运行代码，userTemplate 为空时使用 defaultTemplate
+func RenderRunCode(userTemplate, defaultTemplate string, data RunCodeData) (string, error) {
+	text := defaultTemplate
+	if userTemplate != "" {
+		text = userTemplate
+	}
+	tmpl, err := parseRunCodeTemplate(text)
 	if err != nil {
 		return "", err
 	}
 
 	var builder strings.Builder
-	err = tmpl.Execute(&builder, struct {
-		CommandStr string
-	}{
-		CommandStr: commandStr,
-	})
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("render runcode template: %w", err)
+	}
+	return builder.String(), nil
+}
+
+func parseRunCodeTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("runcode").Funcs(runCodeFuncs).Option("missingkey=error").Parse(text)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("parse runcode template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ResolveRunCodeTemplate 读取 Job.RunCodeTemplate 指向的 ConfigMap，检查模板语法后保存到 Content
+func ResolveRunCodeTemplate(ctx context.Context, clientset kubernetes.Interface, config *model.ClusterConfig) error {
+	if config.Job == nil || config.Job.RunCodeTemplate == nil {
+		return nil
+	}
+	source := config.Job.RunCodeTemplate
+	if source.ConfigMap == "" {
+		return fmt.Errorf("runCodeTemplate.configMap is required")
+	}
+	key := source.Key
+	if key == "" {
+		key = DefaultRunCodeTemplateKey
 	}
 
-	return builder.String(), nil
+	configMap, err := clientset.CoreV1().ConfigMaps(config.Namespace).Get(ctx, source.ConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get runcode template configmap %s/%s: %w", config.Namespace, source.ConfigMap, err)
+	}
+	content, ok := configMap.Data[key]
+	if !ok {
+		return fmt.Errorf("runcode template configmap %s/%s has no key %s", config.Namespace, source.ConfigMap, key)
+	}
+	if _, err := parseRunCodeTemplate(content); err != nil {
+		return err
+	}
+	source.Content = content
+	return nil
+}
+
+// 用户模板内容，没有指定时为空
+func userRunCodeTemplate(config *model.ClusterConfig) string {
+	if config.Job == nil || config.Job.RunCodeTemplate == nil {
+		return ""
+	}
+	return config.Job.RunCodeTemplate.Content
+}
+
+// pythonString 返回 s 的 Python 双引号字符串字面量，转义反斜杠、引号与控制字符
+func pythonString(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			builder.WriteString(`\\`)
+		case '"':
+			builder.WriteString(`\"`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&builder, `\x%02x`, r)
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// pythonList 返回字符串列表的 Python 字面量
+func pythonList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = pythonString(item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
 }
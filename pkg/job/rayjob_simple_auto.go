@@ -2,103 +2,61 @@ package job
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/modcoco/OpsFlow/pkg/model"
 )
 
-// Build runcode config
-func ProcessVllmOnRaySimpleAutoJobClusterConfigByHeaderMachine(clusterConfig *model.ClusterConfig) (*VllmSimpleRunCodeConfigForRayCluster, error) {
-	if clusterConfig.Job == nil || clusterConfig.Job.Kind != "vllmOnRaySimpleAutoJob" {
-		return nil, nil
-	}
-	// Get cluster total machine count
-	machineTypeCount := CountMachines(clusterConfig)
-	if machineTypeCount.TotalMachines == 0 {
-		return nil, fmt.Errorf("total machine size is zero")
-	}
-
-	// Get config
-	var modelPath string
-	var headerMachine *model.MachineConfig
+// vllmServeJobKind 在 head 上运行 vllm serve，并行方式由 PlanVllmLayout 按整个集群计算
+type vllmServeJobKind struct{}
 
-	// Get selectedMachine
-	for _, machine := range clusterConfig.Machines {
-		if machine.IsHeadNode { // First
-			headerMachine = &machine
-			break
-		}
-	}
-	if headerMachine == nil && len(clusterConfig.Machines) > 0 {
-		headerMachine = &clusterConfig.Machines[0]
-	}
-	if headerMachine == nil {
-		return nil, fmt.Errorf("no header machine")
+func (vllmServeJobKind) Validate(config *model.ClusterConfig, opts model.JobOptions) error {
+	if CountMachines(config).TotalMachines == 0 {
+		return fmt.Errorf("total machine size is zero")
 	}
+	_, _, err := vllmServeParams(config, opts.ModelConfigLoader)
+	return err
+}
 
-	// Get header machine modelPath
-	for _, volume := range headerMachine.Volumes {
-		if _, exists := volume.Label["model"]; exists {
-			if path, ok := volume.Label["actualModelPathInPod"]; ok {
-				modelPath = path
-			} else if volume.MountPath != "" {
-				modelPath = volume.MountPath
-			} else {
-				return nil, fmt.Errorf("no model volume, or path is none")
-			}
-			break
-		}
-	}
-	// Get header machine nvidia GPU count
-	var countHeaderMaicheNvidiaGPU int
-	if val, exists := headerMachine.CustomResources["nvidia.com/gpu"]; exists {
-		intValue, err := strconv.Atoi(val.Quantity)
-		if err != nil {
-			return nil, fmt.Errorf("nvidia GPU value is none")
-		}
-		countHeaderMaicheNvidiaGPU = intValue
-	}
-	if countHeaderMaicheNvidiaGPU == 0 {
-		return nil, fmt.Errorf("no gpu")
+func (vllmServeJobKind) Mutate(*model.ClusterConfig) error { return nil }
+
+func (vllmServeJobKind) RunCode(config *model.ClusterConfig, opts model.JobOptions) (*RunCodeConfig, error) {
+	modelPath, layout, err := vllmServeParams(config, opts.ModelConfigLoader)
+	if err != nil {
+		return nil, err
 	}
 
 	vllmJobSimple := VllmSimpleAutoJobScriptParams{
-		RayJobName:           clusterConfig.Job.Name,
+		RayJobName:           config.Job.Name,
 		ModelPath:            modelPath,
-		TensorParallelSize:   countHeaderMaicheNvidiaGPU,
-		PipelineParallelSize: machineTypeCount.TotalMachines,
-		Args:                 clusterConfig.Job.Args,
+		TensorParallelSize:   layout.TensorParallelSize,
+		PipelineParallelSize: layout.PipelineParallelSize,
+		DataParallelSize:     layout.DataParallelSize,
+		Args:                 config.Job.Args,
+		Template:             userRunCodeTemplate(config),
 	}
-
 	vllmCodeConfigMap, err := GetVllmOnRaySimpleAutoJobConfigMap(vllmJobSimple)
 	if err != nil {
-		return nil, fmt.Errorf("can't create configmap")
+		return nil, fmt.Errorf("can't create configmap: %w", err)
 	}
+	vllmCodeConfigMap.Layout = layout
+	return vllmCodeConfigMap, nil
+}
 
-	// Add runcode to header machine
-	var targetHeaderMachine *model.MachineConfig
-	for i := range clusterConfig.Machines {
-		if clusterConfig.Machines[i].IsHeadNode {
-			targetHeaderMachine = &clusterConfig.Machines[i]
-			break
-		}
+// head 上的模型路径与整个集群的并行方式，head 可以没有 GPU
+func vllmServeParams(config *model.ClusterConfig, loader model.ModelConfigLoader) (string, *model.ParallelLayout, error) {
+	headMachine := findHeadMachine(config)
+	if headMachine == nil {
+		return "", nil, fmt.Errorf("no header machine")
 	}
-	if targetHeaderMachine == nil {
-		targetHeaderMachine = &clusterConfig.Machines[0]
-	}
-	if targetHeaderMachine == nil {
-		return nil, fmt.Errorf("no machine available to add volume")
+	modelPath, err := findModelPath(headMachine)
+	if err != nil {
+		return "", nil, err
 	}
-	if vllmCodeConfigMap == nil {
-		return nil, fmt.Errorf("vllmCodeConfigMap is nil")
+	layout, err := PlanVllmLayout(config, loader)
+	if err != nil {
+		return "", nil, err
 	}
-	targetHeaderMachine.Volumes = append(targetHeaderMachine.Volumes, vllmCodeConfigMap.VolumeConfig)
-
-	// Add run cmd
-	clusterConfig.Job.Cmd = "python " + vllmCodeConfigMap.RunCodeFilePathAndScriptName
-
-	fmt.Println(vllmCodeConfigMap.RunCode)
-	return vllmCodeConfigMap, nil
+	return modelPath, layout, nil
 }
 
 type MachineTypeCount struct {
@@ -0,0 +1,170 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+// vLLM 并行参数名，用户可以通过 vllmRuncodeCustomParams 指定
+const (
+	vllmTensorParallelParam   = "--tensor-parallel-size"
+	vllmPipelineParallelParam = "--pipeline-parallel-size"
+	vllmDataParallelParam     = "--data-parallel-size"
+)
+
+// LocalModelConfigLoader 从本地目录读取模型配置，root 为模型卷在本服务中的挂载目录，
+// Pod 中的模型路径拼接在 root 之后，拼接后不在 root 下的路径（如包含 ..）直接拒绝
+func LocalModelConfigLoader(root string) model.ModelConfigLoader {
+	return func(modelPath string) (*model.ModelConfig, error) {
+		path := filepath.Join(root, modelPath, "config.json")
+		if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("model path %s is outside the model config root", modelPath)
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read model config %s: %w", path, err)
+		}
+
+		var config model.ModelConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("parse model config %s: %w", path, err)
+		}
+		if config.NumAttentionHeads == 0 && config.TextConfig != nil {
+			return config.TextConfig, nil
+		}
+		return &config, nil
+	}
+}
+
+// PlanVllmLayout 按整个集群计算 vLLM 的并行方式。没有 GPU 的机器不参与推理，
+// 其余机器的 GPU 数量必须相同。TP 默认为每个机器的 GPU 数，DP 默认为 1，
+// PP 为剩余的倍数，三者可以通过 vllmRuncodeCustomParams 指定，乘积必须等于 GPU 总数。
+// loader 不为空且读取到模型配置时，检查 attention heads 能否被 TP 整除、层数不少于 PP
+func PlanVllmLayout(config *model.ClusterConfig, loader model.ModelConfigLoader) (*model.ParallelLayout, error) {
+	var machines []string
+	gpusPerMachine, gpuMachines := 0, 0
+	homogeneous := true
+	for i := range config.Machines {
+		machine := &config.Machines[i]
+		gpuCount, err := machineGPUCount(machine)
+		if err != nil {
+			return nil, fmt.Errorf("machine %s: %w", machine.Name, err)
+		}
+		if gpuCount == 0 {
+			continue
+		}
+		replicas := 1
+		if machine.Replicas != nil {
+			replicas = int(*machine.Replicas)
+		}
+		if replicas == 0 {
+			continue
+		}
+
+		machines = append(machines, fmt.Sprintf("%s=%d", machine.Name, gpuCount))
+		if gpusPerMachine != 0 && gpuCount != gpusPerMachine {
+			homogeneous = false
+		}
+		gpusPerMachine = gpuCount
+		gpuMachines += replicas
+	}
+	if gpuMachines == 0 {
+		return nil, fmt.Errorf("no gpu")
+	}
+	if !homogeneous {
+		slices.Sort(machines)
+		return nil, fmt.Errorf("all gpu machines must have the same gpu count, got %s", strings.Join(machines, ", "))
+	}
+	totalGPUs := gpusPerMachine * gpuMachines
+
+	params := collectArgParams(config.Job.Args, "vllmRuncodeCustomParams")
+	tensorParallel, err := parallelParam(params, vllmTensorParallelParam, gpusPerMachine)
+	if err != nil {
+		return nil, err
+	}
+	dataParallel, err := parallelParam(params, vllmDataParallelParam, 1)
+	if err != nil {
+		return nil, err
+	}
+	pipelineParallel, err := parallelParam(params, vllmPipelineParallelParam, max(totalGPUs/(tensorParallel*dataParallel), 1))
+	if err != nil {
+		return nil, err
+	}
+	if product := tensorParallel * pipelineParallel * dataParallel; product != totalGPUs {
+		return nil, fmt.Errorf("tensor parallel %d x pipeline parallel %d x data parallel %d = %d, want total gpu count %d (%d machines x %d gpus)",
+			tensorParallel, pipelineParallel, dataParallel, product, totalGPUs, gpuMachines, gpusPerMachine)
+	}
+
+	layout := &model.ParallelLayout{
+		TensorParallelSize:   tensorParallel,
+		PipelineParallelSize: pipelineParallel,
+		DataParallelSize:     dataParallel,
+		GPUMachines:          gpuMachines,
+		GPUsPerMachine:       gpusPerMachine,
+	}
+	if loader == nil {
+		return layout, nil
+	}
+
+	headMachine := findHeadMachine(config)
+	if headMachine == nil {
+		return nil, fmt.Errorf("no header machine")
+	}
+	modelPath, err := findModelPath(headMachine)
+	if err != nil {
+		return nil, err
+	}
+	modelConfig, err := loader(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	if modelConfig == nil {
+		return layout, nil
+	}
+	if err := checkModelLayout(modelConfig, layout); err != nil {
+		return nil, fmt.Errorf("model %s: %w", modelPath, err)
+	}
+	layout.ModelConfigChecked = true
+	return layout, nil
+}
+
+// 读取并行参数，没有指定时使用 def
+func parallelParam(params map[string]string, name string, def int) (int, error) {
+	value, ok := params[name]
+	if !ok {
+		return def, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return size, nil
+}
+
+// vLLM 按 TP 切分 attention heads，按 PP 切分层
+func checkModelLayout(modelConfig *model.ModelConfig, layout *model.ParallelLayout) error {
+	tensorParallel := layout.TensorParallelSize
+	if heads := modelConfig.NumAttentionHeads; heads > 0 && heads%tensorParallel != 0 {
+		return fmt.Errorf("num_attention_heads %d is not divisible by tensor parallel size %d", heads, tensorParallel)
+	}
+	// KV heads 少于 TP 时会被复制，此时 TP 需要是 KV heads 的倍数
+	if kvHeads := modelConfig.NumKeyValueHeads; kvHeads > 0 && kvHeads%tensorParallel != 0 && tensorParallel%kvHeads != 0 {
+		return fmt.Errorf("num_key_value_heads %d and tensor parallel size %d are not multiples of each other", kvHeads, tensorParallel)
+	}
+	if layers := modelConfig.NumHiddenLayers; layers > 0 && layers < layout.PipelineParallelSize {
+		return fmt.Errorf("num_hidden_layers %d is less than pipeline parallel size %d", layers, layout.PipelineParallelSize)
+	}
+	return nil
+}
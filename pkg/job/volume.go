@@ -0,0 +1,313 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+var pvcAccessModes = []string{
+	string(corev1.ReadWriteOnce),
+	string(corev1.ReadOnlyMany),
+	string(corev1.ReadWriteMany),
+	string(corev1.ReadWriteOncePod),
+}
+
+func BuildVolumesAndMounts(volumesConfig []model.VolumeConfig) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+
+	for _, volume := range volumesConfig {
+		source, readOnly := buildVolumeSource(volume)
+		if source == nil {
+			continue
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name:         volume.Name,
+			VolumeSource: *source,
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+			SubPath:   volume.SubPath,
+			ReadOnly:  volume.ReadOnly || readOnly,
+		})
+	}
+
+	return volumes, volumeMounts
+}
+
+// 返回卷的来源以及是否总是只读挂载，没有来源时返回 nil
+func buildVolumeSource(volume model.VolumeConfig) (*corev1.VolumeSource, bool) {
+	source := volume.Source
+	switch {
+	// 挂载 PVC
+	case source.PVC != nil:
+		return &corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: source.PVC.ClaimName,
+				ReadOnly:  volume.ReadOnly,
+			},
+		}, false
+
+	// 挂载 ConfigMap
+	case source.ConfigMap != nil:
+		return &corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: source.ConfigMap.Name,
+				},
+				Items: keyToPaths(source.ConfigMap.Items),
+			},
+		}, true
+
+	case source.Secret != nil:
+		return &corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  source.Secret.SecretName,
+				Items:       keyToPaths(source.Secret.Items),
+				DefaultMode: source.Secret.DefaultMode,
+			},
+		}, true
+
+	case source.EmptyDir != nil:
+		emptyDir := &corev1.EmptyDirVolumeSource{
+			Medium: corev1.StorageMedium(source.EmptyDir.Medium),
+		}
+		if source.EmptyDir.SizeLimit != "" {
+			emptyDir.SizeLimit = ptr.To(resource.MustParse(source.EmptyDir.SizeLimit))
+		}
+		return &corev1.VolumeSource{EmptyDir: emptyDir}, false
+
+	case source.HostPath != nil:
+		hostPath := &corev1.HostPathVolumeSource{Path: source.HostPath.Path}
+		if source.HostPath.Type != "" {
+			hostPath.Type = ptr.To(corev1.HostPathType(source.HostPath.Type))
+		}
+		return &corev1.VolumeSource{HostPath: hostPath}, false
+
+	case source.NFS != nil:
+		return &corev1.VolumeSource{
+			NFS: &corev1.NFSVolumeSource{
+				Server:   source.NFS.Server,
+				Path:     source.NFS.Path,
+				ReadOnly: volume.ReadOnly,
+			},
+		}, false
+
+	case source.CSI != nil:
+		csi := &corev1.CSIVolumeSource{
+			Driver:           source.CSI.Driver,
+			VolumeAttributes: source.CSI.VolumeAttributes,
+		}
+		if volume.ReadOnly {
+			csi.ReadOnly = ptr.To(true)
+		}
+		if source.CSI.FSType != "" {
+			csi.FSType = ptr.To(source.CSI.FSType)
+		}
+		if source.CSI.NodePublishSecretRef != "" {
+			csi.NodePublishSecretRef = &corev1.LocalObjectReference{Name: source.CSI.NodePublishSecretRef}
+		}
+		return &corev1.VolumeSource{CSI: csi}, false
+	}
+	return nil, false
+}
+
+func keyToPaths(items []model.KeyToPathItem) []corev1.KeyToPath {
+	var keyToPaths []corev1.KeyToPath
+	for _, item := range items {
+		keyToPaths = append(keyToPaths, corev1.KeyToPath{
+			Key:  item.Key,
+			Path: item.Path,
+		})
+	}
+	return keyToPaths
+}
+
+// ValidateVolumes 检查所有机器的卷，每个卷最多有一种来源，hostPath 需要满足 policy
+func ValidateVolumes(config *model.ClusterConfig, policy model.HostPathPolicy) error {
+	for _, machine := range config.Machines {
+		names := map[string]bool{}
+		for _, volume := range machine.Volumes {
+			// 没有来源的卷不会挂载，只用 label 标记模型路径等，不检查名称与挂载路径
+			if volumeSourceCount(volume.Source) == 0 {
+				continue
+			}
+			if names[volume.Name] {
+				return fmt.Errorf("machine %s: duplicate volume %s", machine.Name, volume.Name)
+			}
+			names[volume.Name] = true
+			if err := validateVolume(volume, policy); err != nil {
+				return fmt.Errorf("machine %s: volume %s: %w", machine.Name, volume.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func volumeSourceCount(source model.VolumeSource) int {
+	sources := 0
+	for _, set := range []bool{source.PVC != nil, source.ConfigMap != nil, source.Secret != nil, source.EmptyDir != nil, source.HostPath != nil, source.NFS != nil, source.CSI != nil} {
+		if set {
+			sources++
+		}
+	}
+	return sources
+}
+
+func validateVolume(volume model.VolumeConfig, policy model.HostPathPolicy) error {
+	if sources := volumeSourceCount(volume.Source); sources > 1 {
+		return fmt.Errorf("only one source can be set, got %d", sources)
+	}
+	if volume.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !path.IsAbs(volume.MountPath) {
+		return fmt.Errorf("mountPath must be an absolute path, got %q", volume.MountPath)
+	}
+	if path.IsAbs(volume.SubPath) || slices.Contains(strings.Split(volume.SubPath, "/"), "..") {
+		return fmt.Errorf("subPath must be a relative path without '..', got %q", volume.SubPath)
+	}
+
+	source := volume.Source
+	switch {
+	case source.PVC != nil:
+		return ValidatePVCSource(source.PVC)
+	case source.ConfigMap != nil:
+		if source.ConfigMap.Name == "" {
+			return fmt.Errorf("configMap.name is required")
+		}
+	case source.Secret != nil:
+		if source.Secret.SecretName == "" {
+			return fmt.Errorf("secret.secretName is required")
+		}
+	case source.EmptyDir != nil:
+		if medium := source.EmptyDir.Medium; medium != "" && medium != string(corev1.StorageMediumMemory) {
+			return fmt.Errorf("unknown emptyDir.medium %q, supported mediums: %s", medium, corev1.StorageMediumMemory)
+		}
+		if sizeLimit := source.EmptyDir.SizeLimit; sizeLimit != "" {
+			if _, err := resource.ParseQuantity(sizeLimit); err != nil {
+				return fmt.Errorf("invalid emptyDir.sizeLimit %q: %w", sizeLimit, err)
+			}
+		}
+	case source.HostPath != nil:
+		return validateHostPath(source.HostPath.Path, policy)
+	case source.NFS != nil:
+		if source.NFS.Server == "" || !path.IsAbs(source.NFS.Path) {
+			return fmt.Errorf("nfs requires server and an absolute path")
+		}
+	case source.CSI != nil:
+		if source.CSI.Driver == "" {
+			return fmt.Errorf("csi.driver is required")
+		}
+	}
+	return nil
+}
+
+// ValidatePVCSource 检查 PVC 的名称，设置 size 时检查大小与 accessModes
+func ValidatePVCSource(pvc *model.PVCSource) error {
+	if pvc.ClaimName == "" {
+		return fmt.Errorf("pvc.claimName is required")
+	}
+	if pvc.Size == "" {
+		if pvc.StorageClassName != "" || len(pvc.AccessModes) > 0 {
+			return fmt.Errorf("pvc.size is required to create the claim")
+		}
+		return nil
+	}
+	if _, err := resource.ParseQuantity(pvc.Size); err != nil {
+		return fmt.Errorf("invalid pvc.size %q: %w", pvc.Size, err)
+	}
+	for _, mode := range pvc.AccessModes {
+		if !slices.Contains(pvcAccessModes, mode) {
+			return fmt.Errorf("unknown pvc access mode %q, supported modes: %s", mode, strings.Join(pvcAccessModes, ", "))
+		}
+	}
+	return nil
+}
+
+func validateHostPath(hostPath string, policy model.HostPathPolicy) error {
+	if !policy.Enabled {
+		return fmt.Errorf("hostPath volumes are disabled")
+	}
+	if !path.IsAbs(hostPath) {
+		return fmt.Errorf("hostPath.path must be an absolute path, got %q", hostPath)
+	}
+	if len(policy.AllowedPrefixes) == 0 {
+		return nil
+	}
+	cleaned := path.Clean(hostPath)
+	for _, prefix := range policy.AllowedPrefixes {
+		prefix = path.Clean(prefix)
+		if cleaned == prefix || strings.HasPrefix(cleaned, strings.TrimSuffix(prefix, "/")+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("hostPath %s is not under the allowed prefixes: %s", hostPath, strings.Join(policy.AllowedPrefixes, ", "))
+}
+
+// EnsurePVCs 创建设置了 size 但不存在的 PVC，同名的 PVC 只创建一次。PVC 不属于任务，删除任务时保留
+func EnsurePVCs(ctx context.Context, client kubernetes.Interface, namespace string, machines []model.MachineConfig) error {
+	ensured := map[string]bool{}
+	for _, machine := range machines {
+		for _, volume := range machine.Volumes {
+			pvc := volume.Source.PVC
+			if pvc == nil || pvc.Size == "" || ensured[pvc.ClaimName] {
+				continue
+			}
+			ensured[pvc.ClaimName] = true
+
+			_, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvc.ClaimName, metav1.GetOptions{})
+			if err == nil {
+				continue
+			}
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("get pvc %s: %w", pvc.ClaimName, err)
+			}
+			if _, err := client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, CreatePVC(namespace, pvc), metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("create pvc %s: %w", pvc.ClaimName, err)
+			}
+			log.Printf("已创建 PVC %s/%s，大小 %s", namespace, pvc.ClaimName, pvc.Size)
+		}
+	}
+	return nil
+}
+
+// CreatePVC 根据 PVCSource 生成 PVC，accessModes 默认为 ReadWriteOnce
+func CreatePVC(namespace string, pvc *model.PVCSource) *corev1.PersistentVolumeClaim {
+	accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	if len(pvc.AccessModes) > 0 {
+		accessModes = nil
+		for _, mode := range pvc.AccessModes {
+			accessModes = append(accessModes, corev1.PersistentVolumeAccessMode(mode))
+		}
+	}
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pvc.ClaimName,
+			Namespace: namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: accessModes,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(pvc.Size)},
+			},
+		},
+	}
+	if pvc.StorageClassName != "" {
+		claim.Spec.StorageClassName = ptr.To(pvc.StorageClassName)
+	}
+	return claim
+}
@@ -1,5 +1,12 @@
 package model
 
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type ClusterType string
 
 const (
@@ -18,16 +25,60 @@ type ClusterConfig struct {
 	VolcanoWorkerQueue string `json:"workerQueue,omitempty"` // Volcano 特有的字段
 
 	Machines []MachineConfig `json:"machines"`
+	// 已下载的模型（ModelArtifact）名称，与任务在同一个 namespace，以只读方式挂载到所有机器，
+	// 代替在机器上配置 label 为 model 的卷
+	Model string `json:"model,omitempty"`
+	// 所有机器 Pod 模板的默认配置，机器上的配置优先
+	PodDefaults *PodTemplateConfig `json:"podDefaults,omitempty"`
+	// Ray autoscaler 配置，开启后 group 类型的机器在 minReplicas 与 maxReplicas 之间伸缩
+	Autoscaler *AutoscalerConfig `json:"autoscaler,omitempty"`
+	// 创建前按 NodeResourceInfo 的剩余资源检查所有机器能否放置，不能放置时拒绝创建
+	EnforcePlacement bool `json:"enforcePlacement,omitempty"`
 	// 可选的 Job 配置
 	Job *JobConfig `json:"job,omitempty"`
 }
 
 type JobConfig struct {
-	Kind          string    `json:"kind,omitempty"`          // 任务种类, vllmOnRayAutoJob(自动构建code与模型地址)
+	Kind          string    `json:"kind,omitempty"`          // 任务种类，见 job.SupportedJobKinds，为空时直接运行 cmd
 	Name          string    `json:"name"`                    // Job 名称
 	Cmd           string    `json:"cmd,omitempty"`           // Job 执行的命令
 	TargetCluster string    `json:"targetCluster,omitempty"` // 目标集群（可选）
 	Args          []ArgItem `json:"args,omitempty"`          // 自定义参数，适用于修改运行脚本，cmd会运行一个脚本
+	// 用户保存在 ConfigMap 中的运行代码模板，替换任务种类的默认模板
+	RunCodeTemplate *RunCodeTemplateSource `json:"runCodeTemplate,omitempty"`
+	// 推理服务的暴露方式，为空时只创建 ClusterIP Service
+	Expose *ExposeConfig `json:"expose,omitempty"`
+	// 模型部署等内部接口设置的 RayJob label 与 owner，不能通过请求设置
+	Labels          map[string]string       `json:"-"`
+	OwnerReferences []metav1.OwnerReference `json:"-"`
+}
+
+// ExposeConfig 推理服务 head Service 之外的暴露方式
+type ExposeConfig struct {
+	Mode             string            `json:"mode,omitempty"`             // ClusterIP（默认）| NodePort | Ingress | Istio
+	NodePort         int32             `json:"nodePort,omitempty"`         // NodePort 模式的端口，为空时由 Kubernetes 分配
+	Host             string            `json:"host,omitempty"`             // Ingress 与 Istio 模式的域名
+	Path             string            `json:"path,omitempty"`             // Ingress 与 Istio 模式的路径前缀，默认为 /
+	IngressClassName string            `json:"ingressClassName,omitempty"` // 为空时使用集群默认的 IngressClass
+	Annotations      map[string]string `json:"annotations,omitempty"`      // Ingress 的注解，如路径改写
+	Gateway          string            `json:"gateway,omitempty"`          // 已有的 Istio Gateway，namespace/name，为空时为任务创建 Gateway
+	GatewaySelector  map[string]string `json:"gatewaySelector,omitempty"`  // 创建 Gateway 时的 selector，默认为 istio: ingressgateway
+}
+
+// AutoscalerConfig KubeRay 内置 autoscaler 的配置
+type AutoscalerConfig struct {
+	Enabled            bool   `json:"enabled"`
+	IdleTimeoutSeconds *int32 `json:"idleTimeoutSeconds,omitempty"` // 空闲 worker 缩容前等待的时间，默认为 60
+	UpscalingMode      string `json:"upscalingMode,omitempty"`      // Default（默认）| Aggressive | Conservative
+	CPU                string `json:"cpu,omitempty"`                // autoscaler 容器的 CPU，默认为 500m
+	Memory             string `json:"memory,omitempty"`             // autoscaler 容器的内存，默认为 512Mi
+}
+
+// RunCodeTemplateSource 运行代码模板所在的 ConfigMap，与任务在同一个 namespace
+type RunCodeTemplateSource struct {
+	ConfigMap string `json:"configMap"`
+	Key       string `json:"key,omitempty"` // 默认为 runcode.py.tmpl
+	Content   string `json:"-"`             // 创建任务前从 ConfigMap 读取
 }
 type ArgItem struct {
 	Label  map[string]string `json:"label"`
@@ -58,6 +109,25 @@ type MachineConfig struct {
 
 	// 以下字段用来挂载卷
 	Volumes []VolumeConfig `json:"volumes,omitempty"` // 卷挂载配置
+
+	// Pod 模板的其他配置
+	PodTemplateConfig
+}
+
+// PodTemplateConfig head 与 worker Pod 模板的配置，容器相关的字段作用于 Ray 容器
+type PodTemplateConfig struct {
+	Env                []corev1.EnvVar            `json:"env,omitempty"`
+	NodeSelector       map[string]string          `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration        `json:"tolerations,omitempty"`
+	Affinity           *corev1.Affinity           `json:"affinity,omitempty"`
+	SecurityContext    *corev1.SecurityContext    `json:"securityContext,omitempty"`    // Ray 容器的 securityContext
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"` // Pod 的 securityContext
+	ImagePullSecrets   []string                   `json:"imagePullSecrets,omitempty"`   // Secret 名称
+	ShmSize            string                     `json:"shmSize,omitempty"`            // 以内存为介质的 /dev/shm 大小，如 16Gi，vLLM 多卡推理需要
+	ServiceAccountName string                     `json:"serviceAccountName,omitempty"`
+	ExtraContainers    []corev1.Container         `json:"extraContainers,omitempty"` // Ray 容器之外的 sidecar 容器
+	// 最后以 strategic merge patch 的方式应用到 PodTemplateSpec，如 {"spec": {"containers": [{"name": "worker", ...}]}}
+	PodTemplatePatch json.RawMessage `json:"podTemplatePatch,omitempty"`
 }
 
 type CustomResource struct {
@@ -66,21 +136,65 @@ type CustomResource struct {
 }
 
 type VolumeConfig struct {
-	Name      string            `json:"name"`            // 卷名称
-	Label     map[string]string `json:"label,omitempty"` // 挂载标签(比如model挂载代表模型，runcode代表运行代码)
-	MountPath string            `json:"mountPath"`       // 挂载路径
-	Source    VolumeSource      `json:"source"`          // 卷来源
+	Name      string            `json:"name"`               // 卷名称
+	Label     map[string]string `json:"label,omitempty"`    // 挂载标签(比如model挂载代表模型，runcode代表运行代码)
+	MountPath string            `json:"mountPath"`          // 挂载路径
+	SubPath   string            `json:"subPath,omitempty"`  // 只挂载卷中的子目录
+	ReadOnly  bool              `json:"readOnly,omitempty"` // 只读挂载，ConfigMap 与 Secret 总是只读
+	Source    VolumeSource      `json:"source"`             // 卷来源
 }
 
-// VolumeSource 定义了 PVC 或 ConfigMap 作为卷的来源
+// VolumeSource 卷的来源，只能设置一种
 type VolumeSource struct {
 	PVC       *PVCSource       `json:"pvc,omitempty"`       // 持久化存储卷
 	ConfigMap *ConfigMapSource `json:"configMap,omitempty"` // ConfigMap 作为存储卷
+	Secret    *SecretSource    `json:"secret,omitempty"`
+	EmptyDir  *EmptyDirSource  `json:"emptyDir,omitempty"`
+	HostPath  *HostPathSource  `json:"hostPath,omitempty"` // 需要管理员开启，见 JobOptions.HostPath
+	NFS       *NFSSource       `json:"nfs,omitempty"`
+	CSI       *CSISource       `json:"csi,omitempty"` // CSI 临时卷
 }
 
 // PVCSource 表示 PVC 相关信息
 type PVCSource struct {
 	ClaimName string `json:"claimName"` // PVC 名称
+	// 以下字段设置 size 时，PVC 不存在则在创建任务前创建，删除任务时不删除
+	StorageClassName string   `json:"storageClassName,omitempty"` // 为空时使用集群默认的 StorageClass
+	Size             string   `json:"size,omitempty"`             // 如 500Gi
+	AccessModes      []string `json:"accessModes,omitempty"`      // 默认为 ReadWriteOnce
+}
+
+// SecretSource 表示 Secret 相关信息，items 为空时挂载所有键
+type SecretSource struct {
+	SecretName  string          `json:"secretName"`
+	Items       []KeyToPathItem `json:"items,omitempty"`
+	DefaultMode *int32          `json:"defaultMode,omitempty"`
+}
+
+// EmptyDirSource 临时目录，medium 为 Memory 时使用 tmpfs，占用容器的内存
+type EmptyDirSource struct {
+	Medium    string `json:"medium,omitempty"`    // 空或 Memory
+	SizeLimit string `json:"sizeLimit,omitempty"` // 如 16Gi
+}
+
+// HostPathSource 节点上的目录
+type HostPathSource struct {
+	Path string `json:"path"`
+	Type string `json:"type,omitempty"` // 如 Directory、DirectoryOrCreate，为空时不检查
+}
+
+// NFSSource NFS 服务器上的目录，readOnly 使用 VolumeConfig 的设置
+type NFSSource struct {
+	Server string `json:"server"`
+	Path   string `json:"path"`
+}
+
+// CSISource CSI 临时卷，由 CSI 驱动在 Pod 创建时提供
+type CSISource struct {
+	Driver               string            `json:"driver"`
+	FSType               string            `json:"fsType,omitempty"`
+	VolumeAttributes     map[string]string `json:"volumeAttributes,omitempty"`
+	NodePublishSecretRef string            `json:"nodePublishSecretRef,omitempty"` // Secret 名称
 }
 
 // ConfigMapSource 表示 ConfigMap 相关信息
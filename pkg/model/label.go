@@ -1,3 +1,6 @@
 package model
 
 const ModelUniqueID = "model-unique-id"
+
+// JobKindLabel RayJob 上记录的任务种类，用于判断是否需要探测推理服务
+const JobKindLabel = "opsflow.io/job-kind"
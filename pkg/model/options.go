@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// JobOptions 部署时配置的创建与探测任务的选项，由 main 读取环境变量后通过 core.AppContext 传给各个请求
+type JobOptions struct {
+	ModelConfigLoader ModelConfigLoader // 为空时不读取模型配置，只按 GPU 数量计算并行方式
+	EndpointProbe     EndpointProbeOptions
+	HostPath          HostPathPolicy
+}
+
+// HostPathPolicy 是否允许任务挂载节点上的目录，默认不允许
+type HostPathPolicy struct {
+	Enabled         bool
+	AllowedPrefixes []string // 为空时允许所有目录
+}
+
+// EndpointProbeOptions 探测推理服务的设置
+type EndpointProbeOptions struct {
+	Timeout       time.Duration // 单个请求的超时
+	DegradedAfter time.Duration // 持续 Failing 超过该时间时标记为 degraded
+	LoadTimeout   time.Duration // 从没有就绪过的接口加载超过该时间时标记为 degraded，为 0 时不限制
+}
+
+// ModelConfig 模型 config.json 中与并行方式有关的字段，多模态模型在 text_config 中
+type ModelConfig struct {
+	NumAttentionHeads int          `json:"num_attention_heads"`
+	NumKeyValueHeads  int          `json:"num_key_value_heads"`
+	NumHiddenLayers   int          `json:"num_hidden_layers"`
+	TextConfig        *ModelConfig `json:"text_config"`
+}
+
+// ModelConfigLoader 按 Pod 中的模型路径读取 config.json，读取不到时返回 nil, nil
+type ModelConfigLoader func(modelPath string) (*ModelConfig, error)
@@ -1,8 +1,12 @@
 package model
 
+import "time"
+
 type RayJobResponse struct {
 	Namespace string `json:"namespace"`
 	JobID     string `json:"jobId"`
+	// 任务种类计算出的并行方式，目前只有 vLLM 推理有值
+	Layout *ParallelLayout `json:"layout,omitempty"`
 }
 
 func NewRayJobResponse(namespace, jobID string) *RayJobResponse {
@@ -11,3 +15,37 @@ func NewRayJobResponse(namespace, jobID string) *RayJobResponse {
 		JobID:     jobID,
 	}
 }
+
+// ParallelLayout 多机 vLLM 推理的并行方式，TP × PP × DP 等于 GPU 总数
+type ParallelLayout struct {
+	TensorParallelSize   int  `json:"tensorParallelSize"`
+	PipelineParallelSize int  `json:"pipelineParallelSize"`
+	DataParallelSize     int  `json:"dataParallelSize"`
+	GPUMachines          int  `json:"gpuMachines"`        // 有 GPU 的机器数，没有 GPU 的机器（如 CPU head）不参与推理
+	GPUsPerMachine       int  `json:"gpusPerMachine"`     // 每个机器的 GPU 数，所有 GPU 机器必须相同
+	ModelConfigChecked   bool `json:"modelConfigChecked"` // 是否读取到模型的 config.json 并检查了 attention heads 与层数
+}
+
+// EndpointStatus 推理服务 OpenAI 兼容接口的探测结果
+type EndpointStatus struct {
+	State        string     `json:"state"` // Starting | LoadingModel | Ready | Failing
+	URL          string     `json:"url,omitempty"`
+	Models       []string   `json:"models,omitempty"` // /v1/models 返回的模型名
+	Message      string     `json:"message,omitempty"`
+	FailingSince *time.Time `json:"failingSince,omitempty"`
+	LoadingSince *time.Time `json:"loadingSince,omitempty"` // 第一次探测到加载中的时间
+	Degraded     bool       `json:"degraded"`               // 持续 Failing 或加载超过设置的时间
+	ProbedAt     *time.Time `json:"probedAt,omitempty"`     // 最近一次探测的时间
+}
+
+// ExposureStatus 任务推理服务的暴露方式与创建的资源
+type ExposureStatus struct {
+	Mode           string `json:"mode"`
+	Service        string `json:"service,omitempty"`
+	NodePort       int32  `json:"nodePort,omitempty"`
+	Ingress        string `json:"ingress,omitempty"`
+	Gateway        string `json:"gateway,omitempty"` // namespace/name
+	VirtualService string `json:"virtualService,omitempty"`
+	Host           string `json:"host,omitempty"`
+	Path           string `json:"path,omitempty"`
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 
-	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
 	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
 	"google.golang.org/grpc"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -26,12 +28,13 @@ func CheckNodeExistsFromBatchList(nodeName string, batchNodesList *corev1.NodeLi
 }
 
 type BatchUpdateCreateOptions struct {
-	Clientset            kubernetes.Interface
-	CRDClient            *dynamic.NamespaceableResourceInterface
-	GRPCClient           *grpc.ClientConn
-	Nodes                *corev1.NodeList
-	ResourceNamesToTrack map[string]bool
-	Parallelism          int // 最大并行度，0 或 负值时表示无限制
+	Clientset   kubernetes.Interface
+	CRDClient   typedv1beta1.NodeResourceInfoInterface
+	GRPCClient  *grpc.ClientConn
+	Nodes       *corev1.NodeList
+	LoadOptions resourceinfo.LoadOptions
+	Recorder    history.Recorder // 记录节点变化，为空时不记录
+	Parallelism int              // 最大并行度，0 或 负值时表示无限制
 }
 
 // 批量添加 NodeResourceInfo
@@ -63,23 +66,27 @@ func BatchAddNodeResourceInfo(opts BatchUpdateCreateOptions) error {
 
 			log.Printf("处理节点: %s", n.Name)
 
-			nodeResourceInfo := &v1alpha1.NodeResourceInfo{
+			nodeResourceInfo := &v1beta1.NodeResourceInfo{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: n.Name,
 				},
-				Spec: v1alpha1.NodeResourceInfoSpec{
+				Spec: v1beta1.NodeResourceInfoSpec{
 					NodeName:  n.Name,
-					Resources: map[string]v1alpha1.ResourceInfo{},
+					Resources: map[string]v1beta1.ResourceCapacity{},
 				},
 			}
 
 			nodeQuery := resourceinfo.NodeResourceQuery{
-				Clientset:            opts.Clientset,
-				Node:                 &n,
-				ResourceNamesToTrack: opts.ResourceNamesToTrack,
+				Clientset:   opts.Clientset,
+				Node:        &n,
+				LoadOptions: opts.LoadOptions,
 			}
 
-			resourceinfo.LoadNodeResourceInfoFromNode(nodeQuery, nodeResourceInfo)
+			// 资源数据不完整时不能写入 CRD，否则会把使用量记为 0 并通知 manager
+			if err := resourceinfo.LoadNodeResourceInfoFromNode(nodeQuery, nodeResourceInfo); err != nil {
+				errCh <- fmt.Errorf("节点 %s 资源信息加载失败: %w", n.Name, err)
+				return
+			}
 
 			// Load node status
 			status := GetNodeStatus(&node)
@@ -89,15 +96,21 @@ func BatchAddNodeResourceInfo(opts BatchUpdateCreateOptions) error {
 			os := GetOSImage(&node)
 			kernelVersion := GetKernelVersion(&node)
 			containerRuntimeVersion := GetContainerRuntimeVersion(&node)
-			nodeResourceInfo.Spec.Status = status
+			nodeResourceInfo.Status.NodeStatus = status
+			nodeResourceInfo.Status.Health = GetNodeHealth(&n)
+			nodeResourceInfo.Status.NodeConditions = GetNodeConditions(&n)
 			nodeResourceInfo.Spec.Roles = nodeRoles
 			nodeResourceInfo.Spec.ScheduleVersion = kubeletVersion
 			nodeResourceInfo.Spec.InternalIp = internalIP
 			nodeResourceInfo.Spec.OS = os
 			nodeResourceInfo.Spec.KernelVersion = kernelVersion
 			nodeResourceInfo.Spec.ContainerRuntime = containerRuntimeVersion
+			nodeResourceInfo.Spec.Labels = GetNodeLabels(&n)
+			nodeResourceInfo.Spec.Annotations = GetNodeAnnotations(&n)
+			nodeResourceInfo.Spec.Taints = GetNodeTaints(&n)
+			nodeResourceInfo.Status.Conditions = []metav1.Condition{GetNodeReadyCondition(&n)}
 
-			err := resourceinfo.UpdateCreateNodeResourceInfo(*opts.CRDClient, opts.GRPCClient, nodeResourceInfo, string(namespace.UID))
+			err := resourceinfo.UpdateCreateNodeResourceInfo(opts.CRDClient, opts.GRPCClient, nodeResourceInfo, string(namespace.UID), opts.Recorder)
 			if err != nil {
 				errCh <- fmt.Errorf("节点 %s 处理失败: %w", n.Name, err)
 			}
@@ -172,6 +185,52 @@ func GetNodeStatus(node *corev1.Node) string {
 	return strings.Join(statuses, ",")
 }
 
+// GetNodeConditions 返回 Node 上报的所有 conditions，不包含心跳时间与 message，避免每个周期都产生变化
+func GetNodeConditions(node *corev1.Node) []v1beta1.NodeCondition {
+	if len(node.Status.Conditions) == 0 {
+		return nil
+	}
+
+	conditions := make([]v1beta1.NodeCondition, 0, len(node.Status.Conditions))
+	for _, condition := range node.Status.Conditions {
+		conditions = append(conditions, v1beta1.NodeCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			LastTransitionTime: condition.LastTransitionTime,
+		})
+	}
+	return conditions
+}
+
+// GetNodeHealth 根据 conditions 与 unschedulable 推导节点健康状态。
+// Ready 之外的 condition（MemoryPressure、DiskPressure、PIDPressure、NetworkUnavailable
+// 以及 node-problem-detector 上报的 condition）为 True 时表示异常；
+// 节点被 cordon 时优先返回 Cordoned，具体的异常 condition 仍可从 nodeConditions 中看到
+func GetNodeHealth(node *corev1.Node) v1beta1.NodeHealth {
+	ready := corev1.ConditionUnknown
+	degraded := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status
+		} else if condition.Status == corev1.ConditionTrue {
+			degraded = true
+		}
+	}
+
+	switch {
+	case ready == corev1.ConditionFalse:
+		return v1beta1.NodeHealthNotReady
+	case ready != corev1.ConditionTrue:
+		return v1beta1.NodeHealthUnknown
+	case node.Spec.Unschedulable:
+		return v1beta1.NodeHealthCordoned
+	case degraded:
+		return v1beta1.NodeHealthDegraded
+	}
+	return v1beta1.NodeHealthReady
+}
+
 func GetNodeRoles(node *corev1.Node) string {
 	const roleLabelPrefix = "node-role.kubernetes.io/"
 	var roles []string
@@ -211,3 +270,91 @@ func GetKernelVersion(node *corev1.Node) string {
 func GetContainerRuntimeVersion(node *corev1.Node) string {
 	return node.Status.NodeInfo.ContainerRuntimeVersion
 }
+
+// 不同步给 manager 的标签前缀，这些标签数量多且与调度无关
+var ignoredLabelPrefixes = []string{
+	"beta.kubernetes.io/",
+	"feature.node.kubernetes.io/",
+}
+
+// 不同步给 manager 的注解前缀，大多由各组件写入且频繁变化
+var ignoredAnnotationPrefixes = []string{
+	"kubectl.kubernetes.io/",
+	"kubeadm.alpha.kubernetes.io/",
+	"node.alpha.kubernetes.io/",
+	"volumes.kubernetes.io/",
+	"csi.volume.kubernetes.io/",
+	"nfd.node.kubernetes.io/",
+	"projectcalico.org/",
+	"flannel.alpha.coreos.com/",
+	"management.cattle.io/",
+	"rke2.io/",
+	"k3s.io/",
+}
+
+func filterByPrefix(source map[string]string, ignoredPrefixes []string) map[string]string {
+	if len(source) == 0 {
+		return nil
+	}
+
+	filtered := make(map[string]string, len(source))
+	for key, value := range source {
+		if slices.ContainsFunc(ignoredPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(key, prefix)
+		}) {
+			continue
+		}
+		filtered[key] = value
+	}
+
+	if len(filtered) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+func GetNodeLabels(node *corev1.Node) map[string]string {
+	return filterByPrefix(node.Labels, ignoredLabelPrefixes)
+}
+
+func GetNodeAnnotations(node *corev1.Node) map[string]string {
+	return filterByPrefix(node.Annotations, ignoredAnnotationPrefixes)
+}
+
+func GetNodeTaints(node *corev1.Node) []v1beta1.NodeTaint {
+	if len(node.Spec.Taints) == 0 {
+		return nil
+	}
+
+	taints := make([]v1beta1.NodeTaint, 0, len(node.Spec.Taints))
+	for _, taint := range node.Spec.Taints {
+		taints = append(taints, v1beta1.NodeTaint{
+			Key:    taint.Key,
+			Value:  taint.Value,
+			Effect: string(taint.Effect),
+		})
+	}
+	return taints
+}
+
+// 根据 Node 的 Ready condition 生成 NodeResourceInfo 的 Ready condition
+func GetNodeReadyCondition(node *corev1.Node) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    v1beta1.ConditionReady,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "NodeStatusUnknown",
+		Message: "节点未上报 Ready 状态",
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type != corev1.NodeReady {
+			continue
+		}
+		condition.Status = metav1.ConditionStatus(c.Status)
+		if c.Reason != "" {
+			condition.Reason = c.Reason
+		}
+		condition.Message = c.Message
+		break
+	}
+	return condition
+}
@@ -0,0 +1,228 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+)
+
+// ChangeType 节点变化的类型，同时作为 Kubernetes Event 的 reason
+type ChangeType string
+
+const (
+	NodeAdded        ChangeType = "NodeAdded"
+	NodeRemoved      ChangeType = "NodeRemoved"
+	StatusChanged    ChangeType = "StatusChanged"    // 节点健康状态变化，如 Ready -> NotReady
+	ConditionChanged ChangeType = "ConditionChanged" // 某个 Node condition 的状态或原因变化，如 DiskPressure 变为 True
+	ResourceChanged  ChangeType = "ResourceChanged"  // 资源总量或可分配量变化
+	KernelUpgraded   ChangeType = "KernelUpgraded"
+	RuntimeUpgraded  ChangeType = "RuntimeUpgraded"
+	KubeletUpgraded  ChangeType = "KubeletUpgraded"
+	SpecChanged      ChangeType = "SpecChanged" // 其他节点描述变化，如标签、污点、IP
+)
+
+// NodeChange 一次检测到的节点变化
+type NodeChange struct {
+	ID    string     `json:"id,omitempty"` // Redis stream 中的 ID，写入后才有
+	Node  string     `json:"node"`
+	Type  ChangeType `json:"type"`
+	Field string     `json:"field,omitempty"`
+	Old   string     `json:"old,omitempty"`
+	New   string     `json:"new,omitempty"`
+	Time  time.Time  `json:"time"`
+}
+
+func (c NodeChange) Message() string {
+	switch c.Type {
+	case NodeAdded:
+		return fmt.Sprintf("节点 %s 已加入集群", c.Node)
+	case NodeRemoved:
+		return fmt.Sprintf("节点 %s 已从集群中移除", c.Node)
+	case ResourceChanged:
+		return fmt.Sprintf("资源 %s 发生变化: %s -> %s", c.Field, c.Old, c.New)
+	case ConditionChanged:
+		return fmt.Sprintf("condition %s 发生变化: %s -> %s", c.Field, c.Old, c.New)
+	default:
+		return fmt.Sprintf("%s 发生变化: %s -> %s", c.Field, c.Old, c.New)
+	}
+}
+
+// Recorder 记录节点变化，如写入 Kubernetes Event 或 Redis stream
+type Recorder interface {
+	Record(ctx context.Context, nodeResourceInfo *v1beta1.NodeResourceInfo, changes []NodeChange) error
+}
+
+// Recorders 依次写入多个 Recorder，某个失败不影响其他
+type Recorders []Recorder
+
+func (r Recorders) Record(ctx context.Context, nodeResourceInfo *v1beta1.NodeResourceInfo, changes []NodeChange) error {
+	var errs []error
+	for _, recorder := range r {
+		if err := recorder.Record(ctx, nodeResourceInfo, changes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func NewNodeAddedChange(nodeName string) NodeChange {
+	return NodeChange{Node: nodeName, Type: NodeAdded, Time: time.Now()}
+}
+
+func NewNodeRemovedChange(nodeName string) NodeChange {
+	return NodeChange{Node: nodeName, Type: NodeRemoved, Time: time.Now()}
+}
+
+// DiffSpec 比较 CRD 中的节点描述与新采集的节点描述，返回所有变化
+func DiffSpec(existing, observed *v1beta1.NodeResourceInfo) []NodeChange {
+	now := time.Now()
+	var changes []NodeChange
+	add := func(changeType ChangeType, field string, old, new any) {
+		changes = append(changes, NodeChange{
+			Node:  observed.Spec.NodeName,
+			Type:  changeType,
+			Field: field,
+			Old:   formatValue(old),
+			New:   formatValue(new),
+			Time:  now,
+		})
+	}
+
+	oldSpec, newSpec := &existing.Spec, &observed.Spec
+	if oldSpec.ScheduleVersion != newSpec.ScheduleVersion {
+		add(KubeletUpgraded, "scheduleVersion", oldSpec.ScheduleVersion, newSpec.ScheduleVersion)
+	}
+	if oldSpec.KernelVersion != newSpec.KernelVersion {
+		add(KernelUpgraded, "kernelVersion", oldSpec.KernelVersion, newSpec.KernelVersion)
+	}
+	if oldSpec.ContainerRuntime != newSpec.ContainerRuntime {
+		add(RuntimeUpgraded, "containerRuntime", oldSpec.ContainerRuntime, newSpec.ContainerRuntime)
+	}
+	if oldSpec.InternalIp != newSpec.InternalIp {
+		add(SpecChanged, "internalIp", oldSpec.InternalIp, newSpec.InternalIp)
+	}
+	if oldSpec.OS != newSpec.OS {
+		add(SpecChanged, "os", oldSpec.OS, newSpec.OS)
+	}
+	if oldSpec.Roles != newSpec.Roles {
+		add(SpecChanged, "roles", oldSpec.Roles, newSpec.Roles)
+	}
+	if !maps.Equal(oldSpec.Labels, newSpec.Labels) {
+		add(SpecChanged, "labels", oldSpec.Labels, newSpec.Labels)
+	}
+	if !maps.Equal(oldSpec.Annotations, newSpec.Annotations) {
+		add(SpecChanged, "annotations", oldSpec.Annotations, newSpec.Annotations)
+	}
+	if !slices.Equal(oldSpec.Taints, newSpec.Taints) {
+		add(SpecChanged, "taints", oldSpec.Taints, newSpec.Taints)
+	}
+	if !reflect.DeepEqual(oldSpec.GPU, newSpec.GPU) {
+		add(SpecChanged, "gpu", oldSpec.GPU, newSpec.GPU)
+	}
+
+	// 按资源名排序保证变化顺序稳定
+	names := slices.Sorted(maps.Keys(oldSpec.Resources))
+	for name := range newSpec.Resources {
+		if _, ok := oldSpec.Resources[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		oldResource, oldOk := oldSpec.Resources[name]
+		newResource, newOk := newSpec.Resources[name]
+		if oldOk && newOk && oldResource == newResource {
+			continue
+		}
+		var old, new string
+		if oldOk {
+			old = formatCapacity(oldResource)
+		}
+		if newOk {
+			new = formatCapacity(newResource)
+		}
+		add(ResourceChanged, name, old, new)
+	}
+	return changes
+}
+
+// DiffStatus 返回节点健康状态与各 condition 的变化。
+// 旧版本写入的 CRD 没有 health，第一次补充时不记录变化
+func DiffStatus(existing *v1beta1.NodeResourceInfo, observed v1beta1.NodeResourceInfoStatus) []NodeChange {
+	if existing.Status.Health == "" {
+		return nil
+	}
+
+	now := time.Now()
+	var changes []NodeChange
+	add := func(changeType ChangeType, field, old, new string) {
+		changes = append(changes, NodeChange{
+			Node:  existing.Name,
+			Type:  changeType,
+			Field: field,
+			Old:   old,
+			New:   new,
+			Time:  now,
+		})
+	}
+
+	if existing.Status.Health != observed.Health {
+		add(StatusChanged, "health", string(existing.Status.Health), string(observed.Health))
+	}
+
+	previous := map[string]v1beta1.NodeCondition{}
+	for _, condition := range existing.Status.NodeConditions {
+		previous[condition.Type] = condition
+	}
+	for _, condition := range observed.NodeConditions {
+		old, ok := previous[condition.Type]
+		delete(previous, condition.Type)
+		if ok && old.Status == condition.Status && old.Reason == condition.Reason {
+			continue
+		}
+		var oldValue string
+		if ok {
+			oldValue = formatCondition(old)
+		}
+		add(ConditionChanged, condition.Type, oldValue, formatCondition(condition))
+	}
+	// 不再上报的 condition
+	for _, conditionType := range slices.Sorted(maps.Keys(previous)) {
+		add(ConditionChanged, conditionType, formatCondition(previous[conditionType]), "")
+	}
+	return changes
+}
+
+func formatCondition(condition v1beta1.NodeCondition) string {
+	if condition.Reason == "" {
+		return "status=" + condition.Status
+	}
+	return fmt.Sprintf("status=%s,reason=%s", condition.Status, condition.Reason)
+}
+
+func formatCapacity(capacity v1beta1.ResourceCapacity) string {
+	return fmt.Sprintf("total=%s,allocatable=%s", capacity.Total, capacity.Allocatable)
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case *v1beta1.GPUInfo:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%+v", *v)
+	default:
+		if reflect.ValueOf(value).Len() == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%+v", value)
+	}
+}
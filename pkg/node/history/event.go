@@ -0,0 +1,86 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const eventComponent = "opsflow"
+
+// EventRecorder 把节点变化写为 NodeResourceInfo 上的 Kubernetes Event，
+// 集群级对象的 Event 写入 default namespace，kubectl describe 时可以看到
+type EventRecorder struct {
+	Clientset kubernetes.Interface
+	Namespace string // 为空时使用 default
+}
+
+func (r *EventRecorder) Record(ctx context.Context, nodeResourceInfo *v1beta1.NodeResourceInfo, changes []NodeChange) error {
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	var errs []error
+	for i, change := range changes {
+		eventTime := metav1.NewTime(change.Time)
+		event := &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				// 同一次检测的变化时间相同，加上序号避免重名
+				Name:      fmt.Sprintf("%s.%x", nodeResourceInfo.Name, change.Time.UnixNano()+int64(i)),
+				Namespace: namespace,
+			},
+			InvolvedObject: corev1.ObjectReference{
+				APIVersion:      v1beta1.SchemeGroupVersion.String(),
+				Kind:            "NodeResourceInfo",
+				Name:            nodeResourceInfo.Name,
+				UID:             nodeResourceInfo.UID,
+				ResourceVersion: nodeResourceInfo.ResourceVersion,
+			},
+			Reason:         string(change.Type),
+			Message:        change.Message(),
+			Type:           eventType(change),
+			Source:         corev1.EventSource{Component: eventComponent},
+			FirstTimestamp: eventTime,
+			LastTimestamp:  eventTime,
+			Count:          1,
+		}
+		if _, err := r.Clientset.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("无法创建节点 %s 的 %s 事件: %w", nodeResourceInfo.Name, change.Type, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 节点移除、健康状态变为异常或 condition 进入异常状态时为 Warning
+func eventType(change NodeChange) string {
+	switch change.Type {
+	case NodeRemoved:
+		return corev1.EventTypeWarning
+	case StatusChanged:
+		if change.New != string(v1beta1.NodeHealthReady) && change.New != string(v1beta1.NodeHealthCordoned) {
+			return corev1.EventTypeWarning
+		}
+	case ConditionChanged:
+		// Ready 不为 True 或其他 condition 为 True 时表示异常
+		isTrue := strings.HasPrefix(change.New, "status="+string(corev1.ConditionTrue))
+		if change.Field == string(corev1.NodeReady) {
+			return eventTypeIf(!isTrue)
+		}
+		return eventTypeIf(isTrue)
+	}
+	return corev1.EventTypeNormal
+}
+
+func eventTypeIf(warning bool) string {
+	if warning {
+		return corev1.EventTypeWarning
+	}
+	return corev1.EventTypeNormal
+}
@@ -0,0 +1,129 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	nodeStreamPrefix = "opsflow:node:history:" // 每个节点的变化历史
+	feedStream       = "opsflow:node:changes"  // 所有节点的变化，用于实时推送
+	DefaultMaxLen    = 1000
+)
+
+// RedisStore 把节点变化写入有长度上限的 Redis stream，每个节点一个 stream，另有一个汇总 stream
+type RedisStore struct {
+	Client redis.Cmdable
+	MaxLen int64 // 每个 stream 保留的最大条数，近似裁剪，0 时使用 DefaultMaxLen
+}
+
+func nodeStream(nodeName string) string {
+	return nodeStreamPrefix + nodeName
+}
+
+func (s *RedisStore) maxLen() int64 {
+	if s.MaxLen <= 0 {
+		return DefaultMaxLen
+	}
+	return s.MaxLen
+}
+
+func (s *RedisStore) Record(ctx context.Context, nodeResourceInfo *v1beta1.NodeResourceInfo, changes []NodeChange) error {
+	for _, change := range changes {
+		data, err := json.Marshal(change)
+		if err != nil {
+			return fmt.Errorf("无法序列化节点变化: %w", err)
+		}
+		for _, stream := range []string{nodeStream(change.Node), feedStream} {
+			if err := s.Client.XAdd(ctx, &redis.XAddArgs{
+				Stream: stream,
+				MaxLen: s.maxLen(),
+				Approx: true,
+				Values: map[string]any{"change": data},
+			}).Err(); err != nil {
+				return fmt.Errorf("无法写入节点变化到 %s: %w", stream, err)
+			}
+		}
+	}
+	return nil
+}
+
+// List 按时间倒序返回节点的变化，before 为上一页最后一条的 ID，为空时从最新开始
+func (s *RedisStore) List(ctx context.Context, nodeName string, count int64, before string) ([]NodeChange, error) {
+	end := "+"
+	if before != "" {
+		end = "(" + before
+	}
+	messages, err := s.Client.XRevRangeN(ctx, nodeStream(nodeName), end, "-", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("无法读取节点 %s 的变化历史: %w", nodeName, err)
+	}
+	return decodeMessages(messages)
+}
+
+// LatestID 返回 stream 中最新一条的 ID，stream 为空时返回 0-0，nodeName 为空时使用汇总 stream
+func (s *RedisStore) LatestID(ctx context.Context, nodeName string) (string, error) {
+	messages, err := s.Client.XRevRangeN(ctx, s.stream(nodeName), "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("无法读取最新的节点变化: %w", err)
+	}
+	if len(messages) == 0 {
+		return "0-0", nil
+	}
+	return messages[0].ID, nil
+}
+
+// Read 阻塞读取 afterID 之后的变化，超时没有新变化时返回空，nodeName 为空时读取所有节点
+func (s *RedisStore) Read(ctx context.Context, nodeName, afterID string, block time.Duration) ([]NodeChange, error) {
+	streams, err := s.Client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{s.stream(nodeName), afterID},
+		Count:   100,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法读取节点变化: %w", err)
+	}
+
+	var changes []NodeChange
+	for _, stream := range streams {
+		decoded, err := decodeMessages(stream.Messages)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, decoded...)
+	}
+	return changes, nil
+}
+
+func (s *RedisStore) stream(nodeName string) string {
+	if nodeName == "" {
+		return feedStream
+	}
+	return nodeStream(nodeName)
+}
+
+func decodeMessages(messages []redis.XMessage) ([]NodeChange, error) {
+	changes := make([]NodeChange, 0, len(messages))
+	for _, message := range messages {
+		data, ok := message.Values["change"].(string)
+		if !ok {
+			continue
+		}
+		var change NodeChange
+		if err := json.Unmarshal([]byte(data), &change); err != nil {
+			return nil, fmt.Errorf("无法解析节点变化 %s: %w", message.ID, err)
+		}
+		change.ID = message.ID
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
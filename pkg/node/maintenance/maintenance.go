@@ -0,0 +1,309 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+	rayClusterLabel     = "ray.io/cluster"
+	rayNodeTypeLabel    = "ray.io/node-type"
+
+	DefaultDrainTimeout = 5 * time.Minute
+	MaxDrainTimeout     = 10 * time.Minute // drain 同步返回结果，等待时间不能超过该值
+	evictionRetryDelay  = 5 * time.Second
+)
+
+// ErrDrainBlocked 节点上有需要 force 或 deleteEmptyDirData 才能驱逐的 Pod，此时不做任何修改
+var ErrDrainBlocked = errors.New("节点上有需要 force 或 deleteEmptyDirData 才能驱逐的 Pod")
+
+// 驱逐结果中 Pod 的状态
+const (
+	PodWouldEvict   = "WouldEvict"   // dry-run 时会被驱逐
+	PodBlockedByPDB = "BlockedByPDB" // dry-run 时 PDB 当前不允许中断
+	PodEvicted      = "Evicted"
+	PodSkipped      = "Skipped" // DaemonSet、mirror Pod 等不需要驱逐
+	PodBlocked      = "Blocked" // 需要 force 或 deleteEmptyDirData 才能驱逐
+	PodFailed       = "Failed"
+)
+
+// DrainOptions 驱逐节点的选项
+type DrainOptions struct {
+	DryRun             bool   `json:"dryRun,omitempty"`             // 只列出受影响的 Pod，不做任何修改
+	TimeoutSeconds     int64  `json:"timeoutSeconds,omitempty"`     // 等待所有 Pod 驱逐完成的时间，为 0 时使用 DefaultDrainTimeout
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"` // 覆盖 Pod 的 terminationGracePeriodSeconds
+	Force              bool   `json:"force,omitempty"`              // 驱逐没有控制器的 Pod，这些 Pod 不会被重建
+	DeleteEmptyDirData bool   `json:"deleteEmptyDirData,omitempty"` // 驱逐使用 emptyDir 的 Pod，数据会丢失
+}
+
+// Validate 检查 timeoutSeconds 的范围
+func (opts DrainOptions) Validate() error {
+	if opts.TimeoutSeconds < 0 || time.Duration(opts.TimeoutSeconds)*time.Second > MaxDrainTimeout {
+		return fmt.Errorf("timeoutSeconds 需要在 0 到 %d 之间", int64(MaxDrainTimeout/time.Second))
+	}
+	return nil
+}
+
+// DrainPod 节点上一个 Pod 的驱逐情况
+type DrainPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Workload  string `json:"workload"` // 如 Deployment/vllm、RayCluster/demo
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+}
+
+// DrainResult 驱逐节点的结果
+type DrainResult struct {
+	Node      string     `json:"node"`
+	DryRun    bool       `json:"dryRun,omitempty"`
+	Cordoned  bool       `json:"cordoned"`  // 本次操作是否新设置了 unschedulable
+	Completed bool       `json:"completed"` // 所有需要驱逐的 Pod 都已删除
+	Pods      []DrainPod `json:"pods"`
+	Warnings  []string   `json:"warnings,omitempty"`
+}
+
+// Cordon 将节点设置为不可调度，返回是否发生了变化
+func Cordon(ctx context.Context, clientset kubernetes.Interface, nodeName string) (bool, error) {
+	return setUnschedulable(ctx, clientset, nodeName, true)
+}
+
+// Uncordon 恢复节点调度，返回是否发生了变化
+func Uncordon(ctx context.Context, clientset kubernetes.Interface, nodeName string) (bool, error) {
+	return setUnschedulable(ctx, clientset, nodeName, false)
+}
+
+func setUnschedulable(ctx context.Context, clientset kubernetes.Interface, nodeName string, unschedulable bool) (bool, error) {
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("无法获取节点 %s: %w", nodeName, err)
+	}
+	if node.Spec.Unschedulable == unschedulable {
+		return false, nil
+	}
+
+	patch := fmt.Appendf(nil, `{"spec":{"unschedulable":%t}}`, unschedulable)
+	if _, err := clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return false, fmt.Errorf("无法更新节点 %s 的 unschedulable: %w", nodeName, err)
+	}
+	log.Printf("节点 %s unschedulable 已设置为 %t", nodeName, unschedulable)
+	return true, nil
+}
+
+// Drain 先 cordon 节点，再通过 eviction API 驱逐节点上的 Pod。
+// PDB 不允许中断时持续重试直到超时，DaemonSet 与 mirror Pod 不驱逐，
+// 没有控制器或使用 emptyDir 的 Pod 需要显式允许，否则不做任何驱逐直接返回
+func Drain(ctx context.Context, clientset kubernetes.Interface, nodeName string, opts DrainOptions) (*DrainResult, error) {
+	if _, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err != nil {
+		return nil, fmt.Errorf("无法获取节点 %s: %w", nodeName, err)
+	}
+
+	pods, err := resourceinfo.ListActivePodsOnNode(clientset, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	// result.Pods 与 pods 一一对应
+	result := &DrainResult{Node: nodeName, DryRun: opts.DryRun}
+	blocked := false
+	for _, pod := range pods {
+		drainPod := DrainPod{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Workload:  workloadName(&pod),
+		}
+		status, message := podDrainStatus(&pod, opts)
+		drainPod.Status, drainPod.Message = status, message
+		if status == PodBlocked {
+			blocked = true
+		}
+		if status == PodWouldEvict {
+			if warning := rayHeadWarning(&pod); warning != "" {
+				result.Warnings = append(result.Warnings, warning)
+			}
+		}
+		result.Pods = append(result.Pods, drainPod)
+	}
+
+	if opts.DryRun {
+		if err := markPDBBlockedPods(ctx, clientset, pods, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	if blocked {
+		return result, fmt.Errorf("节点 %s: %w", nodeName, ErrDrainBlocked)
+	}
+
+	if result.Cordoned, err = Cordon(ctx, clientset, nodeName); err != nil {
+		return result, err
+	}
+
+	timeout := time.Duration(opts.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	drainCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 并发驱逐，某个 Pod 被 PDB 阻塞时不影响其他 Pod
+	var wg sync.WaitGroup
+	for i := range result.Pods {
+		if result.Pods[i].Status != PodWouldEvict {
+			continue
+		}
+		wg.Add(1)
+		go func(drainPod *DrainPod, pod *corev1.Pod) {
+			defer wg.Done()
+			if err := evictPod(drainCtx, clientset, pod, opts.GracePeriodSeconds); err != nil {
+				drainPod.Status, drainPod.Message = PodFailed, err.Error()
+				return
+			}
+			if err := waitForDeletion(drainCtx, clientset, pod); err != nil {
+				drainPod.Status, drainPod.Message = PodFailed, err.Error()
+				return
+			}
+			drainPod.Status = PodEvicted
+		}(&result.Pods[i], &pods[i])
+	}
+	wg.Wait()
+
+	result.Completed = !slices.ContainsFunc(result.Pods, func(drainPod DrainPod) bool {
+		return drainPod.Status == PodFailed
+	})
+	return result, nil
+}
+
+// 判断 Pod 是否需要驱逐
+func podDrainStatus(pod *corev1.Pod, opts DrainOptions) (string, string) {
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return PodSkipped, "mirror Pod 由 kubelet 管理"
+	}
+
+	controller := metav1.GetControllerOf(pod)
+	if controller != nil && controller.Kind == "DaemonSet" {
+		return PodSkipped, "DaemonSet 管理的 Pod"
+	}
+	if controller == nil && !opts.Force {
+		return PodBlocked, "Pod 没有控制器，驱逐后不会被重建，需要 force"
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil && !opts.DeleteEmptyDirData {
+			return PodBlocked, fmt.Sprintf("Pod 使用 emptyDir %s，驱逐后数据会丢失，需要 deleteEmptyDirData", volume.Name)
+		}
+	}
+	return PodWouldEvict, ""
+}
+
+func workloadName(pod *corev1.Pod) string {
+	workload := resourceinfo.ResolvePodWorkload(pod)
+	return workload.Kind + "/" + workload.Name
+}
+
+// RayCluster 的 head 被驱逐后整个集群会重启，正在运行的 RayJob 会失败
+func rayHeadWarning(pod *corev1.Pod) string {
+	if pod.Labels[rayNodeTypeLabel] != "head" {
+		return ""
+	}
+	return fmt.Sprintf("Pod %s/%s 是 RayCluster %s 的 head，驱逐后集群会重启，正在运行的任务会失败",
+		pod.Namespace, pod.Name, pod.Labels[rayClusterLabel])
+}
+
+// dry-run 时根据 PDB 当前允许的中断数标记会被阻塞的 Pod
+func markPDBBlockedPods(ctx context.Context, clientset kubernetes.Interface, pods []corev1.Pod, result *DrainResult) error {
+	pdbs := map[string][]policyv1.PodDisruptionBudget{}
+	for i := range result.Pods {
+		drainPod := &result.Pods[i]
+		if drainPod.Status != PodWouldEvict {
+			continue
+		}
+
+		namespacePDBs, ok := pdbs[drainPod.Namespace]
+		if !ok {
+			list, err := clientset.PolicyV1().PodDisruptionBudgets(drainPod.Namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("无法获取 namespace %s 的 PodDisruptionBudget: %w", drainPod.Namespace, err)
+			}
+			namespacePDBs = list.Items
+			pdbs[drainPod.Namespace] = namespacePDBs
+		}
+
+		pod := &pods[i]
+		for _, pdb := range namespacePDBs {
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			if pdb.Status.DisruptionsAllowed <= 0 {
+				drainPod.Status = PodBlockedByPDB
+				drainPod.Message = fmt.Sprintf("PodDisruptionBudget %s 当前不允许中断", pdb.Name)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// 通过 eviction API 驱逐 Pod，PDB 不允许时返回 429，等待后重试直到超时
+func evictPod(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod, gracePeriodSeconds *int64) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriodSeconds,
+			Preconditions:      &metav1.Preconditions{UID: &pod.UID},
+		},
+	}
+
+	for {
+		err := clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		if err == nil || apierrors.IsNotFound(err) {
+			return nil
+		}
+		if !apierrors.IsTooManyRequests(err) {
+			return fmt.Errorf("驱逐 Pod %s/%s 失败: %w", pod.Namespace, pod.Name, err)
+		}
+
+		log.Printf("Pod %s/%s 被 PodDisruptionBudget 阻止驱逐，%s 后重试: %v", pod.Namespace, pod.Name, evictionRetryDelay, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待 PodDisruptionBudget 允许驱逐 Pod %s/%s 超时: %w", pod.Namespace, pod.Name, err)
+		case <-time.After(evictionRetryDelay):
+		}
+	}
+}
+
+// 等待 Pod 被删除，同名但 UID 不同的 Pod 视为已删除
+func waitForDeletion(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		current, err := clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待 Pod %s/%s 删除超时", pod.Namespace, pod.Name)
+		case <-ticker.C:
+		}
+	}
+}
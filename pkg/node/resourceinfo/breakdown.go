@@ -0,0 +1,174 @@
+package resourceinfo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UsageBreakdownMode 控制是否记录与上报按 namespace/工作负载拆分的资源申请
+type UsageBreakdownMode string
+
+const (
+	UsageBreakdownDisabled UsageBreakdownMode = ""        // 不记录
+	UsageBreakdownStatus   UsageBreakdownMode = "status"  // 只记录到 CRD status
+	UsageBreakdownManager  UsageBreakdownMode = "manager" // 记录到 CRD status 并上报给 manager
+)
+
+func ParseUsageBreakdownMode(mode string) (UsageBreakdownMode, error) {
+	switch UsageBreakdownMode(mode) {
+	case UsageBreakdownDisabled, UsageBreakdownStatus, UsageBreakdownManager:
+		return UsageBreakdownMode(mode), nil
+	case "off", "false":
+		return UsageBreakdownDisabled, nil
+	default:
+		return UsageBreakdownDisabled, fmt.Errorf("未知的 usage breakdown 模式 %q，可选值: status, manager", mode)
+	}
+}
+
+// WorkloadRef Pod 所属的顶层工作负载
+type WorkloadRef struct {
+	Kind string
+	Name string
+}
+
+// 根据 ownerReferences 找到 Pod 所属的工作负载，ReplicaSet 通过 pod-template-hash 还原为 Deployment，
+// 没有 owner 的 Pod 视为独立的 Pod
+func ResolvePodWorkload(pod *v1.Pod) WorkloadRef {
+	// KubeRay 创建的 Pod 都带有集群名标签
+	if cluster := pod.Labels["ray.io/cluster"]; cluster != "" {
+		return WorkloadRef{Kind: "RayCluster", Name: cluster}
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return WorkloadRef{Kind: "Pod", Name: pod.Name}
+	}
+
+	if owner.Kind == "ReplicaSet" {
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return WorkloadRef{Kind: "Deployment", Name: strings.TrimSuffix(owner.Name, "-"+hash)}
+		}
+	}
+	return WorkloadRef{Kind: owner.Kind, Name: owner.Name}
+}
+
+// 按 namespace 与工作负载汇总追踪资源的 requests，结果排序保证稳定
+func ComputeUsageBreakdown(pods []v1.Pod, tracker ResourceTracker) *v1beta1.UsageBreakdown {
+	type workloadKey struct {
+		namespace string
+		WorkloadRef
+	}
+
+	namespaceRequests := map[string]v1.ResourceList{}
+	namespacePods := map[string]int32{}
+	workloadRequests := map[workloadKey]v1.ResourceList{}
+	workloadPods := map[workloadKey]int32{}
+
+	for i := range pods {
+		pod := &pods[i]
+		requests := v1.ResourceList{}
+		for resourceName, quantity := range PodRequests(pod) {
+			if tracker.Tracks(string(resourceName)) && !quantity.IsZero() {
+				requests[resourceName] = quantity
+			}
+		}
+
+		if _, ok := namespaceRequests[pod.Namespace]; !ok {
+			namespaceRequests[pod.Namespace] = v1.ResourceList{}
+		}
+		addResourceList(namespaceRequests[pod.Namespace], requests)
+		namespacePods[pod.Namespace]++
+
+		key := workloadKey{namespace: pod.Namespace, WorkloadRef: ResolvePodWorkload(pod)}
+		if _, ok := workloadRequests[key]; !ok {
+			workloadRequests[key] = v1.ResourceList{}
+		}
+		addResourceList(workloadRequests[key], requests)
+		workloadPods[key]++
+	}
+
+	breakdown := &v1beta1.UsageBreakdown{}
+	for namespace, requests := range namespaceRequests {
+		breakdown.Namespaces = append(breakdown.Namespaces, v1beta1.NamespaceUsage{
+			Namespace: namespace,
+			Pods:      namespacePods[namespace],
+			Requests:  formatResourceList(requests),
+		})
+	}
+	for key, requests := range workloadRequests {
+		breakdown.Workloads = append(breakdown.Workloads, v1beta1.WorkloadUsage{
+			Namespace: key.namespace,
+			Kind:      key.Kind,
+			Name:      key.Name,
+			Pods:      workloadPods[key],
+			Requests:  formatResourceList(requests),
+		})
+	}
+
+	slices.SortFunc(breakdown.Namespaces, func(a, b v1beta1.NamespaceUsage) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	slices.SortFunc(breakdown.Workloads, func(a, b v1beta1.WorkloadUsage) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return breakdown
+}
+
+func formatResourceList(list v1.ResourceList) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	formatted := make(map[string]string, len(list))
+	for resourceName, quantity := range list {
+		formatted[string(resourceName)] = FormatResourceQuantity(string(resourceName), quantity)
+	}
+	return formatted
+}
+
+// 生成某个资源按 namespace/工作负载拆分的 properties，只包含申请了该资源的条目
+func usageBreakdownProperties(resourceName string, breakdown *v1beta1.UsageBreakdown) map[string]any {
+	if breakdown == nil || !breakdown.ReportToManager {
+		return nil
+	}
+
+	unit := ResourceUnit(resourceName)
+	var namespaces, workloads []any
+	for _, usage := range breakdown.Namespaces {
+		if used, ok := usage.Requests[resourceName]; ok {
+			namespaces = append(namespaces, map[string]any{
+				"namespace": usage.Namespace,
+				"used":      strings.TrimSuffix(used, unit),
+			})
+		}
+	}
+	for _, usage := range breakdown.Workloads {
+		if used, ok := usage.Requests[resourceName]; ok {
+			workloads = append(workloads, map[string]any{
+				"namespace": usage.Namespace,
+				"kind":      usage.Kind,
+				"name":      usage.Name,
+				"used":      strings.TrimSuffix(used, unit),
+			})
+		}
+	}
+
+	properties := map[string]any{}
+	if len(namespaces) > 0 {
+		properties["namespaces"] = namespaces
+	}
+	if len(workloads) > 0 {
+		properties["workloads"] = workloads
+	}
+	return properties
+}
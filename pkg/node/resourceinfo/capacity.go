@@ -0,0 +1,233 @@
+package resourceinfo
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// CapacitySummary 一组节点某个资源的汇总，Free 为 allocatable 减去 requests，不小于 0
+type CapacitySummary struct {
+	Total       string `json:"total"`
+	Allocatable string `json:"allocatable"`
+	Used        string `json:"used"`
+	Free        string `json:"free"`
+}
+
+// PlaceableShape 单个节点上能放下的最大规格，Shape 为该节点所有资源的剩余量
+type PlaceableShape struct {
+	Node  string            `json:"node"`
+	Free  string            `json:"free"`
+	Shape map[string]string `json:"shape"`
+}
+
+// CapacityGroup 按节点标签分组的容量，没有该标签的节点 Value 为空
+type CapacityGroup struct {
+	Value            string                     `json:"value"`
+	Nodes            int                        `json:"nodes"`
+	Resources        map[string]CapacitySummary `json:"resources"`
+	LargestPlaceable map[string]PlaceableShape  `json:"largestPlaceable,omitempty"`
+}
+
+// ExcludedNode 未计入容量的节点
+type ExcludedNode struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"` // NotReady | Unknown | SchedulingDisabled
+}
+
+// ClusterCapacity 集群中 Ready 且可调度节点的资源汇总
+type ClusterCapacity struct {
+	Nodes            int                        `json:"nodes"`
+	Resources        map[string]CapacitySummary `json:"resources"`
+	LargestPlaceable map[string]PlaceableShape  `json:"largestPlaceable,omitempty"`
+	GroupBy          string                     `json:"groupBy,omitempty"`
+	Groups           []CapacityGroup            `json:"groups,omitempty"`
+	ExcludedNodes    []ExcludedNode             `json:"excludedNodes,omitempty"`
+}
+
+// 单个节点解析后的资源量
+type nodeCapacity struct {
+	name        string
+	labels      map[string]string
+	total       v1.ResourceList
+	allocatable v1.ResourceList
+	used        v1.ResourceList
+	free        v1.ResourceList
+}
+
+// 节点是否计入容量，不计入时返回原因。Degraded 的节点仍可调度，计入容量；
+// 旧版本写入的 CRD 没有 health，按 Ready condition 与 nodeStatus 判断
+func NodeSchedulable(nodeResourceInfo *v1beta1.NodeResourceInfo) (bool, string) {
+	switch nodeResourceInfo.Status.Health {
+	case v1beta1.NodeHealthReady, v1beta1.NodeHealthDegraded:
+		return true, ""
+	case v1beta1.NodeHealthNotReady, v1beta1.NodeHealthUnknown:
+		return false, string(nodeResourceInfo.Status.Health)
+	case v1beta1.NodeHealthCordoned:
+		return false, "SchedulingDisabled"
+	}
+
+	if !meta.IsStatusConditionTrue(nodeResourceInfo.Status.Conditions, v1beta1.ConditionReady) {
+		return false, "NotReady"
+	}
+	if slices.Contains(strings.Split(nodeResourceInfo.Status.NodeStatus, ","), "SchedulingDisabled") {
+		return false, "SchedulingDisabled"
+	}
+	return true, ""
+}
+
+// ComputeClusterCapacity 汇总 NodeResourceInfo 中的资源，groupBy 为节点标签名，为空时不分组
+func ComputeClusterCapacity(nodeResourceInfos []v1beta1.NodeResourceInfo, tracker ResourceTracker, groupBy string) *ClusterCapacity {
+	capacity := &ClusterCapacity{GroupBy: groupBy}
+
+	var nodes []nodeCapacity
+	for i := range nodeResourceInfos {
+		nodeResourceInfo := &nodeResourceInfos[i]
+		if ok, reason := NodeSchedulable(nodeResourceInfo); !ok {
+			capacity.ExcludedNodes = append(capacity.ExcludedNodes, ExcludedNode{Name: nodeResourceInfo.Name, Reason: reason})
+			continue
+		}
+		nodes = append(nodes, parseNodeCapacity(nodeResourceInfo, tracker))
+	}
+	slices.SortFunc(nodes, func(a, b nodeCapacity) int {
+		return strings.Compare(a.name, b.name)
+	})
+	slices.SortFunc(capacity.ExcludedNodes, func(a, b ExcludedNode) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	capacity.Nodes = len(nodes)
+	capacity.Resources = sumNodeCapacity(nodes)
+	capacity.LargestPlaceable = largestPlaceable(nodes)
+
+	if groupBy == "" {
+		return capacity
+	}
+
+	groups := map[string][]nodeCapacity{}
+	for _, node := range nodes {
+		value := node.labels[groupBy]
+		groups[value] = append(groups[value], node)
+	}
+	for value, groupNodes := range groups {
+		capacity.Groups = append(capacity.Groups, CapacityGroup{
+			Value:            value,
+			Nodes:            len(groupNodes),
+			Resources:        sumNodeCapacity(groupNodes),
+			LargestPlaceable: largestPlaceable(groupNodes),
+		})
+	}
+	slices.SortFunc(capacity.Groups, func(a, b CapacityGroup) int {
+		return strings.Compare(a.Value, b.Value)
+	})
+	return capacity
+}
+
+// 解析 CRD 中按标准单位记录的资源量，无法解析的资源只记录日志并跳过
+func parseNodeCapacity(nodeResourceInfo *v1beta1.NodeResourceInfo, tracker ResourceTracker) nodeCapacity {
+	node := nodeCapacity{
+		name:        nodeResourceInfo.Name,
+		labels:      nodeResourceInfo.Spec.Labels,
+		total:       v1.ResourceList{},
+		allocatable: v1.ResourceList{},
+		used:        v1.ResourceList{},
+		free:        v1.ResourceList{},
+	}
+
+	for resourceName, resourceCapacity := range nodeResourceInfo.Spec.Resources {
+		if !tracker.Tracks(resourceName) {
+			continue
+		}
+		total, err := resource.ParseQuantity(resourceCapacity.Total)
+		if err != nil {
+			log.Printf("无法解析节点 %s 的资源 %s 总量 %q: %v", node.name, resourceName, resourceCapacity.Total, err)
+			continue
+		}
+		allocatable, err := resource.ParseQuantity(resourceCapacity.Allocatable)
+		if err != nil {
+			log.Printf("无法解析节点 %s 的资源 %s 可分配量 %q: %v", node.name, resourceName, resourceCapacity.Allocatable, err)
+			continue
+		}
+
+		// 没有 Pod 申请的资源 status 中可能没有记录
+		var used resource.Quantity
+		if usage, ok := nodeResourceInfo.Status.Resources[resourceName]; ok && usage.Used != "" {
+			used, err = resource.ParseQuantity(usage.Used)
+			if err != nil {
+				log.Printf("无法解析节点 %s 的资源 %s 使用量 %q: %v", node.name, resourceName, usage.Used, err)
+				continue
+			}
+		}
+
+		free := allocatable.DeepCopy()
+		free.Sub(used)
+		if free.Sign() < 0 {
+			free = resource.Quantity{}
+		}
+
+		name := v1.ResourceName(resourceName)
+		node.total[name] = total
+		node.allocatable[name] = allocatable
+		node.used[name] = used
+		node.free[name] = free
+	}
+	return node
+}
+
+func sumNodeCapacity(nodes []nodeCapacity) map[string]CapacitySummary {
+	total, allocatable, used, free := v1.ResourceList{}, v1.ResourceList{}, v1.ResourceList{}, v1.ResourceList{}
+	for _, node := range nodes {
+		addResourceList(total, node.total)
+		addResourceList(allocatable, node.allocatable)
+		addResourceList(used, node.used)
+		addResourceList(free, node.free)
+	}
+
+	summary := make(map[string]CapacitySummary, len(total))
+	for resourceName := range total {
+		name := string(resourceName)
+		summary[name] = CapacitySummary{
+			Total:       FormatResourceQuantity(name, total[resourceName]),
+			Allocatable: FormatResourceQuantity(name, allocatable[resourceName]),
+			Used:        FormatResourceQuantity(name, used[resourceName]),
+			Free:        FormatResourceQuantity(name, free[resourceName]),
+		}
+	}
+	return summary
+}
+
+// 每种资源剩余最多的节点，剩余相同时取节点名最小的，剩余为 0 的资源不返回
+func largestPlaceable(nodes []nodeCapacity) map[string]PlaceableShape {
+	best := map[v1.ResourceName]*nodeCapacity{}
+	for i := range nodes {
+		node := &nodes[i]
+		for resourceName, free := range node.free {
+			if free.Sign() <= 0 {
+				continue
+			}
+			// nodes 已按名称排序，只有严格更大时才替换
+			if current, ok := best[resourceName]; !ok || free.Cmp(current.free[resourceName]) > 0 {
+				best[resourceName] = node
+			}
+		}
+	}
+	if len(best) == 0 {
+		return nil
+	}
+
+	shapes := make(map[string]PlaceableShape, len(best))
+	for resourceName, node := range best {
+		name := string(resourceName)
+		shapes[name] = PlaceableShape{
+			Node:  node.name,
+			Free:  FormatResourceQuantity(name, node.free[resourceName]),
+			Shape: formatResourceList(node.free),
+		}
+	}
+	return shapes
+}
@@ -0,0 +1,184 @@
+package resourceinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	dcgmGPUUtilMetric = "DCGM_FI_DEV_GPU_UTIL"
+	dcgmFBUsedMetric  = "DCGM_FI_DEV_FB_USED"
+)
+
+// DCGMExporter 读取节点上 dcgm-exporter 的 Prometheus 指标
+type DCGMExporter struct {
+	// 指标地址模板，{node} 替换为节点名，{ip} 替换为节点 InternalIP，
+	// 如 http://{ip}:9400/metrics
+	URLTemplate string
+	Client      *http.Client
+}
+
+func (e *DCGMExporter) url(node *v1.Node) string {
+	var internalIP string
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP {
+			internalIP = addr.Address
+			break
+		}
+	}
+	return strings.NewReplacer("{node}", node.Name, "{ip}", internalIP).Replace(e.URLTemplate)
+}
+
+// Scrape 获取节点上每张 GPU 的利用率与显存使用
+func (e *DCGMExporter) Scrape(ctx context.Context, node *v1.Node) ([]v1beta1.GPUDeviceUtilization, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url(node), nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建 DCGM 请求: %w", err)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 DCGM exporter 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DCGM exporter 返回状态码 %d", resp.StatusCode)
+	}
+	return ParseDCGMMetrics(resp.Body)
+}
+
+// ParseDCGMMetrics 解析 Prometheus 文本格式中的 GPU 利用率与显存指标，按 GPU 序号排序
+func ParseDCGMMetrics(r io.Reader) ([]v1beta1.GPUDeviceUtilization, error) {
+	devices := map[string]*v1beta1.GPUDeviceUtilization{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, value, err := parsePrometheusSample(line)
+		if err != nil {
+			return nil, err
+		}
+		if name != dcgmGPUUtilMetric && name != dcgmFBUsedMetric {
+			continue
+		}
+
+		index := labels["gpu"]
+		device, ok := devices[index]
+		if !ok {
+			device = &v1beta1.GPUDeviceUtilization{
+				Index:     index,
+				UUID:      labels["UUID"],
+				ModelName: labels["modelName"],
+				Namespace: labels["namespace"],
+				Pod:       labels["pod"],
+			}
+			devices[index] = device
+		}
+
+		switch name {
+		case dcgmGPUUtilMetric:
+			device.Utilization = int64(value)
+		case dcgmFBUsedMetric:
+			device.MemoryUsedMiB = int64(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 DCGM 指标失败: %w", err)
+	}
+
+	result := make([]v1beta1.GPUDeviceUtilization, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, *device)
+	}
+	slices.SortFunc(result, func(a, b v1beta1.GPUDeviceUtilization) int {
+		ai, aErr := strconv.Atoi(a.Index)
+		bi, bErr := strconv.Atoi(b.Index)
+		if aErr == nil && bErr == nil {
+			return ai - bi
+		}
+		return strings.Compare(a.Index, b.Index)
+	})
+	return result, nil
+}
+
+// 解析一行 `name{k="v",...} value [timestamp]`
+func parsePrometheusSample(line string) (string, map[string]string, float64, error) {
+	labels := map[string]string{}
+	name := line
+	rest := ""
+
+	if i := strings.IndexByte(line, '{'); i >= 0 {
+		name = line[:i]
+		end := strings.LastIndexByte(line, '}')
+		if end < i {
+			return "", nil, 0, fmt.Errorf("无法解析指标: %s", line)
+		}
+		if err := parsePrometheusLabels(line[i+1:end], labels); err != nil {
+			return "", nil, 0, fmt.Errorf("无法解析指标标签 %s: %w", line, err)
+		}
+		rest = line[end+1:]
+	} else if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name = line[:i]
+		rest = line[i:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("指标缺少值: %s", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("无法解析指标值 %s: %w", line, err)
+	}
+	return strings.TrimSpace(name), labels, value, nil
+}
+
+func parsePrometheusLabels(s string, labels map[string]string) error {
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return fmt.Errorf("非法的标签: %s", s)
+		}
+		key := strings.TrimSpace(s[:eq])
+
+		// 找到未转义的结束引号
+		var value strings.Builder
+		i := eq + 2
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				if s[i] == 'n' {
+					value.WriteByte('\n')
+					continue
+				}
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return fmt.Errorf("标签值缺少结束引号: %s", s)
+		}
+		labels[key] = value.String()
+
+		s = strings.TrimPrefix(strings.TrimSpace(s[i+1:]), ",")
+	}
+	return nil
+}
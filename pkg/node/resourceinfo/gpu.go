@@ -0,0 +1,165 @@
+package resourceinfo
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// GPU feature discovery 写入节点的标签
+const (
+	GPUResourceName      = "nvidia.com/gpu"
+	MIGResourcePrefix    = "nvidia.com/mig-"
+	gpuProductLabel      = "nvidia.com/gpu.product"
+	gpuFamilyLabel       = "nvidia.com/gpu.family"
+	gpuMachineLabel      = "nvidia.com/gpu.machine"
+	gpuMemoryLabel       = "nvidia.com/gpu.memory"
+	gpuCountLabel        = "nvidia.com/gpu.count"
+	migStrategyLabel     = "nvidia.com/mig.strategy"
+	migCountLabelSuffix  = ".count"
+	migMemoryLabelSuffix = ".memory"
+)
+
+// 是否为 GPU 或 MIG 扩展资源
+func IsGPUResourceName(resourceName string) bool {
+	return resourceName == GPUResourceName || strings.HasPrefix(resourceName, MIGResourcePrefix)
+}
+
+// 根据 GFD 标签与节点容量生成 GPU 信息，节点没有 GPU 时返回 nil
+func LoadGPUInfo(node *v1.Node) *v1beta1.GPUInfo {
+	labels := node.Labels
+	gpu := &v1beta1.GPUInfo{
+		Product:     labels[gpuProductLabel],
+		Family:      labels[gpuFamilyLabel],
+		Machine:     labels[gpuMachineLabel],
+		MemoryMiB:   parseLabelInt(labels, gpuMemoryLabel),
+		Count:       parseLabelInt(labels, gpuCountLabel),
+		MigStrategy: labels[migStrategyLabel],
+	}
+
+	for resourceName, quantity := range node.Status.Capacity {
+		name := string(resourceName)
+		if !strings.HasPrefix(name, MIGResourcePrefix) {
+			continue
+		}
+		count := parseLabelInt(labels, name+migCountLabelSuffix)
+		if count == 0 {
+			count = quantity.Value()
+		}
+		gpu.MigProfiles = append(gpu.MigProfiles, v1beta1.MIGProfile{
+			Profile:   strings.TrimPrefix(name, MIGResourcePrefix),
+			Resource:  name,
+			Count:     count,
+			MemoryMiB: parseLabelInt(labels, name+migMemoryLabelSuffix),
+		})
+	}
+	slices.SortFunc(gpu.MigProfiles, func(a, b v1beta1.MIGProfile) int {
+		return strings.Compare(a.Profile, b.Profile)
+	})
+
+	// 没有安装 GFD 时只能从容量中获取卡数
+	if gpu.Count == 0 {
+		if quantity, ok := node.Status.Capacity[GPUResourceName]; ok {
+			gpu.Count = quantity.Value()
+		}
+	}
+
+	if gpu.Product == "" && gpu.Count == 0 && len(gpu.MigProfiles) == 0 {
+		return nil
+	}
+	return gpu
+}
+
+// 按 Pod 统计 GPU/MIG 资源占用，结果按 namespace、pod、资源名排序保证稳定
+func LoadGPUAllocations(pods []v1.Pod) []v1beta1.GPUAllocation {
+	var allocations []v1beta1.GPUAllocation
+	for i := range pods {
+		pod := &pods[i]
+		for resourceName, quantity := range PodRequests(pod) {
+			if !IsGPUResourceName(string(resourceName)) || quantity.IsZero() {
+				continue
+			}
+			allocations = append(allocations, v1beta1.GPUAllocation{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Resource:  string(resourceName),
+				Count:     quantity.Value(),
+			})
+		}
+	}
+	slices.SortFunc(allocations, func(a, b v1beta1.GPUAllocation) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Pod, b.Pod); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Resource, b.Resource)
+	})
+	return allocations
+}
+
+// 生成 NodeResource.properties，只有 GPU 与 MIG 资源有附加信息
+func gpuResourceProperties(resourceName string, gpu *v1beta1.GPUInfo, allocations []v1beta1.GPUAllocation) map[string]any {
+	if gpu == nil || !IsGPUResourceName(resourceName) {
+		return nil
+	}
+
+	properties := map[string]any{}
+	if gpu.Product != "" {
+		properties["product"] = gpu.Product
+	}
+	if gpu.Family != "" {
+		properties["family"] = gpu.Family
+	}
+	if gpu.MigStrategy != "" {
+		properties["migStrategy"] = gpu.MigStrategy
+	}
+
+	if resourceName == GPUResourceName {
+		if gpu.MemoryMiB > 0 {
+			properties["memoryMiB"] = gpu.MemoryMiB
+		}
+		if gpu.Count > 0 {
+			properties["count"] = gpu.Count
+		}
+	} else {
+		for _, profile := range gpu.MigProfiles {
+			if profile.Resource != resourceName {
+				continue
+			}
+			properties["profile"] = profile.Profile
+			properties["count"] = profile.Count
+			if profile.MemoryMiB > 0 {
+				properties["memoryMiB"] = profile.MemoryMiB
+			}
+		}
+	}
+
+	var podAllocations []any
+	for _, allocation := range allocations {
+		if allocation.Resource != resourceName {
+			continue
+		}
+		podAllocations = append(podAllocations, map[string]any{
+			"namespace": allocation.Namespace,
+			"pod":       allocation.Pod,
+			"count":     allocation.Count,
+		})
+	}
+	if len(podAllocations) > 0 {
+		properties["allocations"] = podAllocations
+	}
+	return properties
+}
+
+func parseLabelInt(labels map[string]string, key string) int64 {
+	value, err := strconv.ParseInt(labels[key], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -2,79 +2,94 @@ package resourceinfo
 
 import (
 	"context"
-	"fmt"
+	"log"
 
-	"github.com/modcoco/OpsFlow/pkg/utils"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
-	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
 )
 
+// LoadOptions 控制从节点采集哪些数据
+type LoadOptions struct {
+	ResourceTracker ResourceTracker
+	UsageBreakdown  UsageBreakdownMode    // 是否记录按 namespace/工作负载拆分的资源申请
+	Utilization     *UtilizationCollector // 实际使用采集，为空时不采集
+}
+
 type NodeResourceQuery struct {
-	Clientset            kubernetes.Interface
-	Node                 *v1.Node
-	ResourceNamesToTrack map[string]bool
+	Clientset kubernetes.Interface
+	Node      *v1.Node
+	LoadOptions
 }
 
 // 更新 NodeResourceInfo
-func LoadNodeResourceInfoFromNode(query NodeResourceQuery, nodeResourceInfo *v1alpha1.NodeResourceInfo) error {
+func LoadNodeResourceInfoFromNode(query NodeResourceQuery, nodeResourceInfo *v1beta1.NodeResourceInfo) error {
 	nodeResourceInfo.ObjectMeta = metav1.ObjectMeta{
 		Name: query.Node.Name,
 	}
-	nodeResourceInfo.Spec = v1alpha1.NodeResourceInfoSpec{
+	nodeResourceInfo.Spec = v1beta1.NodeResourceInfoSpec{
 		NodeName:  query.Node.Name,
-		Resources: make(map[string]v1alpha1.ResourceInfo),
+		Resources: make(map[string]v1beta1.ResourceCapacity),
 	}
+	nodeResourceInfo.Status.Resources = make(map[string]v1beta1.ResourceUsage)
 
-	for resourceName, totalResource := range query.Node.Status.Capacity {
-		if !query.ResourceNamesToTrack[string(resourceName)] {
-			continue
-		}
+	// 每次计算只获取一次节点上的 Pod
+	pods, err := ListActivePodsOnNode(query.Clientset, query.Node.Name)
+	if err != nil {
+		return err
+	}
+	podResources := SumPodResources(pods)
 
-		allocatableResource := query.Node.Status.Allocatable[resourceName]
+	// GPU 型号、MIG 规格与按 Pod 的占用
+	nodeResourceInfo.Spec.GPU = LoadGPUInfo(query.Node)
+	nodeResourceInfo.Status.GPUAllocations = LoadGPUAllocations(pods)
+
+	if query.UsageBreakdown != UsageBreakdownDisabled {
+		breakdown := ComputeUsageBreakdown(pods, query.ResourceTracker)
+		breakdown.ReportToManager = query.UsageBreakdown == UsageBreakdownManager
+		nodeResourceInfo.Status.UsageBreakdown = breakdown
+	}
 
-		var usedResource resource.Quantity
-		pods, err := query.Clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
-			FieldSelector: fmt.Sprintf("spec.nodeName=%s", query.Node.Name),
-		})
+	// 实际使用是可选数据，采集失败时只记录日志
+	var utilization *NodeUtilization
+	if query.Utilization != nil {
+		utilization, err = query.Utilization.Collect(context.TODO(), query.Node, pods)
 		if err != nil {
-			return fmt.Errorf("无法获取 Pod 列表: %w", err)
+			log.Printf("采集节点 %s 的实际资源使用失败: %v", query.Node.Name, err)
+		} else {
+			nodeResourceInfo.Status.Utilization = &utilization.Status
 		}
+	}
 
-		for _, pod := range pods.Items {
-			for _, container := range pod.Spec.Containers {
-				if request, ok := container.Resources.Requests[resourceName]; ok {
-					usedResource.Add(request)
-				}
-			}
+	for resourceName, totalResource := range query.Node.Status.Capacity {
+		if !query.ResourceTracker.Tracks(string(resourceName)) {
+			continue
 		}
 
+		allocatableResource := query.Node.Status.Allocatable[resourceName]
+
+		usedResource := podResources.Requests[resourceName]
+		limitResource := podResources.Limits[resourceName]
+
 		resName := string(resourceName)
-		var resourceInfo v1alpha1.ResourceInfo
-		if resName == "cpu" {
-			resourceInfo = v1alpha1.ResourceInfo{
-				Total:       fmt.Sprintf("%dm", totalResource.MilliValue()),
-				Allocatable: fmt.Sprintf("%dm", allocatableResource.MilliValue()),
-				Used:        fmt.Sprintf("%dm", usedResource.MilliValue()),
-			}
-		} else if resName == "memory" {
-			resourceInfo = v1alpha1.ResourceInfo{
-				Total:       fmt.Sprintf("%dMi", utils.ScaledValue(totalResource, resource.Mega)),
-				Allocatable: fmt.Sprintf("%dMi", utils.ScaledValue(allocatableResource, resource.Mega)),
-				Used:        fmt.Sprintf("%dMi", utils.ScaledValue(usedResource, resource.Mega)),
-			}
-		} else {
-			resourceInfo = v1alpha1.ResourceInfo{
-				Total:       totalResource.String(),
-				Allocatable: allocatableResource.String(),
-				Used:        usedResource.String(),
+		capacity := v1beta1.ResourceCapacity{
+			Total:       FormatResourceQuantity(resName, totalResource),
+			Allocatable: FormatResourceQuantity(resName, allocatableResource),
+		}
+		usage := v1beta1.ResourceUsage{
+			Used:   FormatResourceQuantity(resName, usedResource),
+			Limits: FormatResourceQuantity(resName, limitResource),
+		}
+		if utilization != nil {
+			if utilized, ok := utilization.Usage[resourceName]; ok {
+				usage.Utilized = formatUtilized(resName, utilized)
 			}
 		}
 
-		nodeResourceInfo.Spec.Resources[resName] = resourceInfo
+		nodeResourceInfo.Spec.Resources[resName] = capacity
+		nodeResourceInfo.Status.Resources[resName] = usage
 	}
 
 	return nil
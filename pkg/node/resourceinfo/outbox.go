@@ -0,0 +1,202 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	OperationAddNode    = "AddNode"
+	OperationUpdateNode = "UpdateNode"
+)
+
+// manager 返回 0 表示成功，部分实现沿用 http 状态码返回 200
+func isAcknowledged(resp *pb.GenericResponse) bool {
+	return resp.GetCode() == 0 || resp.GetCode() == 200
+}
+
+// 当前 generation 或节点状态还没有被 manager 确认时需要通知
+func needsManagerSync(nodeResourceInfo *v1beta1.NodeResourceInfo) bool {
+	sync := nodeResourceInfo.Status.ManagerSync
+	if sync == nil || sync.AcknowledgedGeneration < nodeResourceInfo.Generation {
+		return true
+	}
+	return !meta.IsStatusConditionTrue(nodeResourceInfo.Status.Conditions, v1beta1.ConditionSyncedToManager)
+}
+
+// manager 从未确认过该节点时使用 AddNode，否则使用 UpdateNode
+func pendingOperation(nodeResourceInfo *v1beta1.NodeResourceInfo) string {
+	sync := nodeResourceInfo.Status.ManagerSync
+	if sync == nil || sync.AcknowledgedGeneration == 0 {
+		return OperationAddNode
+	}
+	return OperationUpdateNode
+}
+
+// SyncToManager 通知 manager 并把结果记录到 CRD status，未确认的 generation 会在后续周期继续重试
+func SyncToManager(crdClient typedv1beta1.NodeResourceInfoInterface, grpcClient *grpc.ClientConn, nodeResourceInfo *v1beta1.NodeResourceInfo, clusterId string, operation string) error {
+	var notifyErr error
+	switch operation {
+	case OperationAddNode:
+		notifyErr = notifyAddNode(grpcClient, nodeResourceInfo, clusterId)
+	case OperationUpdateNode:
+		notifyErr = notifyUpdateNode(grpcClient, nodeResourceInfo, clusterId)
+	default:
+		return fmt.Errorf("未知的 manager 同步操作: %s", operation)
+	}
+
+	sync := v1beta1.ManagerSyncStatus{
+		LastAttemptTime: &metav1.Time{Time: time.Now()},
+	}
+	if previous := nodeResourceInfo.Status.ManagerSync; previous != nil {
+		sync.AcknowledgedGeneration = previous.AcknowledgedGeneration
+		sync.Attempts = previous.Attempts
+	}
+
+	if notifyErr != nil {
+		sync.PendingOperation = operation
+		sync.Attempts++
+		sync.LastError = notifyErr.Error()
+	} else {
+		sync.AcknowledgedGeneration = nodeResourceInfo.Generation
+		sync.Attempts = 0
+	}
+
+	if err := updateManagerSyncStatus(crdClient, nodeResourceInfo.Name, nodeResourceInfo.Generation, sync, notifyErr); err != nil {
+		log.Printf("记录 NodeResourceInfo %s 的 manager 同步状态失败: %v", nodeResourceInfo.Name, err)
+		if notifyErr == nil {
+			return err
+		}
+	}
+
+	if notifyErr != nil {
+		return fmt.Errorf("通知 manager %s 失败 (第 %d 次): %w", operation, sync.Attempts, notifyErr)
+	}
+	log.Printf("manager 已确认 NodeResourceInfo %s generation %d", nodeResourceInfo.Name, nodeResourceInfo.Generation)
+	return nil
+}
+
+// 通过 status 子资源记录同步结果，冲突时基于最新对象重试
+func updateManagerSyncStatus(crdClient typedv1beta1.NodeResourceInfoInterface, name string, generation int64, sync v1beta1.ManagerSyncStatus, notifyErr error) error {
+	condition := metav1.Condition{
+		Type:               v1beta1.ConditionSyncedToManager,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Acknowledged",
+		Message:            "NodeManager 已确认当前节点信息",
+	}
+	if notifyErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NotifyFailed"
+		condition.Message = notifyErr.Error()
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := crdClient.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		// 通知期间 CRD 又被更新时，不能把新的 generation 标记为已确认
+		if current.Generation != generation && notifyErr == nil {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "GenerationChanged"
+			condition.Message = fmt.Sprintf("通知期间 generation 从 %d 变为 %d", generation, current.Generation)
+		}
+		current.Status.ManagerSync = &sync
+		meta.SetStatusCondition(&current.Status.Conditions, condition)
+		_, err = crdClient.UpdateStatus(context.TODO(), current, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("无法更新 NodeResourceInfo status: %w", err)
+	}
+	return nil
+}
+
+func notifyAddNode(grpcClient *grpc.ClientConn, nodeResourceInfo *v1beta1.NodeResourceInfo, clusterId string) error {
+	c := pb.NewNodeManagerClient(grpcClient)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	addResp, err := c.AddNode(ctx, &pb.AddNodeRequest{
+		NodeName:         nodeResourceInfo.Name,
+		ClusterId:        clusterId,
+		NodeStatus:       nodeResourceInfo.Status.NodeStatus,
+		Resources:        buildNodeResources(nodeResourceInfo),
+		Roles:            nodeResourceInfo.Spec.Roles,
+		ScheduleVersion:  nodeResourceInfo.Spec.ScheduleVersion,
+		InternalIp:       nodeResourceInfo.Spec.InternalIp,
+		Os:               nodeResourceInfo.Spec.OS,
+		KernelVersion:    nodeResourceInfo.Spec.KernelVersion,
+		ContainerRuntime: nodeResourceInfo.Spec.ContainerRuntime,
+		Labels:           nodeResourceInfo.Spec.Labels,
+		Annotations:      nodeResourceInfo.Spec.Annotations,
+		Taints:           buildNodeTaints(nodeResourceInfo.Spec.Taints),
+		Health:           string(nodeResourceInfo.Status.Health),
+		Conditions:       BuildNodeConditions(nodeResourceInfo.Status.NodeConditions),
+	})
+	if err != nil {
+		log.Printf("Failed to call AddNode: %v", err)
+		return fmt.Errorf("调用 rpc AddNode 失败: %w", err)
+	}
+	log.Printf("Received response: %v", addResp)
+	if !isAcknowledged(addResp) {
+		return fmt.Errorf("rpc AddNode 返回错误: code=%d, message=%s", addResp.GetCode(), addResp.GetMessage())
+	}
+
+	var addNodeResp pb.AddNodeResponse
+	if err := addResp.GetData().UnmarshalTo(&addNodeResp); err != nil {
+		log.Printf("Failed to unmarshal AddNodeResponse from data: %v", err)
+	}
+	log.Printf("Add node: %s", addNodeResp.GetNodeName())
+	return nil
+}
+
+func notifyUpdateNode(grpcClient *grpc.ClientConn, nodeResourceInfo *v1beta1.NodeResourceInfo, clusterId string) error {
+	c := pb.NewNodeManagerClient(grpcClient)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	updateResp, err := c.UpdateNode(ctx, &pb.UpdateNodeRequest{
+		NodeName:         nodeResourceInfo.Name,
+		ClusterId:        clusterId,
+		NodeStatus:       nodeResourceInfo.Status.NodeStatus,
+		Resources:        buildNodeResources(nodeResourceInfo),
+		Roles:            nodeResourceInfo.Spec.Roles,
+		ScheduleVersion:  nodeResourceInfo.Spec.ScheduleVersion,
+		InternalIp:       nodeResourceInfo.Spec.InternalIp,
+		Os:               nodeResourceInfo.Spec.OS,
+		KernelVersion:    nodeResourceInfo.Spec.KernelVersion,
+		ContainerRuntime: nodeResourceInfo.Spec.ContainerRuntime,
+		Labels:           nodeResourceInfo.Spec.Labels,
+		Annotations:      nodeResourceInfo.Spec.Annotations,
+		Taints:           buildNodeTaints(nodeResourceInfo.Spec.Taints),
+		Health:           string(nodeResourceInfo.Status.Health),
+		Conditions:       BuildNodeConditions(nodeResourceInfo.Status.NodeConditions),
+	})
+	if err != nil {
+		log.Printf("Failed to call UpdateNode: %v", err)
+		return fmt.Errorf("调用 rpc UpdateNode 失败: %w", err)
+	}
+	log.Printf("Received response: %v", updateResp)
+	if !isAcknowledged(updateResp) {
+		return fmt.Errorf("rpc UpdateNode 返回错误: code=%d, message=%s", updateResp.GetCode(), updateResp.GetMessage())
+	}
+
+	var updateNodeResp pb.UpdateNodeResponse
+	if err := updateResp.GetData().UnmarshalTo(&updateNodeResp); err != nil {
+		log.Printf("Failed to unmarshal UpdateNodeResponse from data: %v", err)
+	}
+	log.Printf("Update node: %s", updateNodeResp.GetNodeName())
+	return nil
+}
@@ -0,0 +1,290 @@
+package resourceinfo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// PlacementRequest 一组需要放置的相同规格的 Pod，如 Ray 的 head 或一个 worker 组
+type PlacementRequest struct {
+	Group        string            // head 或 worker 组名
+	Machine      string            // ClusterConfig 中的机器名
+	Replicas     int32             // 副本数
+	Requests     v1.ResourceList   // 单个 Pod 的资源申请
+	NodeSelector map[string]string // Pod 的 nodeSelector
+	Tolerations  []v1.Toleration   // Pod 的容忍
+	NodeAffinity *v1.NodeSelector  // Pod 的 requiredDuringSchedulingIgnoredDuringExecution 节点亲和
+	Unchecked    []string          // 无法按 NodeResourceInfo 模拟的调度约束，如 podAntiAffinity
+}
+
+// Placement 单个副本被放置到的节点
+type Placement struct {
+	Group   string `json:"group"`
+	Machine string `json:"machine"`
+	Replica int32  `json:"replica"`
+	Node    string `json:"node"`
+}
+
+// UnplacedMachine 无法放置的副本以及各节点不满足的原因
+type UnplacedMachine struct {
+	Group    string            `json:"group"`
+	Machine  string            `json:"machine"`
+	Replicas int32             `json:"replicas"` // 无法放置的副本数
+	Requests map[string]string `json:"requests"`
+	Reasons  []string          `json:"reasons"` // 如 "2 node(s) insufficient nvidia.com/gpu"
+}
+
+// PlacementResult 放置模拟的结果
+type PlacementResult struct {
+	Feasible   bool              `json:"feasible"`
+	Placements []Placement       `json:"placements,omitempty"`
+	Unplaced   []UnplacedMachine `json:"unplaced,omitempty"`
+	Unchecked  []string          `json:"unchecked,omitempty"` // 没有检查的调度约束，如 "head: podAntiAffinity"，实际调度可能失败
+}
+
+// SimulatePlacement 按 NodeResourceInfo 的剩余资源模拟放置所有副本。
+// 先放置申请最大的组，每个副本选择放置后剩余最少的节点（best fit），
+// 节点没有记录的资源按 0 计算，如没有 GPU 节点时申请 nvidia.com/gpu 的副本无法放置
+func SimulatePlacement(nodeResourceInfos []v1beta1.NodeResourceInfo, requests []PlacementRequest) *PlacementResult {
+	return simulatePlacement(nodeResourceInfos, requests, false)
+}
+
+// SimulateCapacityPlacement 与 SimulatePlacement 相同，但按节点的可分配量放置，不扣除已有 Pod 的申请，
+// 用于检查 autoscaler 扩容到最大副本数时集群是否可能放下
+func SimulateCapacityPlacement(nodeResourceInfos []v1beta1.NodeResourceInfo, requests []PlacementRequest) *PlacementResult {
+	return simulatePlacement(nodeResourceInfos, requests, true)
+}
+
+func simulatePlacement(nodeResourceInfos []v1beta1.NodeResourceInfo, requests []PlacementRequest, ignoreUsed bool) *PlacementResult {
+	allResources := ResourceTracker{Patterns: []string{"*", "*/*"}}
+
+	type candidate struct {
+		nodeCapacity
+		taints []v1beta1.NodeTaint
+	}
+	var nodes []*candidate
+	excluded := map[string]int{}
+	for i := range nodeResourceInfos {
+		nodeResourceInfo := &nodeResourceInfos[i]
+		if ok, reason := NodeSchedulable(nodeResourceInfo); !ok {
+			excluded[reason]++
+			continue
+		}
+		node := &candidate{
+			nodeCapacity: parseNodeCapacity(nodeResourceInfo, allResources),
+			taints:       nodeResourceInfo.Spec.Taints,
+		}
+		if ignoreUsed {
+			for resourceName, allocatable := range node.allocatable {
+				node.free[resourceName] = allocatable.DeepCopy()
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(a, b *candidate) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	ordered := slices.Clone(requests)
+	slices.SortStableFunc(ordered, func(a, b PlacementRequest) int {
+		return comparePlacementRequests(b.Requests, a.Requests)
+	})
+
+	result := &PlacementResult{Feasible: true}
+	for _, request := range requests {
+		for _, constraint := range request.Unchecked {
+			result.Unchecked = append(result.Unchecked, request.Group+": "+constraint)
+		}
+	}
+	for _, request := range ordered {
+		requested := v1.ResourceList{}
+		for resourceName, quantity := range request.Requests {
+			if !quantity.IsZero() {
+				requested[resourceName] = quantity
+			}
+		}
+
+		for replica := int32(0); replica < request.Replicas; replica++ {
+			var best *candidate
+			reasons := map[string]int{}
+			for reason, count := range excluded {
+				reasons[fmt.Sprintf("node(s) were %s", reason)] += count
+			}
+
+			for _, node := range nodes {
+				if reason := nodeFitReason(node.name, node.labels, node.taints, node.free, request, requested); reason != "" {
+					reasons[reason]++
+					continue
+				}
+				if best == nil || comparePlacementRequests(leftover(node.free, requested), leftover(best.free, requested)) < 0 {
+					best = node
+				}
+			}
+
+			if best == nil {
+				result.Feasible = false
+				result.Unplaced = append(result.Unplaced, UnplacedMachine{
+					Group:    request.Group,
+					Machine:  request.Machine,
+					Replicas: request.Replicas - replica,
+					Requests: formatResourceList(request.Requests),
+					Reasons:  formatPlacementReasons(reasons),
+				})
+				break
+			}
+
+			for resourceName, quantity := range requested {
+				free := best.free[resourceName].DeepCopy()
+				free.Sub(quantity)
+				best.free[resourceName] = free
+			}
+			result.Placements = append(result.Placements, Placement{
+				Group:   request.Group,
+				Machine: request.Machine,
+				Replica: replica,
+				Node:    best.name,
+			})
+		}
+	}
+	return result
+}
+
+// 节点不满足时返回原因，满足时返回空字符串
+func nodeFitReason(name string, labels map[string]string, taints []v1beta1.NodeTaint, free v1.ResourceList, request PlacementRequest, requested v1.ResourceList) string {
+	for key, value := range request.NodeSelector {
+		if labels[key] != value {
+			return "node(s) didn't match node selector"
+		}
+	}
+	if request.NodeAffinity != nil && !matchNodeSelector(request.NodeAffinity, name, labels) {
+		return "node(s) didn't match node affinity"
+	}
+
+	for _, nodeTaint := range taints {
+		effect := v1.TaintEffect(nodeTaint.Effect)
+		if effect != v1.TaintEffectNoSchedule && effect != v1.TaintEffectNoExecute {
+			continue
+		}
+		taint := v1.Taint{Key: nodeTaint.Key, Value: nodeTaint.Value, Effect: effect}
+		if !slices.ContainsFunc(request.Tolerations, func(toleration v1.Toleration) bool {
+			return toleration.ToleratesTaint(&taint)
+		}) {
+			return fmt.Sprintf("node(s) had untolerated taint {%s}", taint.ToString())
+		}
+	}
+
+	// 按名称排序保证原因稳定
+	names := make([]string, 0, len(requested))
+	for resourceName := range requested {
+		names = append(names, string(resourceName))
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if available := free[v1.ResourceName(name)]; available.Cmp(requested[v1.ResourceName(name)]) < 0 {
+			return "node(s) insufficient " + name
+		}
+	}
+	return ""
+}
+
+// 与 kube-scheduler 相同，nodeSelectorTerms 之间为或，term 内的条件为与，空的 term 不匹配任何节点。
+// matchFields 只支持 metadata.name
+func matchNodeSelector(selector *v1.NodeSelector, name string, labels map[string]string) bool {
+	return slices.ContainsFunc(selector.NodeSelectorTerms, func(term v1.NodeSelectorTerm) bool {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			return false
+		}
+		for _, requirement := range term.MatchExpressions {
+			value, ok := labels[requirement.Key]
+			if !matchNodeSelectorRequirement(requirement, value, ok) {
+				return false
+			}
+		}
+		for _, requirement := range term.MatchFields {
+			if requirement.Key != "metadata.name" || !matchNodeSelectorRequirement(requirement, name, true) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func matchNodeSelectorRequirement(requirement v1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn:
+		return exists && slices.Contains(requirement.Values, value)
+	case v1.NodeSelectorOpNotIn:
+		return !exists || !slices.Contains(requirement.Values, value)
+	case v1.NodeSelectorOpExists:
+		return exists
+	case v1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		expected, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == v1.NodeSelectorOpGt {
+			return actual > expected
+		}
+		return actual < expected
+	}
+	return false
+}
+
+func leftover(free, requested v1.ResourceList) v1.ResourceList {
+	result := v1.ResourceList{}
+	for resourceName, quantity := range requested {
+		remaining := free[resourceName].DeepCopy()
+		remaining.Sub(quantity)
+		result[resourceName] = remaining
+	}
+	return result
+}
+
+// 先比较扩展资源（GPU 等）之和，再比较 cpu 与内存
+func comparePlacementRequests(a, b v1.ResourceList) int {
+	extended := func(list v1.ResourceList) resource.Quantity {
+		var sum resource.Quantity
+		for resourceName, quantity := range list {
+			if IsExtendedResourceName(string(resourceName)) {
+				sum.Add(quantity)
+			}
+		}
+		return sum
+	}
+
+	aExtended, bExtended := extended(a), extended(b)
+	if c := aExtended.Cmp(bExtended); c != 0 {
+		return c
+	}
+	if c := a.Cpu().Cmp(*b.Cpu()); c != 0 {
+		return c
+	}
+	return a.Memory().Cmp(*b.Memory())
+}
+
+func formatPlacementReasons(reasons map[string]int) []string {
+	if len(reasons) == 0 {
+		return []string{"no NodeResourceInfo found"}
+	}
+	formatted := make([]string, 0, len(reasons))
+	for reason, count := range reasons {
+		formatted = append(formatted, fmt.Sprintf("%d %s", count, reason))
+	}
+	slices.Sort(formatted)
+	return formatted
+}
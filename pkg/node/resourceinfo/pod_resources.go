@@ -0,0 +1,124 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 获取节点上非终止状态的 Pod，Succeeded/Failed 的 Pod 不再占用节点资源
+func ListActivePodsOnNode(clientset kubernetes.Interface, nodeName string) ([]v1.Pod, error) {
+	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s,status.phase!=%s,status.phase!=%s", nodeName, v1.PodSucceeded, v1.PodFailed),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("无法获取 Pod 列表: %w", err)
+	}
+
+	activePods := make([]v1.Pod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName || isTerminalPod(&pod) {
+			continue
+		}
+		activePods = append(activePods, pod)
+	}
+	return activePods, nil
+}
+
+func isTerminalPod(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
+
+// 按 kube-scheduler 的方式计算 Pod 的资源请求：
+// max(所有应用容器 requests 之和 + sidecar, 每个 init 容器 requests + 之前启动的 sidecar) + overhead
+func PodRequests(pod *v1.Pod) v1.ResourceList {
+	return podResources(pod, func(c *v1.Container) v1.ResourceList { return c.Resources.Requests }, false)
+}
+
+// 与 PodRequests 相同的方式计算 limits，overhead 只累加到已经设置了 limit 的资源上
+func PodLimits(pod *v1.Pod) v1.ResourceList {
+	return podResources(pod, func(c *v1.Container) v1.ResourceList { return c.Resources.Limits }, true)
+}
+
+func podResources(pod *v1.Pod, containerResources func(*v1.Container) v1.ResourceList, overheadOnlyIfSet bool) v1.ResourceList {
+	result := v1.ResourceList{}
+	for i := range pod.Spec.Containers {
+		addResourceList(result, containerResources(&pod.Spec.Containers[i]))
+	}
+
+	// sidecar（restartPolicy=Always 的 init 容器）会一直运行，需要与应用容器一起累加
+	sidecars := v1.ResourceList{}
+	initContainers := v1.ResourceList{}
+	for i := range pod.Spec.InitContainers {
+		container := &pod.Spec.InitContainers[i]
+		resources := containerResources(container)
+
+		if container.RestartPolicy != nil && *container.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			addResourceList(result, resources)
+			addResourceList(sidecars, resources)
+			resources = sidecars.DeepCopy()
+		} else {
+			current := sidecars.DeepCopy()
+			addResourceList(current, resources)
+			resources = current
+		}
+		maxResourceList(initContainers, resources)
+	}
+	maxResourceList(result, initContainers)
+
+	for name, quantity := range pod.Spec.Overhead {
+		if overheadOnlyIfSet {
+			if _, ok := result[name]; !ok {
+				continue
+			}
+		}
+		addResourceQuantity(result, name, quantity)
+	}
+	return result
+}
+
+// 节点上 Pod 的资源 requests 与 limits 之和
+type NodePodResources struct {
+	Requests v1.ResourceList
+	Limits   v1.ResourceList
+}
+
+func SumPodResources(pods []v1.Pod) NodePodResources {
+	sum := NodePodResources{
+		Requests: v1.ResourceList{},
+		Limits:   v1.ResourceList{},
+	}
+	for i := range pods {
+		addResourceList(sum.Requests, PodRequests(&pods[i]))
+		addResourceList(sum.Limits, PodLimits(&pods[i]))
+	}
+	return sum
+}
+
+func addResourceList(list, newList v1.ResourceList) {
+	for name, quantity := range newList {
+		addResourceQuantity(list, name, quantity)
+	}
+}
+
+func addResourceQuantity(list v1.ResourceList, name v1.ResourceName, quantity resource.Quantity) {
+	if value, ok := list[name]; ok {
+		value.Add(quantity)
+		list[name] = value
+	} else {
+		list[name] = quantity.DeepCopy()
+	}
+}
+
+// 对每种资源取较大值
+func maxResourceList(list, newList v1.ResourceList) {
+	for name, quantity := range newList {
+		if value, ok := list[name]; !ok || quantity.Cmp(value) > 0 {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package resourceinfo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// 默认追踪的资源
+var DefaultTrackedResources = []string{"cpu", "memory", "nvidia.com/gpu", "nvidia.com/mig-*"}
+
+// ResourceTracker 决定哪些节点资源写入 NodeResourceInfo
+type ResourceTracker struct {
+	Patterns     []string // glob 匹配资源名，如 *.com/gpu、rdma/*、hugepages-*、ephemeral-storage
+	AutoDiscover bool     // 自动追踪节点上出现的所有扩展资源
+}
+
+// 解析逗号分隔的资源配置，非法的 glob 直接返回错误
+func NewResourceTracker(patterns string, autoDiscover bool) (ResourceTracker, error) {
+	tracker := ResourceTracker{AutoDiscover: autoDiscover}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ResourceTracker{}, fmt.Errorf("非法的资源匹配规则 %q: %w", pattern, err)
+		}
+		tracker.Patterns = append(tracker.Patterns, pattern)
+	}
+	return tracker, nil
+}
+
+func (t ResourceTracker) Tracks(resourceName string) bool {
+	for _, pattern := range t.Patterns {
+		if matched, _ := path.Match(pattern, resourceName); matched {
+			return true
+		}
+	}
+	return t.AutoDiscover && IsExtendedResourceName(resourceName)
+}
+
+// 与 kube-scheduler 的定义一致：带域名前缀且不属于 kubernetes.io 的资源
+func IsExtendedResourceName(resourceName string) bool {
+	if !strings.Contains(resourceName, "/") || strings.HasPrefix(resourceName, "requests.") {
+		return false
+	}
+	domain := resourceName[:strings.Index(resourceName, "/")]
+	return domain != "kubernetes.io" && !strings.HasSuffix(domain, ".kubernetes.io")
+}
+
+// 资源的标准单位：cpu 为 m，内存、hugepages 与存储类资源为 Mi，其他按个数上报
+func ResourceUnit(resourceName string) string {
+	switch {
+	case resourceName == string(v1.ResourceCPU):
+		return "m"
+	case resourceName == string(v1.ResourceMemory),
+		resourceName == string(v1.ResourceEphemeralStorage),
+		resourceName == string(v1.ResourceStorage),
+		strings.HasPrefix(resourceName, v1.ResourceHugePagesPrefix):
+		return "Mi"
+	default:
+		return ""
+	}
+}
+
+// 按资源类型转换为标准单位
+func FormatResourceQuantity(resourceName string, quantity resource.Quantity) string {
+	switch ResourceUnit(resourceName) {
+	case "m":
+		return fmt.Sprintf("%dm", quantity.MilliValue())
+	case "Mi":
+		return fmt.Sprintf("%dMi", utils.ScaledValue(quantity, resource.Mega))
+	default:
+		// 扩展资源只允许整数，统一按个数上报
+		return fmt.Sprintf("%d", quantity.Value())
+	}
+}
@@ -4,17 +4,23 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 
 	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/util/retry"
 
-	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,277 +29,320 @@ const (
 	retryDelay = 1 * time.Second // 重试延迟
 )
 
-// 更新或创建 NodeResourceInfo CRD
-func UpdateCreateNodeResourceInfo(crdClient dynamic.NamespaceableResourceInterface, grpcClient *grpc.ClientConn, nodeResourceInfo *v1alpha1.NodeResourceInfo, clusterId string) error {
+// 更新或创建 NodeResourceInfo CRD，spec 记录节点描述，status 记录节点状态与资源使用量
+// 检测到的节点变化写入 recorder，recorder 为空时不记录
+func UpdateCreateNodeResourceInfo(crdClient typedv1beta1.NodeResourceInfoInterface, grpcClient *grpc.ClientConn, nodeResourceInfo *v1beta1.NodeResourceInfo, clusterId string, recorder history.Recorder) error {
 	var retryCount int
 
 	for {
 		// 获取当前 CRD 资源
-		existingResourceInfo, err := crdClient.Get(context.TODO(), nodeResourceInfo.Spec.NodeName, metav1.GetOptions{})
+		existing, err := crdClient.Get(context.TODO(), nodeResourceInfo.Spec.NodeName, metav1.GetOptions{})
 		if err != nil {
 			if errors.IsNotFound(err) {
 				// CRD 不存在，则创建
-				return CreateNodeResourceInfo(crdClient, grpcClient, nodeResourceInfo, clusterId)
+				return CreateNodeResourceInfo(crdClient, grpcClient, nodeResourceInfo, clusterId, recorder)
 			}
 			return fmt.Errorf("获取 NodeResourceInfo 失败: %w", err)
 		}
 
-		// 检查是否需要更新
-		needsUpdate, err := isNodeResourceInfoUpdated(existingResourceInfo, nodeResourceInfo)
-		if err != nil {
-			return fmt.Errorf("检查 NodeResourceInfo 资源变更失败: %w", err)
-		}
-
-		if !needsUpdate {
-			log.Printf("NodeResourceInfo %s 没有变动，无需更新", nodeResourceInfo.Spec.NodeName)
+		// 正在删除的 CRD 由删除任务处理，等待 manager 确认删除后再重新创建
+		if existing.GetDeletionTimestamp() != nil {
+			log.Printf("NodeResourceInfo %s 正在删除中，跳过更新", nodeResourceInfo.Spec.NodeName)
 			return nil
 		}
 
-		// 设置 resourceVersion 以支持乐观锁
-		resourceVersion, found, err := unstructured.NestedString(existingResourceInfo.Object, "metadata", "resourceVersion")
-		if err != nil || !found {
-			return fmt.Errorf("无法获取现有 CRD 的 resourceVersion")
-		}
-		nodeResourceInfo.ObjectMeta.ResourceVersion = resourceVersion
-
-		// 尝试更新
-		err = updateNodeResourceInfo(crdClient, existingResourceInfo, nodeResourceInfo)
-		if err == nil {
-			// TODO
-			c := pb.NewNodeManagerClient(grpcClient)
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-			defer cancel()
-
-			resources := make([]*pb.NodeResource, 0, len(nodeResourceInfo.Spec.Resources))
-			for resourceName, resourceInfo := range nodeResourceInfo.Spec.Resources {
-				var capacity, allocatable, unit string
-
-				switch resourceName {
-				case "cpu":
-					capacity = strings.TrimSuffix(resourceInfo.Total, "m")
-					allocatable = strings.TrimSuffix(resourceInfo.Allocatable, "m")
-					unit = "m"
-				case "memory":
-					capacity = strings.TrimSuffix(resourceInfo.Total, "Mi")
-					allocatable = strings.TrimSuffix(resourceInfo.Allocatable, "Mi")
-					unit = "Mi"
-				default:
-					capacity = resourceInfo.Total
-					allocatable = resourceInfo.Allocatable
-					unit = ""
-				}
-				resources = append(resources, &pb.NodeResource{
-					ResourceName: resourceName,
-					Capacity:     capacity,
-					Allocatable:  allocatable,
-					Unit:         unit,
-					IsRemoved:    false,
-				})
-			}
-			addResp, err := c.UpdateNode(ctx, &pb.UpdateNodeRequest{
-				NodeName:   nodeResourceInfo.Name,
-				ClusterId:  clusterId,
-				NodeStatus: nodeResourceInfo.Spec.Status,
-				Resources:  resources,
-			})
+		// 检查 spec 是否需要更新，旧版本创建的 CRD 没有 finalizer 时也需要补上
+		specChanges := diffNodeResourceInfoSpec(existing, nodeResourceInfo)
+		statusChanges := history.DiffStatus(existing, nodeResourceInfo.Status)
+		needsUpdate := len(specChanges) > 0
+		needsUpdate = addNodeManagerFinalizer(existing) || needsUpdate
 
+		current := existing
+		if needsUpdate {
+			existing.Spec = nodeResourceInfo.Spec
+			current, err = crdClient.Update(context.TODO(), existing, metav1.UpdateOptions{})
 			if err != nil {
-				log.Printf("Failed to call AddNode: %v", err)
-				return fmt.Errorf("调用 rpc AddNode 失败: %w", err)
-			}
-			genericResp := addResp
-			log.Printf("Received response: %v", genericResp)
-
-			var addNodeResp pb.AddNodeResponse
-			if err := genericResp.GetData().UnmarshalTo(&addNodeResp); err != nil {
-				log.Printf("Failed to unmarshal AddNodeResponse from data: %v", err)
+				// 处理冲突
+				if errors.IsConflict(err) {
+					retryCount++
+					if retryCount >= maxRetries {
+						return fmt.Errorf("更新 NodeResourceInfo %s 失败，已达到最大重试次数: %w", nodeResourceInfo.Spec.NodeName, err)
+					}
+
+					log.Printf("NodeResourceInfo %s 更新冲突，正在重试 (重试次数: %d/%d)", nodeResourceInfo.Spec.NodeName, retryCount, maxRetries)
+					time.Sleep(retryDelay) // 延迟后重试
+					continue
+				}
+				return fmt.Errorf("无法更新 NodeResourceInfo CRD: %w", err)
 			}
-			log.Printf("Add node: %s", addNodeResp.GetNodeName())
-
-			log.Printf("NodeResourceInfo %s 已创建", nodeResourceInfo.Name)
-			return nil // 更新成功
+			log.Printf("NodeResourceInfo %s 已更新", nodeResourceInfo.Name)
+			recordChanges(recorder, current, specChanges)
 		}
 
-		// 处理冲突
-		if errors.IsConflict(err) {
-			retryCount++
-			if retryCount >= maxRetries {
-				return fmt.Errorf("更新 NodeResourceInfo %s 失败，已达到最大重试次数: %w", nodeResourceInfo.Spec.NodeName, err)
-			}
+		current, err = updateNodeResourceInfoStatus(crdClient, current, nodeResourceInfo.Status)
+		if err != nil {
+			return err
+		}
+		recordChanges(recorder, current, statusChanges)
 
-			log.Printf("NodeResourceInfo %s 更新冲突，正在重试 (重试次数: %d/%d)", nodeResourceInfo.Spec.NodeName, retryCount, maxRetries)
-			time.Sleep(retryDelay) // 延迟后重试
-			continue
+		if !needsManagerSync(current) {
+			log.Printf("NodeResourceInfo %s 没有变动，无需通知 manager", nodeResourceInfo.Spec.NodeName)
+			return nil
 		}
 
-		// 其他错误
-		return fmt.Errorf("无法更新 NodeResourceInfo CRD: %w", err)
+		// 通知失败时 generation 未被确认，下一个周期会继续重试
+		log.Printf("NodeResourceInfo %s generation %d 尚未被 manager 确认，通知 manager", nodeResourceInfo.Spec.NodeName, current.Generation)
+		return SyncToManager(crdClient, grpcClient, current, clusterId, pendingOperation(current))
 	}
 }
 
-// createNodeResourceInfo 创建新的 NodeResourceInfo CRD
-func CreateNodeResourceInfo(crdClient dynamic.NamespaceableResourceInterface, grpcClient *grpc.ClientConn, nodeResourceInfo *v1alpha1.NodeResourceInfo, clusterId string) error {
-	unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(nodeResourceInfo)
-	if err != nil {
-		return fmt.Errorf("无法转换 NodeResourceInfo 对象: %w", err)
+// 创建新的 NodeResourceInfo CRD，status 需要在创建后通过子资源单独写入
+func CreateNodeResourceInfo(crdClient typedv1beta1.NodeResourceInfoInterface, grpcClient *grpc.ClientConn, nodeResourceInfo *v1beta1.NodeResourceInfo, clusterId string, recorder history.Recorder) error {
+	newObj := &v1beta1.NodeResourceInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeResourceInfo.Name,
+		},
+		Spec: nodeResourceInfo.Spec,
 	}
-
-	unstructuredObj["kind"] = "NodeResourceInfo"
-	unstructuredObj["apiVersion"] = "opsflow.io/v1alpha1"
+	addNodeManagerFinalizer(newObj)
 
 	// 不存在则创建
-	_, err = crdClient.Get(context.TODO(), nodeResourceInfo.Name, metav1.GetOptions{})
+	current, err := crdClient.Get(context.TODO(), nodeResourceInfo.Name, metav1.GetOptions{})
 	if err == nil {
 		log.Printf("NodeResourceInfo %s 已存在，跳过创建", nodeResourceInfo.Name)
 	} else if errors.IsNotFound(err) {
-		_, err = crdClient.Create(
-			context.TODO(),
-			&unstructured.Unstructured{Object: unstructuredObj},
-			metav1.CreateOptions{},
-		)
+		current, err = crdClient.Create(context.TODO(), newObj, metav1.CreateOptions{})
 		if err != nil {
 			return fmt.Errorf("无法创建 NodeResourceInfo CRD: %w", err)
 		}
 		log.Printf("成功创建 NodeResourceInfo %s", nodeResourceInfo.Name)
+		recordChanges(recorder, current, []history.NodeChange{history.NewNodeAddedChange(nodeResourceInfo.Name)})
 	} else {
 		return fmt.Errorf("无法查询 NodeResourceInfo %s: %w", nodeResourceInfo.Name, err)
 	}
 
-	// TODO：Add Node
-	c := pb.NewNodeManagerClient(grpcClient)
-	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
-	defer cancel()
-
-	log.Printf("NodeResourceInfo %s 创建中", nodeResourceInfo.Name)
-	resources := make([]*pb.NodeResource, 0, len(nodeResourceInfo.Spec.Resources))
-	for resourceName, resourceInfo := range nodeResourceInfo.Spec.Resources {
-		var capacity, allocatable, unit string
-
-		switch resourceName {
-		case "cpu":
-			capacity = strings.TrimSuffix(resourceInfo.Total, "m")
-			allocatable = strings.TrimSuffix(resourceInfo.Allocatable, "m")
-			unit = "m"
-		case "memory":
-			capacity = strings.TrimSuffix(resourceInfo.Total, "Mi")
-			allocatable = strings.TrimSuffix(resourceInfo.Allocatable, "Mi")
-			unit = "Mi"
-		default:
-			capacity = resourceInfo.Total
-			allocatable = resourceInfo.Allocatable
-			unit = ""
-		}
-		resources = append(resources, &pb.NodeResource{
-			ResourceName: resourceName,
-			Capacity:     capacity,
-			Allocatable:  allocatable,
-			Unit:         unit,
-			IsRemoved:    false,
-		})
+	current, err = updateNodeResourceInfoStatus(crdClient, current, nodeResourceInfo.Status)
+	if err != nil {
+		return err
 	}
 
-	addResp, err := c.AddNode(ctx, &pb.AddNodeRequest{
-		NodeName:         nodeResourceInfo.Name,
-		ClusterId:        clusterId,
-		NodeStatus:       nodeResourceInfo.Spec.Status,
-		Resources:        resources,
-		Roles:            nodeResourceInfo.Spec.Roles,
-		ScheduleVersion:  nodeResourceInfo.Spec.ScheduleVersion,
-		InternalIp:       nodeResourceInfo.Spec.InternalIp,
-		Os:               nodeResourceInfo.Spec.OS,
-		KernelVersion:    nodeResourceInfo.Spec.KernelVersion,
-		ContainerRuntime: nodeResourceInfo.Spec.ContainerRuntime,
-	})
+	log.Printf("NodeResourceInfo %s 创建中", nodeResourceInfo.Name)
+	return SyncToManager(crdClient, grpcClient, current, clusterId, OperationAddNode)
+}
 
-	if err != nil {
-		log.Printf("Failed to call AddNode: %v", err)
-		return fmt.Errorf("调用 rpc AddNode 失败: %w", err)
+// 添加 manager finalizer，返回是否有变化
+func addNodeManagerFinalizer(obj *v1beta1.NodeResourceInfo) bool {
+	finalizers := obj.GetFinalizers()
+	if slices.Contains(finalizers, v1beta1.NodeManagerFinalizer) {
+		return false
 	}
-	genericResp := addResp
-	log.Printf("Received response: %v", genericResp)
+	obj.SetFinalizers(append(finalizers, v1beta1.NodeManagerFinalizer))
+	return true
+}
 
-	var addNodeResp pb.AddNodeResponse
-	if err := genericResp.GetData().UnmarshalTo(&addNodeResp); err != nil {
-		log.Printf("Failed to unmarshal AddNodeResponse from data: %v", err)
+// 通过 status 子资源更新节点状态、资源使用量与 Ready condition，
+// 节点状态变化时 manager 需要重新确认
+func updateNodeResourceInfoStatus(crdClient typedv1beta1.NodeResourceInfoInterface, current *v1beta1.NodeResourceInfo, observed v1beta1.NodeResourceInfoStatus) (*v1beta1.NodeResourceInfo, error) {
+	if !isNodeResourceInfoStatusUpdated(current, observed) {
+		return current, nil
 	}
-	log.Printf("Add node: %s", addNodeResp.GetNodeName())
-
-	log.Printf("NodeResourceInfo %s 已创建", nodeResourceInfo.Name)
-	return nil
-}
 
-// 更新已有的 NodeResourceInfo CRD
-func updateNodeResourceInfo(crdClient dynamic.NamespaceableResourceInterface, existing *unstructured.Unstructured, nodeResourceInfo *v1alpha1.NodeResourceInfo) error {
-	existing.Object["spec"] = nodeResourceInfo.Spec
+	var updated *v1beta1.NodeResourceInfo
+	latest := current.DeepCopy()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if isNodeStatusChanged(&latest.Status, &observed) {
+			log.Printf("节点状态发生变化: 旧值 = %v %+v, 新值 = %v %+v", latest.Status.Health, latest.Status.NodeConditions, observed.Health, observed.NodeConditions)
+			meta.SetStatusCondition(&latest.Status.Conditions, metav1.Condition{
+				Type:               v1beta1.ConditionSyncedToManager,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: latest.Generation,
+				Reason:             "NodeStatusChanged",
+				Message:            fmt.Sprintf("节点状态变为 %s，等待 NodeManager 确认", observed.Health),
+			})
+		}
+		// GPU 占用通过 NodeResource.properties 上报，manager 需要收到新的占用
+		if !slices.Equal(latest.Status.GPUAllocations, observed.GPUAllocations) {
+			meta.SetStatusCondition(&latest.Status.Conditions, metav1.Condition{
+				Type:               v1beta1.ConditionSyncedToManager,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: latest.Generation,
+				Reason:             "GPUAllocationsChanged",
+				Message:            "GPU 占用发生变化，等待 NodeManager 确认",
+			})
+		}
+		// 开启上报时 manager 需要收到新的拆分数据
+		if observed.UsageBreakdown != nil && observed.UsageBreakdown.ReportToManager &&
+			!reflect.DeepEqual(latest.Status.UsageBreakdown, observed.UsageBreakdown) {
+			meta.SetStatusCondition(&latest.Status.Conditions, metav1.Condition{
+				Type:               v1beta1.ConditionSyncedToManager,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: latest.Generation,
+				Reason:             "UsageBreakdownChanged",
+				Message:            "资源使用拆分发生变化，等待 NodeManager 确认",
+			})
+		}
+		latest.Status.NodeStatus = observed.NodeStatus
+		latest.Status.Health = observed.Health
+		latest.Status.NodeConditions = observed.NodeConditions
+		latest.Status.Resources = observed.Resources
+		latest.Status.GPUAllocations = observed.GPUAllocations
+		latest.Status.UsageBreakdown = observed.UsageBreakdown
+		latest.Status.Utilization = observed.Utilization
+		latest.Status.ObservedGeneration = latest.Generation
+		for _, condition := range observed.Conditions {
+			condition.ObservedGeneration = latest.Generation
+			meta.SetStatusCondition(&latest.Status.Conditions, condition)
+		}
 
-	_, err := crdClient.Update(context.TODO(), existing, metav1.UpdateOptions{})
+		result, err := crdClient.UpdateStatus(context.TODO(), latest, metav1.UpdateOptions{})
+		if err != nil {
+			// 冲突后基于最新对象重试
+			if errors.IsConflict(err) {
+				if fresh, getErr := crdClient.Get(context.TODO(), current.Name, metav1.GetOptions{}); getErr == nil {
+					latest = fresh
+				}
+			}
+			return err
+		}
+		updated = result
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("无法更新 NodeResourceInfo CRD: %w", err)
+		return nil, fmt.Errorf("无法更新 NodeResourceInfo %s status: %w", current.Name, err)
 	}
-
-	log.Printf("NodeResourceInfo %s 已更新", nodeResourceInfo.Name)
-	return nil
+	return updated, nil
 }
 
-// 检查 CRD 是否需要更新
-func isNodeResourceInfoUpdated(existing *unstructured.Unstructured, newNodeResourceInfo *v1alpha1.NodeResourceInfo) (bool, error) {
-	// 将 Unstructured 转换为 NodeResourceInfo
-	var existingNodeResourceInfo v1alpha1.NodeResourceInfo
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(existing.UnstructuredContent(), &existingNodeResourceInfo)
-	if err != nil {
-		return true, fmt.Errorf("转换 Unstructured 到 NodeResourceInfo 失败: %v", err)
+// 检查 status 中由节点采集的字段是否变化
+func isNodeResourceInfoStatusUpdated(current *v1beta1.NodeResourceInfo, observed v1beta1.NodeResourceInfoStatus) bool {
+	if isNodeStatusChanged(&current.Status, &observed) {
+		return true
 	}
-
-	// 检查 Status 是否变化
-	if existingNodeResourceInfo.Spec.Status != newNodeResourceInfo.Spec.Status {
-		log.Printf("Status 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.Status, newNodeResourceInfo.Spec.Status)
-		return true, nil
+	if current.Status.ObservedGeneration != current.Generation {
+		return true
+	}
+	if !maps.Equal(current.Status.Resources, observed.Resources) {
+		log.Printf("资源使用量发生变化: 旧值 = %+v, 新值 = %+v", current.Status.Resources, observed.Resources)
+		return true
 	}
-	if existingNodeResourceInfo.Spec.ScheduleVersion != newNodeResourceInfo.Spec.ScheduleVersion {
-		log.Printf("ScheduleVersion 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.ScheduleVersion, newNodeResourceInfo.Spec.ScheduleVersion)
-		return true, nil
+	if !slices.Equal(current.Status.GPUAllocations, observed.GPUAllocations) {
+		return true
 	}
-	if existingNodeResourceInfo.Spec.InternalIp != newNodeResourceInfo.Spec.InternalIp {
-		log.Printf("InternalIp 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.InternalIp, newNodeResourceInfo.Spec.InternalIp)
-		return true, nil
+	if !reflect.DeepEqual(current.Status.UsageBreakdown, observed.UsageBreakdown) {
+		return true
 	}
-	if existingNodeResourceInfo.Spec.OS != newNodeResourceInfo.Spec.OS {
-		log.Printf("OS 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.OS, newNodeResourceInfo.Spec.OS)
-		return true, nil
+	if !isUtilizationEqual(current.Status.Utilization, observed.Utilization) {
+		return true
 	}
-	if existingNodeResourceInfo.Spec.KernelVersion != newNodeResourceInfo.Spec.KernelVersion {
-		log.Printf("KernelVersion 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.KernelVersion, newNodeResourceInfo.Spec.KernelVersion)
-		return true, nil
+	for _, condition := range observed.Conditions {
+		existing := meta.FindStatusCondition(current.Status.Conditions, condition.Type)
+		if existing == nil || existing.Status != condition.Status || existing.Reason != condition.Reason || existing.Message != condition.Message {
+			return true
+		}
 	}
-	if existingNodeResourceInfo.Spec.Roles != newNodeResourceInfo.Spec.Roles {
-		log.Printf("Roles 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.Roles, newNodeResourceInfo.Spec.Roles)
-		return true, nil
+	return false
+}
+
+// 比较实际使用时忽略采集时间，采集时间每个周期都不同，只在其他字段变化时随之更新
+func isUtilizationEqual(current, observed *v1beta1.Utilization) bool {
+	if current == nil || observed == nil {
+		return current == observed
 	}
-	if existingNodeResourceInfo.Spec.ContainerRuntime != newNodeResourceInfo.Spec.ContainerRuntime {
-		log.Printf("ContainerRuntime 发生变化: 旧值 = %v, 新值 = %v", existingNodeResourceInfo.Spec.ContainerRuntime, newNodeResourceInfo.Spec.ContainerRuntime)
-		return true, nil
+	a, b := *current, *observed
+	a.Time, b.Time = metav1.Time{}, metav1.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+// 节点状态（兼容字段、健康状态或 conditions）是否变化，变化时需要重新通知 manager
+func isNodeStatusChanged(current, observed *v1beta1.NodeResourceInfoStatus) bool {
+	if current.NodeStatus != observed.NodeStatus || current.Health != observed.Health {
+		return true
 	}
+	// lastTransitionTime 经过 JSON 序列化后时区可能不同，需要用 Equal 比较
+	return !slices.EqualFunc(current.NodeConditions, observed.NodeConditions, func(a, b v1beta1.NodeCondition) bool {
+		return a.Type == b.Type && a.Status == b.Status && a.Reason == b.Reason && a.LastTransitionTime.Equal(&b.LastTransitionTime)
+	})
+}
 
-	// 检查 Resources 是否变化
-	if len(existingNodeResourceInfo.Spec.Resources) != len(newNodeResourceInfo.Spec.Resources) {
-		log.Printf("Resources 数量发生变化: 旧数量 = %d, 新数量 = %d", len(existingNodeResourceInfo.Spec.Resources), len(newNodeResourceInfo.Spec.Resources))
-		return true, nil
+// 检查 CRD spec 是否需要更新，返回检测到的变化
+func diffNodeResourceInfoSpec(existingNodeResourceInfo *v1beta1.NodeResourceInfo, newNodeResourceInfo *v1beta1.NodeResourceInfo) []history.NodeChange {
+	changes := history.DiffSpec(existingNodeResourceInfo, newNodeResourceInfo)
+	for _, change := range changes {
+		log.Printf("%s 发生变化: 旧值 = %v, 新值 = %v", change.Field, change.Old, change.New)
 	}
+	return changes
+}
 
-	for resourceName, newResource := range newNodeResourceInfo.Spec.Resources {
-		existingResource, exists := existingNodeResourceInfo.Spec.Resources[resourceName]
-		if !exists {
-			log.Printf("资源 %s 在现有 CRD 中不存在，新增该资源", resourceName)
-			return true, nil
+// 记录节点变化，失败时只记录日志
+func recordChanges(recorder history.Recorder, nodeResourceInfo *v1beta1.NodeResourceInfo, changes []history.NodeChange) {
+	if recorder == nil || len(changes) == 0 {
+		return
+	}
+	if err := recorder.Record(context.TODO(), nodeResourceInfo, changes); err != nil {
+		log.Printf("记录节点 %s 的变化失败: %v", nodeResourceInfo.Name, err)
+	}
+}
+
+// 将 CRD 中的资源转换为 rpc 的 NodeResource，去掉标准单位后缀后单独上报单位，
+// GPU 与 MIG 资源的型号、显存与占用，以及开启上报时的使用拆分放在 properties 中
+func buildNodeResources(nodeResourceInfo *v1beta1.NodeResourceInfo) []*pb.NodeResource {
+	resourceInfos := nodeResourceInfo.Spec.Resources
+	resources := make([]*pb.NodeResource, 0, len(resourceInfos))
+	for resourceName, resourceInfo := range resourceInfos {
+		unit := ResourceUnit(resourceName)
+		nodeResource := &pb.NodeResource{
+			ResourceName: resourceName,
+			Capacity:     strings.TrimSuffix(resourceInfo.Total, unit),
+			Allocatable:  strings.TrimSuffix(resourceInfo.Allocatable, unit),
+			Unit:         unit,
+			IsRemoved:    false,
 		}
 
-		if existingResource.Total != newResource.Total ||
-			existingResource.Allocatable != newResource.Allocatable ||
-			existingResource.Used != newResource.Used {
-			log.Printf("资源 %s 发生变化: 旧值 = %+v, 新值 = %+v", resourceName, existingResource, newResource)
-			return true, nil
+		properties := gpuResourceProperties(resourceName, nodeResourceInfo.Spec.GPU, nodeResourceInfo.Status.GPUAllocations)
+		if breakdown := usageBreakdownProperties(resourceName, nodeResourceInfo.Status.UsageBreakdown); len(breakdown) > 0 {
+			if properties == nil {
+				properties = map[string]any{}
+			}
+			maps.Copy(properties, breakdown)
 		}
+		if len(properties) > 0 {
+			propertiesStruct, err := structpb.NewStruct(properties)
+			if err != nil {
+				log.Printf("无法转换资源 %s 的 properties: %v", resourceName, err)
+			} else {
+				nodeResource.Properties = propertiesStruct
+			}
+		}
+		resources = append(resources, nodeResource)
 	}
+	return resources
+}
 
-	return false, nil
+func buildNodeTaints(taints []v1beta1.NodeTaint) []*pb.NodeTaint {
+	nodeTaints := make([]*pb.NodeTaint, 0, len(taints))
+	for _, taint := range taints {
+		nodeTaints = append(nodeTaints, &pb.NodeTaint{
+			Key:    taint.Key,
+			Value:  taint.Value,
+			Effect: taint.Effect,
+		})
+	}
+	return nodeTaints
+}
+
+// BuildNodeConditions 将 CRD 中的 conditions 转换为 rpc 中的结构
+func BuildNodeConditions(conditions []v1beta1.NodeCondition) []*pb.NodeCondition {
+	nodeConditions := make([]*pb.NodeCondition, 0, len(conditions))
+	for _, condition := range conditions {
+		nodeCondition := &pb.NodeCondition{
+			Type:   condition.Type,
+			Status: condition.Status,
+			Reason: condition.Reason,
+		}
+		if !condition.LastTransitionTime.IsZero() {
+			nodeCondition.LastTransitionTime = timestamppb.New(condition.LastTransitionTime.Time)
+		}
+		nodeConditions = append(nodeConditions, nodeCondition)
+	}
+	return nodeConditions
 }
@@ -0,0 +1,112 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// UtilizationCollector 从 metrics.k8s.io 与 DCGM exporter 采集节点实际资源使用
+type UtilizationCollector struct {
+	Metrics metricsclient.Interface // metrics-server 客户端，为空时不采集 cpu/内存
+	DCGM    *DCGMExporter           // DCGM exporter，为空时不采集 GPU
+}
+
+// NodeUtilization 一次采集的结果
+type NodeUtilization struct {
+	Usage  v1.ResourceList
+	Status v1beta1.Utilization
+}
+
+// Collect 采集节点的实际使用，NodeMetrics 不存在时使用节点上 Pod 的 PodMetrics 之和
+func (c *UtilizationCollector) Collect(ctx context.Context, node *v1.Node, pods []v1.Pod) (*NodeUtilization, error) {
+	result := &NodeUtilization{
+		Usage:  v1.ResourceList{},
+		Status: v1beta1.Utilization{Time: metav1.Now()},
+	}
+
+	if c.Metrics != nil {
+		if err := c.collectMetrics(ctx, node.Name, pods, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.DCGM != nil {
+		devices, err := c.DCGM.Scrape(ctx, node)
+		if err != nil {
+			return nil, fmt.Errorf("采集节点 %s 的 DCGM 指标失败: %w", node.Name, err)
+		}
+		result.Status.Devices = devices
+		if len(devices) > 0 {
+			result.Usage[GPUResourceName] = gpuEquivalents(devices)
+		}
+	}
+	return result, nil
+}
+
+func (c *UtilizationCollector) collectMetrics(ctx context.Context, nodeName string, pods []v1.Pod, result *NodeUtilization) error {
+	nodeMetrics, err := c.Metrics.MetricsV1beta1().NodeMetricses().Get(ctx, nodeName, metav1.GetOptions{})
+	if err == nil {
+		result.Usage[v1.ResourceCPU] = nodeMetrics.Usage[v1.ResourceCPU]
+		result.Usage[v1.ResourceMemory] = nodeMetrics.Usage[v1.ResourceMemory]
+		result.Status.Time = nodeMetrics.Timestamp
+		result.Status.Window = nodeMetrics.Window.Duration.String()
+		result.Status.Source = "NodeMetrics"
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("获取节点 %s 的 NodeMetrics 失败: %w", nodeName, err)
+	}
+
+	// metrics-server 还没有节点数据时按 Pod 汇总
+	usage := v1.ResourceList{}
+	var latest time.Time
+	for i := range pods {
+		podMetrics, err := c.Metrics.MetricsV1beta1().PodMetricses(pods[i].Namespace).Get(ctx, pods[i].Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("获取 Pod %s/%s 的 PodMetrics 失败: %w", pods[i].Namespace, pods[i].Name, err)
+		}
+		for _, container := range podMetrics.Containers {
+			addResourceList(usage, container.Usage)
+		}
+		if podMetrics.Timestamp.After(latest) {
+			latest = podMetrics.Timestamp.Time
+			result.Status.Window = podMetrics.Window.Duration.String()
+		}
+	}
+	if latest.IsZero() {
+		return nil
+	}
+	result.Usage[v1.ResourceCPU] = usage[v1.ResourceCPU]
+	result.Usage[v1.ResourceMemory] = usage[v1.ResourceMemory]
+	result.Status.Time = metav1.NewTime(latest)
+	result.Status.Source = "PodMetrics"
+	return nil
+}
+
+// 把所有卡的利用率折算为满载的卡数，保留两位小数
+func gpuEquivalents(devices []v1beta1.GPUDeviceUtilization) resource.Quantity {
+	var percent int64
+	for _, device := range devices {
+		percent += device.Utilization
+	}
+	return *resource.NewScaledQuantity(percent, -2)
+}
+
+// 按资源类型格式化实际使用量，GPU 保留小数
+func formatUtilized(resourceName string, quantity resource.Quantity) string {
+	if resourceName == GPUResourceName {
+		return fmt.Sprintf("%.2f", quantity.AsApproximateFloat64())
+	}
+	return FormatResourceQuantity(resourceName, quantity)
+}
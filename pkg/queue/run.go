@@ -5,15 +5,19 @@ import (
 	"log"
 	"sync"
 
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
 type TaskProcessorConfig struct {
 	Clientset   kubernetes.Interface
-	CRDClient   dynamic.NamespaceableResourceInterface
+	CRDClient   typedv1beta1.NodeResourceInfoInterface
+	LoadOptions resourceinfo.LoadOptions // 节点资源采集选项
+	Recorder    history.Recorder         // 节点变化记录
 	RpcConn     *grpc.ClientConn
 	RedisClient redis.Cmdable
 	WorkerCount int
@@ -30,7 +34,7 @@ func StartTaskQueueProcessor(ctx context.Context, config TaskProcessorConfig) {
 	go monitorTaskQueue(ctx, config.RedisClient, config.QueueName, taskChannel)
 
 	var wg sync.WaitGroup
-	processor := NewTaskProcessor(config.Clientset, &config.CRDClient, config.RpcConn)
+	processor := NewTaskProcessor(config.Clientset, config.CRDClient, config.RpcConn, config.LoadOptions, config.Recorder)
 
 	for i := range config.WorkerCount {
 		wg.Add(1)
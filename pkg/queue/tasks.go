@@ -7,11 +7,13 @@ import (
 	"log"
 	"strings"
 
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
 	"github.com/modcoco/OpsFlow/pkg/node"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -59,15 +61,19 @@ func (h *ReportHandler) Handle(payload any) error {
 
 type NodeBatchHandler struct {
 	clientset  kubernetes.Interface
-	crdClient  *dynamic.NamespaceableResourceInterface
+	crdClient  typedv1beta1.NodeResourceInfoInterface
 	grpcClient *grpc.ClientConn
+	options    resourceinfo.LoadOptions
+	recorder   history.Recorder
 }
 
-func NewNodeBatchHandler(clientset kubernetes.Interface, crdClient *dynamic.NamespaceableResourceInterface, grpcClient *grpc.ClientConn) *NodeBatchHandler {
+func NewNodeBatchHandler(clientset kubernetes.Interface, crdClient typedv1beta1.NodeResourceInfoInterface, grpcClient *grpc.ClientConn, options resourceinfo.LoadOptions, recorder history.Recorder) *NodeBatchHandler {
 	return &NodeBatchHandler{
 		clientset:  clientset,
 		crdClient:  crdClient,
 		grpcClient: grpcClient,
+		options:    options,
+		recorder:   recorder,
 	}
 }
 
@@ -86,19 +92,14 @@ func (h *NodeBatchHandler) Handle(payload any) error {
 		return fmt.Errorf("failed to list nodes: %v", err)
 	}
 
-	resourceNamesToTrack := map[string]bool{
-		"cpu":            true,
-		"memory":         true,
-		"nvidia.com/gpu": true,
-	}
-
 	opts := node.BatchUpdateCreateOptions{
-		Clientset:            h.clientset,
-		CRDClient:            h.crdClient,
-		GRPCClient:           h.grpcClient,
-		Nodes:                nodes,
-		ResourceNamesToTrack: resourceNamesToTrack,
-		Parallelism:          3,
+		Clientset:   h.clientset,
+		CRDClient:   h.crdClient,
+		GRPCClient:  h.grpcClient,
+		Nodes:       nodes,
+		LoadOptions: h.options,
+		Recorder:    h.recorder,
+		Parallelism: 3,
 	}
 
 	if err := node.BatchAddNodeResourceInfo(opts); err != nil {
@@ -131,13 +132,13 @@ type TaskProcessor struct {
 	handlers map[string]TaskHandler
 }
 
-func NewTaskProcessor(clientset kubernetes.Interface, crdClient *dynamic.NamespaceableResourceInterface, grpcClient *grpc.ClientConn) *TaskProcessor {
+func NewTaskProcessor(clientset kubernetes.Interface, crdClient typedv1beta1.NodeResourceInfoInterface, grpcClient *grpc.ClientConn, options resourceinfo.LoadOptions, recorder history.Recorder) *TaskProcessor {
 	return &TaskProcessor{
 		handlers: map[string]TaskHandler{
 			"email":        &EmailHandler{},
 			"notification": &NotificationHandler{},
 			"report":       &ReportHandler{},
-			"node_batch":   NewNodeBatchHandler(clientset, crdClient, grpcClient), // 传入 Kubernetes 客户端
+			"node_batch":   NewNodeBatchHandler(clientset, crdClient, grpcClient, options, recorder), // 传入 Kubernetes 客户端
 		},
 	}
 }
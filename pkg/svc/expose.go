@@ -0,0 +1,359 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	istioapi "istio.io/api/networking/v1alpha3"
+	istionetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// 推理服务的暴露方式
+const (
+	ExposeClusterIP = "ClusterIP"
+	ExposeNodePort  = "NodePort"
+	ExposeIngress   = "Ingress"
+	ExposeIstio     = "Istio"
+)
+
+// ServicePort head 上推理服务的端口
+const ServicePort = 8000
+
+var defaultGatewaySelector = map[string]string{"istio": "ingressgateway"}
+
+// ingressRewriteAnnotations 常见 Ingress controller 改写路径的注解，Ingress 没有统一的改写方式，
+// path 不为 / 时需要设置其中之一，否则推理服务收到的路径带有前缀
+var ingressRewriteAnnotations = []string{
+	"nginx.ingress.kubernetes.io/rewrite-target",
+	"traefik.ingress.kubernetes.io/router.middlewares",
+	"haproxy.org/path-rewrite",
+	"ingress.kubernetes.io/rewrite-target",
+}
+
+// ServiceName RayCluster 推理服务 Service 的名称
+func ServiceName(clusterName string) string {
+	return fmt.Sprintf("%s-vllm-svc", clusterName)
+}
+
+// ExposeMode 返回暴露方式，未设置时为 ClusterIP
+func ExposeMode(expose *model.ExposeConfig) string {
+	if expose == nil || expose.Mode == "" {
+		return ExposeClusterIP
+	}
+	return expose.Mode
+}
+
+// 去掉末尾的 /，/llama/ 与 /llama 相同
+func exposePath(expose *model.ExposeConfig) string {
+	if expose.Path == "" {
+		return "/"
+	}
+	return path.Clean(expose.Path)
+}
+
+// ValidateExpose 检查暴露方式的参数
+func ValidateExpose(expose *model.ExposeConfig) error {
+	switch ExposeMode(expose) {
+	case ExposeClusterIP:
+		return nil
+	case ExposeNodePort:
+		if expose.NodePort < 0 || expose.NodePort > 65535 {
+			return fmt.Errorf("invalid expose.nodePort %d", expose.NodePort)
+		}
+		return nil
+	case ExposeIngress, ExposeIstio:
+	default:
+		return fmt.Errorf("unknown expose mode %q, supported modes: %s", expose.Mode,
+			strings.Join([]string{ExposeClusterIP, ExposeNodePort, ExposeIngress, ExposeIstio}, ", "))
+	}
+
+	if expose.Host == "" {
+		return fmt.Errorf("expose.host is required for mode %s", expose.Mode)
+	}
+	if !strings.HasPrefix(exposePath(expose), "/") {
+		return fmt.Errorf("expose.path must start with /, got %q", expose.Path)
+	}
+	if expose.Mode == ExposeIngress && exposePath(expose) != "/" && !slices.ContainsFunc(ingressRewriteAnnotations, func(key string) bool {
+		_, ok := expose.Annotations[key]
+		return ok
+	}) {
+		return fmt.Errorf("expose.path %s requires a path rewrite annotation for mode %s, one of: %s",
+			expose.Path, ExposeIngress, strings.Join(ingressRewriteAnnotations, ", "))
+	}
+	if expose.Gateway != "" {
+		if namespace, name, ok := strings.Cut(expose.Gateway, "/"); !ok || namespace == "" || name == "" {
+			return fmt.Errorf("expose.gateway must be namespace/name, got %q", expose.Gateway)
+		}
+	}
+	return nil
+}
+
+// GenerateExposedService 按暴露方式生成 head Service，NodePort 模式修改 Service 类型
+func GenerateExposedService(namespace, clusterName string, expose *model.ExposeConfig) *corev1.Service {
+	service := GenerateRayClusterService(namespace, clusterName)
+	if ExposeMode(expose) == ExposeNodePort {
+		service.Spec.Type = corev1.ServiceTypeNodePort
+		service.Spec.Ports[0].NodePort = expose.NodePort
+	}
+	return service
+}
+
+// GenerateIngress 把 host 与路径前缀转发到 head Service，Prefix 按路径段匹配，
+// 路径改写通过 annotations 交给 Ingress controller
+func GenerateIngress(namespace, name, serviceName string, expose *model.ExposeConfig) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: maps.Clone(expose.Annotations),
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: expose.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     exposePath(expose),
+							PathType: ptr.To(networkingv1.PathTypePrefix),
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: serviceName,
+									Port: networkingv1.ServiceBackendPort{Number: ServicePort},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if expose.IngressClassName != "" {
+		ingress.Spec.IngressClassName = ptr.To(expose.IngressClassName)
+	}
+	return ingress
+}
+
+// GenerateGateway 为任务创建的 Istio Gateway，监听 80 端口上的 host
+func GenerateGateway(namespace, name string, expose *model.ExposeConfig) *istionetworkingv1.Gateway {
+	selector := expose.GatewaySelector
+	if len(selector) == 0 {
+		selector = defaultGatewaySelector
+	}
+	return &istionetworkingv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: istioapi.Gateway{
+			Selector: maps.Clone(selector),
+			Servers: []*istioapi.Server{{
+				Port:  &istioapi.Port{Number: 80, Name: "http", Protocol: "HTTP"},
+				Hosts: []string{expose.Host},
+			}},
+		},
+	}
+}
+
+// GatewayRef 返回 VirtualService 使用的 Gateway，namespace/name
+func GatewayRef(namespace, name string, expose *model.ExposeConfig) string {
+	if expose.Gateway != "" {
+		return expose.Gateway
+	}
+	return namespace + "/" + name
+}
+
+// WeightedDestination VirtualService 转发到的一个 Service 与权重
+type WeightedDestination struct {
+	ServiceName string
+	Weight      int32
+}
+
+// GenerateVirtualService 把 host 与路径前缀转发到 head Service，路径前缀改写为 /，
+// 推理服务的 OpenAI 接口路径不需要调整。path 为 /llama 时匹配 /llama 与 /llama/ 开头的路径，
+// /llama/v1/models 改写为 /v1/models，不匹配 /llamafoo
+func GenerateVirtualService(namespace, name, serviceName string, expose *model.ExposeConfig) *istionetworkingv1.VirtualService {
+	return GenerateWeightedVirtualService(namespace, name, expose, []WeightedDestination{{ServiceName: serviceName, Weight: 100}})
+}
+
+// GenerateWeightedVirtualService 按权重把流量分配到多个 Service，只有一个目标时不写权重
+func GenerateWeightedVirtualService(namespace, name string, expose *model.ExposeConfig, destinations []WeightedDestination) *istionetworkingv1.VirtualService {
+	prefix := exposePath(expose)
+	route := &istioapi.HTTPRoute{
+		Match: []*istioapi.HTTPMatchRequest{{
+			Uri: &istioapi.StringMatch{MatchType: &istioapi.StringMatch_Prefix{Prefix: prefix}},
+		}},
+	}
+	if prefix != "/" {
+		// 前缀带上 /，改写时替换整个前缀，不会产生 //
+		route.Match = []*istioapi.HTTPMatchRequest{
+			{Uri: &istioapi.StringMatch{MatchType: &istioapi.StringMatch_Prefix{Prefix: prefix + "/"}}},
+			{Uri: &istioapi.StringMatch{MatchType: &istioapi.StringMatch_Exact{Exact: prefix}}},
+		}
+		route.Rewrite = &istioapi.HTTPRewrite{Uri: "/"}
+	}
+	for _, destination := range destinations {
+		routeDestination := &istioapi.HTTPRouteDestination{
+			Destination: &istioapi.Destination{
+				Host: fmt.Sprintf("%s.%s.svc.cluster.local", destination.ServiceName, namespace),
+				Port: &istioapi.PortSelector{Number: ServicePort},
+			},
+		}
+		if len(destinations) > 1 {
+			routeDestination.Weight = destination.Weight
+		}
+		route.Route = append(route.Route, routeDestination)
+	}
+
+	return &istionetworkingv1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: istioapi.VirtualService{
+			Hosts:    []string{expose.Host},
+			Gateways: []string{GatewayRef(namespace, name, expose)},
+			Http:     []*istioapi.HTTPRoute{route},
+		},
+	}
+}
+
+// CreateExposure 创建 head Service 以及暴露方式需要的 Ingress 或 Gateway、VirtualService，
+// 资源以任务名命名并带上 labels，删除任务时按 label 清理
+func CreateExposure(ctx context.Context, core kubernetes.Interface, istio istioclient.Interface, namespace, jobName, clusterName string, labels map[string]string, expose *model.ExposeConfig) error {
+	service := GenerateExposedService(namespace, clusterName, expose)
+	maps.Copy(service.Labels, labels)
+	if _, err := core.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("create service %s: %w", service.Name, err)
+	}
+
+	switch ExposeMode(expose) {
+	case ExposeIngress:
+		ingress := GenerateIngress(namespace, jobName, service.Name, expose)
+		ingress.Labels = maps.Clone(labels)
+		if _, err := core.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create ingress %s: %w", ingress.Name, err)
+		}
+	case ExposeIstio:
+		if expose.Gateway == "" {
+			gateway := GenerateGateway(namespace, jobName, expose)
+			gateway.Labels = maps.Clone(labels)
+			if _, err := istio.NetworkingV1().Gateways(namespace).Create(ctx, gateway, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("create gateway %s: %w", gateway.Name, err)
+			}
+		}
+		virtualService := GenerateVirtualService(namespace, jobName, service.Name, expose)
+		virtualService.Labels = maps.Clone(labels)
+		if _, err := istio.NetworkingV1().VirtualServices(namespace).Create(ctx, virtualService, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create virtualservice %s: %w", virtualService.Name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteExposureByLabel 删除任务的 Ingress、VirtualService 与 Gateway，没有安装 Istio 时跳过
+func DeleteExposureByLabel(ctx context.Context, core kubernetes.Interface, istio istioclient.Interface, namespace, labelSelector string) error {
+	listOptions := metav1.ListOptions{LabelSelector: labelSelector}
+
+	ingresses, err := core.NetworkingV1().Ingresses(namespace).List(ctx, listOptions)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		for _, ingress := range ingresses.Items {
+			if err := core.NetworkingV1().Ingresses(namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	virtualServices, err := istio.NetworkingV1().VirtualServices(namespace).List(ctx, listOptions)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		for _, virtualService := range virtualServices.Items {
+			if err := istio.NetworkingV1().VirtualServices(namespace).Delete(ctx, virtualService.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	gateways, err := istio.NetworkingV1().Gateways(namespace).List(ctx, listOptions)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		for _, gateway := range gateways.Items {
+			if err := istio.NetworkingV1().Gateways(namespace).Delete(ctx, gateway.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetExposure 按 label 查找任务的 Service、Ingress 与 VirtualService，返回暴露方式，
+// Service 还没有创建时返回 nil
+func GetExposure(ctx context.Context, core kubernetes.Interface, istio istioclient.Interface, namespace, labelSelector string) (*model.ExposureStatus, error) {
+	listOptions := metav1.ListOptions{LabelSelector: labelSelector}
+
+	services, err := core.CoreV1().Services(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(services.Items) == 0 {
+		return nil, nil
+	}
+	service := services.Items[0]
+	exposure := &model.ExposureStatus{Mode: ExposeClusterIP, Service: service.Name}
+	if service.Spec.Type == corev1.ServiceTypeNodePort && len(service.Spec.Ports) > 0 {
+		exposure.Mode = ExposeNodePort
+		exposure.NodePort = service.Spec.Ports[0].NodePort
+	}
+
+	ingresses, err := core.NetworkingV1().Ingresses(namespace).List(ctx, listOptions)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && len(ingresses.Items) > 0 {
+		ingress := ingresses.Items[0]
+		exposure.Mode = ExposeIngress
+		exposure.Ingress = ingress.Name
+		if rules := ingress.Spec.Rules; len(rules) > 0 {
+			exposure.Host = rules[0].Host
+			if rules[0].HTTP != nil && len(rules[0].HTTP.Paths) > 0 {
+				exposure.Path = rules[0].HTTP.Paths[0].Path
+			}
+		}
+		return exposure, nil
+	}
+
+	virtualServices, err := istio.NetworkingV1().VirtualServices(namespace).List(ctx, listOptions)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && len(virtualServices.Items) > 0 {
+		virtualService := virtualServices.Items[0]
+		exposure.Mode = ExposeIstio
+		exposure.VirtualService = virtualService.Name
+		if hosts := virtualService.Spec.Hosts; len(hosts) > 0 {
+			exposure.Host = hosts[0]
+		}
+		if gateways := virtualService.Spec.Gateways; len(gateways) > 0 {
+			exposure.Gateway = gateways[0]
+		}
+		if routes := virtualService.Spec.Http; len(routes) > 0 && len(routes[0].Match) > 0 {
+			exposure.Path = routes[0].Match[0].GetUri().GetPrefix()
+			if exposure.Path != "/" {
+				exposure.Path = strings.TrimSuffix(exposure.Path, "/")
+			}
+		}
+	}
+	return exposure, nil
+}
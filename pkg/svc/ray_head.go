@@ -9,7 +9,7 @@ import (
 )
 
 func GenerateRayClusterService(namespace, clusterName string) *corev1.Service {
-	serviceName := fmt.Sprintf("%s-vllm-svc", clusterName)
+	serviceName := ServiceName(clusterName)
 	identifier := fmt.Sprintf("%s-head", clusterName)
 
 	return &corev1.Service{
@@ -28,8 +28,8 @@ func GenerateRayClusterService(namespace, clusterName string) *corev1.Service {
 				{
 					Name:       "target-port",
 					Protocol:   corev1.ProtocolTCP,
-					Port:       8000,
-					TargetPort: intstr.FromInt(8000),
+					Port:       ServicePort,
+					TargetPort: intstr.FromInt(ServicePort),
 				},
 			},
 			Selector: map[string]string{
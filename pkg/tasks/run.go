@@ -4,8 +4,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/modcoco/OpsFlow/pkg/artifact"
 	"github.com/modcoco/OpsFlow/pkg/core"
 	"github.com/modcoco/OpsFlow/pkg/crd"
+	"github.com/modcoco/OpsFlow/pkg/deployment"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 )
@@ -16,7 +21,7 @@ type TaskConfig struct {
 	WaitForCompletion bool          // 是否等待上一个任务完成
 }
 
-func InitializeTasks(clent core.Client, redisClient redis.Cmdable, grpc *grpc.ClientConn) map[string]TaskConfig {
+func InitializeTasks(clent core.Client, redisClient redis.Cmdable, grpc *grpc.ClientConn, recorder history.Recorder, jobOptions model.JobOptions) map[string]TaskConfig {
 	updateNodeInfoConfig := &QueueConfig{
 		Clientset:   clent.Core(),
 		RedisClient: redisClient,
@@ -25,12 +30,29 @@ func InitializeTasks(clent core.Client, redisClient redis.Cmdable, grpc *grpc.Cl
 	}
 
 	nodeInfoConfig := crd.NodeResourceInfoOptions{
-		CRDClient:   clent.DynamicNRI(),
+		CRDClient:   clent.OpsFlow().OpsflowV1beta1().NodeResourceInfos(),
 		KubeClient:  clent.Core(),
 		GRPCClient:  grpc,
+		Recorder:    recorder,
 		Parallelism: 3,
 	}
 
+	endpointProber := job.NewEndpointProber(&job.RedisEndpointStore{Client: redisClient}, jobOptions.EndpointProbe)
+	rolloutReconciler := &deployment.Reconciler{
+		Core:    clent.Core(),
+		Ray:     clent.Ray(),
+		Istio:   clent.Istio(),
+		OpsFlow: clent.OpsFlow(),
+		Prober:  endpointProber,
+		// 与创建任务的接口使用同样的选项
+		JobOptions: jobOptions,
+	}
+
+	artifactReconciler := &artifact.Reconciler{
+		Core:    clent.Core(),
+		OpsFlow: clent.OpsFlow(),
+	}
+
 	return map[string]TaskConfig{
 		"task1": {10 * time.Second, func(ctx context.Context) error {
 			return task1Func(ctx)
@@ -55,6 +77,27 @@ func InitializeTasks(clent core.Client, redisClient redis.Cmdable, grpc *grpc.Cl
 			},
 			WaitForCompletion: true,
 		},
+		"model_rollout": {
+			Duration: 15 * time.Second,
+			TaskFunc: func(ctx context.Context) error {
+				return rolloutReconciler.ReconcileAll(ctx)
+			},
+			WaitForCompletion: true,
+		},
+		"endpoint_probe": {
+			Duration: 15 * time.Second,
+			TaskFunc: func(ctx context.Context) error {
+				return endpointProber.ProbeAll(ctx, clent.Ray())
+			},
+			WaitForCompletion: true,
+		},
+		"model_artifact": {
+			Duration: 15 * time.Second,
+			TaskFunc: func(ctx context.Context) error {
+				return artifactReconciler.ReconcileAll(ctx)
+			},
+			WaitForCompletion: true,
+		},
 	}
 }
 
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConversionReview 与 apiextensions.k8s.io/v1 ConversionReview 结构一致，只保留转换需要的字段
+type ConversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *ConversionRequest  `json:"request,omitempty"`
+	Response        *ConversionResponse `json:"response,omitempty"`
+}
+
+type ConversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type ConversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// ConvertHandle 处理 apiserver 发来的 NodeResourceInfo 版本转换请求
+func ConvertHandle(c *gin.Context) {
+	var review ConversionReview
+	if err := c.ShouldBindJSON(&review); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid ConversionReview: %v", err)})
+		return
+	}
+	if review.Request == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ConversionReview request is empty"})
+		return
+	}
+
+	response := &ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, obj := range review.Request.Objects {
+		converted, err := convertObject(obj.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	review.Request = nil
+	review.Response = response
+	c.JSON(http.StatusOK, review)
+}
+
+func convertObject(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, fmt.Errorf("无法解析对象类型: %w", err)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	switch {
+	case typeMeta.APIVersion == v1alpha1.SchemeGroupVersion.String() && desiredAPIVersion == v1beta1.SchemeGroupVersion.String():
+		var in v1alpha1.NodeResourceInfo
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("无法解析 %s 对象: %w", typeMeta.APIVersion, err)
+		}
+		return json.Marshal(v1beta1.ConvertFromV1alpha1(&in))
+	case typeMeta.APIVersion == v1beta1.SchemeGroupVersion.String() && desiredAPIVersion == v1alpha1.SchemeGroupVersion.String():
+		var in v1beta1.NodeResourceInfo
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("无法解析 %s 对象: %w", typeMeta.APIVersion, err)
+		}
+		return json.Marshal(v1beta1.ConvertToV1alpha1(&in))
+	default:
+		return nil, fmt.Errorf("不支持从 %s 转换到 %s", typeMeta.APIVersion, desiredAPIVersion)
+	}
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/agent"
+	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeServer 在 agent 连接后依次发送 requests，收到 FunctionResult 后写入 results
+type fakeServer struct {
+	pb.UnimplementedAgentServiceServer
+
+	requests []*pb.AgentMessage
+	results  chan *pb.FunctionResult
+}
+
+func (s *fakeServer) AgentStream(stream pb.AgentService_AgentStreamServer) error {
+	// 第一条消息为心跳
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	for _, msg := range s.requests {
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if result := msg.GetFunctionResult(); result != nil {
+			s.results <- result
+		}
+	}
+}
+
+// 启动 fake server 并运行 agent，返回 agent 发回的第一个结果
+func runAgent(t *testing.T, requests ...*pb.AgentMessage) *pb.FunctionResult {
+	t.Helper()
+	server := &fakeServer{requests: requests, results: make(chan *pb.FunctionResult, 1)}
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterAgentServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go agent.RunAgent(conn, "agent-1")
+
+	select {
+	case result := <-server.results:
+		return result
+	case <-time.After(10 * time.Second):
+		t.Fatal("no function result received")
+		return nil
+	}
+}
+
+func functionRequest(t *testing.T, requestID, name string, params map[string]any, timeoutSeconds int64) *pb.AgentMessage {
+	t.Helper()
+	parameters, err := structpb.NewStruct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pb.AgentMessage{Body: &pb.AgentMessage_FunctionRequest{FunctionRequest: &pb.FunctionRequest{
+		RequestId:      requestID,
+		FunctionName:   name,
+		Parameters:     parameters,
+		TimeoutSeconds: timeoutSeconds,
+	}}}
+}
+
+// drain 被阻塞时返回失败，同时带上需要 force 的 Pod
+func TestDrainBlockedReturnsResult(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "gpu-1"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+	agent.RegisterMaintenanceFunctions(clientset)
+
+	result := runAgent(t, functionRequest(t, "drain-1", "DrainNode", map[string]any{"nodeName": "gpu-1"}, 0))
+	if result.RequestId != "drain-1" || result.Success || result.ErrorMessage == "" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	pods := result.Result.GetFields()["pods"].GetListValue().GetValues()
+	if len(pods) != 1 || pods[0].GetStructValue().GetFields()["name"].GetStringValue() != "debug" {
+		t.Fatalf("blocked pods not returned: %v", result.Result)
+	}
+}
+
+func TestDrainTimeoutTooLarge(t *testing.T) {
+	agent.RegisterMaintenanceFunctions(fake.NewSimpleClientset())
+
+	result := runAgent(t, functionRequest(t, "drain-2", "DrainNode", map[string]any{"nodeName": "gpu-1", "timeoutSeconds": 3600}, 0))
+	if result.Success || result.ErrorMessage == "" {
+		t.Fatalf("expected timeoutSeconds to be rejected: %v", result)
+	}
+}
+
+// 函数超过 timeout_seconds 或收到 CancelTask 时 ctx 被取消
+func TestFunctionContextCanceled(t *testing.T) {
+	agent.Register("Wait", func(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		result := runAgent(t, functionRequest(t, "wait-1", "Wait", nil, 1))
+		if result.Success || result.ErrorMessage != context.DeadlineExceeded.Error() {
+			t.Fatalf("unexpected result: %v", result)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		result := runAgent(t,
+			functionRequest(t, "wait-2", "Wait", nil, 0),
+			&pb.AgentMessage{Body: &pb.AgentMessage_CancelTask{CancelTask: &pb.CancelTask{RequestId: "wait-2"}}},
+		)
+		if result.RequestId != "wait-2" || result.Success || result.ErrorMessage != context.Canceled.Error() {
+			t.Fatalf("unexpected result: %v", result)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package artifact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func s3Spec() v1beta1.ModelArtifactSpec {
+	return v1beta1.ModelArtifactSpec{
+		Source: v1beta1.ModelSource{
+			Type: v1beta1.ModelSourceS3,
+			S3: &v1beta1.S3ModelSource{
+				Endpoint:   "http://minio.minio:9000",
+				Bucket:     "models",
+				Prefix:     "qwen",
+				SecretName: "minio-credentials",
+			},
+		},
+		Storage: v1beta1.ModelStorage{Size: "20Gi"},
+	}
+}
+
+func newArtifact(spec v1beta1.ModelArtifactSpec) *v1beta1.ModelArtifact {
+	return &v1beta1.ModelArtifact{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default", UID: "uid-qwen"},
+		Spec:       spec,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(spec *v1beta1.ModelArtifactSpec)
+		wantErr string
+	}{
+		{name: "s3", mutate: func(*v1beta1.ModelArtifactSpec) {}},
+		{
+			name: "huggingface mirror",
+			mutate: func(spec *v1beta1.ModelArtifactSpec) {
+				spec.Source = v1beta1.ModelSource{
+					Type:        v1beta1.ModelSourceHuggingFace,
+					HuggingFace: &v1beta1.HuggingFaceModelSource{Repo: "Qwen/Qwen2.5-7B-Instruct", Endpoint: "https://hf-mirror.com"},
+				}
+			},
+		},
+		{
+			name:    "unknown type",
+			mutate:  func(spec *v1beta1.ModelArtifactSpec) { spec.Source.Type = "GCS" },
+			wantErr: "unknown source.type",
+		},
+		{
+			name:    "s3 without bucket",
+			mutate:  func(spec *v1beta1.ModelArtifactSpec) { spec.Source.S3.Bucket = "" },
+			wantErr: "endpoint and bucket",
+		},
+		{
+			name:    "s3 endpoint without scheme",
+			mutate:  func(spec *v1beta1.ModelArtifactSpec) { spec.Source.S3.Endpoint = "minio:9000" },
+			wantErr: "http or https url",
+		},
+		{
+			name: "two sources",
+			mutate: func(spec *v1beta1.ModelArtifactSpec) {
+				spec.Source.HuggingFace = &v1beta1.HuggingFaceModelSource{Repo: "Qwen/Qwen2.5-7B-Instruct"}
+			},
+			wantErr: "only the source of type S3",
+		},
+		{
+			name:    "missing size",
+			mutate:  func(spec *v1beta1.ModelArtifactSpec) { spec.Storage.Size = "" },
+			wantErr: "storage.size is required",
+		},
+		{
+			name:    "invalid access mode",
+			mutate:  func(spec *v1beta1.ModelArtifactSpec) { spec.Storage.AccessModes = []string{"ReadWriteAll"} },
+			wantErr: "unknown pvc access mode",
+		},
+		{
+			name:    "invalid checksum",
+			mutate:  func(spec *v1beta1.ModelArtifactSpec) { spec.Checksum = "abc" },
+			wantErr: "lowercase hex sha256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := s3Spec()
+			tt.mutate(&spec)
+			err := artifact.Validate("qwen", &spec)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewDownloadJob(t *testing.T) {
+	modelArtifact := newArtifact(s3Spec())
+	downloadJob := artifact.NewDownloadJob(modelArtifact)
+
+	if downloadJob.Name != "qwen-download" || downloadJob.Labels[v1beta1.ModelArtifactLabel] != "qwen" {
+		t.Fatalf("unexpected job metadata: %+v", downloadJob.ObjectMeta)
+	}
+	if len(downloadJob.OwnerReferences) != 1 || downloadJob.OwnerReferences[0].Kind != "ModelArtifact" || downloadJob.OwnerReferences[0].UID != "uid-qwen" {
+		t.Fatalf("unexpected owner references: %+v", downloadJob.OwnerReferences)
+	}
+	podSpec := downloadJob.Spec.Template.Spec
+	if podSpec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Fatalf("expected restartPolicy Never, got %s", podSpec.RestartPolicy)
+	}
+	if claim := podSpec.Volumes[0].PersistentVolumeClaim; claim == nil || claim.ClaimName != "model-qwen" {
+		t.Fatalf("expected the model pvc to be mounted, got %+v", podSpec.Volumes)
+	}
+
+	container := podSpec.Containers[0]
+	if container.Image != artifact.DefaultDownloaderImage {
+		t.Fatalf("expected default image, got %s", container.Image)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	if env["S3_BUCKET"].Value != "models" || env["S3_PREFIX"].Value != "qwen" || env["SOURCE_TYPE"].Value != "S3" {
+		t.Fatalf("unexpected env: %+v", container.Env)
+	}
+	secret := env["AWS_SECRET_ACCESS_KEY"].ValueFrom
+	if secret == nil || secret.SecretKeyRef.Name != "minio-credentials" || secret.SecretKeyRef.Key != "AWS_SECRET_ACCESS_KEY" {
+		t.Fatalf("expected credentials from the secret, got %+v", env["AWS_SECRET_ACCESS_KEY"])
+	}
+}
+
+func TestNewPVC(t *testing.T) {
+	spec := s3Spec()
+	spec.Storage.StorageClassName = "nfs"
+	pvc := artifact.NewPVC(newArtifact(spec))
+
+	if pvc.Name != "model-qwen" || pvc.Labels[v1beta1.ModelArtifactLabel] != "qwen" {
+		t.Fatalf("unexpected pvc metadata: %+v", pvc.ObjectMeta)
+	}
+	if len(pvc.OwnerReferences) != 0 {
+		t.Fatalf("pvc should outlive the model, got owners %+v", pvc.OwnerReferences)
+	}
+	if len(pvc.Spec.AccessModes) != 1 || pvc.Spec.AccessModes[0] != corev1.ReadWriteMany {
+		t.Fatalf("expected ReadWriteMany by default, got %v", pvc.Spec.AccessModes)
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "nfs" {
+		t.Fatalf("unexpected storage class: %v", pvc.Spec.StorageClassName)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "20Gi" {
+		t.Fatalf("unexpected size: %s", size.String())
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	logs := strings.Join([]string{
+		`{"completed": 0, "downloadedBytes": 0, "event": "progress", "files": 3, "totalBytes": 300}`,
+		`Traceback-like noise`,
+		`{"completed": 1, "downloadedBytes": 150, "event": "progress", "files": 3, "totalBytes": 300}`,
+		`{"truncated`,
+	}, "\n")
+
+	progress := artifact.ParseProgress(logs)
+	if progress == nil || progress.Completed != 1 || progress.DownloadedBytes != 150 || progress.Files != 3 {
+		t.Fatalf("expected the last complete progress line, got %+v", progress)
+	}
+	if artifact.ParseProgress("fake logs") != nil {
+		t.Fatal("expected nil without progress lines")
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	result, err := artifact.ParseResult(`{"checksum": "` + testChecksum + `", "completed": 2, "downloadedBytes": 10, "event": "done", "files": 2, "totalBytes": 10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checksum != testChecksum || result.Completed != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	result, err = artifact.ParseResult(`{"event": "failed", "message": "HTTP Error 403: Forbidden"}`)
+	if err != nil || result.Message != "HTTP Error 403: Forbidden" {
+		t.Fatalf("unexpected failed result: %+v, %v", result, err)
+	}
+
+	for _, message := range []string{"not json", `{"event": "progress"}`, `{"event": "done", "checksum": "abc"}`} {
+		if _, err := artifact.ParseResult(message); err == nil {
+			t.Fatalf("expected error for %q", message)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
+)
+
+var modelFiles = map[string]string{
+	"config.json":                      `{"num_attention_heads": 28, "num_hidden_layers": 28}`,
+	"model-00001-of-00002.safetensors": strings.Repeat("a", 4096),
+	"model-00002-of-00002.safetensors": strings.Repeat("b", 2048),
+	"tokenizer/tokenizer.json":         `{"version": "1.0"}`,
+}
+
+// 按下载脚本的规则计算模型的校验和
+func expectedChecksum() string {
+	var names []string
+	for name := range modelFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var manifest strings.Builder
+	for _, name := range names {
+		sum := sha256.Sum256([]byte(modelFiles[name]))
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	sum := sha256.Sum256([]byte(manifest.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func totalBytes() int64 {
+	var total int64
+	for _, content := range modelFiles {
+		total += int64(len(content))
+	}
+	return total
+}
+
+// 以 Job 的命令与环境变量在本地运行下载脚本，Secret 中的值由 secrets 提供
+func runDownloader(t *testing.T, modelArtifact *v1beta1.ModelArtifact, secrets map[string]string) (string, *artifact.Progress, error) {
+	t.Helper()
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+
+	container := artifact.NewDownloadJob(modelArtifact).Spec.Template.Spec.Containers[0]
+	dir := t.TempDir()
+	modelDir := filepath.Join(dir, "model")
+	if err := os.Mkdir(modelDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	terminationLog := filepath.Join(dir, "termination-log")
+
+	cmd := exec.Command(python, container.Command[1:]...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "TERMINATION_LOG=" + terminationLog}
+	for _, env := range container.Env {
+		value := env.Value
+		if env.ValueFrom != nil {
+			value = secrets[env.Name]
+		}
+		if env.Name == "MODEL_DIR" {
+			value = modelDir
+		}
+		cmd.Env = append(cmd.Env, env.Name+"="+value)
+	}
+	output, runErr := cmd.CombinedOutput()
+	if progress := artifact.ParseProgress(string(output)); progress == nil {
+		t.Fatalf("no progress in output:\n%s", output)
+	}
+
+	message, err := os.ReadFile(terminationLog)
+	if err != nil {
+		t.Fatalf("no termination message: %v\n%s", err, output)
+	}
+	result, err := artifact.ParseResult(string(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return modelDir, result, runErr
+}
+
+func checkModelDir(t *testing.T, modelDir string) {
+	t.Helper()
+	for name, content := range modelFiles {
+		data, err := os.ReadFile(filepath.Join(modelDir, name))
+		if err != nil || string(data) != content {
+			t.Fatalf("file %s not downloaded: %v", name, err)
+		}
+	}
+	manifest, err := os.ReadFile(filepath.Join(modelDir, ".opsflow-manifest.sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(manifest)
+	if hex.EncodeToString(sum[:]) != expectedChecksum() {
+		t.Fatalf("manifest does not match the checksum:\n%s", manifest)
+	}
+}
+
+func newHuggingFaceServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer hf_test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/api/models/Qwen/Qwen2.5-0.5B/revision/main" {
+			var siblings []map[string]any
+			for name, content := range modelFiles {
+				siblings = append(siblings, map[string]any{"rfilename": name, "size": len(content)})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"id": "Qwen/Qwen2.5-0.5B", "siblings": siblings})
+			return
+		}
+		name, ok := strings.CutPrefix(r.URL.Path, "/Qwen/Qwen2.5-0.5B/resolve/main/")
+		if content, exists := modelFiles[name]; ok && exists {
+			w.Write([]byte(content))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloaderHuggingFace(t *testing.T) {
+	server := newHuggingFaceServer(t)
+	modelArtifact := newArtifact(v1beta1.ModelArtifactSpec{
+		Source: v1beta1.ModelSource{
+			Type: v1beta1.ModelSourceHuggingFace,
+			HuggingFace: &v1beta1.HuggingFaceModelSource{
+				Repo:       "Qwen/Qwen2.5-0.5B",
+				Endpoint:   server.URL,
+				SecretName: "hf-token",
+			},
+		},
+		Storage:  v1beta1.ModelStorage{Size: "1Gi"},
+		Checksum: expectedChecksum(),
+	})
+
+	modelDir, result, err := runDownloader(t, modelArtifact, map[string]string{"HF_TOKEN": "hf_test"})
+	if err != nil {
+		t.Fatalf("downloader failed: %v, %+v", err, result)
+	}
+	if result.Event != artifact.DoneEvent || result.Checksum != expectedChecksum() || result.Completed != 4 || result.TotalBytes != totalBytes() {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	checkModelDir(t, modelDir)
+}
+
+func TestDownloaderChecksumMismatch(t *testing.T) {
+	server := newHuggingFaceServer(t)
+	modelArtifact := newArtifact(v1beta1.ModelArtifactSpec{
+		Source: v1beta1.ModelSource{
+			Type:        v1beta1.ModelSourceHuggingFace,
+			HuggingFace: &v1beta1.HuggingFaceModelSource{Repo: "Qwen/Qwen2.5-0.5B", Endpoint: server.URL, SecretName: "hf-token"},
+		},
+		Storage:  v1beta1.ModelStorage{Size: "1Gi"},
+		Checksum: strings.Repeat("0", 64),
+	})
+
+	_, result, err := runDownloader(t, modelArtifact, map[string]string{"HF_TOKEN": "hf_test"})
+	if err == nil {
+		t.Fatal("expected the downloader to exit with an error")
+	}
+	if result.Event != artifact.FailedEvent || !strings.Contains(result.Message, "checksum mismatch") {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+// 模拟 MinIO 的 ListObjectsV2，每页一个对象
+func TestDownloaderS3(t *testing.T) {
+	var keys []string
+	for name := range modelFiles {
+		keys = append(keys, "qwen/"+name)
+	}
+	sort.Strings(keys)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") || r.Header.Get("X-Amz-Date") == "" {
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/models" {
+			query := r.URL.Query()
+			if query.Get("list-type") != "2" || query.Get("prefix") != "qwen/" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			index := 0
+			if token := query.Get("continuation-token"); token != "" {
+				fmt.Sscanf(token, "%d", &index)
+			}
+			key := keys[index]
+			truncated := index+1 < len(keys)
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>models</Name><Prefix>qwen/</Prefix><IsTruncated>%t</IsTruncated><NextContinuationToken>%d</NextContinuationToken><Contents><Key>%s</Key><Size>%d</Size></Contents></ListBucketResult>`,
+				truncated, index+1, key, len(modelFiles[strings.TrimPrefix(key, "qwen/")]))
+			return
+		}
+		if content, ok := modelFiles[strings.TrimPrefix(r.URL.Path, "/models/qwen/")]; ok {
+			w.Write([]byte(content))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	spec := s3Spec()
+	spec.Source.S3.Endpoint = server.URL
+	modelDir, result, err := runDownloader(t, newArtifact(spec), map[string]string{
+		"AWS_ACCESS_KEY_ID":     "minio",
+		"AWS_SECRET_ACCESS_KEY": "minio123",
+	})
+	if err != nil {
+		t.Fatalf("downloader failed: %v, %+v", err, result)
+	}
+	if result.Checksum != expectedChecksum() || result.Files != 4 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	checkModelDir(t, modelDir)
+}
@@ -0,0 +1,255 @@
+package artifact
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/artifact"
+	opsflowfake "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/fake"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newReconciler(modelArtifact *v1beta1.ModelArtifact) *artifact.Reconciler {
+	return &artifact.Reconciler{
+		Core:    fake.NewSimpleClientset(),
+		OpsFlow: opsflowfake.NewSimpleClientset(modelArtifact),
+		Now:     func() time.Time { return now },
+	}
+}
+
+func getArtifact(t *testing.T, r *artifact.Reconciler) *v1beta1.ModelArtifact {
+	t.Helper()
+	modelArtifact, err := r.OpsFlow.OpsflowV1beta1().ModelArtifacts("default").Get(context.Background(), "qwen", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return modelArtifact
+}
+
+// 模拟下载任务结束，Pod 的 termination message 为 message
+func finishJob(t *testing.T, r *artifact.Reconciler, conditionType batchv1.JobConditionType, message string) {
+	t.Helper()
+	ctx := context.Background()
+	downloadJob, err := r.Core.BatchV1().Jobs("default").Get(ctx, "qwen-download", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conditionType == batchv1.JobComplete {
+		downloadJob.Status.Succeeded = 1
+	}
+	downloadJob.Status.Conditions = []batchv1.JobCondition{
+		{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, Message: "target"},
+		{Type: conditionType, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"},
+	}
+	if _, err := r.Core.BatchV1().Jobs("default").UpdateStatus(ctx, downloadJob, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen-download-abcde", Namespace: "default", Labels: map[string]string{"job-name": "qwen-download"}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "downloader", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}}},
+			},
+		},
+	}
+	if _, err := r.Core.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileCreatesPVCAndJob(t *testing.T) {
+	r := newReconciler(newArtifact(s3Spec()))
+	ctx := context.Background()
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Core.CoreV1().PersistentVolumeClaims("default").Get(ctx, "model-qwen", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected pvc to be created: %v", err)
+	}
+	if _, err := r.Core.BatchV1().Jobs("default").Get(ctx, "qwen-download", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected job to be created: %v", err)
+	}
+	status := getArtifact(t, r).Status
+	if status.Phase != v1beta1.ModelArtifactDownloading || status.PVCName != "model-qwen" || status.JobName != "qwen-download" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if status.StartedAt == nil || !status.StartedAt.Time.Equal(now) {
+		t.Fatalf("expected startedAt to be set, got %v", status.StartedAt)
+	}
+
+	// 再次调谐不会重复创建
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileReady(t *testing.T) {
+	spec := s3Spec()
+	spec.Checksum = testChecksum
+	r := newReconciler(newArtifact(spec))
+	ctx := context.Background()
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+	finishJob(t, r, batchv1.JobComplete, `{"checksum": "`+testChecksum+`", "completed": 2, "downloadedBytes": 2048, "event": "done", "files": 2, "totalBytes": 2048}`)
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+
+	status := getArtifact(t, r).Status
+	if status.Phase != v1beta1.ModelArtifactReady || status.Checksum != testChecksum {
+		t.Fatalf("expected ready with checksum, got %+v", status)
+	}
+	if status.Files != 2 || status.Completed != 2 || status.TotalBytes != 2048 || status.CompletedAt == nil {
+		t.Fatalf("unexpected progress: %+v", status)
+	}
+}
+
+func TestReconcileChecksumMismatch(t *testing.T) {
+	spec := s3Spec()
+	spec.Checksum = strings.Repeat("0", 64)
+	r := newReconciler(newArtifact(spec))
+	ctx := context.Background()
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+	finishJob(t, r, batchv1.JobComplete, `{"checksum": "`+testChecksum+`", "completed": 1, "event": "done", "files": 1}`)
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+
+	status := getArtifact(t, r).Status
+	if status.Phase != v1beta1.ModelArtifactFailed || !strings.Contains(status.Message, "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %+v", status)
+	}
+}
+
+func TestReconcileJobFailed(t *testing.T) {
+	r := newReconciler(newArtifact(s3Spec()))
+	ctx := context.Background()
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+	finishJob(t, r, batchv1.JobFailed, `{"event": "failed", "message": "HTTP Error 403: Forbidden"}`)
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+
+	status := getArtifact(t, r).Status
+	if status.Phase != v1beta1.ModelArtifactFailed || status.Message != "HTTP Error 403: Forbidden" {
+		t.Fatalf("expected the downloader message, got %+v", status)
+	}
+
+	// 失败的模型不再调谐，删除下载任务后也不会重新创建
+	if err := r.Core.BatchV1().Jobs("default").Delete(ctx, "qwen-download", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Core.BatchV1().Jobs("default").Get(ctx, "qwen-download", metav1.GetOptions{}); err == nil {
+		t.Fatal("failed model should not be retried")
+	}
+}
+
+func TestDeleteKeepsData(t *testing.T) {
+	r := newReconciler(newArtifact(s3Spec()))
+	ctx := context.Background()
+	if err := r.Reconcile(ctx, getArtifact(t, r)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Delete(ctx, getArtifact(t, r), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Core.BatchV1().Jobs("default").Get(ctx, "qwen-download", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected job to be deleted")
+	}
+	if _, err := r.Core.CoreV1().PersistentVolumeClaims("default").Get(ctx, "model-qwen", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected pvc to be kept: %v", err)
+	}
+
+	if err := r.Delete(ctx, getArtifact(t, r), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Core.CoreV1().PersistentVolumeClaims("default").Get(ctx, "model-qwen", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected pvc to be deleted with deleteData")
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	ready := newArtifact(s3Spec())
+	ready.Status = v1beta1.ModelArtifactStatus{Phase: v1beta1.ModelArtifactReady, PVCName: "model-qwen"}
+	client := opsflowfake.NewSimpleClientset(ready)
+	ctx := context.Background()
+
+	config := &model.ClusterConfig{
+		Namespace: "default",
+		Model:     "qwen",
+		Machines: []model.MachineConfig{
+			{Name: "head", IsHeadNode: true},
+			{Name: "worker", MachineType: model.MachineTypeGroup},
+		},
+	}
+	if err := artifact.ResolveModel(ctx, client, config); err != nil {
+		t.Fatal(err)
+	}
+	for _, machine := range config.Machines {
+		if len(machine.Volumes) != 1 {
+			t.Fatalf("machine %s: expected the model volume, got %+v", machine.Name, machine.Volumes)
+		}
+		volume := machine.Volumes[0]
+		if volume.MountPath != "/mnt/data/models/qwen" || !volume.ReadOnly || volume.Label["model"] != "qwen" {
+			t.Fatalf("unexpected model volume: %+v", volume)
+		}
+		if volume.Source.PVC == nil || volume.Source.PVC.ClaimName != "model-qwen" || volume.Source.PVC.Size != "" {
+			t.Fatalf("expected the existing pvc to be mounted, got %+v", volume.Source.PVC)
+		}
+	}
+
+	// 已有模型卷时冲突
+	conflict := &model.ClusterConfig{
+		Namespace: "default",
+		Model:     "qwen",
+		Machines: []model.MachineConfig{{
+			Name:    "head",
+			Volumes: []model.VolumeConfig{{Name: "weights", Label: map[string]string{"model": "true"}, MountPath: "/models"}},
+		}},
+	}
+	if err := artifact.ResolveModel(ctx, client, conflict); err == nil || !strings.Contains(err.Error(), "conflicts with model") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+
+	// 未设置 model 时不修改
+	plain := &model.ClusterConfig{Namespace: "default", Machines: []model.MachineConfig{{Name: "head"}}}
+	if err := artifact.ResolveModel(ctx, client, plain); err != nil || len(plain.Machines[0].Volumes) != 0 {
+		t.Fatalf("expected no change, got %+v, %v", plain.Machines[0].Volumes, err)
+	}
+}
+
+func TestResolveModelNotReady(t *testing.T) {
+	downloading := newArtifact(s3Spec())
+	downloading.Status.Phase = v1beta1.ModelArtifactDownloading
+	client := opsflowfake.NewSimpleClientset(downloading)
+
+	config := &model.ClusterConfig{Namespace: "default", Model: "qwen", Machines: []model.MachineConfig{{Name: "head"}}}
+	if err := artifact.ResolveModel(context.Background(), client, config); err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Fatalf("expected not ready error, got %v", err)
+	}
+	config.Model = "llama"
+	if err := artifact.ResolveModel(context.Background(), client, config); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
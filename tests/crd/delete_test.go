@@ -0,0 +1,163 @@
+package crd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	typedv1beta1 "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/typed/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/crd"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func kubeNode(name string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"kubernetes.io/hostname": name}}}
+}
+
+func deleteResponse(success bool) *pb.GenericResponse {
+	return withData(0, mustAny(&pb.DeleteNodeResponse{Success: success}))
+}
+
+// manager 确认删除时 CRD 仍带有 finalizer，确认后先移除 finalizer 再删除
+func TestDeleteAfterManagerAcknowledged(t *testing.T) {
+	var crdClient typedv1beta1.NodeResourceInfoInterface
+	var finalizersAtNotify []string
+	manager := &fakeManager{deleteNode: func(req *pb.DeleteNodeRequest) *pb.GenericResponse {
+		if current, err := crdClient.Get(context.TODO(), req.NodeName, metav1.GetOptions{}); err == nil {
+			finalizersAtNotify = current.Finalizers
+		}
+		return deleteResponse(true)
+	}}
+	clientset := newCRDClientset(nodeResourceInfo("node-1"), nodeResourceInfo("node-2"))
+	crdClient = clientset.OpsflowV1beta1().NodeResourceInfos()
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:  crdClient,
+		KubeClient: fake.NewSimpleClientset(kubeNode("node-2")),
+		GRPCClient: startManager(t, manager),
+	}
+
+	if err := crd.DeleteNonExistingNodeResourceInfo(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := manager.recorded(); !slices.Equal(calls, []string{"DeleteNode node-1"}) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	if !slices.Contains(finalizersAtNotify, v1beta1.NodeManagerFinalizer) {
+		t.Fatalf("finalizer removed before the manager acknowledged: %v", finalizersAtNotify)
+	}
+
+	var writes []string
+	for _, action := range clientset.Actions() {
+		switch action.GetVerb() {
+		case "update", "delete":
+			writes = append(writes, action.GetVerb())
+		}
+	}
+	if !slices.Equal(writes, []string{"update", "delete"}) {
+		t.Fatalf("expected finalizer removal before delete, got %v", writes)
+	}
+	if _, err := crdClient.Get(context.TODO(), "node-1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("node-1 should be deleted, err: %v", err)
+	}
+	if _, err := crdClient.Get(context.TODO(), "node-2", metav1.GetOptions{}); err != nil {
+		t.Fatalf("node-2 should be kept: %v", err)
+	}
+}
+
+// manager 中不存在该节点同样视为删除完成
+func TestDeleteManagerNotFound(t *testing.T) {
+	manager := &fakeManager{deleteNode: func(*pb.DeleteNodeRequest) *pb.GenericResponse {
+		return &pb.GenericResponse{Code: 404, Message: "node not found"}
+	}}
+	clientset := newCRDClientset(nodeResourceInfo("node-1"))
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:  clientset.OpsflowV1beta1().NodeResourceInfos(),
+		KubeClient: fake.NewSimpleClientset(),
+		GRPCClient: startManager(t, manager),
+	}
+
+	if err := crd.DeleteNonExistingNodeResourceInfo(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opts.CRDClient.Get(context.TODO(), "node-1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("node-1 should be deleted, err: %v", err)
+	}
+}
+
+// manager 未确认时保留 CRD 与 finalizer，等待下个周期重试
+func TestDeleteKeepsFinalizerUntilAcknowledged(t *testing.T) {
+	for name, response := range map[string]*pb.GenericResponse{
+		"error code":  {Code: 500, Message: "unavailable"},
+		"not success": deleteResponse(false),
+	} {
+		t.Run(name, func(t *testing.T) {
+			manager := &fakeManager{deleteNode: func(*pb.DeleteNodeRequest) *pb.GenericResponse { return response }}
+			clientset := newCRDClientset(nodeResourceInfo("node-1"))
+			opts := crd.NodeResourceInfoOptions{
+				CRDClient:  clientset.OpsflowV1beta1().NodeResourceInfos(),
+				KubeClient: fake.NewSimpleClientset(),
+				GRPCClient: startManager(t, manager),
+			}
+
+			if err := crd.DeleteNonExistingNodeResourceInfo(opts, "cluster"); err == nil {
+				t.Fatal("expected an error when the manager does not acknowledge")
+			}
+			current, err := opts.CRDClient.Get(context.TODO(), "node-1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("node-1 should be kept: %v", err)
+			}
+			if !slices.Contains(current.Finalizers, v1beta1.NodeManagerFinalizer) {
+				t.Fatalf("finalizer removed: %v", current.Finalizers)
+			}
+		})
+	}
+}
+
+// 已被删除（带 deletionTimestamp）的 CRD 即使节点仍存在也进入删除流程
+func TestDeleteTerminatingCRD(t *testing.T) {
+	manager := &fakeManager{deleteNode: func(*pb.DeleteNodeRequest) *pb.GenericResponse { return deleteResponse(true) }}
+	terminating := nodeResourceInfo("node-1")
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:  newCRDClientset(terminating).OpsflowV1beta1().NodeResourceInfos(),
+		KubeClient: fake.NewSimpleClientset(kubeNode("node-1")),
+		GRPCClient: startManager(t, manager),
+	}
+
+	if err := crd.DeleteNonExistingNodeResourceInfo(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := manager.recorded(); !slices.Equal(calls, []string{"DeleteNode node-1"}) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+// 失败的节点超过 channel 容量时也能返回全部错误
+func TestDeleteManyErrors(t *testing.T) {
+	manager := &fakeManager{deleteNode: func(*pb.DeleteNodeRequest) *pb.GenericResponse {
+		return &pb.GenericResponse{Code: 500, Message: "unavailable"}
+	}}
+	var objects []runtime.Object
+	for i := range 150 {
+		objects = append(objects, nodeResourceInfo(fmt.Sprintf("node-%d", i)))
+	}
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:   newCRDClientset(objects...).OpsflowV1beta1().NodeResourceInfos(),
+		KubeClient:  fake.NewSimpleClientset(),
+		GRPCClient:  startManager(t, manager),
+		Parallelism: 4,
+	}
+
+	err := crd.DeleteNonExistingNodeResourceInfo(opts, "cluster")
+	if errs, ok := err.(interface{ Unwrap() []error }); !ok || len(errs.Unwrap()) != 150 {
+		t.Fatalf("expected 150 errors, got %v", err)
+	}
+}
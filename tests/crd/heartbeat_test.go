@@ -0,0 +1,164 @@
+package crd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	"github.com/modcoco/OpsFlow/pkg/crd"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHeartbeatRequiresUpdate(t *testing.T) {
+	heartbeatTime := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	manager := &fakeManager{heartbeat: func(req *pb.NodeHeartbeatRequest) *pb.GenericResponse {
+		return withData(0, mustAny(&pb.NodeHeartbeatResponse{
+			NodeName:          req.NodeName,
+			LastHeartbeatTime: timestamppb.New(heartbeatTime),
+			RequiresUpdate:    req.NodeName == "node-1",
+		}))
+	}}
+	clientset := newCRDClientset(nodeResourceInfo("node-1"), nodeResourceInfo("node-2"))
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:  clientset.OpsflowV1beta1().NodeResourceInfos(),
+		GRPCClient: startManager(t, manager),
+	}
+
+	if err := crd.NodeHeartbeat(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	calls := manager.recorded()
+	if !slices.Contains(calls, "UpdateNode node-1") || slices.Contains(calls, "UpdateNode node-2") {
+		t.Fatalf("only node-1 should be pushed, calls: %v", calls)
+	}
+
+	for _, name := range []string{"node-1", "node-2"} {
+		current, err := opts.CRDClient.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current.Status.LastHeartbeatTime == nil || !current.Status.LastHeartbeatTime.Time.Equal(heartbeatTime) {
+			t.Errorf("%s: lastHeartbeatTime = %v, want %v", name, current.Status.LastHeartbeatTime, heartbeatTime)
+		}
+	}
+}
+
+// manager 中不存在节点时重新 AddNode，并记录确认的 generation
+func TestHeartbeatNotFoundAddsNode(t *testing.T) {
+	manager := &fakeManager{heartbeat: func(*pb.NodeHeartbeatRequest) *pb.GenericResponse {
+		return &pb.GenericResponse{Code: 404, Message: "node not found"}
+	}}
+	existing := nodeResourceInfo("node-1")
+	existing.Status.ManagerSync = nil
+	clientset := newCRDClientset(existing)
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:  clientset.OpsflowV1beta1().NodeResourceInfos(),
+		GRPCClient: startManager(t, manager),
+	}
+
+	if err := crd.NodeHeartbeat(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := manager.recorded(); !slices.Equal(calls, []string{"Heartbeat node-1", "AddNode node-1"}) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	current, err := opts.CRDClient.Get(context.TODO(), "node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Status.ManagerSync == nil || current.Status.ManagerSync.AcknowledgedGeneration != 1 {
+		t.Fatalf("unexpected manager sync: %+v", current.Status.ManagerSync)
+	}
+}
+
+// 旧版本 manager 不返回 data 时不是错误，节点数超过并发数与分页大小时也不能阻塞
+func TestHeartbeatWithoutData(t *testing.T) {
+	manager := &fakeManager{heartbeat: func(*pb.NodeHeartbeatRequest) *pb.GenericResponse {
+		return &pb.GenericResponse{Code: 0}
+	}}
+	var objects []runtime.Object
+	for i := range 150 {
+		objects = append(objects, nodeResourceInfo(fmt.Sprintf("node-%d", i)))
+	}
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:   newCRDClientset(objects...).OpsflowV1beta1().NodeResourceInfos(),
+		GRPCClient:  startManager(t, manager),
+		Parallelism: 4,
+	}
+
+	if err := crd.NodeHeartbeat(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range manager.recorded() {
+		if !strings.HasPrefix(call, "Heartbeat ") {
+			t.Fatalf("unexpected call %s", call)
+		}
+	}
+}
+
+// 所有节点都失败时返回全部错误，而不是在错误数超过 channel 容量时死锁
+func TestHeartbeatManyErrors(t *testing.T) {
+	manager := &fakeManager{heartbeat: func(*pb.NodeHeartbeatRequest) *pb.GenericResponse {
+		return &pb.GenericResponse{Code: 500, Message: "unavailable"}
+	}}
+	var objects []runtime.Object
+	for i := range 150 {
+		objects = append(objects, nodeResourceInfo(fmt.Sprintf("node-%d", i)))
+	}
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:   newCRDClientset(objects...).OpsflowV1beta1().NodeResourceInfos(),
+		GRPCClient:  startManager(t, manager),
+		Parallelism: 4,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- crd.NodeHeartbeat(opts, "cluster") }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected heartbeat errors")
+		}
+		if errs, ok := err.(interface{ Unwrap() []error }); !ok || len(errs.Unwrap()) != 150 {
+			t.Fatalf("expected 150 errors, got %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("NodeHeartbeat blocked")
+	}
+}
+
+// 距上次记录不足间隔时不写 lastHeartbeatTime
+func TestHeartbeatTimeThrottled(t *testing.T) {
+	recorded := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	manager := &fakeManager{heartbeat: func(req *pb.NodeHeartbeatRequest) *pb.GenericResponse {
+		return withData(0, mustAny(&pb.NodeHeartbeatResponse{NodeName: req.NodeName, LastHeartbeatTime: timestamppb.Now()}))
+	}}
+	existing := nodeResourceInfo("node-1")
+	existing.Status.LastHeartbeatTime = &recorded
+	clientset := newCRDClientset(existing)
+	opts := crd.NodeResourceInfoOptions{
+		CRDClient:  clientset.OpsflowV1beta1().NodeResourceInfos(),
+		GRPCClient: startManager(t, manager),
+	}
+
+	if err := crd.NodeHeartbeat(opts, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatalf("unexpected status patch: %v", action)
+		}
+	}
+	current, err := opts.CRDClient.Get(context.TODO(), "node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !current.Status.LastHeartbeatTime.Equal(&recorded) {
+		t.Fatalf("lastHeartbeatTime changed to %v", current.Status.LastHeartbeatTime)
+	}
+}
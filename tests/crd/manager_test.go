@@ -0,0 +1,111 @@
+package crd
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	opsflowfake "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/fake"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeManager 按节点返回心跳与删除的响应，并记录收到的请求
+type fakeManager struct {
+	pb.UnimplementedNodeManagerServer
+
+	mu         sync.Mutex
+	heartbeat  func(req *pb.NodeHeartbeatRequest) *pb.GenericResponse
+	deleteNode func(req *pb.DeleteNodeRequest) *pb.GenericResponse
+	calls      []string
+}
+
+func (m *fakeManager) record(call string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+func (m *fakeManager) recorded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+func (m *fakeManager) AddNode(_ context.Context, req *pb.AddNodeRequest) (*pb.GenericResponse, error) {
+	m.record("AddNode " + req.NodeName)
+	return &pb.GenericResponse{Code: 0}, nil
+}
+
+func (m *fakeManager) UpdateNode(_ context.Context, req *pb.UpdateNodeRequest) (*pb.GenericResponse, error) {
+	m.record("UpdateNode " + req.NodeName)
+	return &pb.GenericResponse{Code: 0}, nil
+}
+
+func (m *fakeManager) Heartbeat(_ context.Context, req *pb.NodeHeartbeatRequest) (*pb.GenericResponse, error) {
+	m.record("Heartbeat " + req.NodeName)
+	return m.heartbeat(req), nil
+}
+
+func (m *fakeManager) DeleteNode(_ context.Context, req *pb.DeleteNodeRequest) (*pb.GenericResponse, error) {
+	m.record("DeleteNode " + req.NodeName)
+	return m.deleteNode(req), nil
+}
+
+func withData(code int32, data *anypb.Any) *pb.GenericResponse {
+	return &pb.GenericResponse{Code: code, Data: data}
+}
+
+// 在 fake manager 的 goroutine 中调用，不能使用 t.Fatal
+func mustAny(msg proto.Message) *anypb.Any {
+	data, err := anypb.New(msg)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// 通过 bufconn 启动 fake manager，返回连接到它的客户端
+func startManager(t *testing.T, manager pb.NodeManagerServer) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterNodeManagerServer(server, manager)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func nodeResourceInfo(name string) *v1beta1.NodeResourceInfo {
+	return &v1beta1.NodeResourceInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1, Finalizers: []string{v1beta1.NodeManagerFinalizer}},
+		Spec:       v1beta1.NodeResourceInfoSpec{NodeName: name},
+		Status: v1beta1.NodeResourceInfoStatus{
+			NodeStatus:         "Ready",
+			Health:             v1beta1.NodeHealthReady,
+			ObservedGeneration: 1,
+			ManagerSync:        &v1beta1.ManagerSyncStatus{AcknowledgedGeneration: 1},
+		},
+	}
+}
+
+func newCRDClientset(objects ...runtime.Object) *opsflowfake.Clientset {
+	return opsflowfake.NewSimpleClientset(objects...)
+}
@@ -0,0 +1,212 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	opsflowfake "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/fake"
+	"github.com/modcoco/OpsFlow/pkg/deployment"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	rayfake "github.com/ray-project/kuberay/ray-operator/pkg/client/clientset/versioned/fake"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type memoryEndpointStore map[string]job.EndpointRecord
+
+func (s memoryEndpointStore) Load(_ context.Context, namespace, name string) (job.EndpointRecord, error) {
+	return s[namespace+"/"+name], nil
+}
+
+func (s memoryEndpointStore) Save(_ context.Context, namespace, name string, record job.EndpointRecord) error {
+	s[namespace+"/"+name] = record
+	return nil
+}
+
+func (s memoryEndpointStore) Delete(_ context.Context, namespace, name string) error {
+	delete(s, namespace+"/"+name)
+	return nil
+}
+
+func servingRayJob(name, clusterName string) *rayv1.RayJob {
+	return &rayv1.RayJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{model.JobKindLabel: job.JobKindVllmServe},
+		},
+		Status: rayv1.RayJobStatus{JobStatus: rayv1.JobStatusRunning, RayClusterName: clusterName},
+	}
+}
+
+func newReconciler(t *testing.T, modelDeployment *v1beta1.ModelDeployment, rayJobs ...*rayv1.RayJob) (*deployment.Reconciler, *time.Time) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.Write([]byte(`{"object": "list", "data": [{"id": "qwen", "object": "model"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	clock := now
+	ray := rayfake.NewSimpleClientset()
+	for _, rayJob := range rayJobs {
+		if _, err := ray.RayV1().RayJobs(rayJob.Namespace).Create(context.Background(), rayJob, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prober := &job.EndpointProber{
+		Client:  server.Client(),
+		Store:   memoryEndpointStore{},
+		BaseURL: func(string, string) string { return server.URL },
+		Now:     func() time.Time { return clock },
+	}
+	return &deployment.Reconciler{
+		Core:    fake.NewSimpleClientset(),
+		Ray:     ray,
+		Istio:   istiofake.NewSimpleClientset(),
+		OpsFlow: opsflowfake.NewSimpleClientset(modelDeployment),
+		Prober:  prober,
+		Now:     func() time.Time { return clock },
+	}, &clock
+}
+
+func reconcile(t *testing.T, r *deployment.Reconciler) *v1beta1.ModelDeployment {
+	t.Helper()
+	ctx := context.Background()
+	current, err := r.OpsFlow.OpsflowV1beta1().ModelDeployments("default").Get(ctx, "qwen", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reconcile(ctx, current); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	updated, err := r.OpsFlow.OpsflowV1beta1().ModelDeployments("default").Get(ctx, "qwen", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updated
+}
+
+func routeWeights(t *testing.T, r *deployment.Reconciler) map[string]int32 {
+	t.Helper()
+	virtualService, err := r.Istio.NetworkingV1().VirtualServices("default").Get(context.Background(), "qwen", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := map[string]int32{}
+	for _, route := range virtualService.Spec.Http[0].Route {
+		weights[route.Destination.Host] = route.Weight
+	}
+	return weights
+}
+
+func TestReconcileCanaryRollout(t *testing.T) {
+	modelDeployment := &v1beta1.ModelDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec:       v1beta1.ModelDeploymentSpec{Host: "qwen.example.com", StepWeight: 50, StepIntervalSeconds: 60},
+		Status:     rolloutStatus(0, 0),
+	}
+	r, clock := newReconciler(t, modelDeployment, servingRayJob("qwen-r1", "qwen-r1-raycluster"), servingRayJob("qwen-r2", "qwen-r2-raycluster"))
+
+	const stableHost = "qwen-r1-raycluster-vllm-svc.default.svc.cluster.local"
+	const canaryHost = "qwen-r2-raycluster-vllm-svc.default.svc.cluster.local"
+
+	updated := reconcile(t, r)
+	if updated.Status.CanaryWeight != 50 || updated.Status.Revisions[1].ClusterName != "qwen-r2-raycluster" {
+		t.Fatalf("status = %+v", updated.Status)
+	}
+	if weights := routeWeights(t, r); weights[stableHost] != 50 || weights[canaryHost] != 50 {
+		t.Errorf("weights = %v", weights)
+	}
+	if _, err := r.Istio.NetworkingV1().Gateways("default").Get(context.Background(), "qwen", metav1.GetOptions{}); err != nil {
+		t.Errorf("gateway should be created: %v", err)
+	}
+
+	*clock = clock.Add(time.Minute)
+	updated = reconcile(t, r)
+	if updated.Status.Phase != v1beta1.RolloutSucceeded || updated.Status.StableRevision != 2 || len(updated.Status.Revisions) != 1 {
+		t.Fatalf("status = %+v", updated.Status)
+	}
+	if weights := routeWeights(t, r); len(weights) != 1 || weights[canaryHost] != 0 {
+		t.Errorf("weights = %v", weights)
+	}
+	if _, err := r.Ray.RayV1().RayJobs("default").Get(context.Background(), "qwen-r1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("retired rayjob should be deleted, got %v", err)
+	}
+}
+
+func TestReconcileMissingCanaryRollsBack(t *testing.T) {
+	status := rolloutStatus(0, 0)
+	status.Revisions[1].CreatedAt = metav1.NewTime(now.Add(-2 * time.Minute))
+	modelDeployment := &v1beta1.ModelDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec:       v1beta1.ModelDeploymentSpec{Host: "qwen.example.com"},
+		Status:     status,
+	}
+	r, _ := newReconciler(t, modelDeployment, servingRayJob("qwen-r1", "qwen-r1-raycluster"))
+
+	updated := reconcile(t, r)
+	if updated.Status.Phase != v1beta1.RolloutRolledBack || updated.Status.CanaryRevision != 0 || len(updated.Status.Revisions) != 1 {
+		t.Fatalf("status = %+v", updated.Status)
+	}
+	if weights := routeWeights(t, r); len(weights) != 1 {
+		t.Errorf("weights = %v", weights)
+	}
+
+	if err := r.Rollback(context.Background(), updated, "manual rollback"); err != deployment.ErrNoRollout {
+		t.Errorf("Rollback = %v, want ErrNoRollout", err)
+	}
+}
+
+// 删除被替换的版本失败时状态中保留该版本，下个周期重试删除
+func TestReconcileRetriesRetiredRevisionDelete(t *testing.T) {
+	modelDeployment := &v1beta1.ModelDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec:       v1beta1.ModelDeploymentSpec{Host: "qwen.example.com", Strategy: v1beta1.RolloutBlueGreen},
+		Status:     rolloutStatus(0, 0),
+	}
+	r, _ := newReconciler(t, modelDeployment, servingRayJob("qwen-r1", "qwen-r1-raycluster"), servingRayJob("qwen-r2", "qwen-r2-raycluster"))
+	failDelete := true
+	r.Ray.(*rayfake.Clientset).PrependReactor("delete", "rayjobs", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if failDelete {
+			return true, nil, errors.New("etcdserver: request timed out")
+		}
+		return false, nil, nil
+	})
+
+	ctx := context.Background()
+	current, err := r.OpsFlow.OpsflowV1beta1().ModelDeployments("default").Get(ctx, "qwen", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reconcile(ctx, current); err == nil {
+		t.Fatal("Reconcile should fail while the retired rayjob can't be deleted")
+	}
+	current, err = r.OpsFlow.OpsflowV1beta1().ModelDeployments("default").Get(ctx, "qwen", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Status.StableRevision != 1 || deployment.FindRevision(&current.Status, 1) == nil {
+		t.Fatalf("retired revision should stay in status until deleted: %+v", current.Status)
+	}
+
+	failDelete = false
+	updated := reconcile(t, r)
+	if updated.Status.Phase != v1beta1.RolloutSucceeded || updated.Status.StableRevision != 2 || len(updated.Status.Revisions) != 1 {
+		t.Fatalf("status = %+v", updated.Status)
+	}
+	if _, err := r.Ray.RayV1().RayJobs("default").Get(ctx, "qwen-r1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("retired rayjob should be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/deployment"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func rolloutStatus(weight int32, since time.Duration) v1beta1.ModelDeploymentStatus {
+	return v1beta1.ModelDeploymentStatus{
+		Phase:              v1beta1.RolloutProgressing,
+		StableRevision:     1,
+		CanaryRevision:     2,
+		CanaryWeight:       weight,
+		LatestRevision:     2,
+		Revisions:          []v1beta1.ModelRevision{{Revision: 1, JobName: "qwen-r1"}, {Revision: 2, JobName: "qwen-r2"}},
+		LastTransitionTime: metav1.NewTime(now.Add(-since)),
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	ready := &model.EndpointStatus{State: job.EndpointReady}
+	canary := v1beta1.ModelDeploymentSpec{Host: "qwen.example.com", StepWeight: 30, StepIntervalSeconds: 60}
+	blueGreen := v1beta1.ModelDeploymentSpec{Host: "qwen.example.com", Strategy: v1beta1.RolloutBlueGreen}
+
+	tests := []struct {
+		name        string
+		spec        v1beta1.ModelDeploymentSpec
+		status      v1beta1.ModelDeploymentStatus
+		endpoint    *model.EndpointStatus
+		wantRetired int32
+		wantStable  int32
+		wantCanary  int32
+		wantWeight  int32
+		wantPhase   v1beta1.RolloutPhase
+	}{
+		{
+			name:       "loading model keeps waiting",
+			spec:       canary,
+			status:     rolloutStatus(0, 0),
+			endpoint:   &model.EndpointStatus{State: job.EndpointLoadingModel},
+			wantStable: 1, wantCanary: 2, wantPhase: v1beta1.RolloutProgressing,
+		},
+		{
+			name:        "degraded endpoint rolls back",
+			spec:        canary,
+			status:      rolloutStatus(30, time.Minute),
+			endpoint:    &model.EndpointStatus{State: job.EndpointFailing, Degraded: true, Message: "connection refused"},
+			wantRetired: 2, wantStable: 1, wantPhase: v1beta1.RolloutRolledBack,
+		},
+		{
+			name:        "model that never loads rolls back",
+			spec:        canary,
+			status:      rolloutStatus(0, 0),
+			endpoint:    &model.EndpointStatus{State: job.EndpointLoadingModel, Degraded: true, Message: "endpoint is not ready after 30m0s: connection refused"},
+			wantRetired: 2, wantStable: 1, wantPhase: v1beta1.RolloutRolledBack,
+		},
+		{
+			name:       "ready canary receives the first step",
+			spec:       canary,
+			status:     rolloutStatus(0, 0),
+			endpoint:   ready,
+			wantStable: 1, wantCanary: 2, wantWeight: 30, wantPhase: v1beta1.RolloutProgressing,
+		},
+		{
+			name:       "step waits for the interval",
+			spec:       canary,
+			status:     rolloutStatus(30, 30*time.Second),
+			endpoint:   ready,
+			wantStable: 1, wantCanary: 2, wantWeight: 30, wantPhase: v1beta1.RolloutProgressing,
+		},
+		{
+			name:       "step after the interval",
+			spec:       canary,
+			status:     rolloutStatus(30, time.Minute),
+			endpoint:   ready,
+			wantStable: 1, wantCanary: 2, wantWeight: 60, wantPhase: v1beta1.RolloutProgressing,
+		},
+		{
+			name:        "last step promotes the canary",
+			spec:        canary,
+			status:      rolloutStatus(90, time.Minute),
+			endpoint:    ready,
+			wantRetired: 1, wantStable: 2, wantPhase: v1beta1.RolloutSucceeded,
+		},
+		{
+			name:        "blue green switches at once",
+			spec:        blueGreen,
+			status:      rolloutStatus(0, 0),
+			endpoint:    ready,
+			wantRetired: 1, wantStable: 2, wantPhase: v1beta1.RolloutSucceeded,
+		},
+		{
+			name: "first revision takes all traffic",
+			spec: canary,
+			status: v1beta1.ModelDeploymentStatus{
+				Phase: v1beta1.RolloutProgressing, CanaryRevision: 1, LatestRevision: 1,
+				Revisions: []v1beta1.ModelRevision{{Revision: 1, JobName: "qwen-r1"}},
+			},
+			endpoint:   ready,
+			wantStable: 1, wantPhase: v1beta1.RolloutSucceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status.DeepCopy()
+			retired := deployment.Advance(&tt.spec, status, tt.endpoint, now)
+			if retired != tt.wantRetired {
+				t.Errorf("retired = %d, want %d", retired, tt.wantRetired)
+			}
+			if status.StableRevision != tt.wantStable || status.CanaryRevision != tt.wantCanary || status.CanaryWeight != tt.wantWeight || status.Phase != tt.wantPhase {
+				t.Errorf("status = %+v", status)
+			}
+			if retired != 0 && deployment.FindRevision(status, retired) != nil {
+				t.Errorf("retired revision %d should be removed from %+v", retired, status.Revisions)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	status := rolloutStatus(40, time.Minute)
+	if retired := deployment.Rollback(&status, "manual rollback", now); retired != 2 {
+		t.Fatalf("retired = %d, want 2", retired)
+	}
+	if status.CanaryRevision != 0 || status.CanaryWeight != 0 || status.Phase != v1beta1.RolloutRolledBack ||
+		len(status.Revisions) != 1 || !strings.Contains(status.Message, "manual rollback") {
+		t.Errorf("status = %+v", status)
+	}
+
+	if retired := deployment.Rollback(&status, "manual rollback", now); retired != 0 {
+		t.Errorf("rollback without canary retired %d", retired)
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/core"
+	"github.com/modcoco/OpsFlow/pkg/handler"
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+// 非法的 autoscaler 配置在模拟放置前返回 400，不会在生成 Pod 模板时 panic
+func TestPlacementCheckInvalidAutoscaler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(core.AppContextMiddleware(nil, nil, model.JobOptions{}))
+	router.POST("/placement/check", handler.PlacementCheckHandle)
+
+	body := `{
+		"autoscaler": {"enabled": true, "cpu": "abc"},
+		"machines": [
+			{"name": "head", "cpu": "4", "memory": "16Gi", "isHeadNode": true},
+			{"name": "worker", "machineType": "group", "groupName": "workers", "cpu": "8", "memory": "32Gi", "replicas": 1}
+		]
+	}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/placement/check", bytes.NewBufferString(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "autoscaler.cpu") {
+		t.Fatalf("status = %d, body = %s, want 400 autoscaler.cpu", w.Code, w.Body.String())
+	}
+}
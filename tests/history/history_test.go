@@ -0,0 +1,146 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/history"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newNodeResourceInfo() *v1beta1.NodeResourceInfo {
+	return &v1beta1.NodeResourceInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: types.UID("uid-1")},
+		Spec: v1beta1.NodeResourceInfoSpec{
+			NodeName:         "node-1",
+			KernelVersion:    "5.15.0-91-generic",
+			ContainerRuntime: "containerd://1.7.2",
+			ScheduleVersion:  "v1.30.1",
+			Labels:           map[string]string{"pool": "gpu"},
+			Resources: map[string]v1beta1.ResourceCapacity{
+				"cpu":            {Total: "64000m", Allocatable: "63000m"},
+				"nvidia.com/gpu": {Total: "8", Allocatable: "8"},
+			},
+		},
+		Status: v1beta1.NodeResourceInfoStatus{NodeStatus: "Ready"},
+	}
+}
+
+func TestDiffSpec(t *testing.T) {
+	existing := newNodeResourceInfo()
+	observed := newNodeResourceInfo()
+	observed.Spec.KernelVersion = "6.8.0-40-generic"
+	observed.Spec.ContainerRuntime = "containerd://1.7.20"
+	observed.Spec.Resources = map[string]v1beta1.ResourceCapacity{
+		"cpu":               {Total: "64000m", Allocatable: "63000m"},
+		"nvidia.com/gpu":    {Total: "8", Allocatable: "7"},
+		"rdma/hca_shared_a": {Total: "100", Allocatable: "100"},
+	}
+
+	changes := history.DiffSpec(existing, observed)
+	want := []history.NodeChange{
+		{Node: "node-1", Type: history.KernelUpgraded, Field: "kernelVersion", Old: "5.15.0-91-generic", New: "6.8.0-40-generic"},
+		{Node: "node-1", Type: history.RuntimeUpgraded, Field: "containerRuntime", Old: "containerd://1.7.2", New: "containerd://1.7.20"},
+		{Node: "node-1", Type: history.ResourceChanged, Field: "nvidia.com/gpu", Old: "total=8,allocatable=8", New: "total=8,allocatable=7"},
+		{Node: "node-1", Type: history.ResourceChanged, Field: "rdma/hca_shared_a", New: "total=100,allocatable=100"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		changes[i].Time = time.Time{}
+		if changes[i] != want[i] {
+			t.Errorf("changes[%d] = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	if changes := history.DiffSpec(existing, newNodeResourceInfo()); len(changes) != 0 {
+		t.Errorf("unchanged spec produced changes: %+v", changes)
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	existing := newNodeResourceInfo()
+	existing.Status.Health = v1beta1.NodeHealthReady
+	existing.Status.NodeConditions = []v1beta1.NodeCondition{
+		{Type: "DiskPressure", Status: "False", Reason: "KubeletHasNoDiskPressure"},
+		{Type: "Ready", Status: "True", Reason: "KubeletReady"},
+		{Type: "KernelDeadlock", Status: "False"},
+	}
+
+	observed := v1beta1.NodeResourceInfoStatus{
+		Health: v1beta1.NodeHealthDegraded,
+		NodeConditions: []v1beta1.NodeCondition{
+			{Type: "DiskPressure", Status: "True", Reason: "KubeletHasDiskPressure"},
+			{Type: "Ready", Status: "True", Reason: "KubeletReady"},
+			{Type: "PIDPressure", Status: "False"},
+		},
+	}
+	changes := history.DiffStatus(existing, observed)
+	want := []history.NodeChange{
+		{Node: "node-1", Type: history.StatusChanged, Field: "health", Old: "Ready", New: "Degraded"},
+		{Node: "node-1", Type: history.ConditionChanged, Field: "DiskPressure", Old: "status=False,reason=KubeletHasNoDiskPressure", New: "status=True,reason=KubeletHasDiskPressure"},
+		{Node: "node-1", Type: history.ConditionChanged, Field: "PIDPressure", New: "status=False"},
+		{Node: "node-1", Type: history.ConditionChanged, Field: "KernelDeadlock", Old: "status=False"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		changes[i].Time = time.Time{}
+		if changes[i] != want[i] {
+			t.Errorf("changes[%d] = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	if changes := history.DiffStatus(existing, existing.Status); changes != nil {
+		t.Errorf("unchanged status produced changes: %+v", changes)
+	}
+
+	// 旧版本写入的 CRD 没有 health，第一次补充时不记录变化
+	legacy := newNodeResourceInfo()
+	if changes := history.DiffStatus(legacy, observed); changes != nil {
+		t.Errorf("legacy status produced changes: %+v", changes)
+	}
+}
+
+func TestEventRecorder(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	recorder := &history.EventRecorder{Clientset: clientset}
+
+	nodeResourceInfo := newNodeResourceInfo()
+	now := time.Now()
+	changes := []history.NodeChange{
+		{Node: "node-1", Type: history.StatusChanged, Field: "health", Old: "Ready", New: "NotReady", Time: now},
+		{Node: "node-1", Type: history.ConditionChanged, Field: "Ready", Old: "status=True", New: "status=False,reason=KubeletNotReady", Time: now},
+		{Node: "node-1", Type: history.KernelUpgraded, Field: "kernelVersion", Old: "5.15", New: "6.8", Time: now},
+	}
+	if err := recorder.Record(context.Background(), nodeResourceInfo, changes); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	events, err := clientset.CoreV1().Events(metav1.NamespaceDefault).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list events failed: %v", err)
+	}
+	if len(events.Items) != 3 {
+		t.Fatalf("events = %d, want 3", len(events.Items))
+	}
+
+	eventTypes := map[string]string{}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "NodeResourceInfo" || event.InvolvedObject.UID != "uid-1" || event.InvolvedObject.APIVersion != "opsflow.io/v1beta1" {
+			t.Errorf("involvedObject = %+v", event.InvolvedObject)
+		}
+		eventTypes[event.Reason] = event.Type
+	}
+	if eventTypes["StatusChanged"] != corev1.EventTypeWarning || eventTypes["ConditionChanged"] != corev1.EventTypeWarning ||
+		eventTypes["KernelUpgraded"] != corev1.EventTypeNormal {
+		t.Errorf("event types = %v, want Warning for NotReady and Normal for upgrade", eventTypes)
+	}
+}
@@ -0,0 +1,83 @@
+package job
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	"k8s.io/utils/ptr"
+)
+
+func newWorkerGroup(replicas, minReplicas, maxReplicas *int32) model.MachineConfig {
+	return model.MachineConfig{
+		Name: "worker", MachineType: model.MachineTypeGroup, GroupName: "workers",
+		CPU: "8", Memory: "32Gi", Replicas: replicas, MinReplicas: minReplicas, MaxReplicas: maxReplicas,
+	}
+}
+
+func TestValidateAutoscaler(t *testing.T) {
+	head := model.MachineConfig{Name: "head", CPU: "4", Memory: "16Gi", IsHeadNode: true}
+	valid := []model.ClusterConfig{
+		{Machines: []model.MachineConfig{head, newWorkerGroup(ptr.To(int32(1)), nil, nil)}},
+		{
+			Machines:   []model.MachineConfig{head, newWorkerGroup(ptr.To(int32(1)), ptr.To(int32(0)), ptr.To(int32(4)))},
+			Autoscaler: &model.AutoscalerConfig{Enabled: true, UpscalingMode: job.UpscalingConservative, IdleTimeoutSeconds: ptr.To(int32(300)), CPU: "1", Memory: "1Gi"},
+		},
+		// 未开启时不检查 autoscaler 配置
+		{Machines: []model.MachineConfig{head}, Autoscaler: &model.AutoscalerConfig{UpscalingMode: "Fast"}},
+	}
+	for _, config := range valid {
+		if err := job.ValidateAutoscaler(&config); err != nil {
+			t.Errorf("ValidateAutoscaler(%+v) = %v", config, err)
+		}
+	}
+
+	invalid := map[string]model.ClusterConfig{
+		"minReplicas 3 is greater than maxReplicas 2": {Machines: []model.MachineConfig{newWorkerGroup(nil, ptr.To(int32(3)), ptr.To(int32(2)))}},
+		"replicas 5 is greater than maxReplicas 4":    {Machines: []model.MachineConfig{newWorkerGroup(ptr.To(int32(5)), nil, ptr.To(int32(4)))}},
+		"replicas 0 is less than minReplicas 1":       {Machines: []model.MachineConfig{newWorkerGroup(ptr.To(int32(0)), ptr.To(int32(1)), nil)}},
+		"maxReplicas must not be negative":            {Machines: []model.MachineConfig{newWorkerGroup(nil, nil, ptr.To(int32(-1)))}},
+		"unknown autoscaler.upscalingMode":            {Autoscaler: &model.AutoscalerConfig{Enabled: true, UpscalingMode: "Fast"}},
+		"autoscaler.idleTimeoutSeconds":               {Autoscaler: &model.AutoscalerConfig{Enabled: true, IdleTimeoutSeconds: ptr.To(int32(-1))}},
+		"invalid autoscaler.memory":                   {Autoscaler: &model.AutoscalerConfig{Enabled: true, Memory: "lots"}},
+	}
+	for want, config := range invalid {
+		if err := job.ValidateAutoscaler(&config); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateAutoscaler(%+v) = %v, want %q", config, err, want)
+		}
+	}
+}
+
+func TestApplyAutoscaler(t *testing.T) {
+	machines := []model.MachineConfig{
+		{Name: "head", CPU: "4", Memory: "16Gi", IsHeadNode: true},
+		newWorkerGroup(ptr.To(int32(2)), ptr.To(int32(1)), nil),
+		{Name: "single", MachineType: model.MachineTypeSingle, CPU: "4", Memory: "16Gi"},
+	}
+	spec := rayv1.RayClusterSpec{WorkerGroupSpecs: job.CreateWorkerGroupSpecs(machines, "")}
+
+	job.ApplyAutoscaler(&spec, nil)
+	if spec.EnableInTreeAutoscaling != nil || spec.AutoscalerOptions != nil {
+		t.Fatalf("autoscaler should not be enabled: %+v", spec)
+	}
+
+	job.ApplyAutoscaler(&spec, &model.AutoscalerConfig{Enabled: true, UpscalingMode: job.UpscalingAggressive, IdleTimeoutSeconds: ptr.To(int32(120)), Memory: "1Gi"})
+	if !*spec.EnableInTreeAutoscaling {
+		t.Fatal("autoscaler should be enabled")
+	}
+	options := spec.AutoscalerOptions
+	if *options.UpscalingMode != rayv1.UpscalingMode(job.UpscalingAggressive) || *options.IdleTimeoutSeconds != 120 {
+		t.Errorf("unexpected autoscaler options: %+v", options)
+	}
+	if cpu, memory := options.Resources.Limits.Cpu(), options.Resources.Requests.Memory(); cpu.String() != "500m" || memory.String() != "1Gi" {
+		t.Errorf("autoscaler resources = %s cpu, %s memory", cpu, memory)
+	}
+
+	// 未设置 maxReplicas 的组不向上扩容，single 固定为 1 个副本
+	group, single := spec.WorkerGroupSpecs[0], spec.WorkerGroupSpecs[1]
+	if *group.MinReplicas != 1 || *group.MaxReplicas != 2 || *single.MinReplicas != 1 || *single.MaxReplicas != 1 {
+		t.Errorf("group = %d..%d, single = %d..%d", *group.MinReplicas, *group.MaxReplicas, *single.MinReplicas, *single.MaxReplicas)
+	}
+}
@@ -0,0 +1,288 @@
+package job
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	rayv1 "github.com/ray-project/kuberay/ray-operator/apis/ray/v1"
+	rayfake "github.com/ray-project/kuberay/ray-operator/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type memoryEndpointStore map[string]job.EndpointRecord
+
+func (s memoryEndpointStore) Load(_ context.Context, namespace, name string) (job.EndpointRecord, error) {
+	return s[namespace+"/"+name], nil
+}
+
+func (s memoryEndpointStore) Save(_ context.Context, namespace, name string, record job.EndpointRecord) error {
+	s[namespace+"/"+name] = record
+	return nil
+}
+
+func (s memoryEndpointStore) Delete(_ context.Context, namespace, name string) error {
+	delete(s, namespace+"/"+name)
+	return nil
+}
+
+func newServingRayJob(status rayv1.JobStatus, clusterName string) *rayv1.RayJob {
+	return &rayv1.RayJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: "default",
+			Labels:    map[string]string{model.JobKindLabel: job.JobKindVllmServe},
+		},
+		Status: rayv1.RayJobStatus{JobStatus: status, RayClusterName: clusterName},
+	}
+}
+
+func TestEndpointProberStarting(t *testing.T) {
+	prober := &job.EndpointProber{Store: memoryEndpointStore{}}
+
+	entrypoint := newServingRayJob(rayv1.JobStatusRunning, "demo-raycluster")
+	entrypoint.Labels[model.JobKindLabel] = job.JobKindEntrypoint
+	if status, err := prober.Probe(context.Background(), entrypoint); err != nil || status != nil {
+		t.Errorf("entrypoint job = %+v, %v, want nil", status, err)
+	}
+
+	for _, rayJob := range []*rayv1.RayJob{
+		newServingRayJob(rayv1.JobStatusPending, ""),
+		newServingRayJob(rayv1.JobStatusRunning, ""),
+	} {
+		status, err := prober.Probe(context.Background(), rayJob)
+		if err != nil || status.State != job.EndpointStarting {
+			t.Errorf("job %s = %+v, %v, want Starting", rayJob.Status.JobStatus, status, err)
+		}
+	}
+}
+
+func TestEndpointProberLifecycle(t *testing.T) {
+	var healthy, loaded atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !healthy.Load():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v1/models" && loaded.Load():
+			w.Write([]byte(`{"object": "list", "data": [{"id": "/mnt/models/Qwen2.5-7B", "object": "model"}]}`))
+		case r.URL.Path == "/v1/models":
+			w.Write([]byte(`{"object": "list", "data": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	store := memoryEndpointStore{}
+	prober := &job.EndpointProber{
+		Client:        server.Client(),
+		Store:         store,
+		DegradedAfter: 5 * time.Minute,
+		BaseURL:       func(string, string) string { return server.URL },
+		Now:           func() time.Time { return now },
+	}
+	rayJob := newServingRayJob(rayv1.JobStatusRunning, "demo-raycluster")
+
+	probe := func(wantState string) *model.EndpointStatus {
+		t.Helper()
+		status, err := prober.Probe(context.Background(), rayJob)
+		if err != nil {
+			t.Fatalf("Probe failed: %v", err)
+		}
+		if status.State != wantState {
+			t.Fatalf("state = %s (%s), want %s", status.State, status.Message, wantState)
+		}
+		return status
+	}
+
+	// 就绪之前的失败视为加载中，不会 degraded
+	probe(job.EndpointLoadingModel)
+	healthy.Store(true)
+	probe(job.EndpointLoadingModel)
+	now = now.Add(time.Hour)
+	if status := probe(job.EndpointLoadingModel); status.Degraded || status.FailingSince != nil {
+		t.Errorf("loading endpoint should not be degraded: %+v", status)
+	}
+
+	loaded.Store(true)
+	status := probe(job.EndpointReady)
+	if !slices.Equal(status.Models, []string{"/mnt/models/Qwen2.5-7B"}) || status.URL != server.URL {
+		t.Errorf("ready status = %+v", status)
+	}
+
+	// 就绪后失败，超过 DegradedAfter 后 degraded
+	healthy.Store(false)
+	failingSince := now
+	status = probe(job.EndpointFailing)
+	if status.Degraded || status.FailingSince == nil || !status.FailingSince.Equal(failingSince) {
+		t.Errorf("failing status = %+v", status)
+	}
+	now = now.Add(5 * time.Minute)
+	if status := probe(job.EndpointFailing); !status.Degraded || !status.FailingSince.Equal(failingSince) {
+		t.Errorf("status after DegradedAfter = %+v, want degraded", status)
+	}
+
+	// 恢复后重新计时
+	healthy.Store(true)
+	probe(job.EndpointReady)
+	if record := store["default/demo"]; !record.FailingSince.IsZero() {
+		t.Errorf("failingSince should be cleared, got %v", record.FailingSince)
+	}
+	healthy.Store(false)
+	now = now.Add(time.Minute)
+	if status := probe(job.EndpointFailing); status.Degraded || !status.FailingSince.Equal(now) {
+		t.Errorf("status after recovery = %+v", status)
+	}
+}
+
+// 一直没有就绪的接口超过 LoadTimeout 后 degraded，发布任务据此回滚
+func TestEndpointProberLoadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	store := memoryEndpointStore{}
+	prober := &job.EndpointProber{
+		Client:        server.Client(),
+		Store:         store,
+		DegradedAfter: 5 * time.Minute,
+		LoadTimeout:   30 * time.Minute,
+		BaseURL:       func(string, string) string { return server.URL },
+		Now:           func() time.Time { return now },
+	}
+	rayJob := newServingRayJob(rayv1.JobStatusRunning, "demo-raycluster")
+
+	loadingSince := now
+	status, err := prober.Probe(context.Background(), rayJob)
+	if err != nil || status.State != job.EndpointLoadingModel || status.Degraded || !status.LoadingSince.Equal(loadingSince) {
+		t.Fatalf("first probe = %+v, %v, want loading", status, err)
+	}
+	now = now.Add(29 * time.Minute)
+	if status, err := prober.Probe(context.Background(), rayJob); err != nil || status.Degraded || !status.LoadingSince.Equal(loadingSince) {
+		t.Fatalf("probe before LoadTimeout = %+v, %v, want not degraded", status, err)
+	}
+
+	// 超时后仍为 LoadingModel，Cached 按读取时的时间计算
+	now = now.Add(time.Minute)
+	status, err = prober.Cached(context.Background(), rayJob)
+	if err != nil || status.State != job.EndpointLoadingModel || !status.Degraded || !strings.Contains(status.Message, "not ready after 30m0s") {
+		t.Fatalf("cached status after LoadTimeout = %+v, %v, want degraded", status, err)
+	}
+	if status, err := prober.Probe(context.Background(), rayJob); err != nil || !status.Degraded {
+		t.Fatalf("probe after LoadTimeout = %+v, %v, want degraded", status, err)
+	}
+}
+
+func TestEndpointProberTerminalJob(t *testing.T) {
+	prober := &job.EndpointProber{
+		Store:   memoryEndpointStore{},
+		BaseURL: func(string, string) string { return "http://127.0.0.1:1" },
+	}
+	status, err := prober.Probe(context.Background(), newServingRayJob(rayv1.JobStatusFailed, "demo-raycluster"))
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if status.State != job.EndpointFailing || status.Message != "job is FAILED" || !status.Degraded {
+		t.Errorf("terminal job status = %+v, want degraded Failing", status)
+	}
+}
+
+// Cached 只读取保存的结果，不请求推理服务，degraded 按读取时的时间计算
+func TestEndpointProberCached(t *testing.T) {
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case !healthy.Load():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Write([]byte(`{"data": [{"id": "qwen"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	prober := &job.EndpointProber{
+		Client:        server.Client(),
+		Store:         memoryEndpointStore{},
+		DegradedAfter: 5 * time.Minute,
+		BaseURL:       func(string, string) string { return server.URL },
+		Now:           func() time.Time { return now },
+	}
+	rayJob := newServingRayJob(rayv1.JobStatusRunning, "demo-raycluster")
+	ctx := context.Background()
+
+	status, err := prober.Cached(ctx, rayJob)
+	if err != nil || status.State != job.EndpointLoadingModel || status.ProbedAt != nil {
+		t.Fatalf("status before the first probe = %+v, %v", status, err)
+	}
+
+	if _, err := prober.Probe(ctx, rayJob); err != nil {
+		t.Fatal(err)
+	}
+	status, err = prober.Cached(ctx, rayJob)
+	if err != nil || status.State != job.EndpointReady || !slices.Equal(status.Models, []string{"qwen"}) || !status.ProbedAt.Equal(now) {
+		t.Fatalf("cached status = %+v, %v", status, err)
+	}
+
+	healthy.Store(false)
+	if _, err := prober.Probe(ctx, rayJob); err != nil {
+		t.Fatal(err)
+	}
+	probed := requests.Load()
+	now = now.Add(5 * time.Minute)
+	if status, err := prober.Cached(ctx, rayJob); err != nil || status.State != job.EndpointFailing || !status.Degraded {
+		t.Fatalf("cached status after DegradedAfter = %+v, %v, want degraded", status, err)
+	}
+	if requests.Load() != probed {
+		t.Error("Cached should not request the endpoint")
+	}
+}
+
+// ProbeAll 只探测推理种类的任务，跳过模型部署的版本
+func TestEndpointProbeAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.Write([]byte(`{"data": [{"id": "qwen"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	serving := newServingRayJob(rayv1.JobStatusRunning, "demo-raycluster")
+	entrypoint := newServingRayJob(rayv1.JobStatusRunning, "batch-raycluster")
+	entrypoint.Name = "batch"
+	entrypoint.Labels[model.JobKindLabel] = job.JobKindEntrypoint
+	revision := newServingRayJob(rayv1.JobStatusRunning, "qwen-r1-raycluster")
+	revision.Name = "qwen-r1"
+	revision.Labels[v1beta1.ModelDeploymentLabel] = "qwen"
+
+	store := memoryEndpointStore{}
+	prober := &job.EndpointProber{
+		Client:  server.Client(),
+		Store:   store,
+		BaseURL: func(string, string) string { return server.URL },
+	}
+	if err := prober.ProbeAll(context.Background(), rayfake.NewSimpleClientset(serving, entrypoint, revision)); err != nil {
+		t.Fatalf("ProbeAll failed: %v", err)
+	}
+	if len(store) != 1 || store["default/demo"].State != job.EndpointReady {
+		t.Fatalf("records = %+v, want only default/demo Ready", store)
+	}
+}
@@ -0,0 +1,203 @@
+package job
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+func newGPUMachine(name string, head bool, gpus string, replicas *int32) model.MachineConfig {
+	return model.MachineConfig{
+		Name:        name,
+		IsHeadNode:  head,
+		MachineType: model.MachineTypeSingle,
+		CPU:         "8",
+		Memory:      "16Gi",
+		CustomResources: map[string]model.CustomResource{
+			"nvidia.com/gpu": {Quantity: gpus},
+		},
+		Volumes: []model.VolumeConfig{{
+			Name:      "model-volume",
+			Label:     map[string]string{"model": "true"},
+			MountPath: "/mnt/models/Qwen2.5-7B",
+		}},
+		Replicas: replicas,
+	}
+}
+
+func newClusterConfig(kind string, machines ...model.MachineConfig) *model.ClusterConfig {
+	return &model.ClusterConfig{
+		Namespace: "default",
+		Job:       &model.JobConfig{Kind: kind, Name: "demo"},
+		Machines:  machines,
+	}
+}
+
+func TestUnknownJobKind(t *testing.T) {
+	config := newClusterConfig("tgiServe", newGPUMachine("head", true, "1", nil))
+	_, err := job.PrepareJob(config, model.JobOptions{})
+	if err == nil {
+		t.Fatal("unknown kind should be rejected")
+	}
+	for _, kind := range job.SupportedJobKinds() {
+		if !strings.Contains(err.Error(), kind) {
+			t.Errorf("error %q does not list supported kind %s", err, kind)
+		}
+	}
+}
+
+func TestEntrypointJobKind(t *testing.T) {
+	config := newClusterConfig("", newGPUMachine("head", true, "1", nil))
+	if err := job.ValidateJob(config, model.JobOptions{}); err == nil {
+		t.Error("entrypoint without cmd should be rejected")
+	}
+
+	config.Job.Cmd = "python main.py"
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil || runCodeConfig != nil {
+		t.Fatalf("PrepareJob = %v, %v, want nil, nil", runCodeConfig, err)
+	}
+	if config.Job.Cmd != "python main.py" || len(config.Machines[0].Volumes) != 1 {
+		t.Errorf("entrypoint should not modify config: %+v", config)
+	}
+}
+
+func TestVllmServeJobKind(t *testing.T) {
+	replicas := int32(2)
+	config := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "8", &replicas))
+
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil {
+		t.Fatalf("PrepareJob failed: %v", err)
+	}
+	for _, want := range []string{`"vllm", "serve", "/mnt/models/Qwen2.5-7B"`, `"--tensor-parallel-size", "8"`, `"--pipeline-parallel-size", "3"`} {
+		if !strings.Contains(runCodeConfig.RunCode, want) {
+			t.Errorf("runcode missing %s:\n%s", want, runCodeConfig.RunCode)
+		}
+	}
+	if config.Job.Cmd != "python /home/ray/.runcode/vllm_demo.py" {
+		t.Errorf("cmd = %q", config.Job.Cmd)
+	}
+	head := config.Machines[0]
+	if len(head.Volumes) != 2 || head.Volumes[1].Source.ConfigMap == nil || head.Volumes[1].Source.ConfigMap.Name != runCodeConfig.ConfigMapName {
+		t.Errorf("runcode volume not mounted on head: %+v", head.Volumes)
+	}
+
+	noGPU := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "0", nil))
+	if err := job.ValidateJob(noGPU, model.JobOptions{}); err == nil {
+		t.Error("vllm without GPU should be rejected")
+	}
+}
+
+func TestSGLangServeJobKind(t *testing.T) {
+	config := newClusterConfig(job.JobKindSGLangServe, newGPUMachine("head", true, "4", nil))
+	config.Job.Args = []model.ArgItem{{
+		Label:  map[string]string{"sglangRuncodeCustomParams": "true"},
+		Params: map[string]string{"--port": "30000", "--mem-fraction-static": "0.8"},
+	}}
+
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil {
+		t.Fatalf("PrepareJob failed: %v", err)
+	}
+	want := `command = ["python", "-m", "sglang.launch_server", "--model-path", "/mnt/models/Qwen2.5-7B", "--host", "0.0.0.0", "--mem-fraction-static", "0.8", "--port", "30000", "--tp-size", "4", "--trust-remote-code"]`
+	if !strings.Contains(runCodeConfig.RunCode, want) || !strings.Contains(runCodeConfig.RunCode, "def start_sglang():") ||
+		!strings.Contains(runCodeConfig.RunCode, "ray.get(start_sglang.remote())") {
+		t.Errorf("runcode = %s, want %s", runCodeConfig.RunCode, want)
+	}
+
+	multi := newClusterConfig(job.JobKindSGLangServe, newGPUMachine("head", true, "4", nil), newGPUMachine("worker", false, "4", nil))
+	if err := job.ValidateJob(multi, model.JobOptions{}); err == nil {
+		t.Error("sglang with multiple machines should be rejected")
+	}
+}
+
+func TestRayTrainJobKind(t *testing.T) {
+	replicas := int32(2)
+	config := newClusterConfig(job.JobKindRayTrain, newGPUMachine("head", true, "0", nil), newGPUMachine("worker", false, "4", &replicas))
+	if err := job.ValidateJob(config, model.JobOptions{}); err == nil {
+		t.Error("ray train without cmd should be rejected")
+	}
+
+	config.Job.Cmd = "python /code/train.py --epochs 3"
+	config.Job.Args = []model.ArgItem{{
+		Label:  map[string]string{"rayTrainParams": "true"},
+		Params: map[string]string{"--lr": "1e-5"},
+	}}
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil || runCodeConfig != nil {
+		t.Fatalf("PrepareJob = %v, %v, want nil, nil", runCodeConfig, err)
+	}
+	if want := "python /code/train.py --epochs 3 --lr 1e-5 --num-workers 8 --use-gpu"; config.Job.Cmd != want {
+		t.Errorf("cmd = %q, want %q", config.Job.Cmd, want)
+	}
+}
+
+// 用户传入的参数经过 shell 转义，空格、引号与 $(...) 不会改变命令
+func TestRayTrainJobKindQuotesParams(t *testing.T) {
+	config := newClusterConfig(job.JobKindRayTrain, newGPUMachine("head", true, "1", nil))
+	config.Job.Cmd = "python /code/train.py"
+	config.Job.Args = []model.ArgItem{{
+		Label:  map[string]string{"rayTrainParams": "true"},
+		Params: map[string]string{"--run-name": `it's "baseline"; $(touch /tmp/pwned)`, "--tags": "a b"},
+	}}
+	if _, err := job.PrepareJob(config, model.JobOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `python /code/train.py --num-workers 1 --run-name 'it'\''s "baseline"; $(touch /tmp/pwned)' --tags 'a b' --use-gpu`
+	if config.Job.Cmd != want {
+		t.Fatalf("cmd = %q, want %q", config.Job.Cmd, want)
+	}
+
+	// 由 shell 解析后每个值仍是单个参数
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	args := strings.TrimPrefix(config.Job.Cmd, "python /code/train.py")
+	out, err := exec.Command("sh", "-c", "set --"+args+`; for arg in "$@"; do printf '%s\n' "$arg"; done`).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	wantArgs := []string{"--num-workers", "1", "--run-name", `it's "baseline"; $(touch /tmp/pwned)`, "--tags", "a b", "--use-gpu"}
+	if !slices.Equal(got, wantArgs) {
+		t.Errorf("shell args = %q, want %q", got, wantArgs)
+	}
+}
+
+func TestBatchInferenceJobKind(t *testing.T) {
+	replicas := int32(3)
+	config := newClusterConfig(job.JobKindBatchInference, newGPUMachine("head", true, "2", nil), newGPUMachine("worker", false, "2", &replicas))
+	if err := job.ValidateJob(config, model.JobOptions{}); err == nil || !strings.Contains(err.Error(), "inputPath,outputPath") {
+		t.Errorf("err = %v, want missing inputPath,outputPath", err)
+	}
+
+	config.Job.Args = []model.ArgItem{{
+		Label:  map[string]string{"batchInferenceParams": "true"},
+		Params: map[string]string{"inputPath": "/data/prompts.jsonl", "outputPath": "/data/output", "maxTokens": "512"},
+	}}
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil {
+		t.Fatalf("PrepareJob failed: %v", err)
+	}
+	for _, want := range []string{
+		`INPUT_PATH = "/data/prompts.jsonl"`,
+		`PROMPT_COLUMN = "prompt"`,
+		"tensor_parallel_size=2",
+		"SamplingParams(max_tokens=512)",
+		"concurrency=4, num_gpus=2, batch_size=64",
+	} {
+		if !strings.Contains(runCodeConfig.RunCode, want) {
+			t.Errorf("runcode missing %s:\n%s", want, runCodeConfig.RunCode)
+		}
+	}
+
+	config.Job.Args[0].Params["batchSize"] = "zero"
+	if err := job.ValidateJob(config, model.JobOptions{}); err == nil {
+		t.Error("invalid batchSize should be rejected")
+	}
+}
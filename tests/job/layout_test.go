@@ -0,0 +1,218 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+)
+
+func TestPlanVllmLayout(t *testing.T) {
+	two, zero := int32(2), int32(0)
+	cpuHead := newGPUMachine("head", true, "0", nil)
+	cpuHead.CustomResources = nil
+
+	tests := []struct {
+		name     string
+		machines []model.MachineConfig
+		params   map[string]string
+		want     model.ParallelLayout
+	}{
+		{
+			name:     "single machine",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "4", nil)},
+			want:     model.ParallelLayout{TensorParallelSize: 4, PipelineParallelSize: 1, DataParallelSize: 1, GPUMachines: 1, GPUsPerMachine: 4},
+		},
+		{
+			name:     "cpu head is ignored",
+			machines: []model.MachineConfig{cpuHead, newGPUMachine("worker", false, "8", &two)},
+			want:     model.ParallelLayout{TensorParallelSize: 8, PipelineParallelSize: 2, DataParallelSize: 1, GPUMachines: 2, GPUsPerMachine: 8},
+		},
+		{
+			name:     "worker group without replicas is ignored",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "8", nil), newGPUMachine("spare", false, "4", &zero)},
+			want:     model.ParallelLayout{TensorParallelSize: 8, PipelineParallelSize: 1, DataParallelSize: 1, GPUMachines: 1, GPUsPerMachine: 8},
+		},
+		{
+			name:     "data parallel replicas",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "4", nil), newGPUMachine("worker", false, "4", &two)},
+			params:   map[string]string{"--data-parallel-size": "3"},
+			want:     model.ParallelLayout{TensorParallelSize: 4, PipelineParallelSize: 1, DataParallelSize: 3, GPUMachines: 3, GPUsPerMachine: 4},
+		},
+		{
+			name:     "custom tensor parallel",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "8", nil)},
+			params:   map[string]string{"--tensor-parallel-size": "4"},
+			want:     model.ParallelLayout{TensorParallelSize: 4, PipelineParallelSize: 4, DataParallelSize: 1, GPUMachines: 2, GPUsPerMachine: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newClusterConfig(job.JobKindVllmServe, tt.machines...)
+			if tt.params != nil {
+				config.Job.Args = []model.ArgItem{{Label: map[string]string{"vllmRuncodeCustomParams": "true"}, Params: tt.params}}
+			}
+			layout, err := job.PlanVllmLayout(config, nil)
+			if err != nil {
+				t.Fatalf("PlanVllmLayout failed: %v", err)
+			}
+			if *layout != tt.want {
+				t.Errorf("layout = %+v, want %+v", *layout, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanVllmLayoutErrors(t *testing.T) {
+	cpuHead := newGPUMachine("head", true, "0", nil)
+	tests := []struct {
+		name     string
+		machines []model.MachineConfig
+		params   map[string]string
+		want     string
+	}{
+		{
+			name:     "heterogeneous gpu counts",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "4", nil)},
+			want:     "same gpu count, got head=8, worker=4",
+		},
+		{
+			name:     "no gpu",
+			machines: []model.MachineConfig{cpuHead},
+			want:     "no gpu",
+		},
+		{
+			name:     "layout does not cover all gpus",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "8", nil)},
+			params:   map[string]string{"--pipeline-parallel-size": "1"},
+			want:     "want total gpu count 16",
+		},
+		{
+			name:     "invalid parallel size",
+			machines: []model.MachineConfig{newGPUMachine("head", true, "8", nil)},
+			params:   map[string]string{"--tensor-parallel-size": "auto"},
+			want:     `invalid --tensor-parallel-size "auto"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newClusterConfig(job.JobKindVllmServe, tt.machines...)
+			if tt.params != nil {
+				config.Job.Args = []model.ArgItem{{Label: map[string]string{"vllmRuncodeCustomParams": "true"}, Params: tt.params}}
+			}
+			_, err := job.PlanVllmLayout(config, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want containing %q", err, tt.want)
+			}
+			if err := job.ValidateJob(config, model.JobOptions{}); err == nil {
+				t.Error("ValidateJob should reject the layout")
+			}
+		})
+	}
+}
+
+// 按 Pod 中的模型路径写入 config.json
+func writeModelConfig(t *testing.T, root, content string) {
+	t.Helper()
+	dir := filepath.Join(root, "mnt/models/Qwen2.5-7B")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanVllmLayoutModelConfig(t *testing.T) {
+	root := t.TempDir()
+	loader := job.LocalModelConfigLoader(root)
+	config := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "8", nil))
+
+	// 没有 config.json 时不检查
+	layout, err := job.PlanVllmLayout(config, loader)
+	if err != nil || layout.ModelConfigChecked {
+		t.Fatalf("layout without config.json = %+v, %v", layout, err)
+	}
+
+	writeModelConfig(t, root, `{"num_attention_heads": 28, "num_key_value_heads": 4, "num_hidden_layers": 28}`)
+	if _, err := job.PlanVllmLayout(config, loader); err == nil || !strings.Contains(err.Error(), "num_attention_heads 28 is not divisible by tensor parallel size 8") {
+		t.Errorf("error = %v, want attention heads error", err)
+	}
+
+	// 多模态模型的配置在 text_config 中
+	writeModelConfig(t, root, `{"text_config": {"num_attention_heads": 32, "num_key_value_heads": 8, "num_hidden_layers": 32}}`)
+	layout, err = job.PlanVllmLayout(config, loader)
+	if err != nil || !layout.ModelConfigChecked {
+		t.Fatalf("layout = %+v, %v, want checked layout", layout, err)
+	}
+
+	writeModelConfig(t, root, `{"num_attention_heads": 32, "num_hidden_layers": 1}`)
+	if _, err := job.PlanVllmLayout(config, loader); err == nil || !strings.Contains(err.Error(), "num_hidden_layers 1 is less than pipeline parallel size 2") {
+		t.Errorf("error = %v, want hidden layers error", err)
+	}
+
+	writeModelConfig(t, root, `{`)
+	if _, err := job.PlanVllmLayout(config, loader); err == nil {
+		t.Error("invalid config.json should be rejected")
+	}
+}
+
+// 创建任务时使用 JobOptions 中的 loader 检查模型配置
+func TestValidateJobModelConfigLoader(t *testing.T) {
+	root := t.TempDir()
+	writeModelConfig(t, root, `{"num_attention_heads": 28, "num_key_value_heads": 4, "num_hidden_layers": 28}`)
+	config := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "8", nil))
+
+	if err := job.ValidateJob(config, model.JobOptions{}); err != nil {
+		t.Fatalf("ValidateJob without loader = %v", err)
+	}
+	opts := model.JobOptions{ModelConfigLoader: job.LocalModelConfigLoader(root)}
+	if err := job.ValidateJob(config, opts); err == nil || !strings.Contains(err.Error(), "num_attention_heads 28") {
+		t.Errorf("ValidateJob with loader = %v, want attention heads error", err)
+	}
+}
+
+func TestVllmServeDataParallelRunCode(t *testing.T) {
+	cpuHead := newGPUMachine("head", true, "0", nil)
+	two := int32(2)
+	config := newClusterConfig(job.JobKindVllmServe, cpuHead, newGPUMachine("worker", false, "4", &two))
+	config.Job.Args = []model.ArgItem{{
+		Label:  map[string]string{"vllmRuncodeCustomParams": "true"},
+		Params: map[string]string{"--data-parallel-size": "2"},
+	}}
+
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil {
+		t.Fatalf("PrepareJob failed: %v", err)
+	}
+	for _, want := range []string{`"--data-parallel-size", "2"`, `"--pipeline-parallel-size", "1"`, `"--tensor-parallel-size", "4"`} {
+		if !strings.Contains(runCodeConfig.RunCode, want) {
+			t.Errorf("runcode missing %s:\n%s", want, runCodeConfig.RunCode)
+		}
+	}
+	want := model.ParallelLayout{TensorParallelSize: 4, PipelineParallelSize: 1, DataParallelSize: 2, GPUMachines: 2, GPUsPerMachine: 4}
+	if runCodeConfig.Layout == nil || *runCodeConfig.Layout != want {
+		t.Errorf("layout = %+v, want %+v", runCodeConfig.Layout, want)
+	}
+}
+
+// 模型路径中的 .. 不能读取 root 之外的 config.json
+func TestLocalModelConfigLoaderOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "models")
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"num_attention_heads": 32}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loader := job.LocalModelConfigLoader(root)
+	for _, modelPath := range []string{"..", "/mnt/../..", "../models/../.."} {
+		if config, err := loader(modelPath); err == nil || !strings.Contains(err.Error(), "outside the model config root") {
+			t.Errorf("loader(%q) = %+v, %v, want outside root error", modelPath, config, err)
+		}
+	}
+	if config, err := loader("/mnt/models/missing"); config != nil || err != nil {
+		t.Errorf("loader inside root = %+v, %v, want nil, nil", config, err)
+	}
+}
@@ -0,0 +1,160 @@
+package job
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func newPodTemplateConfig() model.ClusterConfig {
+	return model.ClusterConfig{
+		PodDefaults: &model.PodTemplateConfig{
+			Env:              []corev1.EnvVar{{Name: "HF_ENDPOINT", Value: "https://hf-mirror.com"}, {Name: "NCCL_DEBUG", Value: "WARN"}},
+			NodeSelector:     map[string]string{"pool": "gpu"},
+			Tolerations:      []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}},
+			ImagePullSecrets: []string{"registry"},
+			ShmSize:          "8Gi",
+		},
+		Machines: []model.MachineConfig{
+			{Name: "head", CPU: "4", Memory: "16Gi", IsHeadNode: true},
+			{
+				Name: "worker", MachineType: model.MachineTypeGroup, GroupName: "workers", CPU: "8", Memory: "32Gi", Replicas: ptr.To(int32(2)),
+				PodTemplateConfig: model.PodTemplateConfig{
+					Env:                []corev1.EnvVar{{Name: "NCCL_DEBUG", Value: "INFO"}},
+					NodeSelector:       map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100-SXM4-80GB"},
+					ImagePullSecrets:   []string{"registry", "private"},
+					ShmSize:            "32Gi",
+					ServiceAccountName: "ray-worker",
+					SecurityContext:    &corev1.SecurityContext{Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"IPC_LOCK"}}},
+					ExtraContainers:    []corev1.Container{{Name: "log-agent", Image: "fluent/fluent-bit:3.0"}},
+				},
+			},
+		},
+	}
+}
+
+func TestCreateRayClusterSpecPodTemplate(t *testing.T) {
+	spec, err := job.CreateRayClusterSpec(newPodTemplateConfig(), "2.41.0", "rayproject/ray:2.41.0")
+	if err != nil {
+		t.Fatalf("CreateRayClusterSpec failed: %v", err)
+	}
+
+	head := spec.HeadGroupSpec.Template.Spec
+	if head.NodeSelector["pool"] != "gpu" || len(head.Containers) != 1 || head.ServiceAccountName != "" {
+		t.Errorf("head should only use the defaults: %+v", head)
+	}
+	if head.Volumes[0].EmptyDir.SizeLimit.String() != "8Gi" || head.Containers[0].VolumeMounts[0].MountPath != "/dev/shm" {
+		t.Errorf("head shm = %+v, %+v", head.Volumes, head.Containers[0].VolumeMounts)
+	}
+
+	worker := spec.WorkerGroupSpecs[0].Template.Spec
+	ray := worker.Containers[0]
+	if len(ray.Env) != 2 || ray.Env[1].Value != "INFO" {
+		t.Errorf("machine env should override defaults by name: %+v", ray.Env)
+	}
+	if worker.NodeSelector["pool"] != "gpu" || worker.NodeSelector["nvidia.com/gpu.product"] != "NVIDIA-A100-SXM4-80GB" {
+		t.Errorf("nodeSelector = %v", worker.NodeSelector)
+	}
+	if len(worker.ImagePullSecrets) != 2 || worker.ServiceAccountName != "ray-worker" || len(worker.Tolerations) != 1 {
+		t.Errorf("unexpected worker pod spec: %+v", worker)
+	}
+	if ray.SecurityContext.Capabilities.Add[0] != "IPC_LOCK" || worker.Volumes[0].EmptyDir.Medium != corev1.StorageMediumMemory || worker.Volumes[0].EmptyDir.SizeLimit.String() != "32Gi" {
+		t.Errorf("unexpected ray container: %+v", ray)
+	}
+	if len(worker.Containers) != 2 || worker.Containers[0].Name != "worker" || worker.Containers[1].Name != "log-agent" {
+		t.Errorf("ray container should stay first: %+v", worker.Containers)
+	}
+}
+
+func TestPodTemplatePatch(t *testing.T) {
+	config := newPodTemplateConfig()
+	config.PodDefaults.PodTemplatePatch = json.RawMessage(`{"metadata": {"annotations": {"sidecar.istio.io/inject": "false"}}}`)
+	config.Machines[1].PodTemplatePatch = json.RawMessage(`{
+		"spec": {
+			"priorityClassName": "inference",
+			"containers": [{"name": "worker", "env": [{"name": "VLLM_LOGGING_LEVEL", "value": "DEBUG"}]}],
+			"tolerations": [{"key": "dedicated", "operator": "Equal", "value": "llm", "effect": "NoSchedule"}]
+		}
+	}`)
+	if err := job.ValidatePodTemplates(&config, model.HostPathPolicy{}); err != nil {
+		t.Fatalf("ValidatePodTemplates failed: %v", err)
+	}
+
+	spec, err := job.CreateRayClusterSpec(config, "2.41.0", "rayproject/ray:2.41.0")
+	if err != nil {
+		t.Fatalf("CreateRayClusterSpec failed: %v", err)
+	}
+	if spec.HeadGroupSpec.Template.Annotations["sidecar.istio.io/inject"] != "false" {
+		t.Errorf("defaults patch should apply to the head: %+v", spec.HeadGroupSpec.Template.ObjectMeta)
+	}
+	worker := spec.WorkerGroupSpecs[0].Template
+	ray := worker.Spec.Containers[0]
+	if worker.Annotations["sidecar.istio.io/inject"] != "false" || worker.Spec.PriorityClassName != "inference" {
+		t.Errorf("unexpected worker template: %+v", worker.ObjectMeta)
+	}
+	// strategic merge 按名称合并容器与 env，tolerations 整体替换
+	if ray.Image != "rayproject/ray:2.41.0" || len(ray.Env) != 3 || len(worker.Spec.Containers) != 2 {
+		t.Errorf("containers should be merged by name: %+v", worker.Spec.Containers)
+	}
+	if len(worker.Spec.Tolerations) != 1 || worker.Spec.Tolerations[0].Key != "dedicated" {
+		t.Errorf("tolerations = %+v", worker.Spec.Tolerations)
+	}
+
+	requests := job.ClusterConfigPlacementRequests(config)
+	if requests[1].NodeSelector["pool"] != "gpu" || requests[1].Tolerations[0].Key != "dedicated" {
+		t.Errorf("placement should use the patched template: %+v", requests[1])
+	}
+}
+
+func TestValidatePodTemplates(t *testing.T) {
+	invalid := map[string]func(*model.ClusterConfig){
+		"invalid shmSize":        func(c *model.ClusterConfig) { c.Machines[1].ShmSize = "lots" },
+		"podDefaults: env name":  func(c *model.ClusterConfig) { c.PodDefaults.Env = append(c.PodDefaults.Env, corev1.EnvVar{Value: "x"}) },
+		"require name and image": func(c *model.ClusterConfig) { c.Machines[1].ExtraContainers[0].Image = "" },
+		"conflicts with the ray": func(c *model.ClusterConfig) { c.Machines[1].ExtraContainers[0].Name = "worker" },
+		"must be a json object":  func(c *model.ClusterConfig) { c.Machines[0].PodTemplatePatch = json.RawMessage(`["spec"]`) },
+		"machine worker: podTemplate": func(c *model.ClusterConfig) {
+			c.Machines[1].PodTemplatePatch = json.RawMessage(`{"spec": {"containers": "worker"}}`)
+		},
+	}
+	for want, mutate := range invalid {
+		config := newPodTemplateConfig()
+		mutate(&config)
+		if err := job.ValidatePodTemplates(&config, model.HostPathPolicy{}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidatePodTemplates = %v, want %q", err, want)
+		}
+	}
+}
+
+// podTemplatePatch 添加的 hostPath 卷同样受 hostPath 策略限制
+func TestPodTemplatePatchHostPath(t *testing.T) {
+	patch := json.RawMessage(`{"spec": {"volumes": [{"name": "root", "hostPath": {"path": "/"}}]}}`)
+	for name, mutate := range map[string]func(*model.ClusterConfig){
+		"podDefaults": func(c *model.ClusterConfig) { c.PodDefaults.PodTemplatePatch = patch },
+		"machine":     func(c *model.ClusterConfig) { c.Machines[1].PodTemplatePatch = patch },
+	} {
+		config := newPodTemplateConfig()
+		mutate(&config)
+		if err := job.ValidatePodTemplates(&config, model.HostPathPolicy{}); err == nil || !strings.Contains(err.Error(), "volume root: hostPath volumes are disabled") {
+			t.Errorf("%s: ValidatePodTemplates = %v, want hostPath rejected", name, err)
+		}
+		if err := job.ValidatePodTemplates(&config, model.HostPathPolicy{Enabled: true, AllowedPrefixes: []string{"/data"}}); err == nil || !strings.Contains(err.Error(), "not under the allowed prefixes") {
+			t.Errorf("%s: ValidatePodTemplates = %v, want prefix rejected", name, err)
+		}
+		if err := job.ValidatePodTemplates(&config, model.HostPathPolicy{Enabled: true}); err != nil {
+			t.Errorf("%s: ValidatePodTemplates failed: %v", name, err)
+		}
+	}
+
+	config := newPodTemplateConfig()
+	config.Job = &model.JobConfig{Name: "patched"}
+	config.Machines[1].PodTemplatePatch = patch
+	if err := job.ValidateJob(&config, model.JobOptions{}); err == nil || !strings.Contains(err.Error(), "hostPath volumes are disabled") {
+		t.Errorf("ValidateJob = %v, want hostPath rejected", err)
+	}
+}
@@ -0,0 +1,145 @@
+package job
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+// 与 testdata/<name>.golden 比较，-update 时覆盖 golden 文件
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("write golden: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("runcode does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// 多次生成结果一致，参数顺序不受 map 遍历顺序影响
+func prepareRunCode(t *testing.T, newConfig func() *model.ClusterConfig) string {
+	t.Helper()
+	var first string
+	for i := 0; i < 10; i++ {
+		runCodeConfig, err := job.PrepareJob(newConfig(), model.JobOptions{})
+		if err != nil {
+			t.Fatalf("PrepareJob failed: %v", err)
+		}
+		if i == 0 {
+			first = runCodeConfig.RunCode
+		} else if runCodeConfig.RunCode != first {
+			t.Fatalf("runcode is not stable:\n%s\n---\n%s", first, runCodeConfig.RunCode)
+		}
+	}
+	return first
+}
+
+func TestVllmServeRunCodeGolden(t *testing.T) {
+	runCode := prepareRunCode(t, func() *model.ClusterConfig {
+		config := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "8", nil), newGPUMachine("worker", false, "8", nil))
+		config.Job.Args = []model.ArgItem{{
+			Label: map[string]string{"vllmRuncodeCustomParams": "true"},
+			Params: map[string]string{
+				"--served-model-name": "qwen",
+				"--swap-space":        "16",
+				"--max-model-len":     "32768",
+				// 引号、换行与反斜杠需要转义，不能破坏生成的脚本
+				"--chat-template": "{% for m in messages %}\"{{ m['content'] }}\"\n{% endfor %}\\",
+			},
+		}}
+		return config
+	})
+	assertGolden(t, "vllm_serve", runCode)
+}
+
+func TestSGLangServeRunCodeGolden(t *testing.T) {
+	runCode := prepareRunCode(t, func() *model.ClusterConfig {
+		return newClusterConfig(job.JobKindSGLangServe, newGPUMachine("head", true, "4", nil))
+	})
+	assertGolden(t, "sglang_serve", runCode)
+}
+
+func TestBatchInferenceRunCodeGolden(t *testing.T) {
+	runCode := prepareRunCode(t, func() *model.ClusterConfig {
+		config := newClusterConfig(job.JobKindBatchInference, newGPUMachine("head", true, "2", nil))
+		config.Job.Args = []model.ArgItem{{
+			Label: map[string]string{"batchInferenceParams": "true"},
+			Params: map[string]string{
+				"inputPath":    "s3://bucket/prompts\".jsonl",
+				"outputPath":   "/data/output",
+				"promptColumn": "question",
+			},
+		}}
+		return config
+	})
+	assertGolden(t, "batch_inference", runCode)
+}
+
+func TestUserRunCodeTemplate(t *testing.T) {
+	userTemplate := `# {{.Kind}} {{.JobName}}
+import subprocess
+
+MODEL = {{pyStr .ModelPath}}
+subprocess.run({{pyList .Command}}, check=True)
+`
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "vllm-template", Namespace: "default"},
+		Data: map[string]string{
+			job.DefaultRunCodeTemplateKey: userTemplate,
+			"broken":                      "{{.Command",
+			"unknown":                     "{{.Missing}}",
+		},
+	})
+
+	config := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "1", nil))
+	config.Job.RunCodeTemplate = &model.RunCodeTemplateSource{ConfigMap: "vllm-template"}
+	if err := job.ResolveRunCodeTemplate(context.Background(), clientset, config); err != nil {
+		t.Fatalf("ResolveRunCodeTemplate failed: %v", err)
+	}
+	runCodeConfig, err := job.PrepareJob(config, model.JobOptions{})
+	if err != nil {
+		t.Fatalf("PrepareJob failed: %v", err)
+	}
+	assertGolden(t, "user_template", runCodeConfig.RunCode)
+
+	for _, source := range []model.RunCodeTemplateSource{
+		{ConfigMap: "vllm-template", Key: "broken"},
+		{ConfigMap: "vllm-template", Key: "absent"},
+		{ConfigMap: "absent"},
+	} {
+		config := newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "1", nil))
+		config.Job.RunCodeTemplate = &source
+		if err := job.ResolveRunCodeTemplate(context.Background(), clientset, config); err == nil {
+			t.Errorf("ResolveRunCodeTemplate(%+v) should fail", source)
+		}
+	}
+
+	// 模板引用不存在的字段时在生成时报错
+	config = newClusterConfig(job.JobKindVllmServe, newGPUMachine("head", true, "1", nil))
+	config.Job.RunCodeTemplate = &model.RunCodeTemplateSource{ConfigMap: "vllm-template", Key: "unknown"}
+	if err := job.ResolveRunCodeTemplate(context.Background(), clientset, config); err != nil {
+		t.Fatalf("ResolveRunCodeTemplate failed: %v", err)
+	}
+	if _, err := job.PrepareJob(config, model.JobOptions{}); err == nil {
+		t.Error("template with unknown field should fail")
+	}
+}
@@ -0,0 +1,134 @@
+package job
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestBuildVolumesAndMounts(t *testing.T) {
+	volumes, mounts := job.BuildVolumesAndMounts([]model.VolumeConfig{
+		{Name: "model", MountPath: "/mnt/models", SubPath: "Qwen2.5-7B", ReadOnly: true, Source: model.VolumeSource{PVC: &model.PVCSource{ClaimName: "models"}}},
+		{Name: "token", MountPath: "/etc/hf", Source: model.VolumeSource{Secret: &model.SecretSource{SecretName: "hf-token", DefaultMode: ptr.To(int32(0400))}}},
+		{Name: "cache", MountPath: "/tmp/cache", Source: model.VolumeSource{EmptyDir: &model.EmptyDirSource{Medium: "Memory", SizeLimit: "4Gi"}}},
+		{Name: "nvme", MountPath: "/data", Source: model.VolumeSource{HostPath: &model.HostPathSource{Path: "/mnt/nvme", Type: "Directory"}}},
+		{Name: "shared", MountPath: "/shared", ReadOnly: true, Source: model.VolumeSource{NFS: &model.NFSSource{Server: "10.0.0.2", Path: "/exports/models"}}},
+		{Name: "bucket", MountPath: "/bucket", Source: model.VolumeSource{CSI: &model.CSISource{Driver: "s3.csi.aws.com", VolumeAttributes: map[string]string{"bucketName": "models"}}}},
+		{Name: "model-path", MountPath: "/mnt/models/Qwen2.5-7B", Label: map[string]string{"model": "true"}},
+	})
+	if len(volumes) != 6 || len(mounts) != 6 {
+		t.Fatalf("volumes = %d, mounts = %d, want 6 (volumes without source are skipped)", len(volumes), len(mounts))
+	}
+
+	if pvc := volumes[0].PersistentVolumeClaim; pvc.ClaimName != "models" || !pvc.ReadOnly || mounts[0].SubPath != "Qwen2.5-7B" || !mounts[0].ReadOnly {
+		t.Errorf("pvc volume = %+v, mount = %+v", volumes[0], mounts[0])
+	}
+	if secret := volumes[1].Secret; secret.SecretName != "hf-token" || *secret.DefaultMode != 0400 || !mounts[1].ReadOnly {
+		t.Errorf("secret volume = %+v, mount = %+v", volumes[1], mounts[1])
+	}
+	if emptyDir := volumes[2].EmptyDir; emptyDir.Medium != corev1.StorageMediumMemory || !emptyDir.SizeLimit.Equal(resource.MustParse("4Gi")) {
+		t.Errorf("emptyDir volume = %+v", emptyDir)
+	}
+	if hostPath := volumes[3].HostPath; hostPath.Path != "/mnt/nvme" || *hostPath.Type != corev1.HostPathDirectory {
+		t.Errorf("hostPath volume = %+v", hostPath)
+	}
+	if nfs := volumes[4].NFS; nfs.Server != "10.0.0.2" || !nfs.ReadOnly {
+		t.Errorf("nfs volume = %+v", nfs)
+	}
+	if csi := volumes[5].CSI; csi.Driver != "s3.csi.aws.com" || csi.VolumeAttributes["bucketName"] != "models" || csi.ReadOnly != nil {
+		t.Errorf("csi volume = %+v", csi)
+	}
+}
+
+func volumeClusterConfig(volumes ...model.VolumeConfig) *model.ClusterConfig {
+	return &model.ClusterConfig{Machines: []model.MachineConfig{{Name: "head", IsHeadNode: true, Volumes: volumes}}}
+}
+
+func TestValidateVolumes(t *testing.T) {
+	hostPath := model.VolumeConfig{Name: "nvme", MountPath: "/data", Source: model.VolumeSource{HostPath: &model.HostPathSource{Path: "/mnt/nvme/models"}}}
+	if err := job.ValidateVolumes(volumeClusterConfig(hostPath), model.HostPathPolicy{}); err == nil || !strings.Contains(err.Error(), "hostPath volumes are disabled") {
+		t.Errorf("hostPath should be disabled by default, got %v", err)
+	}
+
+	policy := model.HostPathPolicy{Enabled: true, AllowedPrefixes: []string{"/mnt/nvme"}}
+	if err := job.ValidateVolumes(volumeClusterConfig(hostPath), policy); err != nil {
+		t.Errorf("ValidateVolumes(hostPath) = %v", err)
+	}
+
+	invalid := map[string]model.VolumeConfig{
+		"is not under the allowed prefixes": {Name: "etc", MountPath: "/data", Source: model.VolumeSource{HostPath: &model.HostPathSource{Path: "/mnt/nvme2"}}},
+		"only one source can be set": {Name: "both", MountPath: "/data", Source: model.VolumeSource{
+			PVC: &model.PVCSource{ClaimName: "models"}, EmptyDir: &model.EmptyDirSource{},
+		}},
+		"mountPath must be an absolute path": {Name: "cache", MountPath: "cache", Source: model.VolumeSource{EmptyDir: &model.EmptyDirSource{}}},
+		"subPath must be a relative path":    {Name: "cache", MountPath: "/cache", SubPath: "../etc", Source: model.VolumeSource{EmptyDir: &model.EmptyDirSource{}}},
+		"unknown emptyDir.medium":            {Name: "cache", MountPath: "/cache", Source: model.VolumeSource{EmptyDir: &model.EmptyDirSource{Medium: "HugePages"}}},
+		"invalid pvc.size":                   {Name: "model", MountPath: "/models", Source: model.VolumeSource{PVC: &model.PVCSource{ClaimName: "models", Size: "big"}}},
+		"unknown pvc access mode":            {Name: "model", MountPath: "/models", Source: model.VolumeSource{PVC: &model.PVCSource{ClaimName: "models", Size: "1Ti", AccessModes: []string{"ReadWriteSome"}}}},
+		"nfs requires server":                {Name: "shared", MountPath: "/shared", Source: model.VolumeSource{NFS: &model.NFSSource{Path: "/exports"}}},
+		"csi.driver is required":             {Name: "bucket", MountPath: "/bucket", Source: model.VolumeSource{CSI: &model.CSISource{}}},
+	}
+	for want, volume := range invalid {
+		if err := job.ValidateVolumes(volumeClusterConfig(volume), policy); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateVolumes(%s) = %v, want %q", volume.Name, err, want)
+		}
+	}
+
+	duplicate := model.VolumeConfig{Name: "cache", MountPath: "/cache", Source: model.VolumeSource{EmptyDir: &model.EmptyDirSource{}}}
+	if err := job.ValidateVolumes(volumeClusterConfig(duplicate, duplicate), policy); err == nil || !strings.Contains(err.Error(), "duplicate volume cache") {
+		t.Errorf("duplicate volumes = %v", err)
+	}
+
+	// 只带 label 的卷不会挂载，不检查名称与挂载路径
+	labelOnly := []model.VolumeConfig{
+		{Name: "model-path", MountPath: "mnt/models/Qwen2.5-7B", Label: map[string]string{"model": "true"}},
+		{Label: map[string]string{"model": "true", "actualModelPathInPod": "/mnt/models/Qwen2.5-7B"}},
+		{Label: map[string]string{"runcode": "true"}},
+	}
+	if err := job.ValidateVolumes(volumeClusterConfig(append(labelOnly, duplicate)...), policy); err != nil {
+		t.Errorf("label-only volumes = %v", err)
+	}
+}
+
+func TestEnsurePVCs(t *testing.T) {
+	ctx := context.Background()
+	existing := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"}}
+	client := fake.NewSimpleClientset(existing)
+
+	create := model.VolumeConfig{Name: "model", MountPath: "/models", Source: model.VolumeSource{PVC: &model.PVCSource{
+		ClaimName: "models", StorageClassName: "cephfs", Size: "500Gi", AccessModes: []string{"ReadWriteMany"},
+	}}}
+	machines := []model.MachineConfig{
+		{Name: "head", Volumes: []model.VolumeConfig{create, {Name: "old", MountPath: "/old", Source: model.VolumeSource{PVC: &model.PVCSource{ClaimName: "existing", Size: "1Gi"}}}}},
+		{Name: "worker", Volumes: []model.VolumeConfig{create, {Name: "static", MountPath: "/static", Source: model.VolumeSource{PVC: &model.PVCSource{ClaimName: "static"}}}}},
+	}
+	if err := job.EnsurePVCs(ctx, client, "default", machines); err != nil {
+		t.Fatalf("EnsurePVCs failed: %v", err)
+	}
+
+	pvc, err := client.CoreV1().PersistentVolumeClaims("default").Get(ctx, "models", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if *pvc.Spec.StorageClassName != "cephfs" || pvc.Spec.AccessModes[0] != corev1.ReadWriteMany || storage.String() != "500Gi" {
+		t.Errorf("unexpected pvc spec: %+v", pvc.Spec)
+	}
+
+	// 已存在的 PVC 不修改，没有 size 的 PVC 不创建
+	list, _ := client.CoreV1().PersistentVolumeClaims("default").List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 2 {
+		t.Errorf("pvcs = %d, want existing and models", len(list.Items))
+	}
+	if existing, _ := client.CoreV1().PersistentVolumeClaims("default").Get(ctx, "existing", metav1.GetOptions{}); len(existing.Spec.AccessModes) != 0 {
+		t.Errorf("existing pvc should not be modified: %+v", existing.Spec)
+	}
+}
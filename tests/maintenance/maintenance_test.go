@@ -0,0 +1,213 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/node/maintenance"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newNode(name string, unschedulable bool) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+	}
+}
+
+// ownerKind 为空时 Pod 没有控制器
+func newPod(name, ownerKind string, podLabels map[string]string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name + "-uid"),
+			Labels:    podLabels,
+		},
+		Spec:   corev1.PodSpec{NodeName: "gpu-1"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       ownerKind,
+			Name:       name + "-owner",
+			UID:        types.UID(name + "-owner-uid"),
+			Controller: &controller,
+		}}
+	}
+	return pod
+}
+
+func podStatuses(result *maintenance.DrainResult) map[string]string {
+	statuses := map[string]string{}
+	for _, pod := range result.Pods {
+		statuses[pod.Name] = pod.Status
+	}
+	return statuses
+}
+
+func TestCordonUncordon(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(newNode("gpu-1", false))
+
+	changed, err := maintenance.Cordon(ctx, clientset, "gpu-1")
+	if err != nil || !changed {
+		t.Fatalf("Cordon = %v, %v, want true, nil", changed, err)
+	}
+	node, _ := clientset.CoreV1().Nodes().Get(ctx, "gpu-1", metav1.GetOptions{})
+	if !node.Spec.Unschedulable {
+		t.Fatal("node should be unschedulable after cordon")
+	}
+
+	// 重复 cordon 不发生变化
+	if changed, err := maintenance.Cordon(ctx, clientset, "gpu-1"); err != nil || changed {
+		t.Fatalf("second Cordon = %v, %v, want false, nil", changed, err)
+	}
+
+	if changed, err := maintenance.Uncordon(ctx, clientset, "gpu-1"); err != nil || !changed {
+		t.Fatalf("Uncordon = %v, %v, want true, nil", changed, err)
+	}
+	node, _ = clientset.CoreV1().Nodes().Get(ctx, "gpu-1", metav1.GetOptions{})
+	if node.Spec.Unschedulable {
+		t.Fatal("node should be schedulable after uncordon")
+	}
+
+	if _, err := maintenance.Cordon(ctx, clientset, "missing"); err == nil {
+		t.Fatal("Cordon on missing node should fail")
+	}
+}
+
+func TestDrainDryRun(t *testing.T) {
+	ctx := context.Background()
+	emptyDirPod := newPod("cache", "ReplicaSet", nil)
+	emptyDirPod.Spec.Volumes = []corev1.Volume{{
+		Name:         "scratch",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}}
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "vllm-pdb", Namespace: "default"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+			Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "vllm"}},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+	}
+
+	clientset := fake.NewSimpleClientset(
+		newNode("gpu-1", false),
+		newPod("exporter", "DaemonSet", nil),
+		newPod("debug", "", nil),
+		newPod("demo-head", "RayCluster", map[string]string{"ray.io/cluster": "demo", "ray.io/node-type": "head"}),
+		newPod("vllm", "ReplicaSet", map[string]string{"app": "vllm"}),
+		emptyDirPod,
+		pdb,
+	)
+
+	result, err := maintenance.Drain(ctx, clientset, "gpu-1", maintenance.DrainOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Drain dry-run failed: %v", err)
+	}
+	want := map[string]string{
+		"exporter":  maintenance.PodSkipped,
+		"debug":     maintenance.PodBlocked,
+		"demo-head": maintenance.PodWouldEvict,
+		"vllm":      maintenance.PodBlockedByPDB,
+		"cache":     maintenance.PodBlocked,
+	}
+	if got := podStatuses(result); !maps.Equal(got, want) {
+		t.Errorf("pod statuses = %v, want %v", got, want)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one RayCluster head warning", result.Warnings)
+	}
+
+	// dry-run 不修改节点
+	node, _ := clientset.CoreV1().Nodes().Get(ctx, "gpu-1", metav1.GetOptions{})
+	if node.Spec.Unschedulable || result.Cordoned {
+		t.Error("dry-run should not cordon the node")
+	}
+}
+
+func TestDrainBlocked(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(newNode("gpu-1", false), newPod("debug", "", nil))
+
+	result, err := maintenance.Drain(ctx, clientset, "gpu-1", maintenance.DrainOptions{})
+	if !errors.Is(err, maintenance.ErrDrainBlocked) {
+		t.Fatalf("err = %v, want ErrDrainBlocked", err)
+	}
+	if result.Cordoned {
+		t.Error("blocked drain should not cordon the node")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(
+		newNode("gpu-1", false),
+		newPod("exporter", "DaemonSet", nil),
+		newPod("debug", "", nil),
+		newPod("vllm", "ReplicaSet", map[string]string{"app": "vllm"}),
+	)
+
+	// eviction 成功时删除 Pod
+	var evicted []string
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		evicted = append(evicted, eviction.Name)
+		err := clientset.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+		return true, nil, err
+	})
+
+	result, err := maintenance.Drain(ctx, clientset, "gpu-1", maintenance.DrainOptions{Force: true, TimeoutSeconds: 10})
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if !result.Cordoned || !result.Completed {
+		t.Errorf("cordoned = %v, completed = %v, want both true", result.Cordoned, result.Completed)
+	}
+	want := map[string]string{
+		"exporter": maintenance.PodSkipped,
+		"debug":    maintenance.PodEvicted,
+		"vllm":     maintenance.PodEvicted,
+	}
+	if got := podStatuses(result); !maps.Equal(got, want) {
+		t.Errorf("pod statuses = %v, want %v", got, want)
+	}
+	slices.Sort(evicted)
+	if !slices.Equal(evicted, []string{"debug", "vllm"}) {
+		t.Errorf("evicted = %v, want [debug vllm]", evicted)
+	}
+}
+
+func TestDrainOptionsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		timeoutSeconds int64
+		valid          bool
+	}{
+		{0, true},
+		{600, true},
+		{-1, false},
+		{601, false},
+	} {
+		err := maintenance.DrainOptions{TimeoutSeconds: tc.timeoutSeconds}.Validate()
+		if (err == nil) != tc.valid {
+			t.Errorf("timeoutSeconds %d: err = %v", tc.timeoutSeconds, err)
+		}
+	}
+}
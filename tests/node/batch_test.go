@@ -0,0 +1,36 @@
+package node
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	opsflowfake "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/fake"
+	"github.com/modcoco/OpsFlow/pkg/node"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// 节点上的 Pod 获取失败时返回错误，且不写入 CRD，避免把使用量记为 0
+func TestBatchAddSkipsNodeOnLoadError(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster"}})
+	clientset.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	crdClientset := opsflowfake.NewSimpleClientset()
+
+	err := node.BatchAddNodeResourceInfo(node.BatchUpdateCreateOptions{
+		Clientset: clientset,
+		CRDClient: crdClientset.OpsflowV1beta1().NodeResourceInfos(),
+		Nodes:     &corev1.NodeList{Items: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "node-1") || !strings.Contains(err.Error(), "apiserver unavailable") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actions := crdClientset.Actions(); len(actions) != 0 {
+		t.Fatalf("NodeResourceInfo should not be touched: %v", actions)
+	}
+}
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newNode(unschedulable bool, conditions map[corev1.NodeConditionType]corev1.ConditionStatus) *corev1.Node {
+	n := &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: unschedulable}}
+	for _, conditionType := range []corev1.NodeConditionType{
+		corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure, corev1.NodeReady,
+	} {
+		if status, ok := conditions[conditionType]; ok {
+			n.Status.Conditions = append(n.Status.Conditions, corev1.NodeCondition{Type: conditionType, Status: status})
+		}
+	}
+	return n
+}
+
+func TestGetNodeHealth(t *testing.T) {
+	healthy := map[corev1.NodeConditionType]corev1.ConditionStatus{
+		corev1.NodeMemoryPressure: corev1.ConditionFalse,
+		corev1.NodeDiskPressure:   corev1.ConditionFalse,
+		corev1.NodeReady:          corev1.ConditionTrue,
+	}
+	pressure := map[corev1.NodeConditionType]corev1.ConditionStatus{
+		corev1.NodeDiskPressure: corev1.ConditionTrue,
+		corev1.NodeReady:        corev1.ConditionTrue,
+	}
+
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want v1beta1.NodeHealth
+	}{
+		{"ready", newNode(false, healthy), v1beta1.NodeHealthReady},
+		{"pressure", newNode(false, pressure), v1beta1.NodeHealthDegraded},
+		{"cordoned", newNode(true, healthy), v1beta1.NodeHealthCordoned},
+		{"cordoned with pressure", newNode(true, pressure), v1beta1.NodeHealthCordoned},
+		{"not ready", newNode(true, map[corev1.NodeConditionType]corev1.ConditionStatus{
+			corev1.NodeMemoryPressure: corev1.ConditionTrue,
+			corev1.NodeReady:          corev1.ConditionFalse,
+		}), v1beta1.NodeHealthNotReady},
+		{"ready unknown", newNode(false, map[corev1.NodeConditionType]corev1.ConditionStatus{
+			corev1.NodeReady: corev1.ConditionUnknown,
+		}), v1beta1.NodeHealthUnknown},
+		{"no conditions", newNode(false, nil), v1beta1.NodeHealthUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := node.GetNodeHealth(tt.node); got != tt.want {
+				t.Errorf("GetNodeHealth = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNodeConditions(t *testing.T) {
+	transition := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	n := &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+		Type:               corev1.NodeReady,
+		Status:             corev1.ConditionTrue,
+		Reason:             "KubeletReady",
+		Message:            "kubelet is posting ready status",
+		LastHeartbeatTime:  metav1.Now(),
+		LastTransitionTime: transition,
+	}}}}
+
+	conditions := node.GetNodeConditions(n)
+	want := v1beta1.NodeCondition{Type: "Ready", Status: "True", Reason: "KubeletReady", LastTransitionTime: transition}
+	if len(conditions) != 1 || conditions[0] != want {
+		t.Errorf("conditions = %+v, want [%+v]", conditions, want)
+	}
+	if conditions := node.GetNodeConditions(&corev1.Node{}); conditions != nil {
+		t.Errorf("conditions = %+v, want nil", conditions)
+	}
+}
@@ -0,0 +1,78 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNodeLabels(t *testing.T) {
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		"kubernetes.io/hostname":                 "node-1",
+		"node-role.kubernetes.io/control-plane":  "",
+		"nvidia.com/gpu.product":                 "NVIDIA-A100-SXM4-80GB",
+		"beta.kubernetes.io/arch":                "amd64",
+		"feature.node.kubernetes.io/cpu-cpuid.X": "true",
+	}}}
+
+	want := map[string]string{
+		"kubernetes.io/hostname":                "node-1",
+		"node-role.kubernetes.io/control-plane": "",
+		"nvidia.com/gpu.product":                "NVIDIA-A100-SXM4-80GB",
+	}
+	if got := node.GetNodeLabels(n); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNodeLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestGetNodeAnnotations(t *testing.T) {
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"opsflow.io/rack":                    "r1",
+		"kubeadm.alpha.kubernetes.io/cri":    "unix:///run/containerd/containerd.sock",
+		"node.alpha.kubernetes.io/ttl":       "0",
+		"volumes.kubernetes.io/controller":   "true",
+		"projectcalico.org/IPv4Address":      "10.0.0.1/24",
+		"flannel.alpha.coreos.com/public-ip": "10.0.0.1",
+	}}}
+
+	want := map[string]string{"opsflow.io/rack": "r1"}
+	if got := node.GetNodeAnnotations(n); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNodeAnnotations() = %v, want %v", got, want)
+	}
+}
+
+// 全部被过滤或为空时返回 nil，与 CRD 中省略的字段一致，避免每个周期都判定为变化
+func TestFilteredEmptyIsNil(t *testing.T) {
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Labels:      map[string]string{"beta.kubernetes.io/os": "linux"},
+		Annotations: map[string]string{},
+	}}
+	if got := node.GetNodeLabels(n); got != nil {
+		t.Errorf("GetNodeLabels() = %v, want nil", got)
+	}
+	if got := node.GetNodeAnnotations(n); got != nil {
+		t.Errorf("GetNodeAnnotations() = %v, want nil", got)
+	}
+	if got := node.GetNodeTaints(n); got != nil {
+		t.Errorf("GetNodeTaints() = %v, want nil", got)
+	}
+}
+
+func TestGetNodeTaints(t *testing.T) {
+	n := &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+		{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectPreferNoSchedule},
+	}}}
+
+	want := []v1beta1.NodeTaint{
+		{Key: "node-role.kubernetes.io/control-plane", Effect: "NoSchedule"},
+		{Key: "nvidia.com/gpu", Value: "present", Effect: "PreferNoSchedule"},
+	}
+	if got := node.GetNodeTaints(n); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNodeTaints() = %v, want %v", got, want)
+	}
+}
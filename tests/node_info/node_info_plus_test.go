@@ -9,12 +9,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/modcoco/OpsFlow/pkg/client/clientset/versioned"
 	"github.com/modcoco/OpsFlow/pkg/crd"
 	"github.com/modcoco/OpsFlow/pkg/node"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
 	"github.com/redis/go-redis/v9"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -22,10 +22,12 @@ import (
 // TestCreateOrUpdateNodeResourceInfo 用于创建或更新 NodeResourceInfo CRD
 func TestCreateOrUpdateNodeResourceInfo(t *testing.T) {
 	// 需要追踪的资源类型
-	resourceNamesToTrack := map[string]bool{
-		"cpu":            true, // 统计 CPU
-		"memory":         true, // 统计内存
-		"nvidia.com/gpu": true, // 统计 GPU
+	resourceTracker := resourceinfo.ResourceTracker{
+		Patterns: []string{
+			"cpu",            // 统计 CPU
+			"memory",         // 统计内存
+			"nvidia.com/gpu", // 统计 GPU
+		},
 	}
 
 	// 加载 kubeconfig 配置
@@ -43,18 +45,14 @@ func TestCreateOrUpdateNodeResourceInfo(t *testing.T) {
 		log.Fatalf("无法创建 Kubernetes 客户端: %v", err)
 	}
 
-	// 创建动态客户端
-	dynamicClient, err := dynamic.NewForConfig(cfg)
+	// 创建 OpsFlow 客户端
+	opsflowClient, err := versioned.NewForConfig(cfg)
 	if err != nil {
-		log.Fatalf("无法创建动态客户端: %v", err)
+		log.Fatalf("无法创建 OpsFlow 客户端: %v", err)
 	}
 
 	// 获取 CRD 客户端（用于管理 CRD）
-	crdClient := dynamicClient.Resource(schema.GroupVersionResource{
-		Group:    "opsflow.io",
-		Version:  "v1alpha1",
-		Resource: "noderesourceinfos",
-	})
+	crdClient := opsflowClient.OpsflowV1beta1().NodeResourceInfos()
 
 	// 获取节点列表
 	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
@@ -63,11 +61,11 @@ func TestCreateOrUpdateNodeResourceInfo(t *testing.T) {
 	}
 
 	opts := node.BatchUpdateCreateOptions{
-		Clientset:            clientset,
-		CRDClient:            &crdClient,
-		Nodes:                nodes,
-		ResourceNamesToTrack: resourceNamesToTrack,
-		Parallelism:          3,
+		Clientset:   clientset,
+		CRDClient:   crdClient,
+		Nodes:       nodes,
+		LoadOptions: resourceinfo.LoadOptions{ResourceTracker: resourceTracker},
+		Parallelism: 3,
 	}
 
 	optsDelCRD := crd.NodeResourceInfoOptions{
@@ -2,7 +2,6 @@ package tests
 
 import (
 	"fmt"
-	"strings"
 	"testing"
 
 	"maps"
@@ -69,7 +68,7 @@ func TestVllmOnRayAutoJob(t *testing.T) {
 		},
 	}
 
-	config, err := job.ProcessVllmOnRaySimpleAutoJobClusterConfigByHeaderMachine(&clusterConfig)
+	config, err := job.PrepareJob(&clusterConfig, model.JobOptions{})
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -127,15 +126,8 @@ func TestGenPythonCode(t *testing.T) {
 		}
 	}
 
-	// Fix ""
-	quotedCommand := make([]string, len(baseCommand))
-	for i, item := range baseCommand {
-		quotedCommand[i] = fmt.Sprintf(`"%s"`, item)
-	}
-	commandStr := fmt.Sprintf("command = [%s]", strings.Join(quotedCommand, ", "))
-
 	// Get runcode
-	runCode, err := job.GenerateRunCode(commandStr)
+	runCode, err := job.GenerateRunCode(baseCommand)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -0,0 +1,102 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func ownedPod(namespace, name string, labels map[string]string, owner *metav1.OwnerReference, gpu string) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{container("1", "1Gi", "")},
+		},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	if gpu != "" {
+		pod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse(gpu)
+	}
+	return pod
+}
+
+func controllerRef(kind, name string) *metav1.OwnerReference {
+	controller := true
+	return &metav1.OwnerReference{Kind: kind, Name: name, Controller: &controller}
+}
+
+func TestResolvePodWorkload(t *testing.T) {
+	cases := []struct {
+		pod  corev1.Pod
+		want resourceinfo.WorkloadRef
+	}{
+		{
+			ownedPod("ns", "web-7d9f8-abcde", map[string]string{"pod-template-hash": "7d9f8"}, controllerRef("ReplicaSet", "web-7d9f8"), ""),
+			resourceinfo.WorkloadRef{Kind: "Deployment", Name: "web"},
+		},
+		{
+			ownedPod("ns", "raycluster-head", map[string]string{"ray.io/cluster": "raycluster"}, controllerRef("RayCluster", "raycluster"), ""),
+			resourceinfo.WorkloadRef{Kind: "RayCluster", Name: "raycluster"},
+		},
+		{
+			ownedPod("ns", "train-xyz", nil, controllerRef("Job", "train"), ""),
+			resourceinfo.WorkloadRef{Kind: "Job", Name: "train"},
+		},
+		{
+			ownedPod("ns", "debug", nil, nil, ""),
+			resourceinfo.WorkloadRef{Kind: "Pod", Name: "debug"},
+		},
+	}
+	for _, c := range cases {
+		if got := resourceinfo.ResolvePodWorkload(&c.pod); got != c.want {
+			t.Errorf("ResolvePodWorkload(%s) = %+v, want %+v", c.pod.Name, got, c.want)
+		}
+	}
+}
+
+func TestComputeUsageBreakdown(t *testing.T) {
+	pods := []corev1.Pod{
+		ownedPod("team-a", "raycluster-head", map[string]string{"ray.io/cluster": "raycluster"}, nil, "1"),
+		ownedPod("team-a", "raycluster-worker", map[string]string{"ray.io/cluster": "raycluster"}, nil, "2"),
+		ownedPod("team-b", "web-7d9f8-abcde", map[string]string{"pod-template-hash": "7d9f8"}, controllerRef("ReplicaSet", "web-7d9f8"), ""),
+	}
+	tracker := resourceinfo.ResourceTracker{Patterns: []string{"cpu", "nvidia.com/gpu"}}
+
+	want := &v1beta1.UsageBreakdown{
+		Namespaces: []v1beta1.NamespaceUsage{
+			{Namespace: "team-a", Pods: 2, Requests: map[string]string{"cpu": "2000m", "nvidia.com/gpu": "3"}},
+			{Namespace: "team-b", Pods: 1, Requests: map[string]string{"cpu": "1000m"}},
+		},
+		Workloads: []v1beta1.WorkloadUsage{
+			{Namespace: "team-a", Kind: "RayCluster", Name: "raycluster", Pods: 2, Requests: map[string]string{"cpu": "2000m", "nvidia.com/gpu": "3"}},
+			{Namespace: "team-b", Kind: "Deployment", Name: "web", Pods: 1, Requests: map[string]string{"cpu": "1000m"}},
+		},
+	}
+	if got := resourceinfo.ComputeUsageBreakdown(pods, tracker); !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeUsageBreakdown() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseUsageBreakdownMode(t *testing.T) {
+	for input, want := range map[string]resourceinfo.UsageBreakdownMode{
+		"":        resourceinfo.UsageBreakdownDisabled,
+		"off":     resourceinfo.UsageBreakdownDisabled,
+		"status":  resourceinfo.UsageBreakdownStatus,
+		"manager": resourceinfo.UsageBreakdownManager,
+	} {
+		got, err := resourceinfo.ParseUsageBreakdownMode(input)
+		if err != nil || got != want {
+			t.Errorf("ParseUsageBreakdownMode(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := resourceinfo.ParseUsageBreakdownMode("everything"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
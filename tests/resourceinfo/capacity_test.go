@@ -0,0 +1,144 @@
+package resourceinfo
+
+import (
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCapacityNode(name, nodeStatus string, ready metav1.ConditionStatus, labels map[string]string, resources map[string][3]string) v1beta1.NodeResourceInfo {
+	nodeResourceInfo := v1beta1.NodeResourceInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1beta1.NodeResourceInfoSpec{
+			NodeName:  name,
+			Labels:    labels,
+			Resources: map[string]v1beta1.ResourceCapacity{},
+		},
+		Status: v1beta1.NodeResourceInfoStatus{
+			NodeStatus: nodeStatus,
+			Resources:  map[string]v1beta1.ResourceUsage{},
+			Conditions: []metav1.Condition{{Type: v1beta1.ConditionReady, Status: ready}},
+		},
+	}
+	for resourceName, values := range resources {
+		nodeResourceInfo.Spec.Resources[resourceName] = v1beta1.ResourceCapacity{Total: values[0], Allocatable: values[1]}
+		if values[2] != "" {
+			nodeResourceInfo.Status.Resources[resourceName] = v1beta1.ResourceUsage{Used: values[2]}
+		}
+	}
+	return nodeResourceInfo
+}
+
+func TestComputeClusterCapacity(t *testing.T) {
+	const zone = "topology.kubernetes.io/zone"
+	nodes := []v1beta1.NodeResourceInfo{
+		newCapacityNode("gpu-a", "Ready", metav1.ConditionTrue, map[string]string{zone: "z1"}, map[string][3]string{
+			"cpu":            {"64000m", "63000m", "10000m"},
+			"memory":         {"262144Mi", "260000Mi", "65536Mi"},
+			"nvidia.com/gpu": {"8", "8", "6"},
+		}),
+		newCapacityNode("gpu-b", "Ready", metav1.ConditionTrue, map[string]string{zone: "z2"}, map[string][3]string{
+			"cpu":            {"64000m", "63000m", ""},
+			"memory":         {"262144Mi", "260000Mi", ""},
+			"nvidia.com/gpu": {"8", "8", "4"},
+		}),
+		newCapacityNode("cpu-a", "Ready", metav1.ConditionTrue, map[string]string{zone: "z1"}, map[string][3]string{
+			"cpu":    {"16000m", "15000m", "16000m"},
+			"memory": {"65536Mi", "64000Mi", "1000Mi"},
+		}),
+		newCapacityNode("cordoned", "Ready,SchedulingDisabled", metav1.ConditionTrue, nil, map[string][3]string{
+			"nvidia.com/gpu": {"8", "8", "0"},
+		}),
+		newCapacityNode("down", "Unknown", metav1.ConditionUnknown, nil, map[string][3]string{
+			"nvidia.com/gpu": {"8", "8", "0"},
+		}),
+	}
+
+	tracker := resourceinfo.ResourceTracker{Patterns: []string{"*", "*/*"}}
+	capacity := resourceinfo.ComputeClusterCapacity(nodes, tracker, zone)
+
+	if capacity.Nodes != 3 {
+		t.Errorf("nodes = %d, want 3", capacity.Nodes)
+	}
+	wantExcluded := []resourceinfo.ExcludedNode{{Name: "cordoned", Reason: "SchedulingDisabled"}, {Name: "down", Reason: "NotReady"}}
+	if len(capacity.ExcludedNodes) != 2 || capacity.ExcludedNodes[0] != wantExcluded[0] || capacity.ExcludedNodes[1] != wantExcluded[1] {
+		t.Errorf("excludedNodes = %+v, want %+v", capacity.ExcludedNodes, wantExcluded)
+	}
+
+	wantResources := map[string]resourceinfo.CapacitySummary{
+		// cpu-a 超额申请时剩余按 0 计算
+		"cpu":            {Total: "144000m", Allocatable: "141000m", Used: "26000m", Free: "116000m"},
+		"memory":         {Total: "589824Mi", Allocatable: "584000Mi", Used: "66536Mi", Free: "517464Mi"},
+		"nvidia.com/gpu": {Total: "16", Allocatable: "16", Used: "10", Free: "6"},
+	}
+	for name, want := range wantResources {
+		if got := capacity.Resources[name]; got != want {
+			t.Errorf("resources[%s] = %+v, want %+v", name, got, want)
+		}
+	}
+
+	gpu := capacity.LargestPlaceable["nvidia.com/gpu"]
+	if gpu.Node != "gpu-b" || gpu.Free != "4" || gpu.Shape["cpu"] != "63000m" {
+		t.Errorf("largestPlaceable[nvidia.com/gpu] = %+v, want gpu-b with 4 GPUs", gpu)
+	}
+
+	if len(capacity.Groups) != 2 || capacity.Groups[0].Value != "z1" || capacity.Groups[1].Value != "z2" {
+		t.Fatalf("groups = %+v, want z1 and z2", capacity.Groups)
+	}
+	z1 := capacity.Groups[0]
+	if z1.Nodes != 2 || z1.Resources["nvidia.com/gpu"].Free != "2" || z1.LargestPlaceable["nvidia.com/gpu"].Node != "gpu-a" {
+		t.Errorf("group z1 = %+v", z1)
+	}
+	if _, ok := z1.LargestPlaceable["cpu"]; !ok || z1.LargestPlaceable["cpu"].Node != "gpu-a" {
+		t.Errorf("group z1 largest cpu = %+v, want gpu-a", z1.LargestPlaceable["cpu"])
+	}
+}
+
+func TestComputeClusterCapacityFiltersResources(t *testing.T) {
+	nodes := []v1beta1.NodeResourceInfo{
+		newCapacityNode("gpu-a", "Ready", metav1.ConditionTrue, nil, map[string][3]string{
+			"cpu":            {"64000m", "63000m", "10000m"},
+			"nvidia.com/gpu": {"8", "8", "8"},
+		}),
+	}
+
+	capacity := resourceinfo.ComputeClusterCapacity(nodes, resourceinfo.ResourceTracker{Patterns: []string{"nvidia.com/*"}}, "")
+	if _, ok := capacity.Resources["cpu"]; ok || len(capacity.Resources) != 1 {
+		t.Errorf("resources = %+v, want only nvidia.com/gpu", capacity.Resources)
+	}
+	// 没有剩余的资源不返回最大规格
+	if capacity.LargestPlaceable != nil {
+		t.Errorf("largestPlaceable = %+v, want nil", capacity.LargestPlaceable)
+	}
+	if capacity.Groups != nil {
+		t.Errorf("groups = %+v, want nil", capacity.Groups)
+	}
+}
+
+func TestNodeSchedulableHealth(t *testing.T) {
+	tests := []struct {
+		health     v1beta1.NodeHealth
+		nodeStatus string
+		ready      metav1.ConditionStatus
+		want       bool
+		reason     string
+	}{
+		{v1beta1.NodeHealthReady, "Ready", metav1.ConditionTrue, true, ""},
+		{v1beta1.NodeHealthDegraded, "Ready,DiskPressure", metav1.ConditionTrue, true, ""},
+		{v1beta1.NodeHealthCordoned, "Ready,SchedulingDisabled", metav1.ConditionTrue, false, "SchedulingDisabled"},
+		{v1beta1.NodeHealthNotReady, "MemoryPressure", metav1.ConditionFalse, false, "NotReady"},
+		{v1beta1.NodeHealthUnknown, "Unknown", metav1.ConditionUnknown, false, "Unknown"},
+		// 旧版本写入的 CRD 没有 health
+		{"", "Ready,SchedulingDisabled", metav1.ConditionTrue, false, "SchedulingDisabled"},
+		{"", "Ready", metav1.ConditionTrue, true, ""},
+	}
+	for _, tt := range tests {
+		nodeResourceInfo := newCapacityNode("node", tt.nodeStatus, tt.ready, nil, nil)
+		nodeResourceInfo.Status.Health = tt.health
+		if ok, reason := resourceinfo.NodeSchedulable(&nodeResourceInfo); ok != tt.want || reason != tt.reason {
+			t.Errorf("NodeSchedulable(%q, %q) = %v, %q, want %v, %q", tt.health, tt.nodeStatus, ok, reason, tt.want, tt.reason)
+		}
+	}
+}
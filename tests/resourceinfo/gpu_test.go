@@ -0,0 +1,97 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadGPUInfoFromFeatureDiscoveryLabels(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "gpu-node",
+			Labels: map[string]string{
+				"nvidia.com/gpu.product":         "NVIDIA-A100-SXM4-80GB",
+				"nvidia.com/gpu.family":          "ampere",
+				"nvidia.com/gpu.memory":          "81920",
+				"nvidia.com/gpu.count":           "8",
+				"nvidia.com/mig.strategy":        "mixed",
+				"nvidia.com/mig-1g.10gb.count":   "7",
+				"nvidia.com/mig-1g.10gb.memory":  "9728",
+				"nvidia.com/mig-3g.40gb.memory":  "40192",
+				"feature.node.kubernetes.io/foo": "true",
+			},
+		},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				"nvidia.com/gpu":         resource.MustParse("6"),
+				"nvidia.com/mig-1g.10gb": resource.MustParse("7"),
+				"nvidia.com/mig-3g.40gb": resource.MustParse("2"),
+			},
+		},
+	}
+
+	want := &v1beta1.GPUInfo{
+		Product:     "NVIDIA-A100-SXM4-80GB",
+		Family:      "ampere",
+		MemoryMiB:   81920,
+		Count:       8,
+		MigStrategy: "mixed",
+		MigProfiles: []v1beta1.MIGProfile{
+			{Profile: "1g.10gb", Resource: "nvidia.com/mig-1g.10gb", Count: 7, MemoryMiB: 9728},
+			{Profile: "3g.40gb", Resource: "nvidia.com/mig-3g.40gb", Count: 2, MemoryMiB: 40192},
+		},
+	}
+	if got := resourceinfo.LoadGPUInfo(node); !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadGPUInfo() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadGPUInfoWithoutGPU(t *testing.T) {
+	node := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+		},
+	}
+	if got := resourceinfo.LoadGPUInfo(node); got != nil {
+		t.Errorf("LoadGPUInfo() = %+v, want nil", got)
+	}
+
+	// 没有 GFD 标签时从容量获取卡数
+	node.Status.Capacity["nvidia.com/gpu"] = resource.MustParse("2")
+	if got := resourceinfo.LoadGPUInfo(node); got == nil || got.Count != 2 {
+		t.Errorf("LoadGPUInfo() = %+v, want count 2", got)
+	}
+}
+
+func TestLoadGPUAllocations(t *testing.T) {
+	gpuPod := func(namespace, name string, resources corev1.ResourceList) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{Requests: resources},
+				}},
+			},
+		}
+	}
+
+	pods := []corev1.Pod{
+		gpuPod("team-b", "infer", corev1.ResourceList{"nvidia.com/mig-1g.10gb": resource.MustParse("1")}),
+		gpuPod("team-a", "train", corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")}),
+		gpuPod("team-a", "cpu-only", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}),
+	}
+
+	want := []v1beta1.GPUAllocation{
+		{Namespace: "team-a", Pod: "train", Resource: "nvidia.com/gpu", Count: 4},
+		{Namespace: "team-b", Pod: "infer", Resource: "nvidia.com/mig-1g.10gb", Count: 1},
+	}
+	if got := resourceinfo.LoadGPUAllocations(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadGPUAllocations() = %+v, want %+v", got, want)
+	}
+}
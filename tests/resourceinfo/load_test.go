@@ -0,0 +1,166 @@
+package resourceinfo
+
+import (
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func container(cpu, memory, cpuLimit string) corev1.Container {
+	c := corev1.Container{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+	if cpuLimit != "" {
+		c.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpuLimit)}
+	}
+	return c
+}
+
+func TestPodRequestsInitContainersAndOverhead(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	sidecar := container("100m", "64Mi", "")
+	sidecar.RestartPolicy = &always
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			// init 容器按顺序运行，取最大值：sidecar 之后运行的 init 容器需要加上 sidecar
+			InitContainers: []corev1.Container{
+				container("2", "128Mi", ""),
+				sidecar,
+				container("1900m", "1Gi", ""),
+			},
+			Containers: []corev1.Container{
+				container("500m", "256Mi", "1"),
+				container("500m", "256Mi", ""),
+			},
+			Overhead: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+				corev1.ResourceMemory: resource.MustParse("10Mi"),
+			},
+		},
+	}
+
+	requests := resourceinfo.PodRequests(pod)
+	// cpu: max(500m+500m+100m, 2, 1900m+100m) + 50m = 2050m
+	if cpu := requests[corev1.ResourceCPU]; cpu.MilliValue() != 2050 {
+		t.Errorf("cpu requests = %s, want 2050m", cpu.String())
+	}
+	// memory: max(256Mi+256Mi+64Mi, 128Mi, 1Gi+64Mi) + 10Mi = 1098Mi
+	if memory := requests[corev1.ResourceMemory]; memory.Cmp(resource.MustParse("1098Mi")) != 0 {
+		t.Errorf("memory requests = %s, want 1098Mi", memory.String())
+	}
+
+	limits := resourceinfo.PodLimits(pod)
+	// 只有 cpu 设置了 limit，overhead 只累加到 cpu 上
+	if cpu := limits[corev1.ResourceCPU]; cpu.MilliValue() != 1050 {
+		t.Errorf("cpu limits = %s, want 1050m", cpu.String())
+	}
+	if _, ok := limits[corev1.ResourceMemory]; ok {
+		t.Errorf("memory limits should not be set: %v", limits)
+	}
+}
+
+func TestLoadNodeResourceInfoFromNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("8"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+				"nvidia.com/gpu":      resource.MustParse("4"),
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("7500m"),
+				corev1.ResourceMemory: resource.MustParse("15Gi"),
+				"nvidia.com/gpu":      resource.MustParse("4"),
+			},
+		},
+	}
+
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{container("1", "1Gi", "2")},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	running.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("2")
+	running.Spec.Containers[0].Resources.Limits["nvidia.com/gpu"] = resource.MustParse("2")
+
+	// 已结束的 Pod 不再占用资源
+	succeeded := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "succeeded", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{container("4", "4Gi", "")},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	otherNode := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-2",
+			Containers: []corev1.Container{container("4", "4Gi", "")},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	clientset := fake.NewSimpleClientset(running, succeeded, otherNode)
+	podLists := 0
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		podLists++
+		return false, nil, nil
+	})
+
+	var nodeResourceInfo v1beta1.NodeResourceInfo
+	err := resourceinfo.LoadNodeResourceInfoFromNode(resourceinfo.NodeResourceQuery{
+		Clientset: clientset,
+		Node:      node,
+		LoadOptions: resourceinfo.LoadOptions{
+			ResourceTracker: resourceinfo.ResourceTracker{
+				Patterns: resourceinfo.DefaultTrackedResources,
+			},
+		},
+	}, &nodeResourceInfo)
+	if err != nil {
+		t.Fatalf("LoadNodeResourceInfoFromNode failed: %v", err)
+	}
+
+	if podLists != 1 {
+		t.Errorf("pods listed %d times, want 1", podLists)
+	}
+
+	wantCapacity := map[string]v1beta1.ResourceCapacity{
+		"cpu":            {Total: "8000m", Allocatable: "7500m"},
+		"memory":         {Total: "16384Mi", Allocatable: "15360Mi"},
+		"nvidia.com/gpu": {Total: "4", Allocatable: "4"},
+	}
+	wantUsage := map[string]v1beta1.ResourceUsage{
+		"cpu":            {Used: "1000m", Limits: "2000m"},
+		"memory":         {Used: "1024Mi", Limits: "0Mi"},
+		"nvidia.com/gpu": {Used: "2", Limits: "2"},
+	}
+	for name, want := range wantCapacity {
+		if got := nodeResourceInfo.Spec.Resources[name]; got != want {
+			t.Errorf("spec.resources[%s] = %+v, want %+v", name, got, want)
+		}
+	}
+	for name, want := range wantUsage {
+		if got := nodeResourceInfo.Status.Resources[name]; got != want {
+			t.Errorf("status.resources[%s] = %+v, want %+v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package resourceinfo
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	pb "github.com/modcoco/OpsFlow/pkg/apis/proto"
+	opsflowfake "github.com/modcoco/OpsFlow/pkg/client/clientset/versioned/fake"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeManager 记录收到的请求，code 为 AddNode 与 UpdateNode 返回的业务码
+type fakeManager struct {
+	pb.UnimplementedNodeManagerServer
+
+	mu      sync.Mutex
+	code    int32
+	adds    []*pb.AddNodeRequest
+	updates []*pb.UpdateNodeRequest
+}
+
+func (m *fakeManager) setCode(code int32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.code = code
+}
+
+func (m *fakeManager) calls() ([]*pb.AddNodeRequest, []*pb.UpdateNodeRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.adds, m.updates
+}
+
+func (m *fakeManager) respond(code int32, data *anypb.Any) *pb.GenericResponse {
+	if code != 0 && code != 200 {
+		return &pb.GenericResponse{Code: code, Message: "manager error"}
+	}
+	return &pb.GenericResponse{Code: code, Data: data}
+}
+
+func (m *fakeManager) AddNode(_ context.Context, req *pb.AddNodeRequest) (*pb.GenericResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.adds = append(m.adds, req)
+	data, _ := anypb.New(&pb.AddNodeResponse{NodeName: req.NodeName})
+	return m.respond(m.code, data), nil
+}
+
+func (m *fakeManager) UpdateNode(_ context.Context, req *pb.UpdateNodeRequest) (*pb.GenericResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updates = append(m.updates, req)
+	data, _ := anypb.New(&pb.UpdateNodeResponse{NodeName: req.NodeName})
+	return m.respond(m.code, data), nil
+}
+
+// 通过 bufconn 启动 fake manager，返回连接到它的客户端
+func startManager(t *testing.T, manager pb.NodeManagerServer) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterNodeManagerServer(server, manager)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// 与 apiserver 一样在创建时设置 generation 并在 spec 更新时递增，fake clientset 本身不会修改
+func newCRDClientset(objects ...runtime.Object) *opsflowfake.Clientset {
+	clientset := opsflowfake.NewSimpleClientset(objects...)
+	clientset.PrependReactor("create", "noderesourceinfos", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		action.(k8stesting.CreateAction).GetObject().(*v1beta1.NodeResourceInfo).Generation = 1
+		return false, nil, nil
+	})
+	clientset.PrependReactor("update", "noderesourceinfos", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "" {
+			return false, nil, nil
+		}
+		obj := action.(k8stesting.UpdateAction).GetObject().(*v1beta1.NodeResourceInfo)
+		obj.Generation++
+		return false, nil, nil
+	})
+	return clientset
+}
+
+func observedNode(name string) *v1beta1.NodeResourceInfo {
+	return &v1beta1.NodeResourceInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1beta1.NodeResourceInfoSpec{
+			NodeName:  name,
+			Resources: map[string]v1beta1.ResourceCapacity{"cpu": {Total: "8", Allocatable: "8"}},
+		},
+		Status: v1beta1.NodeResourceInfoStatus{NodeStatus: "Ready", Health: v1beta1.NodeHealthReady},
+	}
+}
+
+func getNode(t *testing.T, client interface {
+	Get(context.Context, string, metav1.GetOptions) (*v1beta1.NodeResourceInfo, error)
+}, name string) *v1beta1.NodeResourceInfo {
+	t.Helper()
+	current, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return current
+}
@@ -0,0 +1,150 @@
+package resourceinfo
+
+import (
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+func TestSyncAcknowledged(t *testing.T) {
+	manager := &fakeManager{}
+	conn := startManager(t, manager)
+	crdClient := newCRDClientset().OpsflowV1beta1().NodeResourceInfos()
+
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	adds, updates := manager.calls()
+	if len(adds) != 1 || len(updates) != 0 || adds[0].ClusterId != "cluster" {
+		t.Fatalf("adds = %d, updates = %d, want one AddNode", len(adds), len(updates))
+	}
+
+	current := getNode(t, crdClient, "node-1")
+	sync := current.Status.ManagerSync
+	if sync == nil || sync.AcknowledgedGeneration != current.Generation || sync.Attempts != 0 || sync.PendingOperation != "" {
+		t.Fatalf("unexpected manager sync: %+v", sync)
+	}
+	if !meta.IsStatusConditionTrue(current.Status.Conditions, v1beta1.ConditionSyncedToManager) {
+		t.Fatalf("SyncedToManager is not true: %+v", current.Status.Conditions)
+	}
+
+	// 没有变化且已确认时不再通知 manager
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	if adds, updates := manager.calls(); len(adds) != 1 || len(updates) != 0 {
+		t.Fatalf("adds = %d, updates = %d after an unchanged cycle", len(adds), len(updates))
+	}
+}
+
+// manager 沿用 http 状态码返回 200 时同样视为确认
+func TestSyncAcknowledgedWithHTTPCode(t *testing.T) {
+	manager := &fakeManager{code: 200}
+	conn := startManager(t, manager)
+	crdClient := newCRDClientset().OpsflowV1beta1().NodeResourceInfos()
+
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	current := getNode(t, crdClient, "node-1")
+	if current.Status.ManagerSync.AcknowledgedGeneration != current.Generation {
+		t.Fatalf("generation %d not acknowledged: %+v", current.Generation, current.Status.ManagerSync)
+	}
+}
+
+func TestSyncRetriesUntilAcknowledged(t *testing.T) {
+	manager := &fakeManager{code: 500}
+	conn := startManager(t, manager)
+	crdClient := newCRDClientset().OpsflowV1beta1().NodeResourceInfos()
+
+	for attempt := int32(1); attempt <= 2; attempt++ {
+		if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err == nil {
+			t.Fatal("expected an error while the manager rejects the node")
+		}
+		sync := getNode(t, crdClient, "node-1").Status.ManagerSync
+		if sync == nil || sync.Attempts != attempt || sync.AcknowledgedGeneration != 0 ||
+			sync.PendingOperation != resourceinfo.OperationAddNode || sync.LastError == "" {
+			t.Fatalf("attempt %d: unexpected manager sync: %+v", attempt, sync)
+		}
+	}
+
+	// manager 恢复后重试仍使用 AddNode，确认后清空重试次数
+	manager.setCode(0)
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	adds, updates := manager.calls()
+	if len(adds) != 3 || len(updates) != 0 {
+		t.Fatalf("adds = %d, updates = %d, want 3 AddNode", len(adds), len(updates))
+	}
+	current := getNode(t, crdClient, "node-1")
+	sync := current.Status.ManagerSync
+	if sync.Attempts != 0 || sync.AcknowledgedGeneration != current.Generation || sync.LastError != "" {
+		t.Fatalf("unexpected manager sync after recovery: %+v", sync)
+	}
+	if !meta.IsStatusConditionTrue(current.Status.Conditions, v1beta1.ConditionSyncedToManager) {
+		t.Fatalf("SyncedToManager is not true: %+v", current.Status.Conditions)
+	}
+}
+
+// 已确认的节点变为 NotReady 时需要重新通知 manager，即使 spec 没有变化
+func TestSyncNodeStatusChange(t *testing.T) {
+	manager := &fakeManager{}
+	conn := startManager(t, manager)
+	crdClient := newCRDClientset().OpsflowV1beta1().NodeResourceInfos()
+
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	notReady := observedNode("node-1")
+	notReady.Status.Health = v1beta1.NodeHealthNotReady
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, notReady, "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, updates := manager.calls()
+	if len(updates) != 1 || updates[0].Health != string(v1beta1.NodeHealthNotReady) {
+		t.Fatalf("unexpected UpdateNode calls: %v", updates)
+	}
+}
+
+// GPU 占用变化时 spec 与 generation 不变，仍然需要把新的 allocations 通知 manager
+func TestSyncGPUAllocationChange(t *testing.T) {
+	manager := &fakeManager{}
+	conn := startManager(t, manager)
+	crdClient := newCRDClientset().OpsflowV1beta1().NodeResourceInfos()
+
+	gpuNode := func(allocations ...v1beta1.GPUAllocation) *v1beta1.NodeResourceInfo {
+		node := observedNode("gpu-1")
+		node.Spec.Resources[resourceinfo.GPUResourceName] = v1beta1.ResourceCapacity{Total: "8", Allocatable: "8"}
+		node.Spec.GPU = &v1beta1.GPUInfo{Product: "NVIDIA-A100-SXM4-80GB", Count: 8}
+		node.Status.GPUAllocations = allocations
+		return node
+	}
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, gpuNode(), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	allocation := v1beta1.GPUAllocation{Namespace: "default", Pod: "vllm-0", Resource: resourceinfo.GPUResourceName, Count: 2}
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, gpuNode(allocation), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, updates := manager.calls()
+	if len(updates) != 1 {
+		t.Fatalf("updates = %d, want one UpdateNode for the new allocation", len(updates))
+	}
+	var allocations []any
+	for _, resource := range updates[0].Resources {
+		if resource.ResourceName == resourceinfo.GPUResourceName {
+			allocations = resource.Properties.AsMap()["allocations"].([]any)
+		}
+	}
+	if len(allocations) != 1 || allocations[0].(map[string]any)["pod"] != "vllm-0" {
+		t.Fatalf("allocations = %v, want vllm-0", allocations)
+	}
+	if !meta.IsStatusConditionTrue(getNode(t, crdClient, "gpu-1").Status.Conditions, v1beta1.ConditionSyncedToManager) {
+		t.Fatal("SyncedToManager should be true after the manager acknowledged")
+	}
+}
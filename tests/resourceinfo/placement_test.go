@@ -0,0 +1,265 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/job"
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func placementNodes() []v1beta1.NodeResourceInfo {
+	gpuA := newCapacityNode("gpu-a", "Ready", metav1.ConditionTrue, map[string]string{"pool": "gpu"}, map[string][3]string{
+		"cpu":            {"64000m", "64000m", "0m"},
+		"memory":         {"262144Mi", "262144Mi", "0Mi"},
+		"nvidia.com/gpu": {"8", "8", "4"},
+	})
+	gpuB := newCapacityNode("gpu-b", "Ready", metav1.ConditionTrue, map[string]string{"pool": "gpu"}, map[string][3]string{
+		"cpu":            {"64000m", "64000m", "0m"},
+		"memory":         {"262144Mi", "262144Mi", "0Mi"},
+		"nvidia.com/gpu": {"8", "8", "0"},
+	})
+	gpuB.Spec.Taints = []v1beta1.NodeTaint{{Key: "nvidia.com/gpu", Value: "present", Effect: "NoSchedule"}}
+	cpuA := newCapacityNode("cpu-a", "Ready", metav1.ConditionTrue, map[string]string{"pool": "cpu"}, map[string][3]string{
+		"cpu":    {"16000m", "16000m", "0m"},
+		"memory": {"65536Mi", "65536Mi", "0Mi"},
+	})
+	down := newCapacityNode("down", "Unknown", metav1.ConditionUnknown, nil, map[string][3]string{
+		"nvidia.com/gpu": {"8", "8", "0"},
+	})
+	return []v1beta1.NodeResourceInfo{gpuA, gpuB, cpuA, down}
+}
+
+func TestSimulatePlacementBestFit(t *testing.T) {
+	requests := []resourceinfo.PlacementRequest{
+		{Group: "head", Machine: "head", Replicas: 1, Requests: corev1.ResourceList{
+			"cpu": resource.MustParse("4"), "memory": resource.MustParse("16Gi"),
+		}},
+		{Group: "workers", Machine: "worker", Replicas: 3, Requests: corev1.ResourceList{
+			"cpu": resource.MustParse("8"), "memory": resource.MustParse("32Gi"), "nvidia.com/gpu": resource.MustParse("2"),
+		}, Tolerations: []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}}},
+	}
+
+	result := resourceinfo.SimulatePlacement(placementNodes(), requests)
+	if !result.Feasible {
+		t.Fatalf("placement infeasible: %+v", result.Unplaced)
+	}
+
+	// GPU 组先放置，best fit 优先填满 gpu-a 剩余的 4 张卡
+	want := []resourceinfo.Placement{
+		{Group: "workers", Machine: "worker", Replica: 0, Node: "gpu-a"},
+		{Group: "workers", Machine: "worker", Replica: 1, Node: "gpu-a"},
+		{Group: "workers", Machine: "worker", Replica: 2, Node: "gpu-b"},
+		{Group: "head", Machine: "head", Replica: 0, Node: "cpu-a"},
+	}
+	if !reflect.DeepEqual(result.Placements, want) {
+		t.Errorf("placements = %+v, want %+v", result.Placements, want)
+	}
+}
+
+func TestSimulatePlacementReportsReasons(t *testing.T) {
+	requests := []resourceinfo.PlacementRequest{
+		{Group: "head", Machine: "head", Replicas: 1, Requests: corev1.ResourceList{
+			"cpu": resource.MustParse("4"), "memory": resource.MustParse("16Gi"), "nvidia.com/gpu": resource.MustParse("6"),
+		}},
+		{Group: "workers", Machine: "cpu-worker", Replicas: 2, NodeSelector: map[string]string{"pool": "cpu"}, Requests: corev1.ResourceList{
+			"cpu": resource.MustParse("8"), "memory": resource.MustParse("16Gi"),
+		}},
+	}
+
+	result := resourceinfo.SimulatePlacement(placementNodes(), requests)
+	if result.Feasible {
+		t.Fatal("placement should be infeasible")
+	}
+	if len(result.Unplaced) != 1 {
+		t.Fatalf("unplaced = %+v, want only head", result.Unplaced)
+	}
+
+	unplaced := result.Unplaced[0]
+	wantReasons := []string{
+		"1 node(s) had untolerated taint {nvidia.com/gpu=present:NoSchedule}",
+		"1 node(s) were NotReady",
+		// gpu-a 只剩 4 张卡，cpu-a 没有 GPU
+		"2 node(s) insufficient nvidia.com/gpu",
+	}
+	if unplaced.Group != "head" || unplaced.Replicas != 1 || !reflect.DeepEqual(unplaced.Reasons, wantReasons) {
+		t.Errorf("unplaced = %+v, want reasons %v", unplaced, wantReasons)
+	}
+	// cpu-a 只能放下两个 8 核 worker
+	if len(result.Placements) != 2 || result.Placements[1].Node != "cpu-a" {
+		t.Errorf("placements = %+v", result.Placements)
+	}
+}
+
+func TestClusterConfigPlacementRequests(t *testing.T) {
+	config := model.ClusterConfig{
+		Machines: []model.MachineConfig{
+			{Name: "head", CPU: "4", Memory: "16Gi", IsHeadNode: true},
+			{
+				Name: "gpu", MachineType: model.MachineTypeGroup, GroupName: "gpu-group",
+				CPU: "8", Memory: "32Gi", Replicas: ptr.To(int32(3)),
+				CustomResources: map[string]model.CustomResource{"nvidia.com/gpu": {Quantity: "1"}},
+			},
+		},
+	}
+
+	requests := job.ClusterConfigPlacementRequests(config)
+	if len(requests) != 2 {
+		t.Fatalf("requests = %+v, want head and one worker group", requests)
+	}
+	if requests[0].Group != "head" || requests[0].Replicas != 1 {
+		t.Errorf("head request = %+v", requests[0])
+	}
+	worker := requests[1]
+	gpu := worker.Requests["nvidia.com/gpu"]
+	if worker.Group != "gpu-group" || worker.Machine != "gpu" || worker.Replicas != 3 || gpu.Value() != 1 {
+		t.Errorf("worker request = %+v", worker)
+	}
+}
+
+func TestAutoscalerCapacityPlacement(t *testing.T) {
+	config := model.ClusterConfig{
+		Autoscaler: &model.AutoscalerConfig{Enabled: true},
+		Machines: []model.MachineConfig{
+			{Name: "head", CPU: "4", Memory: "16Gi", IsHeadNode: true},
+			{
+				Name: "gpu", MachineType: model.MachineTypeGroup, GroupName: "gpu-group",
+				CPU: "8", Memory: "32Gi", Replicas: ptr.To(int32(1)), MinReplicas: ptr.To(int32(1)), MaxReplicas: ptr.To(int32(4)),
+				CustomResources: map[string]model.CustomResource{"nvidia.com/gpu": {Quantity: "2"}},
+			},
+		},
+	}
+
+	requests := job.AutoscalerPlacementRequests(config)
+	if len(requests) != 2 || requests[1].Replicas != 4 {
+		t.Fatalf("requests = %+v, want the worker group at maxReplicas", requests)
+	}
+	// gpu-b 有污点，gpu-a 的 8 张卡可以放下 4 个副本，但当前只剩 4 张
+	if result := resourceinfo.SimulateCapacityPlacement(placementNodes(), requests); !result.Feasible {
+		t.Errorf("capacity placement infeasible: %+v", result.Unplaced)
+	}
+	if result := resourceinfo.SimulatePlacement(placementNodes(), requests); result.Feasible {
+		t.Error("placement on free resources should be infeasible")
+	}
+
+	config.Machines[1].MaxReplicas = ptr.To(int32(5))
+	result := resourceinfo.SimulateCapacityPlacement(placementNodes(), job.AutoscalerPlacementRequests(config))
+	if result.Feasible || result.Unplaced[0].Replicas != 1 {
+		t.Errorf("capacity placement = %+v, want one unplaced replica", result)
+	}
+
+	config.Autoscaler.Enabled = false
+	if requests := job.AutoscalerPlacementRequests(config); requests != nil {
+		t.Errorf("requests without autoscaler = %+v, want nil", requests)
+	}
+}
+
+func TestSimulatePlacementNodeAffinity(t *testing.T) {
+	affinity := func(requirements ...corev1.NodeSelectorRequirement) *corev1.NodeSelector {
+		return &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: requirements}}}
+	}
+	cpu := corev1.ResourceList{"cpu": resource.MustParse("1")}
+	tolerateGPU := []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}}
+
+	for name, tc := range map[string]struct {
+		affinity *corev1.NodeSelector
+		want     []string // 可以放置的节点
+	}{
+		"in":             {affinity(corev1.NodeSelectorRequirement{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"cpu"}}), []string{"cpu-a"}},
+		"not in":         {affinity(corev1.NodeSelectorRequirement{Key: "pool", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"cpu"}}), []string{"gpu-a", "gpu-b"}},
+		"does not exist": {affinity(corev1.NodeSelectorRequirement{Key: "pool", Operator: corev1.NodeSelectorOpDoesNotExist}), nil},
+		"match fields": {&corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu-b"}}},
+		}}}, []string{"gpu-b"}},
+		// term 之间为或
+		"terms": {&corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+			{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"cpu"}}}},
+			{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu-a"}}}},
+		}}, []string{"gpu-a", "cpu-a"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var placed []string
+			for _, node := range placementNodes() {
+				result := resourceinfo.SimulatePlacement([]v1beta1.NodeResourceInfo{node}, []resourceinfo.PlacementRequest{
+					{Group: "head", Replicas: 1, Requests: cpu, Tolerations: tolerateGPU, NodeAffinity: tc.affinity},
+				})
+				if result.Feasible {
+					placed = append(placed, node.Name)
+				} else if node.Name != "down" && !slices.Contains(result.Unplaced[0].Reasons, "1 node(s) didn't match node affinity") {
+					t.Errorf("%s: reasons = %v", node.Name, result.Unplaced[0].Reasons)
+				}
+			}
+			if !slices.Equal(placed, tc.want) {
+				t.Errorf("placed on %v, want %v", placed, tc.want)
+			}
+		})
+	}
+}
+
+// 模板中的节点亲和参与放置，Pod 间亲和无法模拟，在结果中标记为未检查
+func TestClusterConfigPlacementAffinity(t *testing.T) {
+	config := model.ClusterConfig{
+		Machines: []model.MachineConfig{{
+			Name: "head", CPU: "4", Memory: "16Gi", IsHeadNode: true,
+			PodTemplateConfig: model.PodTemplateConfig{Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}},
+					}}},
+				}},
+				PodAntiAffinity: &corev1.PodAntiAffinity{RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					{TopologyKey: "kubernetes.io/hostname"},
+				}},
+			}},
+		}},
+	}
+
+	result := resourceinfo.SimulatePlacement(placementNodes(), job.ClusterConfigPlacementRequests(config))
+	if !result.Feasible || result.Placements[0].Node == "cpu-a" {
+		t.Errorf("head should be placed on a gpu node: %+v", result)
+	}
+	if !slices.Equal(result.Unchecked, []string{"head: podAntiAffinity"}) {
+		t.Errorf("unchecked = %v", result.Unchecked)
+	}
+}
+
+// 没有节点提供 GPU 时申请 GPU 的组无法放置，不能因为资源没有被记录而忽略
+func TestSimulatePlacementUnadvertisedResource(t *testing.T) {
+	nodes := []v1beta1.NodeResourceInfo{}
+	for _, node := range placementNodes() {
+		if node.Spec.Labels["pool"] == "cpu" {
+			nodes = append(nodes, node)
+		}
+	}
+	requests := []resourceinfo.PlacementRequest{
+		{Group: "head", Machine: "head", Replicas: 1, Requests: corev1.ResourceList{
+			"cpu": resource.MustParse("4"), "memory": resource.MustParse("16Gi"),
+		}},
+		{Group: "workers", Machine: "worker", Replicas: 1, Requests: corev1.ResourceList{
+			"cpu": resource.MustParse("4"), "memory": resource.MustParse("16Gi"), "nvidia.com/gpu": resource.MustParse("1"),
+		}},
+	}
+
+	for name, simulate := range map[string]func([]v1beta1.NodeResourceInfo, []resourceinfo.PlacementRequest) *resourceinfo.PlacementResult{
+		"SimulatePlacement":         resourceinfo.SimulatePlacement,
+		"SimulateCapacityPlacement": resourceinfo.SimulateCapacityPlacement,
+	} {
+		result := simulate(nodes, requests)
+		if result.Feasible || len(result.Unplaced) != 1 || result.Unplaced[0].Group != "workers" {
+			t.Fatalf("%s: result = %+v, want workers unplaced", name, result)
+		}
+		if want := []string{"1 node(s) insufficient nvidia.com/gpu"}; !reflect.DeepEqual(result.Unplaced[0].Reasons, want) {
+			t.Errorf("%s: reasons = %v, want %v", name, result.Unplaced[0].Reasons, want)
+		}
+		if len(result.Placements) != 1 || result.Placements[0].Node != "cpu-a" {
+			t.Errorf("%s: placements = %+v", name, result.Placements)
+		}
+	}
+}
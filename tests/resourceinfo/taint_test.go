@@ -0,0 +1,38 @@
+package resourceinfo
+
+import (
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+)
+
+// 已确认的节点 spec 变化后使用 UpdateNode，taint 同步给 manager
+func TestSyncTaintChange(t *testing.T) {
+	manager := &fakeManager{}
+	conn := startManager(t, manager)
+	crdClient := newCRDClientset().OpsflowV1beta1().NodeResourceInfos()
+
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observedNode("node-1"), "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tainted := observedNode("node-1")
+	tainted.Spec.Taints = []v1beta1.NodeTaint{{Key: "nvidia.com/gpu", Value: "present", Effect: "NoSchedule"}}
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, tainted, "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	adds, updates := manager.calls()
+	if len(adds) != 1 || len(updates) != 1 {
+		t.Fatalf("adds = %d, updates = %d, want one AddNode and one UpdateNode", len(adds), len(updates))
+	}
+	taints := updates[0].Taints
+	if len(taints) != 1 || taints[0].Key != "nvidia.com/gpu" || taints[0].Value != "present" || taints[0].Effect != "NoSchedule" {
+		t.Fatalf("unexpected taints in UpdateNode: %v", taints)
+	}
+	current := getNode(t, crdClient, "node-1")
+	if current.Generation != 2 || current.Status.ManagerSync.AcknowledgedGeneration != 2 {
+		t.Fatalf("generation %d, acknowledged %d, want 2", current.Generation, current.Status.ManagerSync.AcknowledgedGeneration)
+	}
+}
@@ -0,0 +1,84 @@
+package resourceinfo
+
+import (
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestResourceTrackerPatterns(t *testing.T) {
+	tracker, err := resourceinfo.NewResourceTracker("cpu, memory,*.com/gpu,rdma/*,hugepages-*,ephemeral-storage", false)
+	if err != nil {
+		t.Fatalf("NewResourceTracker failed: %v", err)
+	}
+
+	cases := map[string]bool{
+		"cpu":                true,
+		"memory":             true,
+		"nvidia.com/gpu":     true,
+		"amd.com/gpu":        true,
+		"rdma/hca_shared":    true,
+		"hugepages-2Mi":      true,
+		"ephemeral-storage":  true,
+		"pods":               false,
+		"nvidia.com/mig-1g":  false,
+		"example.org/widget": false,
+	}
+	for name, want := range cases {
+		if got := tracker.Tracks(name); got != want {
+			t.Errorf("Tracks(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if _, err := resourceinfo.NewResourceTracker("cpu,[", false); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestResourceTrackerAutoDiscover(t *testing.T) {
+	tracker, err := resourceinfo.NewResourceTracker("cpu,memory", true)
+	if err != nil {
+		t.Fatalf("NewResourceTracker failed: %v", err)
+	}
+
+	cases := map[string]bool{
+		"cpu":                        true,
+		"nvidia.com/mig-1g.10gb":     true,
+		"example.org/widget":         true,
+		"pods":                       false,
+		"hugepages-1Gi":              false,
+		"kubernetes.io/batch-cpu":    false,
+		"scheduling.kubernetes.io/x": false,
+		"requests.nvidia.com/gpu":    false,
+	}
+	for name, want := range cases {
+		if got := tracker.Tracks(name); got != want {
+			t.Errorf("Tracks(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFormatResourceQuantity(t *testing.T) {
+	cases := []struct {
+		name     string
+		quantity string
+		want     string
+		unit     string
+	}{
+		{"cpu", "1500m", "1500m", "m"},
+		{"memory", "2Gi", "2048Mi", "Mi"},
+		{"hugepages-2Mi", "1Gi", "1024Mi", "Mi"},
+		{"ephemeral-storage", "100Gi", "102400Mi", "Mi"},
+		{"nvidia.com/gpu", "8", "8", ""},
+		{"rdma/hca_shared", "1k", "1000", ""},
+	}
+	for _, c := range cases {
+		if got := resourceinfo.FormatResourceQuantity(c.name, resource.MustParse(c.quantity)); got != c.want {
+			t.Errorf("FormatResourceQuantity(%s, %s) = %s, want %s", c.name, c.quantity, got, c.want)
+		}
+		if got := resourceinfo.ResourceUnit(c.name); got != c.unit {
+			t.Errorf("ResourceUnit(%s) = %q, want %q", c.name, got, c.unit)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package resourceinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/node/resourceinfo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+const dcgmMetrics = `# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="1",UUID="GPU-bbb",device="nvidia1",modelName="NVIDIA A100-SXM4-80GB",Hostname="gpu-node",namespace="team-a",pod="train-0"} 0
+DCGM_FI_DEV_GPU_UTIL{gpu="0",UUID="GPU-aaa",device="nvidia0",modelName="NVIDIA A100-SXM4-80GB",Hostname="gpu-node",namespace="team-a",pod="train-0"} 85
+# HELP DCGM_FI_DEV_FB_USED Framebuffer memory used (in MiB).
+# TYPE DCGM_FI_DEV_FB_USED gauge
+DCGM_FI_DEV_FB_USED{gpu="0",UUID="GPU-aaa",device="nvidia0",modelName="NVIDIA A100-SXM4-80GB",Hostname="gpu-node",namespace="team-a",pod="train-0"} 40960
+DCGM_FI_DEV_FB_USED{gpu="1",UUID="GPU-bbb",device="nvidia1",modelName="NVIDIA A100-SXM4-80GB",Hostname="gpu-node",namespace="team-a",pod="train-0"} 2
+DCGM_FI_DEV_SM_CLOCK{gpu="0",UUID="GPU-aaa"} 1410
+`
+
+func TestParseDCGMMetrics(t *testing.T) {
+	devices, err := resourceinfo.ParseDCGMMetrics(strings.NewReader(dcgmMetrics))
+	if err != nil {
+		t.Fatalf("ParseDCGMMetrics failed: %v", err)
+	}
+	want := []v1beta1.GPUDeviceUtilization{
+		{Index: "0", UUID: "GPU-aaa", ModelName: "NVIDIA A100-SXM4-80GB", Utilization: 85, MemoryUsedMiB: 40960, Namespace: "team-a", Pod: "train-0"},
+		{Index: "1", UUID: "GPU-bbb", ModelName: "NVIDIA A100-SXM4-80GB", Utilization: 0, MemoryUsedMiB: 2, Namespace: "team-a", Pod: "train-0"},
+	}
+	if len(devices) != len(want) {
+		t.Fatalf("devices = %+v, want %+v", devices, want)
+	}
+	for i := range want {
+		if devices[i] != want[i] {
+			t.Errorf("devices[%d] = %+v, want %+v", i, devices[i], want[i])
+		}
+	}
+}
+
+func TestLoadNodeResourceInfoWithUtilization(t *testing.T) {
+	var requestedPath string
+	exporter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(dcgmMetrics))
+	}))
+	defer exporter.Close()
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-node"},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("16"),
+				corev1.ResourceMemory: resource.MustParse("64Gi"),
+				"nvidia.com/gpu":      resource.MustParse("2"),
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("16"),
+				corev1.ResourceMemory: resource.MustParse("64Gi"),
+				"nvidia.com/gpu":      resource.MustParse("2"),
+			},
+		},
+	}
+
+	timestamp := metav1.NewTime(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC))
+	metricsClient := metricsfake.NewSimpleClientset()
+	metricsClient.PrependReactor("get", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.NodeMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu-node"},
+			Timestamp:  timestamp,
+			Window:     metav1.Duration{Duration: 30 * time.Second},
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2500m"),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		}, nil
+	})
+
+	var nodeResourceInfo v1beta1.NodeResourceInfo
+	err := resourceinfo.LoadNodeResourceInfoFromNode(resourceinfo.NodeResourceQuery{
+		Clientset: fake.NewSimpleClientset(),
+		Node:      node,
+		LoadOptions: resourceinfo.LoadOptions{
+			ResourceTracker: resourceinfo.ResourceTracker{Patterns: resourceinfo.DefaultTrackedResources},
+			Utilization: &resourceinfo.UtilizationCollector{
+				Metrics: metricsClient,
+				DCGM:    &resourceinfo.DCGMExporter{URLTemplate: exporter.URL + "/{node}/metrics"},
+			},
+		},
+	}, &nodeResourceInfo)
+	if err != nil {
+		t.Fatalf("LoadNodeResourceInfoFromNode failed: %v", err)
+	}
+
+	if requestedPath != "/gpu-node/metrics" {
+		t.Errorf("exporter path = %s, want /gpu-node/metrics", requestedPath)
+	}
+
+	wantUtilized := map[string]string{
+		"cpu":            "2500m",
+		"memory":         "8192Mi",
+		"nvidia.com/gpu": "0.85",
+	}
+	for name, want := range wantUtilized {
+		if got := nodeResourceInfo.Status.Resources[name].Utilized; got != want {
+			t.Errorf("status.resources[%s].utilized = %s, want %s", name, got, want)
+		}
+	}
+
+	utilization := nodeResourceInfo.Status.Utilization
+	if utilization == nil {
+		t.Fatal("status.utilization is nil")
+	}
+	if !utilization.Time.Equal(&timestamp) || utilization.Source != "NodeMetrics" || utilization.Window != "30s" {
+		t.Errorf("utilization = %+v", utilization)
+	}
+	if len(utilization.Devices) != 2 || utilization.Devices[1].Utilization != 0 || utilization.Devices[1].Pod != "train-0" {
+		t.Errorf("devices = %+v, want idle reserved GPU 1", utilization.Devices)
+	}
+}
+
+func TestUtilizationFallsBackToPodMetrics(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}},
+	}
+	timestamp := metav1.NewTime(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC))
+
+	metricsClient := metricsfake.NewSimpleClientset()
+	metricsClient.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Timestamp:  timestamp,
+			Window:     metav1.Duration{Duration: 15 * time.Second},
+			Containers: []metricsv1beta1.ContainerMetrics{{
+				Name: "main",
+				Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("512Mi"),
+				},
+			}},
+		}, nil
+	})
+
+	collector := &resourceinfo.UtilizationCollector{Metrics: metricsClient}
+	result, err := collector.Collect(context.Background(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}, pods)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	if result.Status.Source != "PodMetrics" {
+		t.Errorf("source = %s, want PodMetrics", result.Status.Source)
+	}
+	if cpu := result.Usage[corev1.ResourceCPU]; cpu.MilliValue() != 500 {
+		t.Errorf("cpu = %s, want 500m", cpu.String())
+	}
+	if memory := result.Usage[corev1.ResourceMemory]; memory.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("memory = %s, want 1Gi", memory.String())
+	}
+}
+
+// 只有采集时间变化时不更新 status，其他字段变化时连同采集时间一起更新
+func TestUtilizationTimeDoesNotUpdateStatus(t *testing.T) {
+	manager := &fakeManager{}
+	conn := startManager(t, manager)
+	clientset := newCRDClientset()
+	crdClient := clientset.OpsflowV1beta1().NodeResourceInfos()
+
+	observed := observedNode("node-1")
+	observed.Status.Utilization = &v1beta1.Utilization{
+		Time:    metav1.NewTime(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)),
+		Source:  "NodeMetrics",
+		Devices: []v1beta1.GPUDeviceUtilization{{Index: "0", Utilization: 40}},
+	}
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observed, "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	statusUpdates := func() int {
+		count := 0
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+				count++
+			}
+		}
+		return count
+	}
+	before := statusUpdates()
+
+	observed.Status.Utilization.Time = metav1.NewTime(observed.Status.Utilization.Time.Add(30 * time.Second))
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observed, "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	if after := statusUpdates(); after != before {
+		t.Fatalf("status updated %d times for a timestamp-only change", after-before)
+	}
+
+	observed.Status.Utilization.Time = metav1.NewTime(observed.Status.Utilization.Time.Add(30 * time.Second))
+	observed.Status.Utilization.Devices[0].Utilization = 90
+	if err := resourceinfo.UpdateCreateNodeResourceInfo(crdClient, conn, observed, "cluster", nil); err != nil {
+		t.Fatal(err)
+	}
+	current := getNode(t, crdClient, "node-1")
+	if current.Status.Utilization.Devices[0].Utilization != 90 || !current.Status.Utilization.Time.Equal(&observed.Status.Utilization.Time) {
+		t.Fatalf("utilization not updated: %+v", current.Status.Utilization)
+	}
+}
@@ -0,0 +1,259 @@
+package svc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modcoco/OpsFlow/pkg/model"
+	"github.com/modcoco/OpsFlow/pkg/svc"
+	istioapi "istio.io/api/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var jobLabels = map[string]string{model.ModelUniqueID: "demo"}
+
+const labelSelector = "model-unique-id=demo"
+
+func TestValidateExpose(t *testing.T) {
+	valid := []*model.ExposeConfig{
+		nil,
+		{},
+		{Mode: svc.ExposeNodePort, NodePort: 30080},
+		{Mode: svc.ExposeIngress, Host: "qwen.example.com"},
+		{Mode: svc.ExposeIstio, Host: "qwen.example.com", Path: "/qwen", Gateway: "istio-system/public"},
+		{Mode: svc.ExposeIngress, Host: "qwen.example.com", Path: "/qwen", Annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$2"}},
+	}
+	for _, expose := range valid {
+		if err := svc.ValidateExpose(expose); err != nil {
+			t.Errorf("ValidateExpose(%+v) = %v", expose, err)
+		}
+	}
+
+	invalid := map[string]*model.ExposeConfig{
+		"unknown expose mode":         {Mode: "LoadBalancer"},
+		"invalid expose.nodePort":     {Mode: svc.ExposeNodePort, NodePort: 70000},
+		"expose.host is required":     {Mode: svc.ExposeIngress},
+		"expose.path must start with": {Mode: svc.ExposeIstio, Host: "qwen.example.com", Path: "qwen"},
+		"expose.gateway must be":      {Mode: svc.ExposeIstio, Host: "qwen.example.com", Gateway: "public"},
+		// Ingress 没有统一的改写方式，推理服务会收到带前缀的路径
+		"requires a path rewrite annotation": {Mode: svc.ExposeIngress, Host: "qwen.example.com", Path: "/qwen"},
+	}
+	for want, expose := range invalid {
+		if err := svc.ValidateExpose(expose); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateExpose(%+v) = %v, want %q", expose, err, want)
+		}
+	}
+}
+
+func TestCreateExposureClusterIP(t *testing.T) {
+	ctx := context.Background()
+	core, istio := fake.NewSimpleClientset(), istiofake.NewSimpleClientset()
+	if err := svc.CreateExposure(ctx, core, istio, "default", "demo", "demo-raycluster", jobLabels, nil); err != nil {
+		t.Fatalf("CreateExposure failed: %v", err)
+	}
+
+	service, err := core.CoreV1().Services("default").Get(ctx, "demo-raycluster-vllm-svc", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Type != "" || service.Labels[model.ModelUniqueID] != "demo" || service.Labels["ray.io/cluster"] != "demo-raycluster" {
+		t.Errorf("unexpected service: %+v", service.ObjectMeta)
+	}
+
+	exposure, err := svc.GetExposure(ctx, core, istio, "default", labelSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *exposure != (model.ExposureStatus{Mode: svc.ExposeClusterIP, Service: "demo-raycluster-vllm-svc"}) {
+		t.Errorf("exposure = %+v", exposure)
+	}
+}
+
+func TestCreateExposureNodePort(t *testing.T) {
+	ctx := context.Background()
+	core, istio := fake.NewSimpleClientset(), istiofake.NewSimpleClientset()
+	expose := &model.ExposeConfig{Mode: svc.ExposeNodePort, NodePort: 30080}
+	if err := svc.CreateExposure(ctx, core, istio, "kuberay", "demo", "demo-raycluster", jobLabels, expose); err != nil {
+		t.Fatalf("CreateExposure failed: %v", err)
+	}
+
+	service, err := core.CoreV1().Services("kuberay").Get(ctx, "demo-raycluster-vllm-svc", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := service.Spec.Ports[0]
+	if service.Spec.Type != corev1.ServiceTypeNodePort || port.NodePort != 30080 || port.Port != 8000 || port.TargetPort.IntValue() != 8000 {
+		t.Errorf("unexpected service spec: %+v", service.Spec)
+	}
+	if service.Spec.Selector["ray.io/node-type"] != "head" || !service.Spec.PublishNotReadyAddresses {
+		t.Errorf("service should select the head and publish not ready addresses: %+v", service.Spec)
+	}
+
+	exposure, err := svc.GetExposure(ctx, core, istio, "kuberay", labelSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exposure.Mode != svc.ExposeNodePort || exposure.NodePort != 30080 {
+		t.Errorf("exposure = %+v", exposure)
+	}
+}
+
+func TestCreateExposureIngress(t *testing.T) {
+	ctx := context.Background()
+	core, istio := fake.NewSimpleClientset(), istiofake.NewSimpleClientset()
+	expose := &model.ExposeConfig{
+		Mode:             svc.ExposeIngress,
+		Host:             "qwen.example.com",
+		IngressClassName: "nginx",
+		Annotations:      map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "600"},
+	}
+	if err := svc.CreateExposure(ctx, core, istio, "default", "demo", "demo-raycluster", jobLabels, expose); err != nil {
+		t.Fatalf("CreateExposure failed: %v", err)
+	}
+
+	ingress, err := core.NetworkingV1().Ingresses("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := ingress.Spec.Rules[0].HTTP.Paths[0]
+	if *ingress.Spec.IngressClassName != "nginx" || ingress.Spec.Rules[0].Host != "qwen.example.com" || path.Path != "/" ||
+		path.Backend.Service.Name != "demo-raycluster-vllm-svc" || path.Backend.Service.Port.Number != 8000 {
+		t.Errorf("unexpected ingress spec: %+v", ingress.Spec)
+	}
+	if ingress.Annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] != "600" || ingress.Labels[model.ModelUniqueID] != "demo" {
+		t.Errorf("unexpected ingress metadata: %+v", ingress.ObjectMeta)
+	}
+
+	exposure, err := svc.GetExposure(ctx, core, istio, "default", labelSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.ExposureStatus{Mode: svc.ExposeIngress, Service: "demo-raycluster-vllm-svc", Ingress: "demo", Host: "qwen.example.com", Path: "/"}
+	if *exposure != want {
+		t.Errorf("exposure = %+v, want %+v", exposure, want)
+	}
+
+	if err := svc.DeleteExposureByLabel(ctx, core, istio, "default", labelSelector); err != nil {
+		t.Fatal(err)
+	}
+	ingresses, _ := core.NetworkingV1().Ingresses("default").List(ctx, metav1.ListOptions{})
+	if len(ingresses.Items) != 0 {
+		t.Errorf("ingress should be deleted, got %d", len(ingresses.Items))
+	}
+}
+
+func TestCreateExposureIstio(t *testing.T) {
+	ctx := context.Background()
+	core, istio := fake.NewSimpleClientset(), istiofake.NewSimpleClientset()
+	expose := &model.ExposeConfig{Mode: svc.ExposeIstio, Host: "models.example.com", Path: "/qwen"}
+	if err := svc.CreateExposure(ctx, core, istio, "default", "demo", "demo-raycluster", jobLabels, expose); err != nil {
+		t.Fatalf("CreateExposure failed: %v", err)
+	}
+
+	gateway, err := istio.NetworkingV1().Gateways("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gateway.Spec.Selector["istio"] != "ingressgateway" || gateway.Spec.Servers[0].Hosts[0] != "models.example.com" {
+		t.Errorf("unexpected gateway spec: %v", gateway.Spec.String())
+	}
+
+	virtualService, err := istio.NetworkingV1().VirtualServices("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	route := virtualService.Spec.Http[0]
+	if virtualService.Spec.Gateways[0] != "default/demo" || route.Match[0].GetUri().GetPrefix() != "/qwen/" || route.Match[1].GetUri().GetExact() != "/qwen" || route.Rewrite.GetUri() != "/" ||
+		route.Route[0].Destination.Host != "demo-raycluster-vllm-svc.default.svc.cluster.local" || route.Route[0].Destination.Port.Number != 8000 {
+		t.Errorf("unexpected virtualservice spec: %v", virtualService.Spec.String())
+	}
+
+	exposure, err := svc.GetExposure(ctx, core, istio, "default", labelSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.ExposureStatus{
+		Mode: svc.ExposeIstio, Service: "demo-raycluster-vllm-svc", Gateway: "default/demo",
+		VirtualService: "demo", Host: "models.example.com", Path: "/qwen",
+	}
+	if *exposure != want {
+		t.Errorf("exposure = %+v, want %+v", exposure, want)
+	}
+
+	if err := svc.DeleteExposureByLabel(ctx, core, istio, "default", labelSelector); err != nil {
+		t.Fatal(err)
+	}
+	gateways, _ := istio.NetworkingV1().Gateways("default").List(ctx, metav1.ListOptions{})
+	virtualServices, _ := istio.NetworkingV1().VirtualServices("default").List(ctx, metav1.ListOptions{})
+	if len(gateways.Items) != 0 || len(virtualServices.Items) != 0 {
+		t.Errorf("istio resources should be deleted, got %d gateways, %d virtualservices", len(gateways.Items), len(virtualServices.Items))
+	}
+}
+
+func TestCreateExposureIstioExistingGateway(t *testing.T) {
+	ctx := context.Background()
+	core, istio := fake.NewSimpleClientset(), istiofake.NewSimpleClientset()
+	expose := &model.ExposeConfig{Mode: svc.ExposeIstio, Host: "qwen.example.com", Gateway: "istio-system/public"}
+	if err := svc.CreateExposure(ctx, core, istio, "default", "demo", "demo-raycluster", jobLabels, expose); err != nil {
+		t.Fatalf("CreateExposure failed: %v", err)
+	}
+
+	gateways, _ := istio.NetworkingV1().Gateways("").List(ctx, metav1.ListOptions{})
+	if len(gateways.Items) != 0 {
+		t.Errorf("no gateway should be created when using an existing one, got %d", len(gateways.Items))
+	}
+	virtualService, err := istio.NetworkingV1().VirtualServices("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if virtualService.Spec.Gateways[0] != "istio-system/public" || virtualService.Spec.Http[0].Rewrite != nil {
+		t.Errorf("unexpected virtualservice spec: %v", virtualService.Spec.String())
+	}
+}
+
+// 按 Istio 的规则匹配并改写路径：Prefix 匹配时用 rewrite.uri 替换匹配的前缀，Exact 匹配时替换整个路径
+func istioRewrite(route *istioapi.HTTPRoute, uri string) (string, bool) {
+	for _, match := range route.Match {
+		matched := match.GetUri().GetPrefix()
+		if matched == "" || !strings.HasPrefix(uri, matched) {
+			if matched = match.GetUri().GetExact(); matched == "" || uri != matched {
+				continue
+			}
+		}
+		if route.Rewrite == nil {
+			return uri, true
+		}
+		return route.Rewrite.GetUri() + strings.TrimPrefix(uri, matched), true
+	}
+	return "", false
+}
+
+// 路径前缀改写后推理服务收到的路径与直接访问相同，且不匹配同前缀的其他路径
+func TestVirtualServicePathRewrite(t *testing.T) {
+	for _, path := range []string{"/llama", "/llama/"} {
+		virtualService := svc.GenerateVirtualService("default", "demo", "demo-raycluster-vllm-svc",
+			&model.ExposeConfig{Mode: svc.ExposeIstio, Host: "models.example.com", Path: path})
+		route := virtualService.Spec.Http[0]
+		for uri, want := range map[string]string{
+			"/llama/v1/models": "/v1/models",
+			"/llama/":          "/",
+			"/llama":           "/",
+		} {
+			if got, ok := istioRewrite(route, uri); !ok || got != want {
+				t.Errorf("path %s: %s rewritten to %q (matched %v), want %q", path, uri, got, ok, want)
+			}
+		}
+		if got, ok := istioRewrite(route, "/llamafoo/v1/models"); ok {
+			t.Errorf("path %s: /llamafoo/v1/models should not match, rewritten to %q", path, got)
+		}
+	}
+
+	root := svc.GenerateVirtualService("default", "demo", "demo-raycluster-vllm-svc", &model.ExposeConfig{Mode: svc.ExposeIstio, Host: "models.example.com"})
+	if got, ok := istioRewrite(root.Spec.Http[0], "/v1/models"); !ok || got != "/v1/models" {
+		t.Errorf("root path rewritten to %q (matched %v)", got, ok)
+	}
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1alpha1"
+	"github.com/modcoco/OpsFlow/pkg/apis/opsflow.io/v1beta1"
+	"github.com/modcoco/OpsFlow/pkg/webhook"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// 时间字段使用秒精度，与 JSON 序列化后的结果一致，反序列化后时区不同，需要用 Semantic 比较
+var transitionTime = metav1.NewTime(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC))
+
+func betaNode() *v1beta1.NodeResourceInfo {
+	return &v1beta1.NodeResourceInfo{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1beta1.SchemeGroupVersion.String(), Kind: "NodeResourceInfo"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gpu-node-1",
+			Generation:  3,
+			Finalizers:  []string{v1beta1.NodeManagerFinalizer},
+			Annotations: map[string]string{"team": "infra"},
+		},
+		Spec: v1beta1.NodeResourceInfoSpec{
+			NodeName: "gpu-node-1",
+			Resources: map[string]v1beta1.ResourceCapacity{
+				"cpu":            {Total: "64", Allocatable: "63"},
+				"nvidia.com/gpu": {Total: "8", Allocatable: "8"},
+			},
+			Roles:  "worker",
+			Labels: map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100-SXM4-80GB"},
+			Taints: []v1beta1.NodeTaint{{Key: "nvidia.com/gpu", Value: "present", Effect: "NoSchedule"}},
+			GPU:    &v1beta1.GPUInfo{Product: "NVIDIA-A100-SXM4-80GB", MemoryMiB: 81920, Count: 8, MigStrategy: "none"},
+		},
+		Status: v1beta1.NodeResourceInfoStatus{
+			NodeStatus: "Ready",
+			Health:     v1beta1.NodeHealthReady,
+			NodeConditions: []v1beta1.NodeCondition{
+				{Type: "Ready", Status: "True", Reason: "KubeletReady", LastTransitionTime: transitionTime},
+			},
+			Resources: map[string]v1beta1.ResourceUsage{
+				"cpu":            {Used: "12", Limits: "24", Utilized: "6"},
+				"nvidia.com/gpu": {Used: "4", Utilized: "2.5"},
+				"memory":         {Used: "64Gi"},
+			},
+			GPUAllocations: []v1beta1.GPUAllocation{{Namespace: "team-a", Pod: "train-0", Resource: "nvidia.com/gpu", Count: 4}},
+			UsageBreakdown: &v1beta1.UsageBreakdown{
+				Namespaces: []v1beta1.NamespaceUsage{{Namespace: "team-a", Pods: 1, Requests: map[string]string{"nvidia.com/gpu": "4"}}},
+			},
+			Utilization: &v1beta1.Utilization{
+				Time:    transitionTime,
+				Source:  "NodeMetrics",
+				Devices: []v1beta1.GPUDeviceUtilization{{Index: "0", Utilization: 90, Namespace: "team-a", Pod: "train-0"}},
+			},
+			Conditions:         []metav1.Condition{{Type: v1beta1.ConditionReady, Status: metav1.ConditionTrue, Reason: "KubeletReady", LastTransitionTime: transitionTime}},
+			ObservedGeneration: 3,
+			ManagerSync:        &v1beta1.ManagerSyncStatus{AcknowledgedGeneration: 3},
+			LastHeartbeatTime:  &transitionTime,
+		},
+	}
+}
+
+func alphaNode() *v1alpha1.NodeResourceInfo {
+	return &v1alpha1.NodeResourceInfo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "NodeResourceInfo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Generation: 2},
+		Spec: v1alpha1.NodeResourceInfoSpec{
+			NodeName: "node-1",
+			Resources: map[string]v1alpha1.ResourceInfo{
+				"cpu":    {Total: "8", Allocatable: "8", Used: "2"},
+				"memory": {Total: "32Gi", Allocatable: "31Gi", Used: "4Gi"},
+			},
+			Status: "Ready",
+			Roles:  "worker",
+			Taints: []v1alpha1.NodeTaint{{Key: "dedicated", Value: "ray", Effect: "NoSchedule"}},
+		},
+		Status: v1alpha1.NodeResourceInfoStatus{ObservedGeneration: 2},
+	}
+}
+
+func TestRoundTripFromV1beta1(t *testing.T) {
+	in := betaNode()
+	alpha := v1beta1.ConvertToV1alpha1(in)
+	if _, ok := alpha.Annotations[v1beta1.ConversionAnnotation]; !ok {
+		t.Fatalf("v1beta1-only fields not preserved: %v", alpha.Annotations)
+	}
+	if alpha.Spec.Resources["cpu"].Used != "12" || alpha.Spec.Status != "Ready" {
+		t.Fatalf("unexpected v1alpha1 spec: %+v", alpha.Spec)
+	}
+
+	out := v1beta1.ConvertFromV1alpha1(alpha)
+	if !apiequality.Semantic.DeepEqual(out, in) {
+		t.Fatalf("round trip changed the object:\n got: %+v\nwant: %+v", out, in)
+	}
+	if _, ok := in.Annotations[v1beta1.ConversionAnnotation]; ok {
+		t.Fatal("conversion modified the input object")
+	}
+}
+
+func TestRoundTripFromV1alpha1(t *testing.T) {
+	in := alphaNode()
+	beta := v1beta1.ConvertFromV1alpha1(in)
+	if beta.Status.Resources["memory"].Used != "4Gi" || beta.Status.NodeStatus != "Ready" {
+		t.Fatalf("unexpected v1beta1 status: %+v", beta.Status)
+	}
+
+	out := v1beta1.ConvertToV1alpha1(beta)
+	if !apiequality.Semantic.DeepEqual(out, in) {
+		t.Fatalf("round trip changed the object:\n got: %+v\nwant: %+v", out, in)
+	}
+}
+
+// 通过 v1alpha1 修改 used 后，以 v1alpha1 的值为准，其他保存的字段不变
+func TestV1alpha1UpdateKeepsSavedFields(t *testing.T) {
+	alpha := v1beta1.ConvertToV1alpha1(betaNode())
+	cpu := alpha.Spec.Resources["cpu"]
+	cpu.Used = "16"
+	alpha.Spec.Resources["cpu"] = cpu
+
+	out := v1beta1.ConvertFromV1alpha1(alpha)
+	if got := out.Status.Resources["cpu"]; got != (v1beta1.ResourceUsage{Used: "16", Limits: "24", Utilized: "6"}) {
+		t.Fatalf("cpu usage = %+v", got)
+	}
+	if out.Spec.GPU == nil || out.Status.Utilization == nil || out.Status.Health != v1beta1.NodeHealthReady {
+		t.Fatalf("saved fields lost: %+v", out)
+	}
+}
+
+func convert(t *testing.T, desiredAPIVersion string, objects ...any) *webhook.ConversionReview {
+	t.Helper()
+	review := webhook.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request:  &webhook.ConversionRequest{UID: "review-1", DesiredAPIVersion: desiredAPIVersion},
+	}
+	for _, obj := range objects {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: raw})
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/convert", webhook.ConvertHandle)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var response webhook.ConversionReview
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Response == nil || response.Response.UID != "review-1" {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+	return &response
+}
+
+// apiserver 读 v1alpha1 再写回时经过两次转换，v1beta1 的字段不能丢失
+func TestConvertHandleRoundTrip(t *testing.T) {
+	in := betaNode()
+	toAlpha := convert(t, v1alpha1.SchemeGroupVersion.String(), in)
+	if toAlpha.Response.Result.Status != metav1.StatusSuccess || len(toAlpha.Response.ConvertedObjects) != 1 {
+		t.Fatalf("unexpected response: %+v", toAlpha.Response)
+	}
+	var alpha v1alpha1.NodeResourceInfo
+	if err := json.Unmarshal(toAlpha.Response.ConvertedObjects[0].Raw, &alpha); err != nil {
+		t.Fatal(err)
+	}
+	if alpha.APIVersion != v1alpha1.SchemeGroupVersion.String() {
+		t.Fatalf("apiVersion = %s", alpha.APIVersion)
+	}
+
+	toBeta := convert(t, v1beta1.SchemeGroupVersion.String(), &alpha)
+	var out v1beta1.NodeResourceInfo
+	if err := json.Unmarshal(toBeta.Response.ConvertedObjects[0].Raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !apiequality.Semantic.DeepEqual(&out, in) {
+		t.Fatalf("round trip changed the object:\n got: %+v\nwant: %+v", &out, in)
+	}
+}
+
+func TestConvertHandleUnsupportedVersion(t *testing.T) {
+	response := convert(t, "opsflow.io/v2", betaNode())
+	if response.Response.Result.Status != metav1.StatusFailure || response.Response.ConvertedObjects != nil {
+		t.Fatalf("unexpected response: %+v", response.Response)
+	}
+}
+
+func TestConvertHandleInvalidReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/convert", webhook.ConvertHandle)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader([]byte(`{"kind":"ConversionReview"}`))))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}